@@ -34,4 +34,7 @@ const (
 	TypeInternal       = "omniview:internal"
 	TypeValidation     = "omniview:validation"
 	TypeNotImplemented = "omniview:not-implemented"
+
+	// Confirmation errors: the operation needs the user's explicit consent
+	TypeConfirmationRequired = "omniview:confirmation-required"
 )
@@ -0,0 +1,82 @@
+// Package notify sends native desktop notifications.
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/services/notifications"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+)
+
+// ErrUnavailable is returned when notifications cannot be sent on this
+// system, for example a Linux desktop without a D-Bus session.
+var ErrUnavailable = errors.New("desktop notifications are unavailable")
+
+// Service wraps the Wails notification service. Unlike that service it does
+// not fail application startup when the platform cannot send notifications:
+// it logs a warning and SendNotification returns ErrUnavailable.
+type Service struct {
+	logger logging.Logger
+	ns     *notifications.NotificationService
+	ready  atomic.Bool
+}
+
+// New creates a notification Service. It must be registered with the
+// application to start.
+func New(logger logging.Logger) *Service {
+	return &Service{
+		logger: logger.Named("Notifications"),
+		ns:     notifications.New(),
+	}
+}
+
+func (s *Service) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	if err := s.ns.ServiceStartup(ctx, options); err != nil {
+		s.logger.Warnw(ctx, "desktop notifications are unavailable", "error", err)
+		return nil
+	}
+	s.ready.Store(true)
+
+	// macOS asks the user the first time; elsewhere this returns at once.
+	go func() {
+		authorized, err := s.ns.RequestNotificationAuthorization()
+		if err != nil {
+			s.logger.Warnw(ctx, "failed to request notification authorization", "error", err)
+		} else if !authorized {
+			s.logger.Infow(ctx, "desktop notifications were not authorized")
+		}
+	}()
+	return nil
+}
+
+func (s *Service) ServiceShutdown() error {
+	if !s.ready.Swap(false) {
+		return nil
+	}
+	return s.ns.ServiceShutdown()
+}
+
+// Available reports whether notifications can be sent.
+func (s *Service) Available() bool {
+	return s.ready.Load()
+}
+
+// SendNotification shows a notification, or returns ErrUnavailable.
+func (s *Service) SendNotification(options notifications.NotificationOptions) error {
+	if !s.ready.Load() {
+		return ErrUnavailable
+	}
+	return s.ns.SendNotification(options)
+}
+
+// OnNotificationResponse sets the callback called when the user clicks or
+// acts on a notification. Only one callback is kept.
+//
+//wails:ignore
+func (s *Service) OnNotificationResponse(callback func(result notifications.NotificationResult)) {
+	s.ns.OnNotificationResponse(callback)
+}
@@ -0,0 +1,18 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wailsapp/wails/v3/pkg/services/notifications"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+)
+
+func TestService_UnavailableUntilStarted(t *testing.T) {
+	s := New(logging.NewNop())
+	assert.False(t, s.Available())
+	err := s.SendNotification(notifications.NotificationOptions{ID: "n1", Title: "Alert"})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.NoError(t, s.ServiceShutdown(), "shutting down a service that never started is harmless")
+}
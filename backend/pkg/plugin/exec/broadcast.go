@@ -0,0 +1,445 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+// BroadcastMember is a session in a broadcast group.
+type BroadcastMember struct {
+	SessionID string `json:"sessionId"`
+	// Enabled members receive broadcast input. Disabled members stay in the
+	// group but are skipped.
+	Enabled bool `json:"enabled"`
+}
+
+// BroadcastGroup fans input out to a set of sessions, local or
+// plugin-backed. While the group is active, input written to any enabled
+// member with WriteSession is also written to the other enabled members.
+type BroadcastGroup struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Active    bool              `json:"active"`
+	Members   []BroadcastMember `json:"members"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// BroadcastWriteResult is the outcome of writing to one session.
+type BroadcastWriteResult struct {
+	SessionID string             `json:"sessionId"`
+	Error     *apperror.AppError `json:"error,omitempty"`
+}
+
+// BroadcastResult reports a broadcast write, one result per session written.
+type BroadcastResult struct {
+	// SourceSessionID is the session the input was typed into, if any.
+	SourceSessionID string                 `json:"sourceSessionId,omitempty"`
+	GroupIDs        []string               `json:"groupIds"`
+	Written         int                    `json:"written"`
+	Failed          int                    `json:"failed"`
+	Results         []BroadcastWriteResult `json:"results"`
+}
+
+// BroadcastRequest is passed to the confirmation hook before input is
+// broadcast.
+type BroadcastRequest struct {
+	GroupIDs   []string
+	SessionIDs []string
+	Data       []byte
+	// Line is the command line the input submits, reconstructed from the
+	// input broadcast since the last submit, or "" if the input does not
+	// submit a line.
+	Line string
+	// Confirmed is set when the user already confirmed this input.
+	Confirmed bool
+}
+
+// BroadcastConfirmFunc is consulted before input is broadcast. Returning an
+// error blocks the input for every session, including the source.
+type BroadcastConfirmFunc func(req BroadcastRequest) error
+
+// WithBroadcastConfirm replaces the confirmation hook for broadcast input.
+// The default is ConfirmDestructiveCommands.
+func WithBroadcastConfirm(fn BroadcastConfirmFunc) ControllerOption {
+	return func(o *controllerOptions) { o.broadcastConfirm = fn }
+}
+
+// destructiveCommands match command lines that should not reach several
+// sessions without a second look.
+//
+//nolint:gochecknoglobals // compiled once
+var destructiveCommands = []*regexp.Regexp{
+	regexp.MustCompile(`\brm\s+(-[a-zA-Z]*[rf][a-zA-Z]*\s+)+`),
+	regexp.MustCompile(`\b(shutdown|reboot|halt|poweroff|mkfs(\.\w+)?|dd)\b`),
+	regexp.MustCompile(`\bkubectl\s+.*\b(delete|drain|cordon|scale)\b`),
+	regexp.MustCompile(`(?i)\b(drop|truncate)\s+(table|database|schema)\b`),
+	regexp.MustCompile(`\bkill(all)?\s+(-9\s+|-KILL\s+)?(-1|1)\b`),
+}
+
+// ConfirmDestructiveCommands requires confirmation before a command line
+// that deletes data or stops machines is submitted to a broadcast group.
+func ConfirmDestructiveCommands(req BroadcastRequest) error {
+	if req.Confirmed || req.Line == "" {
+		return nil
+	}
+	for _, re := range destructiveCommands {
+		if re.MatchString(req.Line) {
+			return apperror.New(apperror.TypeConfirmationRequired, 428, "Confirm broadcast",
+				fmt.Sprintf("%q would run in %d sessions.", req.Line, len(req.SessionIDs))).
+				WithInstance(strings.Join(req.GroupIDs, ",")).
+				WithSuggestions("Confirm to send the command to every session in the group.")
+		}
+	}
+	return nil
+}
+
+// broadcasts holds the controller's broadcast groups.
+type broadcasts struct {
+	mu      sync.Mutex
+	groups  map[string]*BroadcastGroup
+	lines   map[string]*lineTracker // by source session or group key
+	confirm BroadcastConfirmFunc
+}
+
+func newBroadcasts(confirm BroadcastConfirmFunc) *broadcasts {
+	if confirm == nil {
+		confirm = ConfirmDestructiveCommands
+	}
+	return &broadcasts{
+		groups:  make(map[string]*BroadcastGroup),
+		lines:   make(map[string]*lineTracker),
+		confirm: confirm,
+	}
+}
+
+// lineTracker follows the command line being typed, well enough to show it
+// to the confirmation hook: printable input is appended, backspace removes a
+// character, and Ctrl-C or Ctrl-U clears the line.
+type lineTracker struct {
+	line []rune
+}
+
+// feed applies input and returns the line it submits, if any, along with
+// the tracker state to restore if the input is blocked.
+func (t *lineTracker) feed(data []byte) (string, []rune) {
+	saved := slices.Clone(t.line)
+	var submitted string
+	for _, r := range string(data) {
+		switch {
+		case r == '\r' || r == '\n':
+			if submitted == "" {
+				submitted = strings.TrimSpace(string(t.line))
+			}
+			t.line = t.line[:0]
+		case r == 0x7f || r == '\b':
+			if len(t.line) > 0 {
+				t.line = t.line[:len(t.line)-1]
+			}
+		case r == 0x03 || r == 0x15:
+			t.line = t.line[:0]
+		case r >= 0x20:
+			t.line = append(t.line, r)
+		}
+	}
+	return submitted, saved
+}
+
+func cloneGroup(g *BroadcastGroup) BroadcastGroup {
+	out := *g
+	out.Members = slices.Clone(g.Members)
+	return out
+}
+
+func broadcastGroupNotFound(groupID string) *apperror.AppError {
+	return apperror.NotFound("Broadcast group not found", fmt.Sprintf("No broadcast group with ID %s exists.", groupID))
+}
+
+// checkSessions verifies that every session exists.
+func (c *controller) checkSessions(sessionIDs []string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, id := range sessionIDs {
+		if _, ok := c.sessionIndex[id]; !ok {
+			return apperror.SessionNotFound(id)
+		}
+	}
+	return nil
+}
+
+// CreateBroadcastGroup creates an inactive group of enabled sessions.
+func (c *controller) CreateBroadcastGroup(name string, sessionIDs []string) (*BroadcastGroup, error) {
+	if err := c.checkSessions(sessionIDs); err != nil {
+		return nil, err
+	}
+	group := &BroadcastGroup{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Members:   []BroadcastMember{},
+		CreatedAt: time.Now(),
+	}
+	for _, id := range sessionIDs {
+		if !hasMember(group, id) {
+			group.Members = append(group.Members, BroadcastMember{SessionID: id, Enabled: true})
+		}
+	}
+
+	c.broadcasts.mu.Lock()
+	defer c.broadcasts.mu.Unlock()
+	c.broadcasts.groups[group.ID] = group
+	out := cloneGroup(group)
+	return &out, nil
+}
+
+// ListBroadcastGroups returns every broadcast group, oldest first.
+func (c *controller) ListBroadcastGroups() ([]BroadcastGroup, error) {
+	c.broadcasts.mu.Lock()
+	defer c.broadcasts.mu.Unlock()
+	groups := make([]BroadcastGroup, 0, len(c.broadcasts.groups))
+	for _, g := range c.broadcasts.groups {
+		groups = append(groups, cloneGroup(g))
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].CreatedAt.Before(groups[j].CreatedAt) })
+	return groups, nil
+}
+
+// GetBroadcastGroup returns a broadcast group.
+func (c *controller) GetBroadcastGroup(groupID string) (*BroadcastGroup, error) {
+	return c.updateBroadcastGroup(groupID, func(*BroadcastGroup) error { return nil })
+}
+
+// AddBroadcastSessions adds enabled sessions to a group.
+func (c *controller) AddBroadcastSessions(groupID string, sessionIDs []string) (*BroadcastGroup, error) {
+	if err := c.checkSessions(sessionIDs); err != nil {
+		return nil, err
+	}
+	return c.updateBroadcastGroup(groupID, func(g *BroadcastGroup) error {
+		for _, id := range sessionIDs {
+			if !hasMember(g, id) {
+				g.Members = append(g.Members, BroadcastMember{SessionID: id, Enabled: true})
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveBroadcastSessions removes sessions from a group.
+func (c *controller) RemoveBroadcastSessions(groupID string, sessionIDs []string) (*BroadcastGroup, error) {
+	return c.updateBroadcastGroup(groupID, func(g *BroadcastGroup) error {
+		g.Members = slices.DeleteFunc(g.Members, func(m BroadcastMember) bool {
+			return slices.Contains(sessionIDs, m.SessionID)
+		})
+		return nil
+	})
+}
+
+// SetBroadcastSessionEnabled includes or skips a member in broadcasts.
+func (c *controller) SetBroadcastSessionEnabled(groupID, sessionID string, enabled bool) (*BroadcastGroup, error) {
+	return c.updateBroadcastGroup(groupID, func(g *BroadcastGroup) error {
+		for i := range g.Members {
+			if g.Members[i].SessionID == sessionID {
+				g.Members[i].Enabled = enabled
+				return nil
+			}
+		}
+		return apperror.NotFound("Session not in broadcast group",
+			fmt.Sprintf("Session %s is not a member of broadcast group %s.", sessionID, groupID))
+	})
+}
+
+// SetBroadcastActive turns mirroring of WriteSession input on or off.
+func (c *controller) SetBroadcastActive(groupID string, active bool) (*BroadcastGroup, error) {
+	return c.updateBroadcastGroup(groupID, func(g *BroadcastGroup) error {
+		g.Active = active
+		return nil
+	})
+}
+
+// DeleteBroadcastGroup removes a broadcast group. Its sessions are not
+// affected.
+func (c *controller) DeleteBroadcastGroup(groupID string) error {
+	c.broadcasts.mu.Lock()
+	defer c.broadcasts.mu.Unlock()
+	if _, ok := c.broadcasts.groups[groupID]; !ok {
+		return broadcastGroupNotFound(groupID)
+	}
+	delete(c.broadcasts.groups, groupID)
+	delete(c.broadcasts.lines, "group:"+groupID)
+	return nil
+}
+
+func (c *controller) updateBroadcastGroup(groupID string, fn func(*BroadcastGroup) error) (*BroadcastGroup, error) {
+	c.broadcasts.mu.Lock()
+	defer c.broadcasts.mu.Unlock()
+	g, ok := c.broadcasts.groups[groupID]
+	if !ok {
+		return nil, broadcastGroupNotFound(groupID)
+	}
+	if err := fn(g); err != nil {
+		return nil, err
+	}
+	out := cloneGroup(g)
+	return &out, nil
+}
+
+func hasMember(g *BroadcastGroup, sessionID string) bool {
+	return slices.ContainsFunc(g.Members, func(m BroadcastMember) bool { return m.SessionID == sessionID })
+}
+
+// BroadcastWrite writes data to every enabled member of a group, whether or
+// not the group is active. confirmed skips the confirmation the hook would
+// otherwise ask for.
+func (c *controller) BroadcastWrite(groupID string, data []byte, confirmed bool) (*BroadcastResult, error) {
+	c.broadcasts.mu.Lock()
+	g, ok := c.broadcasts.groups[groupID]
+	if !ok {
+		c.broadcasts.mu.Unlock()
+		return nil, broadcastGroupNotFound(groupID)
+	}
+	targets := enabledMembers(g)
+	c.broadcasts.mu.Unlock()
+
+	return c.broadcast(context.Background(), "", "group:"+groupID, []string{groupID}, targets, data, confirmed)
+}
+
+// activeBroadcastTargets returns the active groups in which sessionID is an
+// enabled member, and the enabled members of those groups with sessionID
+// first.
+func (c *controller) activeBroadcastTargets(sessionID string) ([]string, []string) {
+	c.broadcasts.mu.Lock()
+	defer c.broadcasts.mu.Unlock()
+	var groupIDs []string
+	targets := []string{sessionID}
+	for _, g := range c.broadcasts.groups {
+		if !g.Active || !slices.Contains(enabledMembers(g), sessionID) {
+			continue
+		}
+		groupIDs = append(groupIDs, g.ID)
+		for _, id := range enabledMembers(g) {
+			if !slices.Contains(targets, id) {
+				targets = append(targets, id)
+			}
+		}
+	}
+	sort.Strings(groupIDs)
+	return groupIDs, targets
+}
+
+func enabledMembers(g *BroadcastGroup) []string {
+	ids := make([]string, 0, len(g.Members))
+	for _, m := range g.Members {
+		if m.Enabled {
+			ids = append(ids, m.SessionID)
+		}
+	}
+	return ids
+}
+
+// broadcast confirms and writes data to targets concurrently, returning
+// once every write has finished so successive writes stay ordered.
+func (c *controller) broadcast(
+	ctx context.Context,
+	sourceSessionID string,
+	lineKey string,
+	groupIDs []string,
+	targets []string,
+	data []byte,
+	confirmed bool,
+) (*BroadcastResult, error) {
+	c.broadcasts.mu.Lock()
+	tracker, ok := c.broadcasts.lines[lineKey]
+	if !ok {
+		tracker = &lineTracker{}
+		c.broadcasts.lines[lineKey] = tracker
+	}
+	line, saved := tracker.feed(data)
+	confirm := c.broadcasts.confirm
+	c.broadcasts.mu.Unlock()
+
+	if err := confirm(BroadcastRequest{
+		GroupIDs:   groupIDs,
+		SessionIDs: targets,
+		Data:       data,
+		Line:       line,
+		Confirmed:  confirmed,
+	}); err != nil {
+		// Nothing was written: keep the line as it was so a confirmed retry
+		// of the same input submits it.
+		c.broadcasts.mu.Lock()
+		tracker.line = saved
+		c.broadcasts.mu.Unlock()
+		return nil, err
+	}
+
+	result := &BroadcastResult{
+		SourceSessionID: sourceSessionID,
+		GroupIDs:        groupIDs,
+		Results:         make([]BroadcastWriteResult, len(targets)),
+	}
+	var wg sync.WaitGroup
+	for i, id := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result.Results[i] = BroadcastWriteResult{SessionID: id}
+			if err := c.writeSession(ctx, id, data); err != nil {
+				result.Results[i].Error = toAppError(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range result.Results {
+		if r.Error != nil {
+			result.Failed++
+		} else {
+			result.Written++
+		}
+	}
+	return result, nil
+}
+
+// broadcastInput handles WriteSession input to a member of an active group.
+// The per-session results are emitted on "core/exec/broadcast/<sessionID>";
+// the returned error is the source session's.
+func (c *controller) broadcastInput(ctx context.Context, sessionID string, groupIDs, targets []string, data []byte) error {
+	result, err := c.broadcast(ctx, sessionID, sessionID, groupIDs, targets, data, false)
+	if err != nil {
+		return err
+	}
+	if c.app != nil && result.Failed > 0 {
+		c.app.Event.Emit("core/exec/broadcast/"+sessionID, result)
+	}
+	if source := result.Results[0]; source.Error != nil {
+		return source.Error
+	}
+	return nil
+}
+
+// forgetBroadcastSession drops a closed session from every group.
+func (c *controller) forgetBroadcastSession(sessionID string) {
+	c.broadcasts.mu.Lock()
+	defer c.broadcasts.mu.Unlock()
+	for _, g := range c.broadcasts.groups {
+		g.Members = slices.DeleteFunc(g.Members, func(m BroadcastMember) bool { return m.SessionID == sessionID })
+	}
+	delete(c.broadcasts.lines, sessionID)
+}
+
+func toAppError(err error) *apperror.AppError {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperror.Wrap(err, apperror.TypeSessionFailed, 500, "Failed to write to session")
+}
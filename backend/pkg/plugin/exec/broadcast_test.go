@@ -0,0 +1,213 @@
+package exec
+
+import (
+	"errors"
+	"testing"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+// newBroadcastController builds a controller with plugin sessions s1-s3
+// whose input is captured on a buffered channel, and s4 whose plugin has
+// stopped.
+func newBroadcastController(t *testing.T, opts ...ControllerOption) (*controller, chan exec.StreamInput) {
+	t.Helper()
+	c := NewController(logging.NewNop(), nil, nil, opts...).(*controller)
+	in := make(chan exec.StreamInput, 64)
+	c.inChans["kubernetes"] = in
+	for _, id := range []string{"s1", "s2", "s3"} {
+		c.sessionIndex[id] = sessionIndex{pluginID: "kubernetes", connectionID: "prod"}
+	}
+	c.sessionIndex["s4"] = sessionIndex{pluginID: "stopped", connectionID: "prod"}
+	return c, in
+}
+
+// drain returns the input written so far, by session.
+func drain(in chan exec.StreamInput) map[string]string {
+	got := make(map[string]string)
+	for {
+		select {
+		case input := <-in:
+			got[input.SessionID] += string(input.Data)
+		default:
+			return got
+		}
+	}
+}
+
+func TestBroadcast_GroupManagement(t *testing.T) {
+	c, _ := newBroadcastController(t)
+
+	_, err := c.CreateBroadcastGroup("pods", []string{"s1", "missing"})
+	requireAppErrorType(t, err, apperror.TypeSessionNotFound)
+
+	group, err := c.CreateBroadcastGroup("pods", []string{"s1", "s2", "s1"})
+	require.NoError(t, err)
+	assert.False(t, group.Active)
+	assert.Equal(t, []BroadcastMember{{SessionID: "s1", Enabled: true}, {SessionID: "s2", Enabled: true}}, group.Members)
+
+	group, err = c.AddBroadcastSessions(group.ID, []string{"s3"})
+	require.NoError(t, err)
+	assert.Len(t, group.Members, 3)
+
+	group, err = c.SetBroadcastSessionEnabled(group.ID, "s3", false)
+	require.NoError(t, err)
+	assert.False(t, group.Members[2].Enabled)
+	_, err = c.SetBroadcastSessionEnabled(group.ID, "s4", true)
+	requireAppErrorType(t, err, apperror.TypeResourceNotFound)
+
+	group, err = c.RemoveBroadcastSessions(group.ID, []string{"s2"})
+	require.NoError(t, err)
+	assert.Len(t, group.Members, 2)
+
+	// Returned groups are copies.
+	group.Members[0].Enabled = false
+	stored, err := c.GetBroadcastGroup(group.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.Members[0].Enabled)
+
+	groups, err := c.ListBroadcastGroups()
+	require.NoError(t, err)
+	assert.Len(t, groups, 1)
+
+	require.NoError(t, c.DeleteBroadcastGroup(group.ID))
+	_, err = c.GetBroadcastGroup(group.ID)
+	requireAppErrorType(t, err, apperror.TypeResourceNotFound)
+	requireAppErrorType(t, c.DeleteBroadcastGroup(group.ID), apperror.TypeResourceNotFound)
+}
+
+func TestBroadcast_WriteReportsPerSessionErrors(t *testing.T) {
+	c, in := newBroadcastController(t)
+	group, err := c.CreateBroadcastGroup("pods", []string{"s1", "s2", "s3", "s4"})
+	require.NoError(t, err)
+	_, err = c.SetBroadcastSessionEnabled(group.ID, "s3", false)
+	require.NoError(t, err)
+
+	result, err := c.BroadcastWrite(group.ID, []byte("uptime\r"), false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Written)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Results, 3)
+	assert.Nil(t, result.Results[0].Error)
+	assert.Equal(t, "s4", result.Results[2].SessionID)
+	require.NotNil(t, result.Results[2].Error)
+	assert.Equal(t, apperror.TypeSessionNotFound, result.Results[2].Error.Type)
+
+	assert.Equal(t, map[string]string{"s1": "uptime\r", "s2": "uptime\r"}, drain(in))
+
+	_, err = c.BroadcastWrite("missing", []byte("x"), false)
+	requireAppErrorType(t, err, apperror.TypeResourceNotFound)
+}
+
+func TestBroadcast_WriteSessionMirrorsActiveGroup(t *testing.T) {
+	c, in := newBroadcastController(t)
+	group, err := c.CreateBroadcastGroup("pods", []string{"s1", "s2"})
+	require.NoError(t, err)
+
+	require.NoError(t, c.WriteSession("s1", []byte("a")))
+	assert.Equal(t, map[string]string{"s1": "a"}, drain(in), "inactive groups do not mirror")
+
+	_, err = c.SetBroadcastActive(group.ID, true)
+	require.NoError(t, err)
+	require.NoError(t, c.WriteSession("s2", []byte("b")))
+	require.NoError(t, c.WriteSession("s3", []byte("c")))
+	assert.Equal(t, map[string]string{"s1": "b", "s2": "b", "s3": "c"}, drain(in))
+
+	_, err = c.SetBroadcastSessionEnabled(group.ID, "s2", false)
+	require.NoError(t, err)
+	require.NoError(t, c.WriteSession("s2", []byte("d")))
+	assert.Equal(t, map[string]string{"s2": "d"}, drain(in), "disabled members type alone")
+
+	c.forgetBroadcastSession("s1")
+	stored, err := c.GetBroadcastGroup(group.ID)
+	require.NoError(t, err)
+	assert.Len(t, stored.Members, 1)
+}
+
+func TestBroadcast_ConfirmsDestructiveCommands(t *testing.T) {
+	c, in := newBroadcastController(t)
+	group, err := c.CreateBroadcastGroup("pods", []string{"s1", "s2"})
+	require.NoError(t, err)
+	_, err = c.SetBroadcastActive(group.ID, true)
+	require.NoError(t, err)
+
+	for _, r := range "rm -rf /var/lib/data" {
+		require.NoError(t, c.WriteSession("s1", []byte(string(r))))
+	}
+	drain(in)
+
+	err = c.WriteSession("s1", []byte("\r"))
+	requireAppErrorType(t, err, apperror.TypeConfirmationRequired)
+	assert.Empty(t, drain(in), "blocked input reaches no session")
+
+	// Asking again without confirmation is still blocked.
+	requireAppErrorType(t, c.WriteSession("s1", []byte("\r")), apperror.TypeConfirmationRequired)
+
+	result, err := c.ConfirmBroadcastInput("s1", []byte("\r"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Written)
+	assert.Equal(t, map[string]string{"s1": "\r", "s2": "\r"}, drain(in))
+
+	// The line was submitted; the next one starts fresh.
+	require.NoError(t, c.WriteSession("s1", []byte("ls\r")))
+	_, err = c.BroadcastWrite(group.ID, []byte("kubectl delete pod web-0\n"), false)
+	requireAppErrorType(t, err, apperror.TypeConfirmationRequired)
+	_, err = c.BroadcastWrite(group.ID, []byte("kubectl delete pod web-0\n"), true)
+	require.NoError(t, err)
+}
+
+func TestBroadcast_CustomConfirmHook(t *testing.T) {
+	var seen []BroadcastRequest
+	deny := errors.New("denied")
+	c, in := newBroadcastController(t, WithBroadcastConfirm(func(req BroadcastRequest) error {
+		seen = append(seen, req)
+		if req.Line == "deploy" && !req.Confirmed {
+			return deny
+		}
+		return nil
+	}))
+	group, err := c.CreateBroadcastGroup("hosts", []string{"s1", "s2"})
+	require.NoError(t, err)
+
+	_, err = c.BroadcastWrite(group.ID, []byte("deplo"), false)
+	require.NoError(t, err)
+	_, err = c.BroadcastWrite(group.ID, []byte("x\x7fy\x7f"), false)
+	require.NoError(t, err)
+	_, err = c.BroadcastWrite(group.ID, []byte("y\r"), false)
+	assert.ErrorIs(t, err, deny)
+
+	require.Len(t, seen, 3)
+	assert.Empty(t, seen[0].Line)
+	assert.Equal(t, "deploy", seen[2].Line)
+	assert.Equal(t, []string{group.ID}, seen[2].GroupIDs)
+	assert.Equal(t, []string{"s1", "s2"}, seen[2].SessionIDs)
+	assert.Equal(t, "deplox\x7fy\x7f", drain(in)["s1"])
+}
+
+func TestLineTracker(t *testing.T) {
+	var tr lineTracker
+	line, _ := tr.feed([]byte("echo hi\x15rm -rf /tmp/x\r"))
+	assert.Equal(t, "rm -rf /tmp/x", line)
+	line, _ = tr.feed([]byte("ls\x03"))
+	assert.Empty(t, line)
+	line, _ = tr.feed([]byte("\r"))
+	assert.Empty(t, line)
+}
+
+func TestConfirmDestructiveCommands(t *testing.T) {
+	for _, line := range []string{
+		"rm -rf /", "sudo rm -r build", "sudo reboot", "kubectl -n prod delete deploy web",
+		"DROP TABLE users;", "kill -9 1", "mkfs.ext4 /dev/sdb",
+	} {
+		assert.Error(t, ConfirmDestructiveCommands(BroadcastRequest{Line: line}), line)
+		assert.NoError(t, ConfirmDestructiveCommands(BroadcastRequest{Line: line, Confirmed: true}), line)
+	}
+	for _, line := range []string{"ls -la", "kubectl get pods", "rm file.txt", "echo reboots", ""} {
+		assert.NoError(t, ConfirmDestructiveCommands(BroadcastRequest{Line: line}), line)
+	}
+}
@@ -0,0 +1,58 @@
+package exec
+
+import (
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+// shellIntegration returns the ShellIntegrationSetting, which is on unless
+// turned off.
+func (c *controller) shellIntegration() bool {
+	if c.settingsProvider == nil {
+		return true
+	}
+	enabled, err := c.settingsProvider.GetBool(ShellIntegrationSetting)
+	return err != nil || enabled
+}
+
+// emitCommand notifies the frontend of a command that finished in a local
+// terminal, so it can mark failed commands.
+func (c *controller) emitCommand(sessionID string, cmd terminal.Command) {
+	if c.app != nil {
+		c.app.Event.Emit("core/exec/command/"+sessionID, cmd)
+	}
+}
+
+// localSession checks that a session is a local terminal, the only kind
+// whose command history is tracked.
+func (c *controller) localSession(sessionID string) error {
+	index, _, ok := c.lookupSession(sessionID)
+	if !ok {
+		return apperror.SessionNotFound(sessionID)
+	}
+	if !index.local {
+		return apperror.NotImplemented(
+			"Command history unavailable",
+			"Command history is only tracked for local terminals.",
+		)
+	}
+	return nil
+}
+
+// GetCommandHistory returns the commands run in a local terminal with shell
+// integration, oldest first.
+func (c *controller) GetCommandHistory(sessionID string) ([]terminal.Command, error) {
+	if err := c.localSession(sessionID); err != nil {
+		return nil, err
+	}
+	return c.terminalManager.CommandHistory(sessionID)
+}
+
+// GetCommandOutput returns the output of a command in a local terminal's
+// history as plain text.
+func (c *controller) GetCommandOutput(sessionID string, commandID int) (terminal.CommandOutput, error) {
+	if err := c.localSession(sessionID); err != nil {
+		return terminal.CommandOutput{}, err
+	}
+	return c.terminalManager.CommandOutput(sessionID, commandID)
+}
@@ -0,0 +1,47 @@
+package exec
+
+import (
+	"errors"
+	"testing"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	pkgsettings "github.com/omniviewdev/plugin-sdk/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+func TestController_ShellIntegration(t *testing.T) {
+	assert.True(t, newTestController().(*controller).shellIntegration(), "on without a settings provider")
+
+	sp := pkgsettings.NewProvider(pkgsettings.ProviderOpts{
+		Logger: zap.NewNop().Sugar(),
+		PluginSettings: []pkgsettings.Category{{
+			ID: "terminal",
+			Settings: map[string]pkgsettings.Setting{
+				"shellIntegration": {ID: "shellIntegration", Type: pkgsettings.Toggle, Default: true},
+			},
+		}},
+	})
+	c := NewController(logging.NewNop(), sp, nil).(*controller)
+	assert.True(t, c.shellIntegration())
+
+	require.NoError(t, sp.SetSetting(ShellIntegrationSetting, false))
+	assert.False(t, c.shellIntegration())
+}
+
+func TestGetCommandHistory_Errors(t *testing.T) {
+	c := newTestController().(*controller)
+	c.sessionIndex["remote"] = sessionIndex{pluginID: "kubernetes", connectionID: "prod"}
+
+	_, err := c.GetCommandHistory("missing")
+	var target *apperror.AppError
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+
+	_, err = c.GetCommandOutput("remote", 1)
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeNotImplemented, target.Type)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource"
 	internaltypes "github.com/omniviewdev/omniview/backend/pkg/plugin/types"
 	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal/recording"
 
 	"github.com/omniviewdev/plugin-sdk/pkg/config"
 	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
@@ -24,6 +25,18 @@ import (
 
 var tracer = otel.Tracer("omniview.exec")
 
+// DefaultShellSetting is the setting holding the shell local terminals start
+// when no command is given.
+const DefaultShellSetting = "terminal.defaultShell"
+
+// PersistSessionsSetting is the setting that runs new local terminals in the
+// session daemon, so they survive the application quitting.
+const PersistSessionsSetting = "terminal.persistSessions"
+
+// ShellIntegrationSetting is the setting that loads the shell integration
+// scripts into new local bash, zsh and fish terminals.
+const ShellIntegrationSetting = "terminal.shellIntegration"
+
 type Controller interface {
 	internaltypes.Controller
 	ServiceStartup(ctx context.Context, options application.ServiceOptions) error
@@ -33,6 +46,7 @@ type Controller interface {
 	GetHandler(plugin, resource string) *exec.Handler
 	CreateSession(plugin, connectionID string, opts exec.SessionOptions) (*exec.Session, error)
 	CreateTerminal(opts exec.SessionOptions) (*exec.Session, error)
+	CreateConnectionTerminal(plugin, connectionID string, opts exec.SessionOptions) (*exec.Session, error)
 	ListSessions() ([]*exec.Session, error)
 	GetSession(sessionID string) (*exec.Session, error)
 	AttachSession(sessionID string) (*exec.Session, []byte, error)
@@ -40,6 +54,43 @@ type Controller interface {
 	WriteSession(sessionID string, data []byte) error
 	CloseSession(sessionID string) error
 	ResizeSession(sessionID string, rows, cols uint16) error
+
+	// Recording
+	StartRecording(sessionID string, opts RecordingOptions) (recording.Info, error)
+	StopRecording(sessionID string) (recording.Info, error)
+	ListRecordings() ([]recording.Info, error)
+	GetRecording(recordingID string) (*Recording, error)
+	ExportRecording(recordingID string, format RecordingExportFormat) (string, error)
+	DeleteRecording(recordingID string) error
+	ReplayRecording(recordingID string, speed float64) (string, error)
+	CancelReplay(replayID string) error
+
+	// Broadcast
+	CreateBroadcastGroup(name string, sessionIDs []string) (*BroadcastGroup, error)
+	ListBroadcastGroups() ([]BroadcastGroup, error)
+	GetBroadcastGroup(groupID string) (*BroadcastGroup, error)
+	AddBroadcastSessions(groupID string, sessionIDs []string) (*BroadcastGroup, error)
+	RemoveBroadcastSessions(groupID string, sessionIDs []string) (*BroadcastGroup, error)
+	SetBroadcastSessionEnabled(groupID, sessionID string, enabled bool) (*BroadcastGroup, error)
+	SetBroadcastActive(groupID string, active bool) (*BroadcastGroup, error)
+	DeleteBroadcastGroup(groupID string) error
+	BroadcastWrite(groupID string, data []byte, confirmed bool) (*BroadcastResult, error)
+	ConfirmBroadcastInput(sessionID string, data []byte) (*BroadcastResult, error)
+
+	// Command history
+	GetCommandHistory(sessionID string) ([]terminal.Command, error)
+	GetCommandOutput(sessionID string, commandID int) (terminal.CommandOutput, error)
+
+	// Scrollback
+	SearchScrollback(sessionID string, opts terminal.SearchOptions) (*terminal.SearchResult, error)
+	ExportScrollback(sessionID string, format ScrollbackExportFormat) (string, error)
+
+	// Output throttling
+	ResumeOutput(sessionID string) error
+	GetOutputState(sessionID string) (terminal.OutputState, error)
+
+	// Commands
+	RunCommand(ctx context.Context, plugin, connectionID string, opts exec.SessionOptions) (*CommandRun, error)
 }
 
 // make it easy for us to lookup sessions by ID, without having to know
@@ -50,11 +101,45 @@ type sessionIndex struct {
 	local        bool
 }
 
+type controllerOptions struct {
+	recordings   *recording.Store
+	daemonSocket string
+	daemonExe    string
+
+	broadcastConfirm BroadcastConfirmFunc
+}
+
+// ControllerOption configures the exec controller.
+type ControllerOption func(*controllerOptions)
+
+// WithRecordingStore enables session recording to store. Without it the
+// recording methods return a not-implemented error.
+func WithRecordingStore(store *recording.Store) ControllerOption {
+	return func(o *controllerOptions) { o.recordings = store }
+}
+
+// WithSessionDaemon lets local terminals outlive the application, when
+// PersistSessionsSetting is on, by running them in the session daemon on
+// socketPath, started from executable. Sessions still running in the daemon
+// are reattached on startup.
+func WithSessionDaemon(socketPath, executable string) ControllerOption {
+	return func(o *controllerOptions) {
+		o.daemonSocket = socketPath
+		o.daemonExe = executable
+	}
+}
+
 func NewController(
 	logger logging.Logger,
 	sp pkgsettings.Provider,
 	resourceClient resource.Service,
+	opts ...ControllerOption,
 ) Controller {
+	var cfg controllerOptions
+	for _, o := range opts {
+		o(&cfg)
+	}
+
 	return &controller{
 		logger:           logger.Named("ExecController"),
 		settingsProvider: sp,
@@ -65,6 +150,15 @@ func NewController(
 		resizeMux:        make(chan exec.StreamResize),
 		resourceClient:   resourceClient,
 		handlerMap:       make(map[string]map[string]exec.Handler),
+		recordings:       cfg.recordings,
+		recorders:        make(map[string]*recording.Recorder),
+		replays:          make(map[string]context.CancelFunc),
+		termSizes:        make(map[string]termSize),
+		daemonSocket:     cfg.daemonSocket,
+		daemonExe:        cfg.daemonExe,
+		broadcasts:       newBroadcasts(cfg.broadcastConfirm),
+		scrollbacks:      make(map[string]*terminal.OutputBuffer),
+		runs:             make(map[string]*commandRun),
 	}
 }
 
@@ -90,6 +184,27 @@ type controller struct {
 
 	resourceClient  resource.Service
 	terminalManager *terminal.Manager
+
+	// session recording; recordings is nil when recording is disabled
+	recordings *recording.Store
+	recMu      sync.Mutex
+	recorders  map[string]*recording.Recorder // by session ID
+	replays    map[string]context.CancelFunc  // by replay ID
+	termSizes  map[string]termSize            // last known size by session ID
+
+	// session daemon; daemonSocket is empty when it is disabled
+	daemonSocket string
+	daemonExe    string
+
+	broadcasts *broadcasts
+
+	// scrollback of plugin sessions by session ID
+	scrollMu    sync.Mutex
+	scrollbacks map[string]*terminal.OutputBuffer
+
+	// commands started with RunCommand by session ID
+	runMu sync.Mutex
+	runs  map[string]*commandRun
 }
 
 func (c *controller) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
@@ -98,15 +213,60 @@ func (c *controller) ServiceStartup(ctx context.Context, options application.Ser
 
 	// Initialize the terminal manager synchronously so c.terminalManager is
 	// safe to read before any goroutine starts.
-	manager, inMux, outMux, resizeMux := terminal.NewManager(ctx, c.logger)
+	managerOpts := []terminal.ManagerOption{
+		terminal.WithDefaultShell(c.defaultShell),
+		terminal.WithShellIntegration(c.shellIntegration),
+		terminal.WithOutputLimits(c.outputLimits),
+	}
+	if c.daemonSocket != "" {
+		managerOpts = append(managerOpts, terminal.WithSessionDaemon(c.daemonSocket, c.daemonExe, c.persistSessions))
+	}
+	manager, inMux, outMux, resizeMux := terminal.NewManager(ctx, c.logger, managerOpts...)
 	c.terminalManager = manager
+	// Local output is recorded at the PTY rather than from the mux, which
+	// also carries the scrollback replayed on attach.
+	manager.SetOutputObserver(c.captureOutput)
+	manager.SetCommandObserver(c.emitCommand)
+	manager.SetExitObserver(c.finishRun)
+	manager.SetOutputStateObserver(c.emitOutputState)
+
+	// Pick up terminals left running in the session daemon by a previous run.
+	for _, session := range manager.Reattach() {
+		c.sessionIndex[session.ID] = sessionIndex{
+			local:        true,
+			connectionID: session.Labels[TerminalConnectionLabel],
+		}
+	}
 
 	go c.runMux()                                // plugin mux
 	go c.runLocalMux(inMux, outMux, resizeMux)   // local terminal should be muxed separately to avoid latency
 	return nil
 }
 
+// defaultShell returns the terminal.defaultShell setting, or "" if it is
+// unavailable.
+func (c *controller) defaultShell() string {
+	if c.settingsProvider == nil {
+		return ""
+	}
+	shell, err := c.settingsProvider.GetString(DefaultShellSetting)
+	if err != nil {
+		return ""
+	}
+	return shell
+}
+
+// persistSessions returns the PersistSessionsSetting.
+func (c *controller) persistSessions() bool {
+	if c.settingsProvider == nil {
+		return false
+	}
+	persist, err := c.settingsProvider.GetBool(PersistSessionsSetting)
+	return err == nil && persist
+}
+
 func (c *controller) ServiceShutdown() error {
+	c.stopAllRecordings()
 	return nil
 }
 
@@ -167,6 +327,8 @@ func (c *controller) runLocalMux(
 				c.mu.Lock()
 				delete(c.sessionIndex, output.SessionID)
 				c.mu.Unlock()
+				c.recordClose(output.SessionID)
+				c.forgetBroadcastSession(output.SessionID)
 			default:
 				c.logger.Debugw(context.Background(), "received signal", "signal", output.Signal.String())
 				eventkey = "core/exec/signal/" + output.Signal.String() + "/" + output.SessionID
@@ -209,6 +371,8 @@ func (c *controller) runMux() {
 			switch output.Signal {
 			case exec.StreamSignalNone:
 				eventkey = "core/exec/stream/" + output.Target.String() + "/" + output.SessionID
+				c.recordOutput(output.SessionID, output.Data)
+				c.appendScrollback(output.SessionID, output.Data)
 			case exec.StreamSignalError:
 				eventkey = "core/exec/signal/" + output.Signal.String() + "/" + output.SessionID
 				if output.Error != nil {
@@ -229,6 +393,9 @@ func (c *controller) runMux() {
 				c.mu.Lock()
 				delete(c.sessionIndex, output.SessionID)
 				c.mu.Unlock()
+				c.recordClose(output.SessionID)
+				c.forgetBroadcastSession(output.SessionID)
+				c.forgetScrollback(output.SessionID)
 			default:
 				c.logger.Debugw(context.Background(), "received signal", "signal", output.Signal.String())
 				eventkey = "core/exec/signal/" + output.Signal.String() + "/" + output.SessionID
@@ -352,6 +519,7 @@ func (c *controller) cleanupPluginLocked(pluginID string) (ExecProvider, bool) {
 	for sid, idx := range c.sessionIndex {
 		if idx.pluginID == pluginID {
 			delete(c.sessionIndex, sid)
+			c.forgetScrollback(sid)
 		}
 	}
 	for plugin, resources := range c.handlerMap {
@@ -498,12 +666,83 @@ func (c *controller) GetHandler(
 
 // CreateTerminal creates a local terminal session with TTY enabled.
 // This is a convenience wrapper for CreateSession("local", "local", opts)
-// with TTY forced on.
+// with TTY forced on. Without a command it starts the shell configured by
+// DefaultShellSetting.
 func (c *controller) CreateTerminal(opts exec.SessionOptions) (*exec.Session, error) {
 	opts.TTY = true
 	return c.CreateSession("local", "local", opts)
 }
 
+// CreateConnectionTerminal creates a local terminal "in" a plugin connection:
+// the shell gets the environment and working directory the plugin sets in the
+// connection's terminal.* data keys (see terminalEnvironment), such as the
+// kubeconfig and context of a Kubernetes cluster or AWS_PROFILE and
+// AWS_REGION for an AWS account. The session is labeled with the plugin and
+// connection IDs.
+func (c *controller) CreateConnectionTerminal(
+	plugin string,
+	connectionID string,
+	opts exec.SessionOptions,
+) (session *exec.Session, err error) {
+	ctx, span := tracer.Start(context.Background(), "exec.CreateConnectionTerminal")
+	defer span.End()
+	defer func() {
+		if err != nil {
+			telemetryutil.RecordError(span, err)
+		}
+	}()
+	span.SetAttributes(
+		attribute.String("plugin_id", plugin),
+		attribute.String("connection_id", connectionID),
+	)
+
+	if c.resourceClient == nil {
+		return nil, apperror.New(apperror.TypeSessionFailed, 500, "Connection terminals unavailable",
+			"The resource service is not available.")
+	}
+	connection, err := c.resourceClient.GetConnection(plugin, connectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.TTY = true
+	labels := make(map[string]string, len(opts.Labels)+2)
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	labels[TerminalPluginLabel] = plugin
+	labels[TerminalConnectionLabel] = connectionID
+	opts.Labels = labels
+
+	// The session stays local: it is not indexed under the plugin, so it
+	// survives the plugin stopping like any other local terminal.
+	return c.startLocalSession(ctx, opts, terminalEnvironment(connection), sessionIndex{
+		local:        true,
+		connectionID: connectionID,
+	})
+}
+
+func (c *controller) startLocalSession(
+	ctx context.Context,
+	opts exec.SessionOptions,
+	local terminal.SessionOptions,
+	index sessionIndex,
+) (*exec.Session, error) {
+	session, err := c.terminalManager.StartSession(
+		c.getUnconnectedCtx(ctx, "local"),
+		opts,
+		local,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.sessionIndex[session.ID] = index
+	c.mu.Unlock()
+	c.recordOnCreate(session, index)
+	return session, nil
+}
+
 func (c *controller) CreateSession(
 	plugin string,
 	connectionID string,
@@ -523,17 +762,7 @@ func (c *controller) CreateSession(
 
 	if plugin == "local" {
 		// start local terminal
-		session, err = c.terminalManager.StartSession(
-			c.getUnconnectedCtx(ctx, plugin),
-			opts,
-		)
-		if err != nil {
-			return nil, err
-		}
-		c.mu.Lock()
-		c.sessionIndex[session.ID] = sessionIndex{local: true}
-		c.mu.Unlock()
-		return session, nil
+		return c.startLocalSession(ctx, opts, terminal.SessionOptions{}, sessionIndex{local: true})
 	}
 
 	c.mu.RLock()
@@ -551,13 +780,15 @@ func (c *controller) CreateSession(
 		return nil, err
 	}
 
-	c.mu.Lock()
-	c.sessionIndex[session.ID] = sessionIndex{
+	index := sessionIndex{
 		local:        false,
 		pluginID:     plugin,
 		connectionID: connectionID,
 	}
+	c.mu.Lock()
+	c.sessionIndex[session.ID] = index
 	c.mu.Unlock()
+	c.recordOnCreate(session, index)
 
 	return session, nil
 }
@@ -660,10 +891,14 @@ func (c *controller) AttachSession(sessionID string) (session *exec.Session, dat
 	if client == nil {
 		return nil, nil, apperror.PluginNotFound(index.pluginID)
 	}
-	return client.AttachSession(
+	session, data, err = client.AttachSession(
 		c.getConnectedCtx(ctx, index.pluginID, index.connectionID),
 		sessionID,
 	)
+	if err == nil {
+		c.seedScrollback(sessionID, data)
+	}
+	return session, data, err
 }
 
 func (c *controller) DetachSession(sessionID string) (session *exec.Session, err error) {
@@ -706,6 +941,33 @@ func (c *controller) WriteSession(
 	}()
 	span.SetAttributes(attribute.String("session_id", sessionID))
 
+	if groupIDs, targets := c.activeBroadcastTargets(sessionID); len(groupIDs) > 0 {
+		return c.broadcastInput(ctx, sessionID, groupIDs, targets, data)
+	}
+	return c.writeSession(ctx, sessionID, data)
+}
+
+// ConfirmBroadcastInput writes input to a member of an active broadcast
+// group after the user confirmed it, bypassing the confirmation hook that
+// blocked it in WriteSession.
+func (c *controller) ConfirmBroadcastInput(sessionID string, data []byte) (*BroadcastResult, error) {
+	groupIDs, targets := c.activeBroadcastTargets(sessionID)
+	if len(groupIDs) == 0 {
+		if err := c.writeSession(context.Background(), sessionID, data); err != nil {
+			return nil, err
+		}
+		return &BroadcastResult{
+			SourceSessionID: sessionID,
+			GroupIDs:        []string{},
+			Written:         1,
+			Results:         []BroadcastWriteResult{{SessionID: sessionID}},
+		}, nil
+	}
+	return c.broadcast(context.Background(), sessionID, sessionID, groupIDs, targets, data, true)
+}
+
+// writeSession writes data to a single session.
+func (c *controller) writeSession(ctx context.Context, sessionID string, data []byte) error {
 	c.mu.RLock()
 	index, ok := c.sessionIndex[sessionID]
 	var inchan chan exec.StreamInput
@@ -716,6 +978,7 @@ func (c *controller) WriteSession(
 	if !ok {
 		return apperror.SessionNotFound(sessionID)
 	}
+	c.recordInput(sessionID, data)
 	if index.local {
 		return c.terminalManager.WriteSession(sessionID, data)
 	}
@@ -775,15 +1038,19 @@ func (c *controller) ResizeSession(
 		return apperror.SessionNotFound(sessionID)
 	}
 	if index.local {
-		return c.terminalManager.ResizeSession(sessionID, rows, cols)
-	}
-	if client == nil {
+		err = c.terminalManager.ResizeSession(sessionID, rows, cols)
+	} else if client == nil {
 		return apperror.PluginNotFound(index.pluginID)
+	} else {
+		err = client.ResizeSession(
+			c.getConnectedCtx(ctx, index.pluginID, index.connectionID),
+			sessionID,
+			int32(cols),
+			int32(rows),
+		)
 	}
-	return client.ResizeSession(
-		c.getConnectedCtx(ctx, index.pluginID, index.connectionID),
-		sessionID,
-		int32(cols),
-		int32(rows),
-	)
+	if err == nil {
+		c.recordResize(sessionID, cols, rows)
+	}
+	return err
 }
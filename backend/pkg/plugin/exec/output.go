@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+// OutputRateLimitSetting is the setting holding the output rate, in KiB per
+// second, above which a local terminal's output is paused; 0 for no limit.
+const OutputRateLimitSetting = "terminal.outputRateLimit"
+
+// DefaultOutputRateLimit is the OutputRateLimitSetting used when the setting
+// is unavailable.
+const DefaultOutputRateLimit = 4096
+
+// outputLimits returns the output limits for a new local terminal.
+func (c *controller) outputLimits() terminal.OutputLimits {
+	rate := DefaultOutputRateLimit
+	if c.settingsProvider != nil {
+		if value, err := c.settingsProvider.GetInt(OutputRateLimitSetting); err == nil {
+			rate = value
+		}
+	}
+	return terminal.OutputLimits{RateLimit: max(rate, 0) * 1024}
+}
+
+// emitOutputState notifies the frontend that a local terminal's output was
+// paused or resumed.
+func (c *controller) emitOutputState(sessionID string, state terminal.OutputState) {
+	if c.app != nil {
+		c.app.Event.Emit("core/exec/output/"+sessionID, state)
+	}
+}
+
+// ResumeOutput forwards a local terminal's output again after it was paused
+// for exceeding the output rate limit. Output produced while paused is not
+// replayed, but can be found in the scrollback.
+func (c *controller) ResumeOutput(sessionID string) error {
+	if err := c.throttledSession(sessionID); err != nil {
+		return err
+	}
+	return c.terminalManager.ResumeOutput(sessionID)
+}
+
+// GetOutputState returns whether a local terminal's output is paused, and
+// how much of its output was coalesced and dropped.
+func (c *controller) GetOutputState(sessionID string) (terminal.OutputState, error) {
+	if err := c.throttledSession(sessionID); err != nil {
+		return terminal.OutputState{}, err
+	}
+	return c.terminalManager.OutputState(sessionID)
+}
+
+// throttledSession checks that a session is a local terminal, the only kind
+// whose output is rate limited.
+func (c *controller) throttledSession(sessionID string) error {
+	index, _, ok := c.lookupSession(sessionID)
+	if !ok {
+		return apperror.SessionNotFound(sessionID)
+	}
+	if !index.local {
+		return apperror.NotImplemented(
+			"Output throttling unavailable",
+			"Output is only rate limited for local terminals.",
+		)
+	}
+	return nil
+}
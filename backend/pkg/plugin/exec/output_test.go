@@ -0,0 +1,63 @@
+package exec
+
+import (
+	"errors"
+	"testing"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	pkgsettings "github.com/omniviewdev/plugin-sdk/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+func TestController_OutputLimits(t *testing.T) {
+	limits := newTestController().(*controller).outputLimits()
+	assert.Equal(t, DefaultOutputRateLimit*1024, limits.RateLimit, "the default without a settings provider")
+
+	sp := pkgsettings.NewProvider(pkgsettings.ProviderOpts{
+		Logger: zap.NewNop().Sugar(),
+		PluginSettings: []pkgsettings.Category{{
+			ID: "terminal",
+			Settings: map[string]pkgsettings.Setting{
+				"outputRateLimit": {ID: "outputRateLimit", Type: pkgsettings.Integer, Default: 4096},
+			},
+		}},
+	})
+	c := NewController(logging.NewNop(), sp, nil).(*controller)
+	require.NoError(t, sp.SetSetting(OutputRateLimitSetting, 64))
+	assert.Equal(t, terminal.OutputLimits{RateLimit: 64 * 1024}, c.outputLimits())
+
+	require.NoError(t, sp.SetSetting(OutputRateLimitSetting, 0))
+	assert.Zero(t, c.outputLimits().RateLimit, "0 disables the limit")
+}
+
+func TestOutputState_LocalTerminal(t *testing.T) {
+	c := newRunController(t)
+	session, err := c.CreateTerminal(exec.SessionOptions{Command: []string{"/bin/sh", "-c", "echo hi; sleep 30"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.CloseSession(session.ID) })
+
+	state, err := c.GetOutputState(session.ID)
+	require.NoError(t, err)
+	assert.False(t, state.Paused)
+	require.NoError(t, c.ResumeOutput(session.ID), "resuming output that is not paused is allowed")
+}
+
+func TestOutputState_Errors(t *testing.T) {
+	c := newTestController().(*controller)
+	c.sessionIndex["remote"] = sessionIndex{pluginID: "kubernetes", connectionID: "prod"}
+
+	var target *apperror.AppError
+	_, err := c.GetOutputState("missing")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+
+	err = c.ResumeOutput("remote")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeNotImplemented, target.Type)
+}
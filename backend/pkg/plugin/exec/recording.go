@@ -0,0 +1,339 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/store/sidecar"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal/recording"
+)
+
+// RecordLabel is the session label that starts a recording as soon as the
+// session is created. "true" records output and resizes; "input" also
+// captures the data written to the session.
+const RecordLabel = "omniview.dev/record"
+
+// RecordingOptions configures a recording started with StartRecording.
+type RecordingOptions struct {
+	// Title is stored in the asciicast header. Defaults to the session command.
+	Title string `json:"title,omitempty"`
+	// CaptureInput records keystrokes as "i" events. Off by default because
+	// input can include secrets typed at prompts.
+	CaptureInput bool `json:"captureInput"`
+}
+
+// Recording is a decoded recording for client-side playback.
+type Recording struct {
+	Info   recording.Info    `json:"info"`
+	Header recording.Header  `json:"header"`
+	Events []recording.Event `json:"events"`
+}
+
+// RecordingExportFormat selects the output of ExportRecording.
+type RecordingExportFormat string
+
+const (
+	// RecordingExportCast is the asciicast v2 file, playable with asciinema.
+	RecordingExportCast RecordingExportFormat = "cast"
+	// RecordingExportText is the session output with escape sequences removed.
+	RecordingExportText RecordingExportFormat = "text"
+)
+
+// RecordingsMaxSizeSetting is the setting holding the size, in MiB, of the
+// recordings directory above which the oldest finished recordings are
+// deleted; 0 for no limit.
+const RecordingsMaxSizeSetting = "terminal.recordingsMaxSize"
+
+// recordingsMaxSize returns the RecordingsMaxSizeSetting in bytes, or the
+// store's default when the setting is unavailable.
+func (c *controller) recordingsMaxSize() int64 {
+	if c.settingsProvider != nil {
+		if value, err := c.settingsProvider.GetInt(RecordingsMaxSizeSetting); err == nil {
+			return int64(max(value, 0)) << 20
+		}
+	}
+	return recording.DefaultMaxSize
+}
+
+// recordingKind names recordings in the errors of recording operations.
+const recordingKind sidecar.Kind = "recording"
+
+// replayIdleLimit caps pauses during replay so idle stretches of a long
+// session don't stall playback.
+const replayIdleLimit = 2 * time.Second
+
+type termSize struct {
+	cols, rows uint16
+}
+
+// StartRecording starts recording a session's output to an asciicast file.
+func (c *controller) StartRecording(sessionID string, opts RecordingOptions) (recording.Info, error) {
+	if c.recordings == nil {
+		return recording.Info{}, recordingKind.Unavailable()
+	}
+	index, _, ok := c.lookupSession(sessionID)
+	if !ok {
+		return recording.Info{}, apperror.SessionNotFound(sessionID)
+	}
+
+	var command []string
+	var labels map[string]string
+	if session, err := c.GetSession(sessionID); err == nil && session != nil {
+		command, labels = session.Command, session.Labels
+	}
+	return c.startRecording(sessionID, index, command, labels, opts)
+}
+
+func (c *controller) startRecording(
+	sessionID string,
+	index sessionIndex,
+	command []string,
+	labels map[string]string,
+	opts RecordingOptions,
+) (recording.Info, error) {
+	pluginID := index.pluginID
+	if index.local {
+		pluginID = "local"
+	}
+	if opts.Title == "" {
+		opts.Title = strings.Join(command, " ")
+	}
+
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	if existing, ok := c.recorders[sessionID]; ok {
+		return recording.Info{}, apperror.New(apperror.TypeResourceConflict, 409, "Session is already being recorded",
+			fmt.Sprintf("Session %s is already being recorded (recording %s).", sessionID, existing.Info().ID))
+	}
+	size := c.termSizes[sessionID]
+	c.recordings.SetMaxSize(c.recordingsMaxSize())
+	rec, err := c.recordings.Start(recording.Options{
+		SessionID:    sessionID,
+		PluginID:     pluginID,
+		ConnectionID: index.connectionID,
+		Title:        opts.Title,
+		Command:      command,
+		Labels:       labels,
+		Width:        int(size.cols),
+		Height:       int(size.rows),
+		CaptureInput: opts.CaptureInput,
+	})
+	if err != nil {
+		return recording.Info{}, apperror.Internal(err, "Failed to start recording")
+	}
+	c.recorders[sessionID] = rec
+	return rec.Info(), nil
+}
+
+// StopRecording stops recording a session and returns the finished recording.
+func (c *controller) StopRecording(sessionID string) (recording.Info, error) {
+	c.recMu.Lock()
+	rec, ok := c.recorders[sessionID]
+	delete(c.recorders, sessionID)
+	c.recMu.Unlock()
+	if !ok {
+		return recording.Info{}, apperror.NotFound("Recording not found",
+			fmt.Sprintf("Session %s is not being recorded.", sessionID))
+	}
+	info, err := rec.Close()
+	if err != nil {
+		return info, apperror.Internal(err, "Recording did not finish cleanly")
+	}
+	return info, nil
+}
+
+// ListRecordings returns every recording, newest first.
+func (c *controller) ListRecordings() ([]recording.Info, error) {
+	if c.recordings == nil {
+		return []recording.Info{}, nil
+	}
+	infos, err := c.recordings.List()
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to list recordings")
+	}
+	return infos, nil
+}
+
+// GetRecording returns a recording's info, header and events.
+func (c *controller) GetRecording(recordingID string) (*Recording, error) {
+	if c.recordings == nil {
+		return nil, recordingKind.Unavailable()
+	}
+	info, err := c.recordings.Get(recordingID)
+	if err != nil {
+		return nil, recordingKind.Error(err, recordingID)
+	}
+	header, events, err := c.recordings.Load(recordingID)
+	if err != nil {
+		return nil, recordingKind.Error(err, recordingID)
+	}
+	return &Recording{Info: info, Header: *header, Events: events}, nil
+}
+
+// ExportRecording returns a recording as an asciicast file or as plain text.
+func (c *controller) ExportRecording(recordingID string, format RecordingExportFormat) (string, error) {
+	if c.recordings == nil {
+		return "", recordingKind.Unavailable()
+	}
+	switch format {
+	case RecordingExportCast:
+		data, err := c.recordings.ReadCast(recordingID)
+		if err != nil {
+			return "", recordingKind.Error(err, recordingID)
+		}
+		return string(data), nil
+	case RecordingExportText:
+		text, err := c.recordings.Text(recordingID)
+		if err != nil {
+			return "", recordingKind.Error(err, recordingID)
+		}
+		return text, nil
+	default:
+		return "", apperror.New(apperror.TypeValidation, 400, "Invalid export format",
+			fmt.Sprintf("Unknown format %q (must be \"cast\" or \"text\").", format))
+	}
+}
+
+// DeleteRecording removes a finished recording.
+func (c *controller) DeleteRecording(recordingID string) error {
+	if c.recordings == nil {
+		return recordingKind.Unavailable()
+	}
+	if err := c.recordings.Delete(recordingID); err != nil {
+		return recordingKind.Error(err, recordingID)
+	}
+	return nil
+}
+
+// ReplayRecording plays a recording back through the regular session stream
+// events, under a new replay ID, so it can be shown in a terminal view:
+// output arrives on "core/exec/stream/stdout/<replayID>" with its recorded
+// timing (scaled by speed) and a CLOSE signal follows the last event. Pauses
+// are capped at two seconds.
+func (c *controller) ReplayRecording(recordingID string, speed float64) (string, error) {
+	if c.recordings == nil {
+		return "", recordingKind.Unavailable()
+	}
+	if c.app == nil {
+		return "", apperror.New(apperror.TypeSessionFailed, 500, "Replay unavailable", "The application has not started.")
+	}
+	_, events, err := c.recordings.Load(recordingID)
+	if err != nil {
+		return "", recordingKind.Error(err, recordingID)
+	}
+
+	replayID := "replay-" + uuid.NewString()
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.recMu.Lock()
+	c.replays[replayID] = cancel
+	c.recMu.Unlock()
+
+	go func() {
+		defer func() {
+			c.recMu.Lock()
+			delete(c.replays, replayID)
+			c.recMu.Unlock()
+			cancel()
+		}()
+		outKey := "core/exec/stream/" + exec.StreamTargetStdOut.String() + "/" + replayID
+		_ = recording.Replay(ctx, events, speed, replayIdleLimit, func(event recording.Event) {
+			if event.Type == recording.EventOutput {
+				c.app.Event.Emit(outKey, []byte(event.Data))
+			}
+		})
+		c.app.Event.Emit("core/exec/signal/"+exec.StreamSignalClose.String()+"/"+replayID, []byte("Replay finished"))
+	}()
+	return replayID, nil
+}
+
+// CancelReplay stops a running replay. The CLOSE signal is still emitted.
+func (c *controller) CancelReplay(replayID string) error {
+	c.recMu.Lock()
+	cancel, ok := c.replays[replayID]
+	c.recMu.Unlock()
+	if !ok {
+		return apperror.NotFound("Replay not found", fmt.Sprintf("No replay with ID %s is running.", replayID))
+	}
+	cancel()
+	return nil
+}
+
+// recorder returns the active recorder for a session, if any.
+func (c *controller) recorder(sessionID string) *recording.Recorder {
+	c.recMu.Lock()
+	defer c.recMu.Unlock()
+	return c.recorders[sessionID]
+}
+
+// recordOutput is called with every chunk of session output.
+func (c *controller) recordOutput(sessionID string, data []byte) {
+	if rec := c.recorder(sessionID); rec != nil {
+		rec.Output(data)
+	}
+}
+
+func (c *controller) recordInput(sessionID string, data []byte) {
+	if rec := c.recorder(sessionID); rec != nil {
+		rec.Input(data)
+	}
+}
+
+func (c *controller) recordResize(sessionID string, cols, rows uint16) {
+	c.recMu.Lock()
+	c.termSizes[sessionID] = termSize{cols: cols, rows: rows}
+	rec := c.recorders[sessionID]
+	c.recMu.Unlock()
+	if rec != nil {
+		rec.Resize(cols, rows)
+	}
+}
+
+// recordClose finishes any recording of a session that has ended.
+func (c *controller) recordClose(sessionID string) {
+	c.recMu.Lock()
+	rec := c.recorders[sessionID]
+	delete(c.recorders, sessionID)
+	delete(c.termSizes, sessionID)
+	c.recMu.Unlock()
+	if rec == nil {
+		return
+	}
+	if _, err := rec.Close(); err != nil {
+		c.logger.Errorw(context.Background(), "error finishing recording", "session", sessionID, "error", err)
+	}
+}
+
+// recordOnCreate starts a recording for a new session that carries RecordLabel.
+func (c *controller) recordOnCreate(session *exec.Session, index sessionIndex) {
+	mode := session.Labels[RecordLabel]
+	if mode == "" || c.recordings == nil {
+		return
+	}
+	opts := RecordingOptions{CaptureInput: mode == "input"}
+	if _, err := c.startRecording(session.ID, index, session.Command, session.Labels, opts); err != nil {
+		c.logger.Errorw(context.Background(), "error starting recording", "session", session.ID, "error", err)
+	}
+}
+
+// stopAllRecordings finishes every recording and cancels running replays.
+func (c *controller) stopAllRecordings() {
+	c.recMu.Lock()
+	c.recorders = make(map[string]*recording.Recorder)
+	for _, cancel := range c.replays {
+		cancel()
+	}
+	c.recMu.Unlock()
+	if c.recordings == nil {
+		return
+	}
+	if err := c.recordings.CloseAll(); err != nil {
+		c.logger.Errorw(context.Background(), "error finishing recordings", "error", err)
+	}
+}
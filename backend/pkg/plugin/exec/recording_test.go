@@ -0,0 +1,136 @@
+package exec
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	pkgsettings "github.com/omniviewdev/plugin-sdk/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal/recording"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+)
+
+// newRecordingController builds a controller with recording enabled and a
+// plugin session "sess-1" registered in the session index.
+func newRecordingController(t *testing.T) *controller {
+	t.Helper()
+	store, err := recording.NewStore(filepath.Join(t.TempDir(), "recordings"))
+	require.NoError(t, err)
+	c := NewController(logging.NewNop(), nil, nil, WithRecordingStore(store)).(*controller)
+	c.sessionIndex["sess-1"] = sessionIndex{pluginID: "kubernetes", connectionID: "prod"}
+	return c
+}
+
+func requireAppErrorType(t *testing.T, err error, want string) {
+	t.Helper()
+	var target *apperror.AppError
+	require.True(t, errors.As(err, &target), "expected AppError, got %v", err)
+	assert.Equal(t, want, target.Type)
+}
+
+func TestRecording_StartStop(t *testing.T) {
+	c := newRecordingController(t)
+	c.recordResize("sess-1", 132, 43)
+
+	info, err := c.startRecording("sess-1", c.sessionIndex["sess-1"], []string{"sh"}, nil, RecordingOptions{CaptureInput: true})
+	require.NoError(t, err)
+	assert.Equal(t, "kubernetes", info.PluginID)
+	assert.Equal(t, "prod", info.ConnectionID)
+	assert.Equal(t, "sh", info.Title)
+
+	_, err = c.startRecording("sess-1", c.sessionIndex["sess-1"], nil, nil, RecordingOptions{})
+	requireAppErrorType(t, err, apperror.TypeResourceConflict)
+
+	c.recordOutput("sess-1", []byte("$ "))
+	c.recordInput("sess-1", []byte("exit\r"))
+	c.recordOutput("other-session", []byte("ignored"))
+
+	finished, err := c.StopRecording("sess-1")
+	require.NoError(t, err)
+	assert.Equal(t, info.ID, finished.ID)
+	assert.False(t, finished.Active)
+
+	rec, err := c.GetRecording(info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 132, rec.Header.Width)
+	assert.Equal(t, 43, rec.Header.Height)
+	require.Len(t, rec.Events, 2)
+	assert.Equal(t, recording.EventOutput, rec.Events[0].Type)
+	assert.Equal(t, recording.EventInput, rec.Events[1].Type)
+
+	_, err = c.StopRecording("sess-1")
+	requireAppErrorType(t, err, apperror.TypeResourceNotFound)
+}
+
+func TestRecording_StartsFromLabelAndStopsOnClose(t *testing.T) {
+	c := newRecordingController(t)
+	session := &exec.Session{ID: "sess-1", Labels: map[string]string{RecordLabel: "true"}}
+	c.recordOnCreate(session, c.sessionIndex["sess-1"])
+
+	c.recordOutput("sess-1", []byte("hello\r\n"))
+	c.recordClose("sess-1")
+
+	infos, err := c.ListRecordings()
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.False(t, infos[0].Active)
+	assert.False(t, infos[0].CaptureInput)
+
+	text, err := c.ExportRecording(infos[0].ID, RecordingExportText)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", text)
+
+	cast, err := c.ExportRecording(infos[0].ID, RecordingExportCast)
+	require.NoError(t, err)
+	assert.Contains(t, cast, `"version":2`)
+
+	_, err = c.ExportRecording(infos[0].ID, "html")
+	requireAppErrorType(t, err, apperror.TypeValidation)
+
+	require.NoError(t, c.DeleteRecording(infos[0].ID))
+	_, err = c.GetRecording(infos[0].ID)
+	requireAppErrorType(t, err, apperror.TypeResourceNotFound)
+}
+
+func TestRecording_SessionNotFound(t *testing.T) {
+	c := newRecordingController(t)
+	_, err := c.StartRecording("missing", RecordingOptions{})
+	requireAppErrorType(t, err, apperror.TypeSessionNotFound)
+}
+
+func TestRecording_Disabled(t *testing.T) {
+	c := newTestController()
+	_, err := c.StartRecording("sess-1", RecordingOptions{})
+	requireAppErrorType(t, err, apperror.TypeNotImplemented)
+
+	infos, err := c.ListRecordings()
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+}
+
+func TestRecording_MaxSizeSetting(t *testing.T) {
+	assert.Equal(t, recording.DefaultMaxSize, newTestController().(*controller).recordingsMaxSize(),
+		"the default without a settings provider")
+
+	sp := pkgsettings.NewProvider(pkgsettings.ProviderOpts{
+		Logger: zap.NewNop().Sugar(),
+		PluginSettings: []pkgsettings.Category{{
+			ID: "terminal",
+			Settings: map[string]pkgsettings.Setting{
+				"recordingsMaxSize": {ID: "recordingsMaxSize", Type: pkgsettings.Integer, Default: 1024},
+			},
+		}},
+	})
+	c := NewController(logging.NewNop(), sp, nil).(*controller)
+	require.NoError(t, sp.SetSetting(RecordingsMaxSizeSetting, 64))
+	assert.Equal(t, int64(64<<20), c.recordingsMaxSize())
+
+	require.NoError(t, sp.SetSetting(RecordingsMaxSizeSetting, 0))
+	assert.Zero(t, c.recordingsMaxSize(), "0 disables the limit")
+}
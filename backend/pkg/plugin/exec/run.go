@@ -0,0 +1,128 @@
+package exec
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+// MaxCommandRunOutput is the number of bytes of output kept for a command
+// run with RunCommand; earlier output is dropped.
+const MaxCommandRunOutput = 1 << 20
+
+// CommandRun is the result of a command run with RunCommand.
+type CommandRun struct {
+	SessionID string `json:"sessionId"`
+	// ExitCode is -1 when the process was killed by a signal or the run was
+	// cancelled.
+	ExitCode int `json:"exitCode"`
+	// Output is the command's output with escape sequences removed.
+	Output string `json:"output"`
+	// Truncated reports that the start of the output was dropped.
+	Truncated bool      `json:"truncated"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+}
+
+// commandRun collects the output and exit code of a RunCommand session.
+type commandRun struct {
+	output   *terminal.OutputBuffer
+	done     chan struct{}
+	exitCode int
+}
+
+// captureOutput is the terminal manager's output observer.
+func (c *controller) captureOutput(sessionID string, data []byte) {
+	c.recordOutput(sessionID, data)
+	c.runMu.Lock()
+	run := c.runs[sessionID]
+	c.runMu.Unlock()
+	if run != nil {
+		run.output.Append(data)
+	}
+}
+
+// finishRun is the terminal manager's exit observer.
+func (c *controller) finishRun(sessionID string, exitCode int) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	if run, ok := c.runs[sessionID]; ok {
+		run.exitCode = exitCode
+		close(run.done)
+		delete(c.runs, sessionID)
+	}
+}
+
+// RunCommand runs opts.Command to completion in a local terminal and returns
+// its output and exit code. With a plugin and connection the terminal is
+// opened in the connection, as with CreateConnectionTerminal. The session is
+// an ordinary terminal session, so its output can be followed live while it
+// runs; opts.ID, if set, is used as its ID.
+//
+// Cancelling ctx closes the session and returns the output so far together
+// with the context's error.
+func (c *controller) RunCommand(
+	ctx context.Context,
+	plugin string,
+	connectionID string,
+	opts exec.SessionOptions,
+) (*CommandRun, error) {
+	if len(opts.Command) == 0 {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid command",
+			"A command to run is required.")
+	}
+	if opts.ID == "" {
+		opts.ID = uuid.NewString()
+	}
+
+	// Register before starting so no output is missed.
+	run := &commandRun{output: terminal.NewOutputBuffer(MaxCommandRunOutput), done: make(chan struct{})}
+	c.runMu.Lock()
+	if _, exists := c.runs[opts.ID]; exists {
+		c.runMu.Unlock()
+		return nil, apperror.New(apperror.TypeResourceConflict, 409, "Session already exists",
+			"A command is already running in session "+opts.ID+".")
+	}
+	c.runs[opts.ID] = run
+	c.runMu.Unlock()
+
+	var (
+		session *exec.Session
+		err     error
+	)
+	if plugin == "" {
+		session, err = c.CreateTerminal(opts)
+	} else {
+		session, err = c.CreateConnectionTerminal(plugin, connectionID, opts)
+	}
+	if err != nil {
+		c.runMu.Lock()
+		delete(c.runs, opts.ID)
+		c.runMu.Unlock()
+		return nil, err
+	}
+
+	result := &CommandRun{SessionID: session.ID, ExitCode: -1, StartedAt: time.Now()}
+	select {
+	case <-run.done:
+		result.ExitCode = run.exitCode
+	case <-ctx.Done():
+		if closeErr := c.CloseSession(session.ID); closeErr != nil {
+			c.logger.Debugw(context.Background(), "error closing cancelled command", "session", session.ID, "error", closeErr)
+		}
+		c.runMu.Lock()
+		delete(c.runs, session.ID)
+		c.runMu.Unlock()
+		err = ctx.Err()
+	}
+	result.EndedAt = time.Now()
+	output, dropped := run.output.Snapshot()
+	result.Output = string(terminal.StripANSI(output))
+	result.Truncated = dropped > 0
+	return result, err
+}
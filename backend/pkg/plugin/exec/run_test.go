@@ -0,0 +1,82 @@
+package exec
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+// newRunController returns a controller with a terminal manager whose
+// output is drained, as ServiceStartup would set it up.
+func newRunController(t *testing.T) *controller {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("local terminals are not supported on Windows")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	c := newTestController().(*controller)
+	c.ctx = ctx
+	manager, _, out, _ := terminal.NewManager(ctx, logging.NewNop())
+	manager.SetOutputObserver(c.captureOutput)
+	manager.SetExitObserver(c.finishRun)
+	c.terminalManager = manager
+	go func() {
+		for range out { //nolint:revive // drain
+		}
+	}()
+	return c
+}
+
+func TestRunCommand(t *testing.T) {
+	c := newRunController(t)
+
+	run, err := c.RunCommand(context.Background(), "", "", exec.SessionOptions{
+		ID:      "run-1",
+		Command: []string{"/bin/sh", "-c", "printf '\\033[1mbold\\033[0m\\n'; exit 3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "run-1", run.SessionID)
+	assert.Equal(t, 3, run.ExitCode)
+	assert.Contains(t, run.Output, "bold")
+	assert.NotContains(t, run.Output, "\x1b", "escape sequences are removed")
+	assert.False(t, run.Truncated)
+	c.runMu.Lock()
+	assert.Empty(t, c.runs)
+	c.runMu.Unlock()
+}
+
+func TestRunCommand_Cancel(t *testing.T) {
+	c := newRunController(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	run, err := c.RunCommand(ctx, "", "", exec.SessionOptions{
+		Command: []string{"/bin/sh", "-c", "echo started; sleep 30"},
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, run)
+	assert.Equal(t, -1, run.ExitCode)
+	assert.Contains(t, run.Output, "started")
+	c.runMu.Lock()
+	assert.Empty(t, c.runs)
+	c.runMu.Unlock()
+}
+
+func TestRunCommand_RequiresCommand(t *testing.T) {
+	c := newTestController().(*controller)
+	_, err := c.RunCommand(context.Background(), "", "", exec.SessionOptions{})
+	var target *apperror.AppError
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type)
+}
@@ -0,0 +1,109 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+)
+
+// ScrollbackExportFormat selects the output of ExportScrollback.
+type ScrollbackExportFormat string
+
+const (
+	// ScrollbackExportText is the scrollback with escape sequences removed.
+	ScrollbackExportText ScrollbackExportFormat = "text"
+	// ScrollbackExportHTML is an HTML page with the output's colors.
+	ScrollbackExportHTML ScrollbackExportFormat = "html"
+)
+
+// appendScrollback adds output of a plugin session to its scrollback. Local
+// sessions are buffered by the terminal manager.
+func (c *controller) appendScrollback(sessionID string, data []byte) {
+	c.scrollMu.Lock()
+	buffer, ok := c.scrollbacks[sessionID]
+	if !ok {
+		buffer = terminal.NewOutputBuffer(exec.DefaultOutputBufferSize)
+		c.scrollbacks[sessionID] = buffer
+	}
+	c.scrollMu.Unlock()
+	buffer.Append(data)
+}
+
+// seedScrollback fills the scrollback of a plugin session that has none yet
+// with the output the plugin buffered, e.g. from before this controller
+// started tracking it.
+func (c *controller) seedScrollback(sessionID string, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	c.scrollMu.Lock()
+	defer c.scrollMu.Unlock()
+	if _, ok := c.scrollbacks[sessionID]; ok {
+		return
+	}
+	buffer := terminal.NewOutputBuffer(exec.DefaultOutputBufferSize)
+	buffer.Append(data)
+	c.scrollbacks[sessionID] = buffer
+}
+
+func (c *controller) forgetScrollback(sessionID string) {
+	c.scrollMu.Lock()
+	delete(c.scrollbacks, sessionID)
+	c.scrollMu.Unlock()
+}
+
+// scrollback returns a session's buffered output and the number of bytes of
+// output evicted before it.
+func (c *controller) scrollback(sessionID string) ([]byte, int64, error) {
+	index, _, ok := c.lookupSession(sessionID)
+	if !ok {
+		return nil, 0, apperror.SessionNotFound(sessionID)
+	}
+	if index.local {
+		return c.terminalManager.Scrollback(sessionID)
+	}
+	c.scrollMu.Lock()
+	buffer := c.scrollbacks[sessionID]
+	c.scrollMu.Unlock()
+	if buffer == nil {
+		return nil, 0, nil
+	}
+	data, dropped := buffer.Snapshot()
+	return data, dropped, nil
+}
+
+// SearchScrollback searches a session's scrollback, with escape sequences
+// removed, for a regular expression.
+func (c *controller) SearchScrollback(sessionID string, opts terminal.SearchOptions) (*terminal.SearchResult, error) {
+	if opts.Pattern == "" {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid search", "The search pattern is empty.")
+	}
+	data, dropped, err := c.scrollback(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := terminal.SearchScrollback(data, dropped, opts)
+	if err != nil {
+		return nil, apperror.Wrap(err, apperror.TypeValidation, 400, "Invalid search pattern")
+	}
+	return result, nil
+}
+
+// ExportScrollback returns a session's scrollback as plain text or as an
+// HTML page that keeps its colors.
+func (c *controller) ExportScrollback(sessionID string, format ScrollbackExportFormat) (string, error) {
+	if format != ScrollbackExportText && format != ScrollbackExportHTML {
+		return "", apperror.New(apperror.TypeValidation, 400, "Invalid export format",
+			fmt.Sprintf("Unknown format %q (must be \"text\" or \"html\").", format))
+	}
+	data, _, err := c.scrollback(sessionID)
+	if err != nil {
+		return "", err
+	}
+	if format == ScrollbackExportHTML {
+		return terminal.RenderHTML(data, "Session "+sessionID), nil
+	}
+	return string(terminal.StripANSI(data)), nil
+}
@@ -0,0 +1,66 @@
+package exec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+func TestScrollback_PluginSession(t *testing.T) {
+	c := newTestController().(*controller)
+	c.sessionIndex["sess-1"] = sessionIndex{pluginID: "kubernetes", connectionID: "prod"}
+
+	// output that arrived before the attach wins over the plugin's buffer
+	c.appendScrollback("sess-1", []byte("pod/web \x1b[31mError\x1b[0m\r\n"))
+	c.seedScrollback("sess-1", []byte("ignored"))
+	c.appendScrollback("sess-1", []byte("pod/db Running\r\n"))
+
+	result, err := c.SearchScrollback("sess-1", terminal.SearchOptions{Pattern: `pod/\w+ Error`})
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, 0, result.Matches[0].Line)
+
+	text, err := c.ExportScrollback("sess-1", ScrollbackExportText)
+	require.NoError(t, err)
+	assert.Equal(t, "pod/web Error\npod/db Running\n", text)
+
+	page, err := c.ExportScrollback("sess-1", ScrollbackExportHTML)
+	require.NoError(t, err)
+	assert.Contains(t, page, `<span style="color:#cd3131;">Error</span>`)
+
+	c.forgetScrollback("sess-1")
+	text, err = c.ExportScrollback("sess-1", ScrollbackExportText)
+	require.NoError(t, err)
+	assert.Empty(t, text)
+
+	c.seedScrollback("sess-1", []byte("from plugin"))
+	text, err = c.ExportScrollback("sess-1", ScrollbackExportText)
+	require.NoError(t, err)
+	assert.Equal(t, "from plugin", text)
+}
+
+func TestScrollback_Errors(t *testing.T) {
+	c := newTestController().(*controller)
+	c.sessionIndex["sess-1"] = sessionIndex{pluginID: "kubernetes", connectionID: "prod"}
+
+	var target *apperror.AppError
+	_, err := c.SearchScrollback("missing", terminal.SearchOptions{Pattern: "x"})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+
+	for _, pattern := range []string{"", "("} {
+		_, err = c.SearchScrollback("sess-1", terminal.SearchOptions{Pattern: pattern})
+		require.True(t, errors.As(err, &target), pattern)
+		assert.Equal(t, apperror.TypeValidation, target.Type)
+	}
+
+	_, err = c.ExportScrollback("sess-1", "pdf")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, 400, target.Status)
+	assert.Contains(t, target.Detail, "pdf")
+}
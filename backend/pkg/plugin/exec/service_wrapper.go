@@ -5,6 +5,9 @@ import (
 
 	execsdk "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
 	"github.com/wailsapp/wails/v3/pkg/application"
+
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal/recording"
 )
 
 // ServiceWrapper is an explicit delegation wrapper around exec.Controller.
@@ -35,6 +38,9 @@ func (s *ServiceWrapper) CreateSession(plugin, connectionID string, opts execsdk
 func (s *ServiceWrapper) CreateTerminal(opts execsdk.SessionOptions) (*execsdk.Session, error) {
 	return s.Ctrl.CreateTerminal(opts)
 }
+func (s *ServiceWrapper) CreateConnectionTerminal(plugin, connectionID string, opts execsdk.SessionOptions) (*execsdk.Session, error) {
+	return s.Ctrl.CreateConnectionTerminal(plugin, connectionID, opts)
+}
 func (s *ServiceWrapper) ListSessions() ([]*execsdk.Session, error) {
 	return s.Ctrl.ListSessions()
 }
@@ -71,3 +77,77 @@ func (s *ServiceWrapper) ListPlugins() ([]string, error) {
 func (s *ServiceWrapper) HasPlugin(pluginID string) bool {
 	return s.Ctrl.HasPlugin(pluginID)
 }
+func (s *ServiceWrapper) StartRecording(sessionID string, opts RecordingOptions) (recording.Info, error) {
+	return s.Ctrl.StartRecording(sessionID, opts)
+}
+func (s *ServiceWrapper) StopRecording(sessionID string) (recording.Info, error) {
+	return s.Ctrl.StopRecording(sessionID)
+}
+func (s *ServiceWrapper) ListRecordings() ([]recording.Info, error) {
+	return s.Ctrl.ListRecordings()
+}
+func (s *ServiceWrapper) GetRecording(recordingID string) (*Recording, error) {
+	return s.Ctrl.GetRecording(recordingID)
+}
+func (s *ServiceWrapper) ExportRecording(recordingID string, format RecordingExportFormat) (string, error) {
+	return s.Ctrl.ExportRecording(recordingID, format)
+}
+func (s *ServiceWrapper) DeleteRecording(recordingID string) error {
+	return s.Ctrl.DeleteRecording(recordingID)
+}
+func (s *ServiceWrapper) ReplayRecording(recordingID string, speed float64) (string, error) {
+	return s.Ctrl.ReplayRecording(recordingID, speed)
+}
+func (s *ServiceWrapper) CancelReplay(replayID string) error {
+	return s.Ctrl.CancelReplay(replayID)
+}
+
+func (s *ServiceWrapper) CreateBroadcastGroup(name string, sessionIDs []string) (*BroadcastGroup, error) {
+	return s.Ctrl.CreateBroadcastGroup(name, sessionIDs)
+}
+func (s *ServiceWrapper) ListBroadcastGroups() ([]BroadcastGroup, error) {
+	return s.Ctrl.ListBroadcastGroups()
+}
+func (s *ServiceWrapper) GetBroadcastGroup(groupID string) (*BroadcastGroup, error) {
+	return s.Ctrl.GetBroadcastGroup(groupID)
+}
+func (s *ServiceWrapper) AddBroadcastSessions(groupID string, sessionIDs []string) (*BroadcastGroup, error) {
+	return s.Ctrl.AddBroadcastSessions(groupID, sessionIDs)
+}
+func (s *ServiceWrapper) RemoveBroadcastSessions(groupID string, sessionIDs []string) (*BroadcastGroup, error) {
+	return s.Ctrl.RemoveBroadcastSessions(groupID, sessionIDs)
+}
+func (s *ServiceWrapper) SetBroadcastSessionEnabled(groupID, sessionID string, enabled bool) (*BroadcastGroup, error) {
+	return s.Ctrl.SetBroadcastSessionEnabled(groupID, sessionID, enabled)
+}
+func (s *ServiceWrapper) SetBroadcastActive(groupID string, active bool) (*BroadcastGroup, error) {
+	return s.Ctrl.SetBroadcastActive(groupID, active)
+}
+func (s *ServiceWrapper) DeleteBroadcastGroup(groupID string) error {
+	return s.Ctrl.DeleteBroadcastGroup(groupID)
+}
+func (s *ServiceWrapper) BroadcastWrite(groupID string, data []byte, confirmed bool) (*BroadcastResult, error) {
+	return s.Ctrl.BroadcastWrite(groupID, data, confirmed)
+}
+func (s *ServiceWrapper) ConfirmBroadcastInput(sessionID string, data []byte) (*BroadcastResult, error) {
+	return s.Ctrl.ConfirmBroadcastInput(sessionID, data)
+}
+
+func (s *ServiceWrapper) GetCommandHistory(sessionID string) ([]terminal.Command, error) {
+	return s.Ctrl.GetCommandHistory(sessionID)
+}
+func (s *ServiceWrapper) GetCommandOutput(sessionID string, commandID int) (terminal.CommandOutput, error) {
+	return s.Ctrl.GetCommandOutput(sessionID, commandID)
+}
+func (s *ServiceWrapper) SearchScrollback(sessionID string, opts terminal.SearchOptions) (*terminal.SearchResult, error) {
+	return s.Ctrl.SearchScrollback(sessionID, opts)
+}
+func (s *ServiceWrapper) ExportScrollback(sessionID string, format ScrollbackExportFormat) (string, error) {
+	return s.Ctrl.ExportScrollback(sessionID, format)
+}
+func (s *ServiceWrapper) ResumeOutput(sessionID string) error {
+	return s.Ctrl.ResumeOutput(sessionID)
+}
+func (s *ServiceWrapper) GetOutputState(sessionID string) (terminal.OutputState, error) {
+	return s.Ctrl.GetOutputState(sessionID)
+}
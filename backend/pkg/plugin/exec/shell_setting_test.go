@@ -0,0 +1,30 @@
+package exec
+
+import (
+	"testing"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	pkgsettings "github.com/omniviewdev/plugin-sdk/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestController_DefaultShell(t *testing.T) {
+	assert.Empty(t, newTestController().(*controller).defaultShell(), "no settings provider")
+
+	sp := pkgsettings.NewProvider(pkgsettings.ProviderOpts{
+		Logger: zap.NewNop().Sugar(),
+		PluginSettings: []pkgsettings.Category{{
+			ID: "terminal",
+			Settings: map[string]pkgsettings.Setting{
+				"defaultShell": {ID: "defaultShell", Type: pkgsettings.Text, Default: "/bin/zsh"},
+			},
+		}},
+	})
+	c := NewController(logging.NewNop(), sp, nil).(*controller)
+	assert.Equal(t, "/bin/zsh", c.defaultShell())
+
+	require.NoError(t, sp.SetSetting(DefaultShellSetting, "/usr/bin/fish"))
+	assert.Equal(t, "/usr/bin/fish", c.defaultShell())
+}
@@ -0,0 +1,58 @@
+package exec
+
+import (
+	"fmt"
+
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+
+	sdktypes "github.com/omniviewdev/plugin-sdk/pkg/types"
+)
+
+// Connection data keys a plugin sets to shape terminals opened in one of its
+// connections. The plugin owns the connection, so it alone knows which
+// credentials and directory a shell in it needs; core applies what it finds.
+const (
+	// ConnectionTerminalEnvKey holds a map of environment variables, such as
+	// AWS_PROFILE and AWS_REGION.
+	ConnectionTerminalEnvKey = "terminal.env"
+	// ConnectionTerminalDirKey holds the shell's working directory.
+	ConnectionTerminalDirKey = "terminal.cwd"
+	// ConnectionTerminalKubeconfigKey holds the kubeconfig file the shell's
+	// KUBECONFIG points at.
+	ConnectionTerminalKubeconfigKey = "terminal.kubeconfig"
+	// ConnectionTerminalContextKey holds the kubeconfig context selected for
+	// the shell, without changing the current context of the file.
+	ConnectionTerminalContextKey = "terminal.context"
+)
+
+// Labels added to terminals opened in a connection.
+const (
+	TerminalPluginLabel     = "omniview.dev/plugin"
+	TerminalConnectionLabel = "omniview.dev/connection"
+)
+
+// terminalEnvironment returns the environment a connection contributes to a
+// local terminal through its terminal.* data keys.
+func terminalEnvironment(conn sdktypes.Connection) terminal.SessionOptions {
+	opts := terminal.SessionOptions{
+		Env:        make(map[string]string),
+		Dir:        connString(conn, ConnectionTerminalDirKey),
+		Kubeconfig: connString(conn, ConnectionTerminalKubeconfigKey),
+		Context:    connString(conn, ConnectionTerminalContextKey),
+	}
+	if env, ok := conn.Data[ConnectionTerminalEnvKey].(map[string]any); ok {
+		for k, v := range env {
+			if v == nil {
+				continue
+			}
+			opts.Env[k] = fmt.Sprint(v)
+		}
+	}
+	return opts
+}
+
+// connString returns a string from the connection's data.
+func connString(conn sdktypes.Connection, key string) string {
+	s, _ := conn.Data[key].(string)
+	return s
+}
@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sdktypes "github.com/omniviewdev/plugin-sdk/pkg/types"
+)
+
+func TestTerminalEnvironment(t *testing.T) {
+	opts := terminalEnvironment(sdktypes.Connection{
+		ID: "staging",
+		Data: map[string]any{
+			ConnectionTerminalEnvKey:        map[string]any{"AWS_PROFILE": "admin", "RETRIES": 3, "UNSET": nil},
+			ConnectionTerminalDirKey:        "~/infra",
+			ConnectionTerminalKubeconfigKey: "/home/me/.kube/prod",
+			ConnectionTerminalContextKey:    "arn:aws:eks:prod",
+		},
+	})
+	assert.Equal(t, map[string]string{"AWS_PROFILE": "admin", "RETRIES": "3"}, opts.Env)
+	assert.Equal(t, "~/infra", opts.Dir)
+	assert.Equal(t, "/home/me/.kube/prod", opts.Kubeconfig)
+	assert.Equal(t, "arn:aws:eks:prod", opts.Context)
+}
+
+func TestTerminalEnvironment_NothingGuessed(t *testing.T) {
+	opts := terminalEnvironment(sdktypes.Connection{
+		ID:     "prod",
+		Data:   map[string]any{"kubeconfig": "/home/me/.kube/prod", "region": "eu-west-1"},
+		Labels: map[string]any{ConnectionTerminalDirKey: "~/infra"},
+	})
+	assert.Empty(t, opts.Env)
+	assert.Empty(t, opts.Dir)
+	assert.Empty(t, opts.Kubeconfig)
+	assert.Empty(t, opts.Context, "the connection ID is not taken for a context")
+}
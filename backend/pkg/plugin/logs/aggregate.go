@@ -0,0 +1,312 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/telemetryutil"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+)
+
+// AggregateReorderWindow is how long the lines of an aggregate session are
+// held so that a line arriving late from one source can still be merged
+// before later lines of another.
+const AggregateReorderWindow = 250 * time.Millisecond
+
+// Labels added to every line of an aggregate session naming where it came
+// from.
+const (
+	AggregateSourceLabel  = "omniview.dev/aggregate-source"
+	AggregateSessionLabel = "omniview.dev/aggregate-session"
+)
+
+// AggregateSource describes one underlying session of an aggregate session.
+type AggregateSource struct {
+	PluginID     string                    `json:"pluginId"`
+	ConnectionID string                    `json:"connectionId"`
+	Options      logs.CreateSessionOptions `json:"options"`
+	// Label tags the source's lines. It defaults to the connection and
+	// resource the session reads from.
+	Label string `json:"label"`
+}
+
+// AggregateSourceSession is an underlying session of an aggregate session.
+type AggregateSourceSession struct {
+	SessionID    string `json:"sessionId"`
+	PluginID     string `json:"pluginId"`
+	ConnectionID string `json:"connectionId"`
+	Label        string `json:"label"`
+}
+
+// AggregateSession is a virtual log session fanning out to several plugin
+// sessions, possibly of different plugins and connections. Their lines are
+// merged by timestamp and emitted under the aggregate session's ID, each
+// tagged with AggregateSourceLabel. Closing, pausing or resuming the
+// aggregate session applies to all of its sources.
+type AggregateSession struct {
+	ID        string                   `json:"id"`
+	Sources   []AggregateSourceSession `json:"sources"`
+	Paused    bool                     `json:"paused"`
+	CreatedAt time.Time                `json:"createdAt"`
+}
+
+type aggregate struct {
+	session AggregateSession
+	merger  *lineMerger
+}
+
+// CreateAggregateSession creates a session for every source and merges them
+// into one aggregate session. If any session cannot be created, the ones
+// already created are closed.
+//
+// Sources start streaming as soon as they are created, so their output is
+// held until the aggregate is registered and then replayed through it.
+func (c *controller) CreateAggregateSession(sources []AggregateSource) (*AggregateSession, error) {
+	_, span := tracer.Start(context.Background(), "logs.CreateAggregateSession")
+	defer span.End()
+	span.SetAttributes(attribute.Int("sources", len(sources)))
+
+	if len(sources) == 0 {
+		err := apperror.New(apperror.TypeValidation, 400,
+			"Invalid aggregate session", "An aggregate session needs at least one source.")
+		telemetryutil.RecordError(span, err)
+		return nil, err
+	}
+
+	session := AggregateSession{
+		ID:        "aggregate-" + uuid.NewString(),
+		Sources:   make([]AggregateSourceSession, 0, len(sources)),
+		CreatedAt: time.Now(),
+	}
+	c.creating.Add(1)
+	defer c.releaseHeldOutput()
+	for _, source := range sources {
+		created, err := c.CreateSession(source.PluginID, source.ConnectionID, source.Options)
+		if err != nil {
+			c.mu.Lock()
+			for _, s := range session.Sources {
+				delete(c.aggregateOf, s.SessionID)
+			}
+			c.mu.Unlock()
+			for _, s := range session.Sources {
+				_ = c.CloseSession(s.SessionID)
+			}
+			telemetryutil.RecordError(span, err)
+			return nil, err
+		}
+		c.mu.Lock()
+		c.aggregateOf[created.ID] = session.ID
+		c.mu.Unlock()
+		label := source.Label
+		if label == "" {
+			label = source.ConnectionID + "/" + source.Options.ResourceID
+		}
+		session.Sources = append(session.Sources, AggregateSourceSession{
+			SessionID:    created.ID,
+			PluginID:     source.PluginID,
+			ConnectionID: source.ConnectionID,
+			Label:        label,
+		})
+	}
+
+	agg := &aggregate{session: session}
+	agg.merger = newLineMerger(AggregateReorderWindow, func(lines []logs.LogLine) {
+		for _, line := range lines {
+			c.bufferLine(session.ID, line)
+		}
+	})
+
+	c.holdMu.Lock()
+	c.mu.Lock()
+	c.aggregates[session.ID] = agg
+	c.mu.Unlock()
+	c.holdMu.Unlock()
+
+	return cloneAggregateSession(session), nil
+}
+
+// holdOutput holds output that cannot be routed yet: output of a source
+// whose aggregate is not registered, of a session not yet known while an
+// aggregate is being created, or of a session with output already held. It
+// reports whether it held the output.
+func (c *controller) holdOutput(output logs.StreamOutput) bool {
+	c.holdMu.Lock()
+	defer c.holdMu.Unlock()
+	if _, ok := c.held[output.SessionID]; !ok && !c.mustHold(output.SessionID) {
+		return false
+	}
+	c.held[output.SessionID] = append(c.held[output.SessionID], output)
+	return true
+}
+
+// mustHold reports whether output of a session must wait for an aggregate
+// being created. Caller must hold holdMu.
+func (c *controller) mustHold(sessionID string) bool {
+	if c.creating.Load() == 0 {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if aggID, ok := c.aggregateOf[sessionID]; ok {
+		_, registered := c.aggregates[aggID]
+		return !registered
+	}
+	_, known := c.sessionIndex[sessionID]
+	return !known
+}
+
+// releaseHeldOutput ends an aggregate creation and dispatches the held output
+// that no longer needs to wait, in arrival order.
+func (c *controller) releaseHeldOutput() {
+	c.holdMu.Lock()
+	defer c.holdMu.Unlock()
+	c.creating.Add(-1)
+	for sessionID, outputs := range c.held {
+		if c.mustHold(sessionID) {
+			continue
+		}
+		delete(c.held, sessionID)
+		for _, output := range outputs {
+			c.dispatchOutput(output)
+		}
+	}
+}
+
+// GetAggregateSession returns an aggregate session.
+func (c *controller) GetAggregateSession(id string) (*AggregateSession, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	agg, ok := c.aggregates[id]
+	if !ok {
+		return nil, apperror.SessionNotFound(id)
+	}
+	return cloneAggregateSession(agg.session), nil
+}
+
+// ListAggregateSessions returns the aggregate sessions, oldest first.
+func (c *controller) ListAggregateSessions() ([]*AggregateSession, error) {
+	c.mu.RLock()
+	sessions := make([]*AggregateSession, 0, len(c.aggregates))
+	for _, agg := range c.aggregates {
+		sessions = append(sessions, cloneAggregateSession(agg.session))
+	}
+	c.mu.RUnlock()
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.Before(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+// routeAggregateOutput hands the output of a session belonging to an
+// aggregate session to the aggregate. It reports whether it did.
+func (c *controller) routeAggregateOutput(output logs.StreamOutput) bool {
+	c.mu.RLock()
+	agg, ok := c.aggregates[c.aggregateOf[output.SessionID]]
+	c.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	if output.Line != nil {
+		line := *output.Line
+		line.Labels = maps.Clone(line.Labels)
+		if line.Labels == nil {
+			line.Labels = make(map[string]string, 2)
+		}
+		for _, s := range agg.session.Sources {
+			if s.SessionID == output.SessionID {
+				line.Labels[AggregateSourceLabel] = s.Label
+				break
+			}
+		}
+		line.Labels[AggregateSessionLabel] = output.SessionID
+		line.SessionID = agg.session.ID
+		agg.merger.add(line)
+		return true
+	}
+
+	if output.Event != nil {
+		c.emitEvent(agg.session.ID, output.Event)
+	}
+	return true
+}
+
+// closeAggregate closes every source of an aggregate session, flushing the
+// lines still held for reordering.
+func (c *controller) closeAggregate(id string) error {
+	c.mu.Lock()
+	agg, ok := c.aggregates[id]
+	if !ok {
+		c.mu.Unlock()
+		return apperror.SessionNotFound(id)
+	}
+	delete(c.aggregates, id)
+	for _, s := range agg.session.Sources {
+		delete(c.aggregateOf, s.SessionID)
+	}
+	c.mu.Unlock()
+
+	agg.merger.close()
+	c.flushSession(id)
+	c.dropPipeline(id)
+	c.captureClose(id)
+
+	var errs []error
+	for _, s := range agg.session.Sources {
+		if err := c.CloseSession(s.SessionID); err != nil && !isSessionNotFound(err) {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendAggregateCommand sends a command to every source of an aggregate
+// session.
+func (c *controller) sendAggregateCommand(id string, cmd logs.LogStreamCommand) error {
+	if cmd == logs.StreamCommandClose {
+		return c.closeAggregate(id)
+	}
+
+	c.mu.Lock()
+	agg, ok := c.aggregates[id]
+	if !ok {
+		c.mu.Unlock()
+		return apperror.SessionNotFound(id)
+	}
+	agg.session.Paused = cmd == logs.StreamCommandPause
+	sources := slices.Clone(agg.session.Sources)
+	c.mu.Unlock()
+
+	var errs []error
+	for _, s := range sources {
+		if err := c.SendCommand(s.SessionID, cmd); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isAggregate reports whether id is an aggregate session.
+func (c *controller) isAggregate(id string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.aggregates[id]
+	return ok
+}
+
+func isSessionNotFound(err error) bool {
+	var appErr *apperror.AppError
+	return errors.As(err, &appErr) && appErr.Type == apperror.TypeSessionNotFound
+}
+
+func cloneAggregateSession(s AggregateSession) *AggregateSession {
+	s.Sources = slices.Clone(s.Sources)
+	return &s
+}
@@ -0,0 +1,238 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	sdktypes "github.com/omniviewdev/plugin-sdk/pkg/types"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wailsapp/wails/v3/pkg/application"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource"
+)
+
+// fakeResources serves connections to the controller.
+type fakeResources struct {
+	resource.Service
+}
+
+func (fakeResources) GetConnection(_, connectionID string) (sdktypes.Connection, error) {
+	return sdktypes.Connection{ID: connectionID}, nil
+}
+
+// recordingProvider is a LogsProvider that tracks its open sessions and can
+// be made to fail session creation.
+type recordingProvider struct {
+	stubLogsProvider
+
+	mu         sync.Mutex
+	prefix     string
+	open       map[string]bool
+	failCreate bool
+	nextID     int
+	// onCreate, if set, runs before CreateSession returns, as if the
+	// session had started streaming.
+	onCreate func(id string)
+}
+
+func newRecordingProvider(prefix string) *recordingProvider {
+	return &recordingProvider{prefix: prefix, open: make(map[string]bool)}
+}
+
+func (p *recordingProvider) CreateSession(_ *sdktypes.PluginContext, _ logs.CreateSessionOptions) (*logs.LogSession, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failCreate {
+		return nil, errors.New("resource not found")
+	}
+	p.nextID++
+	id := fmt.Sprintf("%s-%d", p.prefix, p.nextID)
+	p.open[id] = true
+	if p.onCreate != nil {
+		p.onCreate(id)
+	}
+	return &logs.LogSession{ID: id}, nil
+}
+
+func (p *recordingProvider) CloseSession(_ *sdktypes.PluginContext, id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.open, id)
+	return nil
+}
+
+func (p *recordingProvider) openSessions() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.open)
+}
+
+// newAggregateController returns a controller with two plugins whose command
+// channels are buffered so commands can be inspected.
+func newAggregateController(t *testing.T) (*controller, map[string]*recordingProvider) {
+	t.Helper()
+	c := NewController(logging.NewNop(), nil, fakeResources{}).(*controller)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	require.NoError(t, c.ServiceStartup(ctx, application.ServiceOptions{}))
+
+	providers := map[string]*recordingProvider{
+		"kubernetes": newRecordingProvider("k8s"),
+		"aws":        newRecordingProvider("aws"),
+	}
+	for id, p := range providers {
+		c.clients[id] = p
+		c.inChans[id] = make(chan logs.StreamInput, 16)
+	}
+	return c, providers
+}
+
+func aggregateSources() []AggregateSource {
+	return []AggregateSource{
+		{PluginID: "kubernetes", ConnectionID: "prod", Label: "api",
+			Options: logs.CreateSessionOptions{ResourceID: "api-0"}},
+		{PluginID: "aws", ConnectionID: "us-east-1",
+			Options: logs.CreateSessionOptions{ResourceID: "lambda"}},
+	}
+}
+
+func TestAggregateSession_MergesAndTagsLines(t *testing.T) {
+	c, _ := newAggregateController(t)
+	session, err := c.CreateAggregateSession(aggregateSources())
+	require.NoError(t, err)
+	require.Len(t, session.Sources, 2)
+	assert.Equal(t, "api", session.Sources[0].Label)
+	assert.Equal(t, "us-east-1/lambda", session.Sources[1].Label, "the label defaults to the connection and resource")
+
+	r := &lineRecorder{}
+	c.mu.Lock()
+	c.aggregates[session.ID].merger = newLineMerger(20*time.Millisecond, r.release)
+	c.mu.Unlock()
+
+	base := time.Now()
+	k8s, aws := session.Sources[0].SessionID, session.Sources[1].SessionID
+	c.handleOutput(logs.StreamOutput{SessionID: k8s, Line: &logs.LogLine{
+		Content: "k8s late", Timestamp: base.Add(2 * time.Second), Labels: map[string]string{"pod": "api-0"},
+	}})
+	c.handleOutput(logs.StreamOutput{SessionID: aws, Line: &logs.LogLine{
+		Content: "aws early", Timestamp: base.Add(time.Second),
+	}})
+
+	require.Eventually(t, func() bool { return len(r.contents()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"aws early", "k8s late"}, r.contents())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	assert.Equal(t, session.ID, r.lines[0].SessionID)
+	assert.Equal(t, "us-east-1/lambda", r.lines[0].Labels[AggregateSourceLabel])
+	assert.Equal(t, aws, r.lines[0].Labels[AggregateSessionLabel])
+	assert.Equal(t, "api", r.lines[1].Labels[AggregateSourceLabel])
+	assert.Equal(t, "api-0", r.lines[1].Labels["pod"], "the source's own labels are kept")
+}
+
+func TestAggregateSession_LinesBeforeRegistration(t *testing.T) {
+	c, providers := newAggregateController(t)
+	var sourceIDs []string
+	for _, p := range providers {
+		p.onCreate = func(id string) {
+			sourceIDs = append(sourceIDs, id)
+			c.handleOutput(logs.StreamOutput{SessionID: id, Line: &logs.LogLine{Content: "first", Timestamp: time.Now()}})
+		}
+	}
+
+	session, err := c.CreateAggregateSession(aggregateSources())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		c.batchMux.Lock()
+		defer c.batchMux.Unlock()
+		_, ok := c.batches[session.ID]
+		return ok
+	}, time.Second, time.Millisecond, "lines sent while the sources were created reach the aggregate")
+	c.batchMux.Lock()
+	defer c.batchMux.Unlock()
+	for _, id := range sourceIDs {
+		_, ok := c.batches[id]
+		assert.False(t, ok, "no line is emitted under a source session")
+	}
+	c.holdMu.Lock()
+	defer c.holdMu.Unlock()
+	assert.Empty(t, c.held)
+}
+
+func TestAggregateSession_CommandsFanOut(t *testing.T) {
+	c, _ := newAggregateController(t)
+	session, err := c.CreateAggregateSession(aggregateSources())
+	require.NoError(t, err)
+
+	require.NoError(t, c.SendCommand(session.ID, logs.StreamCommandPause))
+	assert.Equal(t, logs.StreamInput{SessionID: session.Sources[0].SessionID, Command: logs.StreamCommandPause},
+		<-c.inChans["kubernetes"])
+	assert.Equal(t, logs.StreamInput{SessionID: session.Sources[1].SessionID, Command: logs.StreamCommandPause},
+		<-c.inChans["aws"])
+	got, err := c.GetAggregateSession(session.ID)
+	require.NoError(t, err)
+	assert.True(t, got.Paused)
+
+	require.NoError(t, c.SendCommand(session.ID, logs.StreamCommandResume))
+	assert.Equal(t, logs.StreamCommandResume, (<-c.inChans["kubernetes"]).Command)
+	assert.Equal(t, logs.StreamCommandResume, (<-c.inChans["aws"]).Command)
+	got, err = c.GetAggregateSession(session.ID)
+	require.NoError(t, err)
+	assert.False(t, got.Paused)
+}
+
+func TestAggregateSession_CloseClosesSources(t *testing.T) {
+	c, providers := newAggregateController(t)
+	session, err := c.CreateAggregateSession(aggregateSources())
+	require.NoError(t, err)
+	sessions, err := c.ListAggregateSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	require.NoError(t, c.CloseSession(session.ID))
+	assert.Zero(t, providers["kubernetes"].openSessions())
+	assert.Zero(t, providers["aws"].openSessions())
+	sessions, err = c.ListAggregateSessions()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	// Output still in flight for a closed source no longer reaches the aggregate.
+	c.handleOutput(logs.StreamOutput{SessionID: session.Sources[0].SessionID, Line: &logs.LogLine{Content: "late"}})
+	c.batchMux.Lock()
+	_, ok := c.batches[session.ID]
+	c.batchMux.Unlock()
+	assert.False(t, ok)
+
+	var target *apperror.AppError
+	require.True(t, errors.As(c.CloseSession(session.ID), &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+}
+
+func TestCreateAggregateSession_Errors(t *testing.T) {
+	c, providers := newAggregateController(t)
+	var target *apperror.AppError
+
+	_, err := c.CreateAggregateSession(nil)
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type)
+
+	providers["aws"].failCreate = true
+	_, err = c.CreateAggregateSession(aggregateSources())
+	require.Error(t, err)
+	assert.Zero(t, providers["kubernetes"].openSessions(), "sessions already created are closed")
+	sessions, err := c.ListAggregateSessions()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	_, err = c.GetAggregateSession("missing")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+}
@@ -0,0 +1,185 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAlertRuleNotFound is returned when an alert rule does not exist.
+var ErrAlertRuleNotFound = errors.New("log alert rule not found")
+
+// AlertRule watches the lines of log sessions and fires a LogAlert when
+// enough of them match within a window. Rules are saved once and apply to
+// every session in their scope, including sessions opened later.
+type AlertRule struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	// PluginID and ConnectionID limit the rule to the sessions of a plugin,
+	// or of one of its connections. Empty watches every session.
+	PluginID     string `json:"pluginId,omitempty"`
+	ConnectionID string `json:"connectionId,omitempty"`
+	// Pattern is a regular expression matched against a line's content.
+	Pattern string `json:"pattern,omitempty"`
+	// Query is a log query matched against the parsed line, so it can test
+	// structured fields and the level (see Query for the syntax). When both
+	// Pattern and Query are set a line must match both.
+	Query string `json:"query,omitempty"`
+	// Threshold is the number of matching lines arriving within WindowSeconds
+	// that fires the rule. Zero or one fires on every matching line. Lines
+	// logged longer ago than the window or cooldown are not counted.
+	Threshold     int `json:"threshold,omitempty"`
+	WindowSeconds int `json:"windowSeconds,omitempty"`
+	// CooldownSeconds is how long a rule stays quiet on a session after it
+	// fires. Zero defaults to the window, or a minute without one.
+	CooldownSeconds int `json:"cooldownSeconds,omitempty"`
+	// Notify sends a desktop notification when the rule fires, besides the
+	// LogAlertFired event.
+	Notify    bool      `json:"notify"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks that a rule has a name and a condition that compiles.
+func (r *AlertRule) Validate() error {
+	_, err := r.compile()
+	return err
+}
+
+// compile validates the rule and compiles its condition.
+func (r *AlertRule) compile() (*alertRule, error) {
+	switch {
+	case strings.TrimSpace(r.Name) == "":
+		return nil, errors.New("a name is required")
+	case strings.TrimSpace(r.Pattern) == "" && strings.TrimSpace(r.Query) == "":
+		return nil, errors.New("a pattern or a query is required")
+	case r.Threshold < 0 || r.WindowSeconds < 0 || r.CooldownSeconds < 0:
+		return nil, errors.New("threshold, window and cooldown cannot be negative")
+	case r.Threshold > 1 && r.WindowSeconds == 0:
+		return nil, fmt.Errorf("a window is required to count %d lines", r.Threshold)
+	}
+
+	compiled := &alertRule{AlertRule: *r}
+	if strings.TrimSpace(r.Pattern) != "" {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		compiled.pattern = pattern
+	}
+	if strings.TrimSpace(r.Query) != "" {
+		query, err := ParseQuery(r.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %w", err)
+		}
+		compiled.query = query
+	}
+	return compiled, nil
+}
+
+// AlertStore persists alert rules in a JSON file.
+type AlertStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAlertStore creates an AlertStore backed by the file at path, creating
+// its directory if needed.
+func NewAlertStore(path string) (*AlertStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return &AlertStore{path: path}, nil
+}
+
+// List returns the rules in the order they were created.
+func (s *AlertStore) List() ([]AlertRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+// Load returns a rule, or ErrAlertRuleNotFound.
+func (s *AlertStore) Load(id string) (*AlertRule, error) {
+	rules, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(rules, func(r AlertRule) bool { return r.ID == id })
+	if i < 0 {
+		return nil, ErrAlertRuleNotFound
+	}
+	return &rules[i], nil
+}
+
+// Save adds rule, or replaces the rule with the same ID.
+func (s *AlertStore) Save(rule *AlertRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules, err := s.read()
+	if err != nil {
+		return err
+	}
+	if i := slices.IndexFunc(rules, func(r AlertRule) bool { return r.ID == rule.ID }); i >= 0 {
+		rules[i] = *rule
+	} else {
+		rules = append(rules, *rule)
+	}
+	return s.write(rules)
+}
+
+// Delete removes a rule, or returns ErrAlertRuleNotFound.
+func (s *AlertStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules, err := s.read()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(rules, func(r AlertRule) bool { return r.ID == id })
+	if i < 0 {
+		return ErrAlertRuleNotFound
+	}
+	return s.write(slices.Delete(rules, i, i+1))
+}
+
+// read loads the rules. Caller must hold s.mu.
+func (s *AlertStore) read() ([]AlertRule, error) {
+	rules := []AlertRule{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read log alert rules: %w", err)
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("decode log alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// write replaces the rules. Caller must hold s.mu.
+func (s *AlertStore) write(rules []AlertRule) error {
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode log alert rules: %w", err)
+	}
+	if err := os.WriteFile(s.path+".tmp", data, 0o600); err != nil {
+		return fmt.Errorf("write log alert rules: %w", err)
+	}
+	if err := os.Rename(s.path+".tmp", s.path); err != nil {
+		return fmt.Errorf("write log alert rules: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAlertStore(t *testing.T) *AlertStore {
+	t.Helper()
+	store, err := NewAlertStore(filepath.Join(t.TempDir(), "state", "log-alerts.json"))
+	require.NoError(t, err)
+	return store
+}
+
+func TestAlertRule_Validate(t *testing.T) {
+	valid := AlertRule{Name: "oom", Pattern: "OOMKilled"}
+	require.NoError(t, valid.Validate())
+
+	tests := []struct {
+		name string
+		rule AlertRule
+	}{
+		{"no name", AlertRule{Pattern: "OOMKilled"}},
+		{"no condition", AlertRule{Name: "empty"}},
+		{"bad pattern", AlertRule{Name: "bad", Pattern: "("}},
+		{"bad query", AlertRule{Name: "bad", Query: "level>="}},
+		{"negative threshold", AlertRule{Name: "neg", Pattern: "x", Threshold: -1}},
+		{"threshold without window", AlertRule{Name: "burst", Query: "level=error", Threshold: 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.rule.Validate())
+		})
+	}
+}
+
+func TestAlertStore_SaveListDelete(t *testing.T) {
+	store := newTestAlertStore(t)
+	rules, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, rules, "a missing file holds no rules")
+
+	first := AlertRule{ID: "r1", Name: "oom", Pattern: "OOMKilled"}
+	second := AlertRule{ID: "r2", Name: "errors", Query: "level=error", Threshold: 20, WindowSeconds: 60}
+	require.NoError(t, store.Save(&first))
+	require.NoError(t, store.Save(&second))
+	first.Name = "out of memory"
+	require.NoError(t, store.Save(&first))
+
+	rules, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "out of memory", rules[0].Name, "saving replaces the rule in place")
+	assert.Equal(t, second, rules[1])
+
+	loaded, err := store.Load("r2")
+	require.NoError(t, err)
+	assert.Equal(t, second, *loaded)
+
+	require.NoError(t, store.Delete("r1"))
+	assert.ErrorIs(t, store.Delete("r1"), ErrAlertRuleNotFound)
+	_, err = store.Load("r1")
+	assert.ErrorIs(t, err, ErrAlertRuleNotFound)
+
+	require.NoError(t, os.WriteFile(store.path, []byte("{"), 0o600))
+	_, err = store.List()
+	assert.Error(t, err)
+}
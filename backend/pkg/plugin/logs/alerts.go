@@ -0,0 +1,414 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/services/notifications"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/notify"
+)
+
+// Wails event keys for log alerts.
+const (
+	// LogAlertFired carries a LogAlert whenever an alert rule fires.
+	LogAlertFired = "core/logs/alert"
+	// LogAlertOpened carries the LogAlert whose desktop notification the user
+	// clicked, so the UI can open its session.
+	LogAlertOpened = "core/logs/alert/opened"
+)
+
+func init() {
+	application.RegisterEvent[LogAlert](LogAlertFired)
+	application.RegisterEvent[LogAlert](LogAlertOpened)
+}
+
+const (
+	// AlertContextLines is the number of lines preceding the matching line
+	// that an alert carries.
+	AlertContextLines = 5
+
+	// maxRecentAlerts is the number of fired alerts kept for ListAlerts.
+	maxRecentAlerts = 100
+	// defaultAlertCooldown applies to rules with neither a cooldown nor a
+	// window, so a crash loop does not raise a notification per line.
+	defaultAlertCooldown = time.Minute
+	// maxNotificationBody is the number of characters of a line shown in a
+	// desktop notification.
+	maxNotificationBody = 200
+)
+
+// AlertNotifier sends the desktop notifications of rules with Notify set.
+// It is satisfied by notify.Service.
+type AlertNotifier interface {
+	SendNotification(options notifications.NotificationOptions) error
+	OnNotificationResponse(callback func(result notifications.NotificationResult))
+}
+
+// LogAlert is an alert rule firing on a session.
+type LogAlert struct {
+	ID       string `json:"id"`
+	RuleID   string `json:"ruleId"`
+	RuleName string `json:"ruleName"`
+	// SessionID is the session the line is shown in: the aggregate session
+	// for a line of one of its sources.
+	SessionID string `json:"sessionId"`
+	// SourceSessionID is the plugin session the line came from, when it
+	// differs from SessionID.
+	SourceSessionID string `json:"sourceSessionId,omitempty"`
+	PluginID        string `json:"pluginId"`
+	ConnectionID    string `json:"connectionId"`
+	// Count is the number of matching lines that fired the rule.
+	Count int `json:"count"`
+	// Line is the line that fired the rule, and Context the lines of the
+	// session preceding it, oldest first.
+	Line    logs.LogLine   `json:"line"`
+	Context []logs.LogLine `json:"context"`
+	FiredAt time.Time      `json:"firedAt"`
+}
+
+// alertRule is an enabled rule with its condition compiled.
+type alertRule struct {
+	AlertRule
+	pattern *regexp.Regexp
+	query   *Query
+}
+
+func (r *alertRule) watches(index sessionIndex) bool {
+	return (r.PluginID == "" || r.PluginID == index.pluginID) &&
+		(r.ConnectionID == "" || r.ConnectionID == index.connectionID)
+}
+
+// match reports whether a line meets the rule's condition. entry parses the
+// line on first use, so lines are only parsed for rules with a query.
+func (r *alertRule) match(line logs.LogLine, entry func() *LogEntry) bool {
+	if r.pattern != nil && !r.pattern.MatchString(line.Content) {
+		return false
+	}
+	return r.query == nil || r.query.Match(entry())
+}
+
+func (r *alertRule) threshold() int {
+	return max(r.Threshold, 1)
+}
+
+func (r *alertRule) window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
+
+func (r *alertRule) cooldown() time.Duration {
+	switch {
+	case r.CooldownSeconds > 0:
+		return time.Duration(r.CooldownSeconds) * time.Second
+	case r.WindowSeconds > 0:
+		return r.window()
+	default:
+		return defaultAlertCooldown
+	}
+}
+
+// stale reports whether line was logged too long before now to count towards
+// the rule, as with the backlog a session replays when it starts. Lines
+// without a timestamp are never stale.
+func (r *alertRule) stale(line logs.LogLine, now time.Time) bool {
+	return !line.Timestamp.IsZero() && line.Timestamp.Before(now.Add(-max(r.window(), r.cooldown())))
+}
+
+// alertWatch is the alert state of a plugin session.
+type alertWatch struct {
+	recent []logs.LogLine          // the last AlertContextLines lines, oldest first
+	rules  map[string]*alertWindow // by rule ID
+}
+
+// alertWindow counts a rule's matches on a session.
+type alertWindow struct {
+	matches    []time.Time // arrival times of the matches within the window
+	quietUntil time.Time   // end of the cooldown after firing
+}
+
+// ListAlertRules returns the saved alert rules in the order they were
+// created.
+func (c *controller) ListAlertRules() ([]AlertRule, error) {
+	if c.alertStore == nil {
+		return []AlertRule{}, nil
+	}
+	rules, err := c.alertStore.List()
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to list log alert rules")
+	}
+	return rules, nil
+}
+
+// SaveAlertRule creates a rule, when its ID is empty, or replaces the rule
+// with its ID. Open sessions are watched by the saved rule from their next
+// line, with its counts reset. It returns the rule as saved.
+func (c *controller) SaveAlertRule(rule AlertRule) (*AlertRule, error) {
+	if err := c.requireAlerts(); err != nil {
+		return nil, err
+	}
+	rule.Name = strings.TrimSpace(rule.Name)
+	if err := rule.Validate(); err != nil {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid log alert rule", err.Error())
+	}
+
+	now := time.Now()
+	if rule.ID == "" {
+		rule.ID = uuid.NewString()
+		rule.CreatedAt = now
+	} else {
+		existing, err := c.alertStore.Load(rule.ID)
+		if err != nil {
+			return nil, alertRuleError(err, rule.ID)
+		}
+		rule.CreatedAt = existing.CreatedAt
+	}
+	rule.UpdatedAt = now
+	if err := c.alertStore.Save(&rule); err != nil {
+		return nil, alertRuleError(err, rule.ID)
+	}
+	if err := c.loadAlertRules(rule.ID); err != nil {
+		return nil, alertRuleError(err, rule.ID)
+	}
+	return &rule, nil
+}
+
+// DeleteAlertRule removes a rule and stops watching sessions with it.
+func (c *controller) DeleteAlertRule(ruleID string) error {
+	if err := c.requireAlerts(); err != nil {
+		return err
+	}
+	if err := c.alertStore.Delete(ruleID); err != nil {
+		return alertRuleError(err, ruleID)
+	}
+	if err := c.loadAlertRules(ruleID); err != nil {
+		return alertRuleError(err, ruleID)
+	}
+	return nil
+}
+
+// ListAlerts returns the alerts fired recently, newest first.
+func (c *controller) ListAlerts() ([]LogAlert, error) {
+	c.alertMu.Lock()
+	defer c.alertMu.Unlock()
+	alerts := slices.Clone(c.alerts)
+	slices.Reverse(alerts)
+	if alerts == nil {
+		alerts = []LogAlert{}
+	}
+	return alerts, nil
+}
+
+// loadAlertRules compiles the enabled rules of the store and resets the
+// counts of rule resetID on every session.
+func (c *controller) loadAlertRules(resetID string) error {
+	rules, err := c.alertStore.List()
+	if err != nil {
+		return err
+	}
+	compiled := make([]*alertRule, 0, len(rules))
+	for i := range rules {
+		if !rules[i].Enabled {
+			continue
+		}
+		rule, err := rules[i].compile()
+		if err != nil {
+			// Only a file edited by hand holds an invalid rule; skip it.
+			c.logger.Warnw(context.Background(), "skipping invalid log alert rule",
+				"rule", rules[i].ID, "error", err)
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+
+	c.alertMu.Lock()
+	defer c.alertMu.Unlock()
+	c.alertRules = compiled
+	for _, watch := range c.watches {
+		delete(watch.rules, resetID)
+	}
+	return nil
+}
+
+// evaluateAlerts runs a line of a plugin session through the rules watching
+// the session, firing those that reach their threshold.
+func (c *controller) evaluateAlerts(sessionID string, line logs.LogLine) {
+	c.alertMu.Lock()
+	if len(c.alertRules) == 0 {
+		c.alertMu.Unlock()
+		return
+	}
+
+	c.mu.RLock()
+	index, ok := c.sessionIndex[sessionID]
+	view := c.aggregateOf[sessionID]
+	c.mu.RUnlock()
+	if !ok {
+		c.alertMu.Unlock()
+		return
+	}
+
+	watch, ok := c.watches[sessionID]
+	if !ok {
+		watch = &alertWatch{rules: make(map[string]*alertWindow)}
+		c.watches[sessionID] = watch
+	}
+
+	var parsed *LogEntry
+	entry := func() *LogEntry {
+		if parsed == nil {
+			e := parseEntry(line)
+			parsed = &e
+		}
+		return parsed
+	}
+
+	now := c.alertNow()
+	var fired []LogAlert
+	var notify []bool
+	for _, rule := range c.alertRules {
+		if !rule.watches(index) || !rule.match(line, entry) {
+			continue
+		}
+		window, ok := watch.rules[rule.ID]
+		if !ok {
+			window = &alertWindow{}
+			watch.rules[rule.ID] = window
+		}
+		if now.Before(window.quietUntil) || rule.stale(line, now) {
+			continue
+		}
+
+		cutoff := now.Add(-rule.window())
+		window.matches = slices.DeleteFunc(window.matches, func(t time.Time) bool { return !t.After(cutoff) })
+		window.matches = append(window.matches, now)
+		if len(window.matches) < rule.threshold() {
+			continue
+		}
+
+		alert := LogAlert{
+			ID:           uuid.NewString(),
+			RuleID:       rule.ID,
+			RuleName:     rule.Name,
+			SessionID:    sessionID,
+			PluginID:     index.pluginID,
+			ConnectionID: index.connectionID,
+			Count:        len(window.matches),
+			Line:         line,
+			Context:      slices.Clone(watch.recent),
+			FiredAt:      now,
+		}
+		if view != "" {
+			alert.SessionID, alert.SourceSessionID = view, sessionID
+		}
+		window.matches = nil
+		window.quietUntil = now.Add(rule.cooldown())
+		fired = append(fired, alert)
+		notify = append(notify, rule.Notify)
+	}
+
+	if len(watch.recent) == AlertContextLines {
+		watch.recent = slices.Delete(watch.recent, 0, 1)
+	}
+	watch.recent = append(watch.recent, line)
+
+	c.alerts = append(c.alerts, fired...)
+	if over := len(c.alerts) - maxRecentAlerts; over > 0 {
+		c.alerts = slices.Delete(c.alerts, 0, over)
+	}
+	c.alertMu.Unlock()
+
+	for i, alert := range fired {
+		c.emitter.Emit(LogAlertFired, alert)
+		if notify[i] && c.notifier != nil {
+			go c.sendAlertNotification(alert)
+		}
+	}
+}
+
+// dropAlertWatch forgets the alert state of a closed session.
+func (c *controller) dropAlertWatch(sessionID string) {
+	c.alertMu.Lock()
+	delete(c.watches, sessionID)
+	c.alertMu.Unlock()
+}
+
+// sendAlertNotification raises a desktop notification for an alert. Its data
+// carries the alert ID, so clicking it emits LogAlertOpened.
+func (c *controller) sendAlertNotification(alert LogAlert) {
+	content := strings.TrimSpace(alert.Line.Content)
+	if runes := []rune(content); len(runes) > maxNotificationBody {
+		content = string(runes[:maxNotificationBody]) + "…"
+	}
+	body := content
+	if alert.Count > 1 {
+		body = fmt.Sprintf("%d matching lines. Last: %s", alert.Count, content)
+	}
+
+	err := c.notifier.SendNotification(notifications.NotificationOptions{
+		ID:       alert.ID,
+		Title:    alert.RuleName,
+		Subtitle: alert.Line.SourceID,
+		Body:     body,
+		Data: map[string]any{
+			"alertId":   alert.ID,
+			"ruleId":    alert.RuleID,
+			"sessionId": alert.SessionID,
+		},
+	})
+	if err != nil && !errors.Is(err, notify.ErrUnavailable) {
+		c.logger.Warnw(context.Background(), "failed to send log alert notification",
+			"rule", alert.RuleID, "error", err)
+	}
+}
+
+// handleNotificationResponse emits LogAlertOpened when the user clicks the
+// notification of an alert.
+func (c *controller) handleNotificationResponse(result notifications.NotificationResult) {
+	if result.Error != nil {
+		c.logger.Warnw(context.Background(), "notification response failed", "error", result.Error)
+		return
+	}
+	alertID, _ := result.Response.UserInfo["alertId"].(string)
+	if alertID == "" {
+		alertID = result.Response.ID
+	}
+
+	c.alertMu.Lock()
+	i := slices.IndexFunc(c.alerts, func(a LogAlert) bool { return a.ID == alertID })
+	var alert LogAlert
+	if i >= 0 {
+		alert = c.alerts[i]
+	}
+	c.alertMu.Unlock()
+	if i >= 0 {
+		c.emitter.Emit(LogAlertOpened, alert)
+	}
+}
+
+// requireAlerts checks that alert rules can be saved.
+func (c *controller) requireAlerts() error {
+	if c.alertStore == nil {
+		return apperror.NotImplemented("Log alerts unavailable",
+			"Alert rules are kept in log-alerts.json, which could not be read at startup.")
+	}
+	return nil
+}
+
+// alertRuleError converts an AlertStore error into an app error.
+func alertRuleError(err error, ruleID string) error {
+	if errors.Is(err, ErrAlertRuleNotFound) {
+		return apperror.NotFound("Log alert rule not found",
+			fmt.Sprintf("Log alert rule %s does not exist.", ruleID))
+	}
+	return apperror.Internal(err, "Failed to access log alert rules")
+}
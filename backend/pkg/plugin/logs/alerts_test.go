@@ -0,0 +1,256 @@
+package logs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wailsapp/wails/v3/pkg/services/notifications"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+// fakeNotifier records the notifications it is asked to send.
+type fakeNotifier struct {
+	mu       sync.Mutex
+	sent     []notifications.NotificationOptions
+	callback func(notifications.NotificationResult)
+}
+
+func (n *fakeNotifier) SendNotification(options notifications.NotificationOptions) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, options)
+	return nil
+}
+
+func (n *fakeNotifier) OnNotificationResponse(callback func(notifications.NotificationResult)) {
+	n.callback = callback
+}
+
+func (n *fakeNotifier) notifications() []notifications.NotificationOptions {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]notifications.NotificationOptions(nil), n.sent...)
+}
+
+// alerts returns the alerts emitted under an event key.
+func (e *recordingEmitter) alerts(eventKey string) []LogAlert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var alerts []LogAlert
+	for _, data := range e.events[eventKey] {
+		alerts = append(alerts, data.(LogAlert))
+	}
+	return alerts
+}
+
+// newAlertController returns a controller with an alert store, a notifier
+// and an emitter recording its events.
+func newAlertController(t *testing.T) (*controller, *recordingEmitter, *fakeNotifier) {
+	t.Helper()
+	c, _ := newAggregateController(t)
+	emitter := &recordingEmitter{}
+	c.emitter = emitter
+	c.alertStore = newTestAlertStore(t)
+	notifier := &fakeNotifier{}
+	c.notifier = notifier
+	notifier.OnNotificationResponse(c.handleNotificationResponse)
+	c.alertNow = func() time.Time { return alertClock }
+	return c, emitter, notifier
+}
+
+// alertClock is the alert controller's time in tests, that of outputLine.
+var alertClock = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func lineAtTime(sessionID, content string, ts time.Time) logs.StreamOutput {
+	output := outputLine(sessionID, content)
+	output.Line.Timestamp = ts
+	return output
+}
+
+func TestAlerts_PatternFiresWithContext(t *testing.T) {
+	c, emitter, notifier := newAlertController(t)
+	rule, err := c.SaveAlertRule(AlertRule{Name: "OOM killed", Enabled: true, Pattern: "OOMKilled", Notify: true})
+	require.NoError(t, err)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	for _, content := range []string{"one", "two", "three", "four", "five", "six", "container web OOMKilled"} {
+		c.handleOutput(outputLine(session.ID, content))
+	}
+	c.handleOutput(outputLine(session.ID, "OOMKilled again"))
+
+	fired := emitter.alerts(LogAlertFired)
+	require.Len(t, fired, 1, "the rule is quiet during its cooldown")
+	alert := fired[0]
+	assert.Equal(t, rule.ID, alert.RuleID)
+	assert.Equal(t, session.ID, alert.SessionID)
+	assert.Empty(t, alert.SourceSessionID)
+	assert.Equal(t, "kubernetes", alert.PluginID)
+	assert.Equal(t, 1, alert.Count)
+	assert.Equal(t, "container web OOMKilled", alert.Line.Content)
+	require.Len(t, alert.Context, AlertContextLines)
+	assert.Equal(t, "two", alert.Context[0].Content)
+	assert.Equal(t, "six", alert.Context[AlertContextLines-1].Content)
+
+	require.Eventually(t, func() bool { return len(notifier.notifications()) == 1 }, 5*time.Second, time.Millisecond)
+	sent := notifier.notifications()[0]
+	assert.Equal(t, "OOM killed", sent.Title)
+	assert.Equal(t, "container web OOMKilled", sent.Body)
+	assert.Equal(t, alert.ID, sent.Data["alertId"])
+	assert.Equal(t, session.ID, sent.Data["sessionId"])
+
+	alerts, err := c.ListAlerts()
+	require.NoError(t, err)
+	assert.Equal(t, fired, alerts)
+
+	// Clicking the notification asks the UI to open the alert's session.
+	notifier.callback(notifications.NotificationResult{Response: notifications.NotificationResponse{
+		ID: alert.ID, UserInfo: map[string]any{"alertId": alert.ID},
+	}})
+	assert.Equal(t, fired, emitter.alerts(LogAlertOpened))
+}
+
+func TestAlerts_ThresholdWithinWindow(t *testing.T) {
+	c, emitter, notifier := newAlertController(t)
+	_, err := c.SaveAlertRule(AlertRule{
+		Name: "error burst", Enabled: true, Query: "level=error", Threshold: 3, WindowSeconds: 60,
+	})
+	require.NoError(t, err)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	arrive := func(content string, at time.Duration) {
+		c.alertNow = func() time.Time { return start.Add(at) }
+		c.handleOutput(lineAtTime(session.ID, content, start.Add(at)))
+	}
+	arrive("level=error msg=first", 0)
+	arrive("level=info msg=fine", 10*time.Second)
+	arrive("level=error msg=second", 20*time.Second)
+	arrive("level=error msg=third", 70*time.Second)
+	assert.Empty(t, emitter.alerts(LogAlertFired), "the first error fell out of the window")
+
+	arrive(`{"level":"error","msg":"fourth"}`, 75*time.Second)
+	fired := emitter.alerts(LogAlertFired)
+	require.Len(t, fired, 1)
+	assert.Equal(t, 3, fired[0].Count)
+	assert.Equal(t, `{"level":"error","msg":"fourth"}`, fired[0].Line.Content)
+	assert.Empty(t, notifier.notifications(), "the rule does not ask for notifications")
+}
+
+func TestAlerts_ReplayedBacklogDoesNotFire(t *testing.T) {
+	c, emitter, _ := newAlertController(t)
+	_, err := c.SaveAlertRule(AlertRule{
+		Name: "error burst", Enabled: true, Query: "level=error", Threshold: 3, WindowSeconds: 60,
+	})
+	require.NoError(t, err)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	// A session starting replays errors logged hours ago, all at once.
+	logged := alertClock.Add(-3 * time.Hour)
+	for i := range 5 {
+		c.handleOutput(lineAtTime(session.ID, "level=error msg=old", logged.Add(time.Duration(i)*time.Second)))
+	}
+	assert.Empty(t, emitter.alerts(LogAlertFired), "old lines do not count towards the threshold")
+
+	for range 3 {
+		c.handleOutput(outputLine(session.ID, "level=error msg=new"))
+	}
+	require.Len(t, emitter.alerts(LogAlertFired), 1)
+	assert.Equal(t, 3, emitter.alerts(LogAlertFired)[0].Count)
+}
+
+func TestAlerts_Scope(t *testing.T) {
+	c, emitter, _ := newAlertController(t)
+	_, err := c.SaveAlertRule(AlertRule{Name: "aws only", Enabled: true, PluginID: "aws", Pattern: "panic"})
+	require.NoError(t, err)
+	_, err = c.SaveAlertRule(AlertRule{Name: "disabled", Pattern: "panic"})
+	require.NoError(t, err)
+
+	session, err := c.CreateAggregateSession(aggregateSources())
+	require.NoError(t, err)
+	c.handleOutput(outputLine(session.Sources[0].SessionID, "panic: kubernetes"))
+	c.handleOutput(outputLine(session.Sources[1].SessionID, "panic: aws"))
+
+	fired := emitter.alerts(LogAlertFired)
+	require.Len(t, fired, 1)
+	assert.Equal(t, "aws only", fired[0].RuleName)
+	assert.Equal(t, session.ID, fired[0].SessionID, "alerts link to the aggregate session the line is shown in")
+	assert.Equal(t, session.Sources[1].SessionID, fired[0].SourceSessionID)
+
+	require.NoError(t, c.CloseSession(session.ID))
+	c.alertMu.Lock()
+	assert.Empty(t, c.watches, "closing a session drops its alert state")
+	c.alertMu.Unlock()
+}
+
+func TestAlertRules_PersistedAndUpdated(t *testing.T) {
+	c, emitter, _ := newAlertController(t)
+	rule, err := c.SaveAlertRule(AlertRule{Name: " timeouts ", Enabled: true, Pattern: "timeout"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, rule.ID)
+	assert.Equal(t, "timeouts", rule.Name)
+	assert.False(t, rule.CreatedAt.IsZero())
+
+	// A controller sharing the store picks the rule up.
+	other, otherEmitter, _ := newAlertController(t)
+	other.alertStore = c.alertStore
+	require.NoError(t, other.loadAlertRules(""))
+	session, err := other.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+	other.handleOutput(outputLine(session.ID, "request timeout"))
+	assert.Len(t, otherEmitter.alerts(LogAlertFired), 1)
+
+	session, err = c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+	c.handleOutput(outputLine(session.ID, "request timeout"))
+	require.Len(t, emitter.alerts(LogAlertFired), 1)
+
+	// Saving the rule again resets its cooldown.
+	rule.Pattern = "deadline"
+	updated, err := c.SaveAlertRule(*rule)
+	require.NoError(t, err)
+	assert.True(t, rule.CreatedAt.Equal(updated.CreatedAt))
+	c.handleOutput(outputLine(session.ID, "request timeout"))
+	c.handleOutput(outputLine(session.ID, "deadline exceeded"))
+	require.Len(t, emitter.alerts(LogAlertFired), 2)
+
+	require.NoError(t, c.DeleteAlertRule(rule.ID))
+	c.handleOutput(outputLine(session.ID, "deadline exceeded"))
+	assert.Len(t, emitter.alerts(LogAlertFired), 2)
+	rules, err := c.ListAlertRules()
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestAlertRules_Errors(t *testing.T) {
+	var target *apperror.AppError
+
+	disabled := newTestController()
+	_, err := disabled.SaveAlertRule(AlertRule{Name: "oom", Pattern: "OOMKilled"})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeNotImplemented, target.Type)
+	rules, err := disabled.ListAlertRules()
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	c, _, _ := newAlertController(t)
+	_, err = c.SaveAlertRule(AlertRule{Name: "bad", Pattern: "("})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type)
+
+	_, err = c.SaveAlertRule(AlertRule{ID: "missing", Name: "oom", Pattern: "OOMKilled"})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceNotFound, target.Type)
+
+	err = c.DeleteAlertRule("missing")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceNotFound, target.Type)
+}
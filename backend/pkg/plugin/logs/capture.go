@@ -0,0 +1,362 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/logs/capture"
+	"github.com/omniviewdev/omniview/backend/pkg/store/sidecar"
+)
+
+// CaptureOptions configures a capture started with StartCapture.
+type CaptureOptions struct {
+	// Title names the capture in listings. Defaults to the session's
+	// resource, or its sources for an aggregate session.
+	Title string `json:"title,omitempty"`
+}
+
+// captureKind names log captures in the errors of capture operations.
+const captureKind sidecar.Kind = "capture"
+
+// capturedSessionPrefix starts the IDs of sessions reading back a capture.
+const capturedSessionPrefix = "capture-"
+
+// captureReplayPause is the pause after every BatchMaxSize lines read back
+// from a capture, which holds replay to about 10,000 lines a second so the
+// frontend is not flooded.
+const captureReplayPause = 10 * time.Millisecond
+
+// capturedSession is a session reading back a capture. It lives until it is
+// closed, so its pipeline can be changed and the capture replayed.
+type capturedSession struct {
+	captureID string
+	// replay cancels the read in progress, if any.
+	replay *context.CancelFunc
+}
+
+// StartCapture starts writing a session's lines to disk, before they are
+// parsed or filtered, until StopCapture is called or the session closes.
+func (c *controller) StartCapture(sessionID string, opts CaptureOptions) (capture.Info, error) {
+	if c.captures == nil {
+		return capture.Info{}, captureKind.Unavailable()
+	}
+	if c.isCapturedSession(sessionID) {
+		return capture.Info{}, apperror.New(apperror.TypeValidation, 400, "Session is read-only",
+			"A reopened capture cannot be captured again.")
+	}
+
+	c.mu.RLock()
+	index, isPlugin := c.sessionIndex[sessionID]
+	agg, isAggregate := c.aggregates[sessionID]
+	var sources []string
+	if isAggregate {
+		for _, s := range agg.session.Sources {
+			sources = append(sources, s.Label)
+		}
+	}
+	c.mu.RUnlock()
+	if !isPlugin && !isAggregate {
+		return capture.Info{}, apperror.SessionNotFound(sessionID)
+	}
+
+	captureOpts := capture.Options{
+		SessionID:    sessionID,
+		PluginID:     index.pluginID,
+		ConnectionID: index.connectionID,
+		Title:        opts.Title,
+	}
+	if isPlugin {
+		if session, err := c.GetSession(sessionID); err == nil && session != nil {
+			captureOpts.ResourceKey, captureOpts.ResourceID = session.ResourceKey, session.ResourceID
+		}
+	}
+	if captureOpts.Title == "" {
+		captureOpts.Title = captureOpts.ResourceID
+		if isAggregate {
+			captureOpts.Title = strings.Join(sources, ", ")
+		}
+	}
+
+	c.capMu.Lock()
+	defer c.capMu.Unlock()
+	if existing, ok := c.capturers[sessionID]; ok {
+		return capture.Info{}, apperror.New(apperror.TypeResourceConflict, 409, "Session is already being captured",
+			fmt.Sprintf("Session %s is already being captured (capture %s).", sessionID, existing.Info().ID))
+	}
+	capturer, err := c.captures.Start(captureOpts)
+	if err != nil {
+		return capture.Info{}, apperror.Internal(err, "Failed to start capture")
+	}
+	c.capturers[sessionID] = capturer
+	return capturer.Info(), nil
+}
+
+// StopCapture stops capturing a session and returns the finished capture.
+func (c *controller) StopCapture(sessionID string) (capture.Info, error) {
+	c.capMu.Lock()
+	capturer, ok := c.capturers[sessionID]
+	delete(c.capturers, sessionID)
+	c.capMu.Unlock()
+	if !ok {
+		return capture.Info{}, apperror.NotFound("Capture not found",
+			fmt.Sprintf("Session %s is not being captured.", sessionID))
+	}
+	info, err := capturer.Close()
+	if err != nil {
+		return info, apperror.Internal(err, "Capture did not finish cleanly")
+	}
+	return info, nil
+}
+
+// ListCaptures returns every capture, newest first.
+func (c *controller) ListCaptures() ([]capture.Info, error) {
+	if c.captures == nil {
+		return []capture.Info{}, nil
+	}
+	infos, err := c.captures.List()
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to list captures")
+	}
+	return infos, nil
+}
+
+// GetCapture returns the info of a capture.
+func (c *controller) GetCapture(captureID string) (capture.Info, error) {
+	if c.captures == nil {
+		return capture.Info{}, captureKind.Unavailable()
+	}
+	info, err := c.captures.Get(captureID)
+	if err != nil {
+		return capture.Info{}, captureKind.Error(err, captureID)
+	}
+	return info, nil
+}
+
+// ExportCapture writes a capture as plain text or NDJSON to the file at
+// path, replacing it. The file only appears once the export is complete.
+func (c *controller) ExportCapture(captureID string, format capture.Format, path string) error {
+	if c.captures == nil {
+		return captureKind.Unavailable()
+	}
+	if !format.Valid() {
+		return apperror.New(apperror.TypeValidation, 400, "Invalid export format",
+			fmt.Sprintf("Unknown format %q (must be \"text\" or \"ndjson\").", format))
+	}
+	if !filepath.IsAbs(path) {
+		return apperror.New(apperror.TypeValidation, 400, "Invalid export path",
+			"Captures are exported to an absolute file path.")
+	}
+	if _, err := c.captures.Get(captureID); err != nil {
+		return captureKind.Error(err, captureID)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return apperror.Internal(err, "Failed to create export file")
+	}
+	defer os.Remove(file.Name()) // no-op once renamed
+	w := bufio.NewWriter(file)
+	if err := c.captures.Export(captureID, w, format); err != nil {
+		file.Close()
+		return captureKind.Error(err, captureID)
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return apperror.Internal(err, "Failed to write export file")
+	}
+	if err := file.Close(); err != nil {
+		return apperror.Internal(err, "Failed to write export file")
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return apperror.Internal(err, "Failed to write export file")
+	}
+	return nil
+}
+
+// DeleteCapture removes a finished capture.
+func (c *controller) DeleteCapture(captureID string) error {
+	if c.captures == nil {
+		return captureKind.Unavailable()
+	}
+	if err := c.captures.Delete(captureID); err != nil {
+		return captureKind.Error(err, captureID)
+	}
+	return nil
+}
+
+// OpenCapture reopens a capture as a read-only session and returns its ID.
+// Nothing is read until ReplayCapture is called, so the frontend can
+// subscribe to the session's events first.
+func (c *controller) OpenCapture(captureID string, pipeline LogPipeline) (string, error) {
+	if c.captures == nil {
+		return "", captureKind.Unavailable()
+	}
+	if _, err := c.captures.Get(captureID); err != nil {
+		return "", captureKind.Error(err, captureID)
+	}
+
+	sessionID := capturedSessionPrefix + uuid.NewString()
+	c.capMu.Lock()
+	c.readers[sessionID] = &capturedSession{captureID: captureID}
+	c.capMu.Unlock()
+
+	if _, err := c.SetSessionPipeline(sessionID, pipeline); err != nil {
+		c.closeCapturedSession(sessionID)
+		return "", err
+	}
+	return sessionID, nil
+}
+
+// ReplayCapture reads a reopened capture from the start. The captured lines
+// are emitted on "core/logs/lines/<sessionID>" like a live session's, after
+// the session's pipeline is applied, and a StreamEnded event follows the last
+// of them. A capture can be replayed again once the previous read has ended,
+// for example after changing the pipeline.
+func (c *controller) ReplayCapture(sessionID string) error {
+	base := c.ctx
+	if base == nil {
+		base = context.Background()
+	}
+	ctx, cancel := context.WithCancel(base)
+
+	c.capMu.Lock()
+	session, ok := c.readers[sessionID]
+	if !ok {
+		c.capMu.Unlock()
+		cancel()
+		return apperror.SessionNotFound(sessionID)
+	}
+	if session.replay != nil {
+		c.capMu.Unlock()
+		cancel()
+		return apperror.New(apperror.TypeResourceConflict, 409, "Capture is being read",
+			"Wait for the capture to be read to the end before replaying it.")
+	}
+	replay := &cancel
+	session.replay = replay
+	captureID := session.captureID
+	c.capMu.Unlock()
+
+	go func() {
+		defer c.endReplay(sessionID, replay)
+		read := 0
+		err := c.captures.Read(captureID, func(line logs.LogLine) error {
+			if read++; read%BatchMaxSize == 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(captureReplayPause):
+				}
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			line.SessionID = sessionID
+			c.bufferLine(sessionID, line)
+			return nil
+		})
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		c.flushSession(sessionID)
+		event := &logs.LogStreamEvent{
+			Type:      logs.StreamEventStreamEnded,
+			Message:   "End of capture",
+			Timestamp: time.Now(),
+		}
+		if err != nil {
+			event.Type, event.Message = logs.StreamEventStreamError, err.Error()
+		}
+		c.emitEvent(sessionID, event)
+	}()
+	return nil
+}
+
+// captureLine writes a line to the session's capture, if it is captured.
+func (c *controller) captureLine(sessionID string, line logs.LogLine) {
+	c.capMu.Lock()
+	capturer := c.capturers[sessionID]
+	c.capMu.Unlock()
+	if capturer != nil {
+		capturer.Write(line)
+	}
+}
+
+// captureClose finishes the capture of a session that has closed.
+func (c *controller) captureClose(sessionID string) {
+	c.capMu.Lock()
+	capturer := c.capturers[sessionID]
+	delete(c.capturers, sessionID)
+	c.capMu.Unlock()
+	if capturer == nil {
+		return
+	}
+	if _, err := capturer.Close(); err != nil {
+		c.logger.Errorw(context.Background(), "error finishing capture", "session", sessionID, "error", err)
+	}
+}
+
+// isCapturedSession reports whether sessionID is a reopened capture.
+func (c *controller) isCapturedSession(sessionID string) bool {
+	c.capMu.Lock()
+	defer c.capMu.Unlock()
+	_, ok := c.readers[sessionID]
+	return ok
+}
+
+// endReplay marks a read of a reopened capture as finished, unless the
+// session has been closed or replayed since.
+func (c *controller) endReplay(sessionID string, replay *context.CancelFunc) {
+	(*replay)()
+	c.capMu.Lock()
+	defer c.capMu.Unlock()
+	if session, ok := c.readers[sessionID]; ok && session.replay == replay {
+		session.replay = nil
+	}
+}
+
+// closeCapturedSession stops reading back a capture and drops the session's
+// state.
+func (c *controller) closeCapturedSession(sessionID string) {
+	c.capMu.Lock()
+	session, ok := c.readers[sessionID]
+	delete(c.readers, sessionID)
+	c.capMu.Unlock()
+	if !ok {
+		return
+	}
+	if session.replay != nil {
+		(*session.replay)()
+	}
+	c.dropPipeline(sessionID)
+}
+
+// stopAllCaptures finishes every capture and stops reading back captures.
+func (c *controller) stopAllCaptures() {
+	c.capMu.Lock()
+	c.capturers = make(map[string]*capture.Capture)
+	readers := c.readers
+	c.readers = make(map[string]*capturedSession)
+	c.capMu.Unlock()
+	for _, session := range readers {
+		if session.replay != nil {
+			(*session.replay)()
+		}
+	}
+	if c.captures == nil {
+		return
+	}
+	if err := c.captures.CloseAll(); err != nil {
+		c.logger.Errorw(context.Background(), "error finishing captures", "error", err)
+	}
+}
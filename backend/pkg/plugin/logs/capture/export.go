@@ -0,0 +1,62 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+)
+
+// Format selects the output of Export.
+type Format string
+
+const (
+	// FormatText is one line per log line: timestamp, source and content.
+	FormatText Format = "text"
+	// FormatNDJSON is one JSON log line per line, as the capture stores them.
+	FormatNDJSON Format = "ndjson"
+)
+
+// Valid reports whether f is a known export format.
+func (f Format) Valid() bool {
+	return f == FormatText || f == FormatNDJSON
+}
+
+// Export writes the lines of a capture to w in the given format.
+func (s *Store) Export(id string, w io.Writer, format Format) error {
+	if !format.Valid() {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	err := s.Read(id, func(line logs.LogLine) error {
+		if format == FormatNDJSON {
+			return enc.Encode(line)
+		}
+		_, err := bw.WriteString(textLine(line))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// textLine formats a log line as a line of plain text export.
+func textLine(line logs.LogLine) string {
+	var b strings.Builder
+	if !line.Timestamp.IsZero() {
+		b.WriteString(line.Timestamp.UTC().Format(time.RFC3339Nano))
+		b.WriteByte(' ')
+	}
+	if line.SourceID != "" {
+		b.WriteString("[" + line.SourceID + "] ")
+	}
+	b.WriteString(strings.TrimRight(line.Content, "\r\n"))
+	b.WriteByte('\n')
+	return b.String()
+}
@@ -0,0 +1,475 @@
+// Package capture persists the lines of log sessions to disk so they outlive
+// the session. A capture is a directory of gzip-compressed NDJSON segments,
+// one logs.LogLine per line, rotated by size, plus an info file describing
+// the capture.
+package capture
+
+import (
+	"bufio"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/sidecar"
+)
+
+var (
+	// ErrNotFound is returned for an unknown capture ID.
+	ErrNotFound = fmt.Errorf("capture %w", sidecar.ErrNotFound)
+	// ErrActive is returned when deleting a capture that is still being written.
+	ErrActive = fmt.Errorf("capture %w", sidecar.ErrActive)
+)
+
+const (
+	infoFile   = "info.json"
+	segmentExt = ".ndjson.gz"
+
+	// DefaultSegmentSize is the uncompressed size a segment is rotated at.
+	DefaultSegmentSize = 8 << 20
+	// DefaultMaxSegments is how many segments a capture keeps; the oldest
+	// are deleted beyond it.
+	DefaultMaxSegments = 32
+	// DefaultFlushInterval is how long a line waits in a capture's buffer
+	// before it is flushed to disk, so quiet sessions lose little on a crash.
+	DefaultFlushInterval = time.Second
+
+	// maxLineSize bounds a single line when reading a capture back.
+	maxLineSize = 4 << 20
+)
+
+// Limits bound the disk space of a capture.
+type Limits struct {
+	// SegmentSize is the uncompressed size a segment is rotated at.
+	SegmentSize int64
+	// MaxSegments is how many segments a capture keeps.
+	MaxSegments int
+	// FlushInterval is how long buffered lines wait to be flushed to disk.
+	FlushInterval time.Duration
+}
+
+func (l Limits) withDefaults() Limits {
+	l.SegmentSize = cmp.Or(l.SegmentSize, DefaultSegmentSize)
+	l.MaxSegments = cmp.Or(l.MaxSegments, DefaultMaxSegments)
+	l.FlushInterval = cmp.Or(l.FlushInterval, DefaultFlushInterval)
+	return l
+}
+
+// Info describes a capture. It is kept in the capture's info.json and
+// rewritten as segments rotate, so listing captures never opens a segment.
+type Info struct {
+	ID           string            `json:"id"`
+	SessionID    string            `json:"sessionID"`
+	PluginID     string            `json:"pluginID,omitempty"`
+	ConnectionID string            `json:"connectionID,omitempty"`
+	ResourceKey  string            `json:"resourceKey,omitempty"`
+	ResourceID   string            `json:"resourceID,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	StartedAt    time.Time         `json:"startedAt"`
+	EndedAt      time.Time         `json:"endedAt,omitzero"`
+	// FirstLine and LastLine are the timestamps of the first and last line
+	// captured.
+	FirstLine time.Time `json:"firstLine,omitzero"`
+	LastLine  time.Time `json:"lastLine,omitzero"`
+	// Lines is the number of lines captured, and Size their uncompressed
+	// size in bytes, including lines since dropped by rotation.
+	Lines int64 `json:"lines"`
+	Size  int64 `json:"size"`
+	// Segments is the number of segment files kept.
+	Segments int `json:"segments"`
+	// Truncated is set once the oldest segments have been deleted to stay
+	// within the capture's limits.
+	Truncated bool `json:"truncated"`
+	// Active is set while the session is still being captured.
+	Active bool `json:"active"`
+	// Error is set if writing stopped early because of an I/O error.
+	Error string `json:"error,omitempty"`
+}
+
+// Options describes a capture to start.
+type Options struct {
+	SessionID    string
+	PluginID     string
+	ConnectionID string
+	ResourceKey  string
+	ResourceID   string
+	Title        string
+	Labels       map[string]string
+}
+
+// Store keeps captures as directories named by capture ID.
+type Store struct {
+	dir    string
+	limits Limits
+	index  *sidecar.Index[Info, *Capture]
+}
+
+// NewStore returns a Store rooted at dir, creating it if needed. Zero limits
+// take their defaults.
+func NewStore(dir string, limits Limits) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create captures directory: %w", err)
+	}
+	return &Store{dir: dir, limits: limits.withDefaults(), index: sidecar.NewIndex[Info, *Capture](ErrNotFound)}, nil
+}
+
+// Start creates a new capture with its first segment.
+func (s *Store) Start(opts Options) (*Capture, error) {
+	info := Info{
+		ID:           sidecar.NewID(),
+		SessionID:    opts.SessionID,
+		PluginID:     opts.PluginID,
+		ConnectionID: opts.ConnectionID,
+		ResourceKey:  opts.ResourceKey,
+		ResourceID:   opts.ResourceID,
+		Title:        opts.Title,
+		Labels:       opts.Labels,
+		StartedAt:    time.Now().UTC(),
+		Active:       true,
+	}
+	if err := os.Mkdir(s.path(info.ID), 0o700); err != nil {
+		return nil, fmt.Errorf("create capture: %w", err)
+	}
+
+	c := &Capture{store: s, info: info}
+	err := c.openSegmentLocked()
+	if err == nil {
+		err = s.writeInfo(c.info)
+	}
+	if err != nil {
+		c.closeSegmentLocked()
+		os.RemoveAll(s.path(info.ID))
+		return nil, fmt.Errorf("start capture: %w", err)
+	}
+
+	s.index.Track(info.ID, c)
+	return c, nil
+}
+
+// List returns every capture, newest first.
+func (s *Store) List() ([]Info, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := []Info{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		info, err := s.Get(e.Name())
+		if err != nil {
+			continue // a capture being deleted, or one with a damaged info file
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b Info) int { return b.StartedAt.Compare(a.StartedAt) })
+	return infos, nil
+}
+
+// Get returns the info of a capture. Active captures report their live
+// counts.
+func (s *Store) Get(id string) (Info, error) {
+	info, active, err := s.index.Info(id, filepath.Join(s.path(id), infoFile))
+	if err != nil {
+		return Info{}, err
+	}
+	// An info file still marked active was left by a crash; only a capture
+	// being written now counts as active.
+	info.Active = active
+	return info, nil
+}
+
+// Read calls fn with every line of a capture, oldest first, stopping at the
+// first error fn returns. An active capture is flushed first, so every line
+// written so far is read.
+func (s *Store) Read(id string, fn func(line logs.LogLine) error) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	if c, ok := s.index.Live(id); ok {
+		_ = c.Flush()
+	}
+	segments, err := s.segments(id)
+	if err != nil {
+		return err
+	}
+	for _, name := range segments {
+		if err := readSegment(filepath.Join(s.path(id), name), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a finished capture.
+func (s *Store) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	if _, active := s.index.Live(id); active {
+		return ErrActive
+	}
+	return os.RemoveAll(s.path(id))
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// segments returns the segment file names of a capture, oldest first.
+func (s *Store) segments(id string) ([]string, error) {
+	entries, err := os.ReadDir(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), segmentExt) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names) // zero-padded sequence numbers sort in order
+	return names, nil
+}
+
+// CloseAll finishes every active capture.
+func (s *Store) CloseAll() error {
+	return s.index.CloseAll()
+}
+
+func (s *Store) writeInfo(info Info) error {
+	return s.index.WriteInfo(filepath.Join(s.path(info.ID), infoFile), info)
+}
+
+func (s *Store) finish(c *Capture) {
+	s.index.Untrack(c.info.ID)
+}
+
+// readSegment decodes the lines of one segment. A segment cut short, as the
+// one still being written, ends at its last complete line.
+func readSegment(path string, fn func(line logs.LogLine) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil // nothing flushed yet
+	}
+	if err != nil {
+		return fmt.Errorf("read capture segment: %w", err)
+	}
+	defer zr.Close()
+
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	for scanner.Scan() {
+		var line logs.LogLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue // a partially flushed last line
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("read capture segment: %w", err)
+	}
+	return nil
+}
+
+// ============================================================================
+// Capture
+// ============================================================================
+
+// Capture appends lines to one capture and may be shared between
+// goroutines. After a write error the lines written so far are kept and new
+// ones are dropped; Close and Info report the error.
+type Capture struct {
+	store *Store
+
+	mu      sync.Mutex
+	info    Info
+	seq     int   // sequence number of the current segment
+	oldest  int   // sequence number of the oldest segment kept
+	segSize int64 // uncompressed bytes in the current segment
+	file    *os.File
+	zw      *gzip.Writer
+	buf     *bufio.Writer
+	// flushTimer is armed by the first line written after a flush.
+	flushTimer *time.Timer
+	closed     bool
+	err        error
+}
+
+// Info returns the current info of the capture.
+func (c *Capture) Info() Info {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.info
+}
+
+// Write appends a line to the capture.
+func (c *Capture) Write(line logs.LogLine) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.err != nil {
+		return
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	if c.segSize > 0 && c.segSize+int64(len(data))+1 > c.store.limits.SegmentSize {
+		if err := c.rotateLocked(); err != nil {
+			c.failLocked(err)
+			return
+		}
+	}
+	if _, err := c.buf.Write(append(data, '\n')); err != nil {
+		c.failLocked(err)
+		return
+	}
+
+	c.segSize += int64(len(data)) + 1
+	c.info.Size += int64(len(data)) + 1
+	c.info.Lines++
+	if c.info.FirstLine.IsZero() {
+		c.info.FirstLine = line.Timestamp
+	}
+	c.info.LastLine = line.Timestamp
+
+	if c.flushTimer == nil {
+		c.flushTimer = time.AfterFunc(c.store.limits.FlushInterval, func() { _ = c.Flush() })
+	}
+}
+
+// Flush writes buffered lines to disk.
+func (c *Capture) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.err != nil {
+		return c.err
+	}
+	if err := c.flushLocked(); err != nil {
+		c.failLocked(err)
+	}
+	return c.err
+}
+
+// Close finishes the capture and returns its final info. Close is
+// idempotent.
+func (c *Capture) Close() (Info, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return c.info, c.err
+	}
+	c.closed = true
+	c.stopFlushTimerLocked()
+	if err := c.closeSegmentLocked(); err != nil && c.err == nil {
+		c.err = err
+	}
+	c.info.Active = false
+	c.info.EndedAt = time.Now().UTC()
+	if c.err != nil {
+		c.info.Error = c.err.Error()
+	}
+	if err := c.store.writeInfo(c.info); err != nil && c.err == nil {
+		c.err = err
+	}
+	c.store.finish(c)
+	return c.info, c.err
+}
+
+func (c *Capture) segmentPath(seq int) string {
+	return filepath.Join(c.store.path(c.info.ID), fmt.Sprintf("%06d%s", seq, segmentExt))
+}
+
+func (c *Capture) openSegmentLocked() error {
+	c.seq++
+	if c.oldest == 0 {
+		c.oldest = c.seq
+	}
+	f, err := os.OpenFile(c.segmentPath(c.seq), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	c.file = f
+	c.zw = gzip.NewWriter(f)
+	c.buf = bufio.NewWriter(c.zw)
+	c.segSize = 0
+	c.info.Segments++
+	return nil
+}
+
+// closeSegmentLocked flushes and closes the current segment.
+func (c *Capture) closeSegmentLocked() error {
+	if c.file == nil {
+		return nil
+	}
+	err := c.buf.Flush()
+	if zerr := c.zw.Close(); err == nil {
+		err = zerr
+	}
+	if ferr := c.file.Close(); err == nil {
+		err = ferr
+	}
+	c.file, c.zw, c.buf = nil, nil, nil
+	return err
+}
+
+// rotateLocked starts a new segment, deleting the oldest ones beyond the
+// store's limit.
+func (c *Capture) rotateLocked() error {
+	if err := c.closeSegmentLocked(); err != nil {
+		return err
+	}
+	if err := c.openSegmentLocked(); err != nil {
+		return err
+	}
+	for c.info.Segments > c.store.limits.MaxSegments {
+		if err := os.Remove(c.segmentPath(c.oldest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		c.oldest++
+		c.info.Segments--
+		c.info.Truncated = true
+	}
+	return c.store.writeInfo(c.info)
+}
+
+func (c *Capture) flushLocked() error {
+	c.stopFlushTimerLocked()
+	if err := c.buf.Flush(); err != nil {
+		return err
+	}
+	return c.zw.Flush()
+}
+
+func (c *Capture) stopFlushTimerLocked() {
+	if c.flushTimer != nil {
+		c.flushTimer.Stop()
+		c.flushTimer = nil
+	}
+}
+
+func (c *Capture) failLocked(err error) {
+	c.err = err
+	c.info.Error = err.Error()
+}
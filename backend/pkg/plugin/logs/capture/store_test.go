@@ -0,0 +1,184 @@
+package capture
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, limits Limits) *Store {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "captures"), limits)
+	require.NoError(t, err)
+	return s
+}
+
+func testLine(i int) logs.LogLine {
+	return logs.LogLine{
+		SessionID: "sess-1",
+		SourceID:  "web-0",
+		Labels:    map[string]string{"container": "web"},
+		Timestamp: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+		Content:   fmt.Sprintf("line %d", i),
+		Level:     logs.LogLevelInfo,
+	}
+}
+
+// readAll returns the contents of every line of a capture.
+func readAll(t *testing.T, s *Store, id string) []string {
+	t.Helper()
+	var contents []string
+	require.NoError(t, s.Read(id, func(line logs.LogLine) error {
+		contents = append(contents, line.Content)
+		return nil
+	}))
+	return contents
+}
+
+func TestCapture_WritesCompressedNDJSON(t *testing.T) {
+	s := newTestStore(t, Limits{})
+	c, err := s.Start(Options{SessionID: "sess-1", PluginID: "kubernetes", ResourceID: "web-0"})
+	require.NoError(t, err)
+	for i := range 3 {
+		c.Write(testLine(i))
+	}
+	info, err := c.Close()
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+	assert.Equal(t, int64(3), info.Lines)
+	assert.Equal(t, 1, info.Segments)
+	assert.Equal(t, testLine(0).Timestamp, info.FirstLine)
+	assert.Equal(t, testLine(2).Timestamp, info.LastLine)
+
+	// The segment is a gzip stream of JSON lines with their metadata.
+	f, err := os.Open(filepath.Join(s.path(info.ID), "000001"+segmentExt))
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	var first logs.LogLine
+	require.NoError(t, json.NewDecoder(zr).Decode(&first))
+	assert.Equal(t, testLine(0), first)
+
+	stored, err := s.Get(info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, info, stored)
+	assert.Equal(t, []string{"line 0", "line 1", "line 2"}, readAll(t, s, info.ID))
+}
+
+func TestCapture_RotatesAndDropsOldestSegments(t *testing.T) {
+	size := int64(len(mustJSON(t, testLine(0))) + 1)
+	s := newTestStore(t, Limits{SegmentSize: 2 * size, MaxSegments: 2})
+	c, err := s.Start(Options{SessionID: "sess-1"})
+	require.NoError(t, err)
+	for i := range 7 {
+		c.Write(testLine(i))
+	}
+	info, err := c.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(7), info.Lines)
+	assert.Equal(t, 2, info.Segments)
+	assert.True(t, info.Truncated)
+	assert.Equal(t, []string{"line 4", "line 5", "line 6"}, readAll(t, s, info.ID),
+		"the oldest segments are deleted, lines stay in order")
+}
+
+func TestCapture_ReadWhileActive(t *testing.T) {
+	s := newTestStore(t, Limits{FlushInterval: time.Hour})
+	c, err := s.Start(Options{SessionID: "sess-1"})
+	require.NoError(t, err)
+	defer c.Close()
+
+	assert.Empty(t, readAll(t, s, c.Info().ID))
+	c.Write(testLine(0))
+	c.Write(testLine(1))
+	assert.Equal(t, []string{"line 0", "line 1"}, readAll(t, s, c.Info().ID), "reading flushes the buffered lines")
+
+	info, err := s.Get(c.Info().ID)
+	require.NoError(t, err)
+	assert.True(t, info.Active)
+	assert.ErrorIs(t, s.Delete(info.ID), ErrActive)
+}
+
+func TestCapture_FlushesQuietSession(t *testing.T) {
+	s := newTestStore(t, Limits{FlushInterval: 10 * time.Millisecond})
+	c, err := s.Start(Options{SessionID: "sess-1"})
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.Write(testLine(0))
+	assert.Eventually(t, func() bool {
+		var n int
+		_ = readSegment(c.segmentPath(1), func(logs.LogLine) error { n++; return nil })
+		return n == 1
+	}, time.Second, 5*time.Millisecond, "the last line is flushed without another write")
+}
+
+func TestStore_ListAndDelete(t *testing.T) {
+	s := newTestStore(t, Limits{})
+	first, err := s.Start(Options{SessionID: "sess-1"})
+	require.NoError(t, err)
+	_, err = first.Close()
+	require.NoError(t, err)
+	second, err := s.Start(Options{SessionID: "sess-2"})
+	require.NoError(t, err)
+
+	infos, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "sess-2", infos[0].SessionID, "newest first")
+	assert.True(t, infos[0].Active)
+	_, err = second.Close()
+	require.NoError(t, err)
+
+	require.NoError(t, s.Delete(first.Info().ID))
+	assert.ErrorIs(t, s.Delete(first.Info().ID), ErrNotFound)
+	_, err = s.Get("../../etc")
+	assert.ErrorIs(t, err, ErrNotFound)
+	infos, err = s.List()
+	require.NoError(t, err)
+	assert.Len(t, infos, 1)
+}
+
+func TestStore_Export(t *testing.T) {
+	s := newTestStore(t, Limits{})
+	c, err := s.Start(Options{SessionID: "sess-1"})
+	require.NoError(t, err)
+	c.Write(testLine(0))
+	c.Write(logs.LogLine{Content: "no metadata\n"})
+	info, err := c.Close()
+	require.NoError(t, err)
+
+	var text bytes.Buffer
+	require.NoError(t, s.Export(info.ID, &text, FormatText))
+	assert.Equal(t, "2024-01-01T00:00:00Z [web-0] line 0\nno metadata\n", text.String())
+
+	var ndjson bytes.Buffer
+	require.NoError(t, s.Export(info.ID, &ndjson, FormatNDJSON))
+	lines := strings.Split(strings.TrimSpace(ndjson.String()), "\n")
+	require.Len(t, lines, 2)
+	var line logs.LogLine
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, testLine(0), line)
+
+	assert.Error(t, s.Export(info.ID, &text, "csv"))
+	assert.ErrorIs(t, s.Export("00000000-0000-0000-0000-000000000000", &text, FormatText), ErrNotFound)
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,261 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/logs/capture"
+)
+
+// recordingEmitter records emitted events by key.
+type recordingEmitter struct {
+	mu     sync.Mutex
+	events map[string][]any
+}
+
+func (e *recordingEmitter) Emit(eventKey string, data ...any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.events == nil {
+		e.events = make(map[string][]any)
+	}
+	e.events[eventKey] = append(e.events[eventKey], data[0])
+}
+
+// lines returns the contents of the lines emitted for a session.
+func (e *recordingEmitter) lines(t *testing.T, sessionID string) []string {
+	t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var contents []string
+	for _, data := range e.events["core/logs/lines/"+sessionID] {
+		var entries []LogEntry
+		require.NoError(t, json.Unmarshal([]byte(data.(string)), &entries))
+		for _, entry := range entries {
+			contents = append(contents, entry.Content)
+		}
+	}
+	return contents
+}
+
+func (e *recordingEmitter) streamEvents(t *testing.T, sessionID string) []logs.LogStreamEvent {
+	t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var events []logs.LogStreamEvent
+	for _, data := range e.events["core/logs/event/"+sessionID] {
+		var event logs.LogStreamEvent
+		require.NoError(t, json.Unmarshal([]byte(data.(string)), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+// newCaptureController returns a controller with a capture store and an
+// emitter recording its events.
+func newCaptureController(t *testing.T) (*controller, *recordingEmitter) {
+	t.Helper()
+	c, _ := newAggregateController(t)
+	store, err := capture.NewStore(filepath.Join(t.TempDir(), "captures"), capture.Limits{})
+	require.NoError(t, err)
+	c.captures = store
+	emitter := &recordingEmitter{}
+	c.emitter = emitter
+	t.Cleanup(func() { _ = c.ServiceShutdown() })
+	return c, emitter
+}
+
+func outputLine(sessionID, content string) logs.StreamOutput {
+	return logs.StreamOutput{SessionID: sessionID, Line: &logs.LogLine{
+		SessionID: sessionID,
+		SourceID:  "web-0",
+		Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Content:   content,
+	}}
+}
+
+func TestCapture_StartAndStop(t *testing.T) {
+	c, _ := newCaptureController(t)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	info, err := c.StartCapture(session.ID, CaptureOptions{Title: "web"})
+	require.NoError(t, err)
+	assert.True(t, info.Active)
+	assert.Equal(t, "kubernetes", info.PluginID)
+
+	var target *apperror.AppError
+	_, err = c.StartCapture(session.ID, CaptureOptions{})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceConflict, target.Type)
+
+	// Lines are captured before the session's pipeline filters them.
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{Query: "error"})
+	require.NoError(t, err)
+	c.handleOutput(outputLine(session.ID, "starting"))
+	c.handleOutput(outputLine(session.ID, "error: boom"))
+
+	info, err = c.StopCapture(session.ID)
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+	assert.Equal(t, int64(2), info.Lines)
+	assert.Equal(t, "web", info.Title)
+
+	dir := t.TempDir()
+	textPath := filepath.Join(dir, "web.log")
+	require.NoError(t, c.ExportCapture(info.ID, capture.FormatText, textPath))
+	text, err := os.ReadFile(textPath)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01T00:00:00Z [web-0] starting\n2024-01-01T00:00:00Z [web-0] error: boom\n", string(text))
+	ndjsonPath := filepath.Join(dir, "web.ndjson")
+	require.NoError(t, c.ExportCapture(info.ID, capture.FormatNDJSON, ndjsonPath))
+	ndjson, err := os.ReadFile(ndjsonPath)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(ndjson)), "\n"), 2)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files are left behind")
+
+	_, err = c.StopCapture(session.ID)
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceNotFound, target.Type)
+}
+
+func TestCapture_FinishedWhenSessionCloses(t *testing.T) {
+	c, _ := newCaptureController(t)
+	session, err := c.CreateAggregateSession(aggregateSources())
+	require.NoError(t, err)
+	info, err := c.StartCapture(session.ID, CaptureOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "api, us-east-1/lambda", info.Title, "aggregate captures are named by their sources")
+
+	c.handleOutput(outputLine(session.Sources[0].SessionID, "merged"))
+	require.NoError(t, c.CloseSession(session.ID))
+
+	captures, err := c.ListCaptures()
+	require.NoError(t, err)
+	require.Len(t, captures, 1)
+	assert.False(t, captures[0].Active)
+	assert.Equal(t, int64(1), captures[0].Lines, "held lines are flushed into the capture on close")
+}
+
+func TestOpenCapture_EmitsCapturedLines(t *testing.T) {
+	c, emitter := newCaptureController(t)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+	info, err := c.StartCapture(session.ID, CaptureOptions{})
+	require.NoError(t, err)
+	for _, content := range []string{"level=info msg=ready", "level=error msg=failed", "level=warn msg=slow"} {
+		c.handleOutput(outputLine(session.ID, content))
+	}
+	_, err = c.StopCapture(session.ID)
+	require.NoError(t, err)
+
+	reopened, err := c.OpenCapture(info.ID, LogPipeline{Query: "level>=warn"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(reopened, capturedSessionPrefix))
+	time.Sleep(2 * BatchFlushInterval)
+	assert.Empty(t, emitter.lines(t, reopened), "nothing is read before the capture is replayed")
+
+	require.NoError(t, c.ReplayCapture(reopened))
+	require.Eventually(t, func() bool { return len(emitter.streamEvents(t, reopened)) == 1 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, logs.StreamEventStreamEnded, emitter.streamEvents(t, reopened)[0].Type)
+	assert.Equal(t, []string{"level=error msg=failed", "level=warn msg=slow"}, emitter.lines(t, reopened))
+
+	// The session outlives the read, so it can be filtered and replayed.
+	_, err = c.SetSessionPipeline(reopened, LogPipeline{Query: "level=info"})
+	require.NoError(t, err)
+	require.NoError(t, c.ReplayCapture(reopened))
+	require.Eventually(t, func() bool { return len(emitter.streamEvents(t, reopened)) == 2 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, []string{"level=error msg=failed", "level=warn msg=slow", "level=info msg=ready"}, emitter.lines(t, reopened))
+
+	require.NoError(t, c.CloseSession(reopened))
+	var target *apperror.AppError
+	require.True(t, errors.As(c.ReplayCapture(reopened), &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+}
+
+func TestReplayCapture_PacedAndCancelledOnClose(t *testing.T) {
+	c, emitter := newCaptureController(t)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+	info, err := c.StartCapture(session.ID, CaptureOptions{})
+	require.NoError(t, err)
+	for range 50 * BatchMaxSize {
+		c.handleOutput(outputLine(session.ID, "line"))
+	}
+	_, err = c.StopCapture(session.ID)
+	require.NoError(t, err)
+
+	reopened, err := c.OpenCapture(info.ID, LogPipeline{})
+	require.NoError(t, err)
+	require.NoError(t, c.ReplayCapture(reopened))
+	var target *apperror.AppError
+	require.True(t, errors.As(c.ReplayCapture(reopened), &target), "one read at a time")
+	assert.Equal(t, apperror.TypeResourceConflict, target.Type)
+
+	time.Sleep(5 * captureReplayPause)
+	require.NoError(t, c.CloseSession(reopened))
+	time.Sleep(5 * captureReplayPause)
+	assert.Less(t, len(emitter.lines(t, reopened)), 50*BatchMaxSize, "closing the session stops the paced read")
+	assert.Empty(t, emitter.streamEvents(t, reopened))
+}
+
+func TestCapture_Errors(t *testing.T) {
+	var target *apperror.AppError
+
+	disabled := newTestController()
+	_, err := disabled.StartCapture("session", CaptureOptions{})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeNotImplemented, target.Type)
+	captures, err := disabled.ListCaptures()
+	require.NoError(t, err)
+	assert.Empty(t, captures)
+
+	c, _ := newCaptureController(t)
+	_, err = c.StartCapture("missing", CaptureOptions{})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+	_, err = c.OpenCapture("00000000-0000-0000-0000-000000000000", LogPipeline{})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceNotFound, target.Type)
+
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+	info, err := c.StartCapture(session.ID, CaptureOptions{})
+	require.NoError(t, err)
+	err = c.DeleteCapture(info.ID)
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceConflict, target.Type, "active captures cannot be deleted")
+	err = c.ExportCapture(info.ID, "csv", filepath.Join(t.TempDir(), "out.log"))
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type)
+	err = c.ExportCapture(info.ID, capture.FormatText, "out.log")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type, "export paths are absolute")
+	err = c.ExportCapture("00000000-0000-0000-0000-000000000000", capture.FormatText, filepath.Join(t.TempDir(), "out.log"))
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceNotFound, target.Type)
+
+	_, err = c.OpenCapture(info.ID, LogPipeline{Query: "("})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type)
+	c.capMu.Lock()
+	assert.Empty(t, c.readers, "a reader is not left behind by an invalid pipeline")
+	c.capMu.Unlock()
+
+	_, err = c.StopCapture(session.ID)
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteCapture(info.ID))
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
@@ -15,6 +17,7 @@ import (
 	logging "github.com/omniviewdev/plugin-sdk/log"
 
 	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/logs/capture"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/telemetryutil"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource"
 	internaltypes "github.com/omniviewdev/omniview/backend/pkg/plugin/types"
@@ -44,6 +47,28 @@ type Controller interface {
 	CloseSession(sessionID string) error
 	SendCommand(sessionID string, cmd logs.LogStreamCommand) error
 	UpdateSessionOptions(sessionID string, opts logs.LogSessionOptions) (*logs.LogSession, error)
+	CreateAggregateSession(sources []AggregateSource) (*AggregateSession, error)
+	GetAggregateSession(id string) (*AggregateSession, error)
+	ListAggregateSessions() ([]*AggregateSession, error)
+	SetSessionPipeline(sessionID string, config LogPipeline) (*LogPipelineState, error)
+	GetSessionPipeline(sessionID string) (*LogPipelineState, error)
+	ListSessionPatterns(sessionID string, opts PatternOptions) ([]LogTemplate, error)
+
+	// Capture
+	StartCapture(sessionID string, opts CaptureOptions) (capture.Info, error)
+	StopCapture(sessionID string) (capture.Info, error)
+	ListCaptures() ([]capture.Info, error)
+	GetCapture(captureID string) (capture.Info, error)
+	ExportCapture(captureID string, format capture.Format, path string) error
+	DeleteCapture(captureID string) error
+	OpenCapture(captureID string, pipeline LogPipeline) (string, error)
+	ReplayCapture(sessionID string) error
+
+	// Alerts
+	ListAlertRules() ([]AlertRule, error)
+	SaveAlertRule(rule AlertRule) (*AlertRule, error)
+	DeleteAlertRule(ruleID string) error
+	ListAlerts() ([]LogAlert, error)
 }
 
 type sessionIndex struct {
@@ -55,6 +80,7 @@ var _ Controller = (*controller)(nil)
 
 type controller struct {
 	app              *application.App
+	emitter          EventEmitter
 	ctx              context.Context
 	logger           logging.Logger
 	settingsProvider pkgsettings.Provider
@@ -67,23 +93,86 @@ type controller struct {
 	inChans      map[string]chan logs.StreamInput
 	handlerMap   map[string]map[string]logs.Handler
 
+	// aggregate sessions, and the aggregate each underlying session feeds.
+	// While aggregates are being created, output that may belong to one of
+	// their sources is held until the aggregate is registered. holdMu is
+	// taken before mu.
+	aggregates  map[string]*aggregate
+	aggregateOf map[string]string
+	creating    atomic.Int32
+	holdMu      sync.Mutex
+	held        map[string][]logs.StreamOutput
+
+	// per-session parsing and filtering, applied before batching
+	pipelines  map[string]*pipeline
+	pipelineMu sync.RWMutex
+
+	// log capture; captures is nil when capture is disabled
+	captures  *capture.Store
+	capMu     sync.Mutex
+	capturers map[string]*capture.Capture // by session ID
+	readers   map[string]*capturedSession // reopened captures, by session ID
+
+	// log alerts; alertStore is nil when rules cannot be saved. alertMu is
+	// taken before mu.
+	alertStore *AlertStore
+	notifier   AlertNotifier
+	alertMu    sync.Mutex
+	alertRules []*alertRule           // enabled rules
+	watches    map[string]*alertWatch // by plugin session ID
+	alerts     []LogAlert             // fired recently, oldest first
+	alertNow   func() time.Time       // clock for alert windows and cooldowns
+
 	// batch flush state
 	batches   map[string]*logBatch
 	batchMux  sync.Mutex
 }
 
 type logBatch struct {
-	lines []logs.LogLine
+	lines []LogEntry
 	timer *time.Timer
 }
 
+// ControllerOption configures optional controller features.
+type ControllerOption func(*controllerOptions)
+
+type controllerOptions struct {
+	captures   *capture.Store
+	alertStore *AlertStore
+	notifier   AlertNotifier
+}
+
+// WithCaptureStore enables capturing sessions to store. Without it the
+// capture methods return a not-implemented error.
+func WithCaptureStore(store *capture.Store) ControllerOption {
+	return func(o *controllerOptions) { o.captures = store }
+}
+
+// WithAlertStore persists alert rules in store. Without it no rules are
+// evaluated and saving one returns a not-implemented error.
+func WithAlertStore(store *AlertStore) ControllerOption {
+	return func(o *controllerOptions) { o.alertStore = store }
+}
+
+// WithAlertNotifier raises desktop notifications for the rules that ask for
+// them through notifier.
+func WithAlertNotifier(notifier AlertNotifier) ControllerOption {
+	return func(o *controllerOptions) { o.notifier = notifier }
+}
+
 func NewController(
 	logger logging.Logger,
 	sp pkgsettings.Provider,
 	resourceClient resource.Service,
+	opts ...ControllerOption,
 ) Controller {
-	return &controller{
+	var cfg controllerOptions
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	c := &controller{
 		logger:           logger.Named("LogController"),
+		emitter:          NoopEmitter{},
 		settingsProvider: sp,
 		clients:          make(map[string]LogsProvider),
 		sessionIndex:     make(map[string]sessionIndex),
@@ -92,17 +181,41 @@ func NewController(
 		resourceClient:   resourceClient,
 		handlerMap:       make(map[string]map[string]logs.Handler),
 		batches:          make(map[string]*logBatch),
+		aggregates:       make(map[string]*aggregate),
+		aggregateOf:      make(map[string]string),
+		held:             make(map[string][]logs.StreamOutput),
+		pipelines:        make(map[string]*pipeline),
+		captures:         cfg.captures,
+		capturers:        make(map[string]*capture.Capture),
+		readers:          make(map[string]*capturedSession),
+		alertStore:       cfg.alertStore,
+		notifier:         cfg.notifier,
+		watches:          make(map[string]*alertWatch),
+		alertNow:         time.Now,
+	}
+	if c.alertStore != nil {
+		if err := c.loadAlertRules(""); err != nil {
+			c.logger.Warnw(context.Background(), "failed to load log alert rules", "error", err)
+		}
 	}
+	if c.notifier != nil {
+		c.notifier.OnNotificationResponse(c.handleNotificationResponse)
+	}
+	return c
 }
 
 func (c *controller) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
 	c.app = application.Get()
+	if c.app != nil {
+		c.emitter = &appEmitter{app: c.app}
+	}
 	c.ctx = ctx
 	go c.runMux()
 	return nil
 }
 
 func (c *controller) ServiceShutdown() error {
+	c.stopAllCaptures()
 	return nil
 }
 
@@ -118,33 +231,56 @@ func (c *controller) runMux() {
 }
 
 func (c *controller) handleOutput(output logs.StreamOutput) {
+	if c.creating.Load() > 0 && c.holdOutput(output) {
+		return
+	}
+	c.dispatchOutput(output)
+}
+
+func (c *controller) dispatchOutput(output logs.StreamOutput) {
+	if output.Line != nil {
+		c.evaluateAlerts(output.SessionID, *output.Line)
+	}
+	if c.routeAggregateOutput(output) {
+		return
+	}
 	if output.Line != nil {
 		c.bufferLine(output.SessionID, *output.Line)
 	} else if output.Event != nil {
 		// Events are sent immediately, not batched
-		eventKey := "core/logs/event/" + output.SessionID
-		data, err := json.Marshal(output.Event)
-		if err != nil {
-			c.logger.Errorw(context.Background(), "failed to marshal log event", "error", err)
-			return
-		}
-		c.app.Event.Emit(eventKey, string(data))
+		c.emitEvent(output.SessionID, output.Event)
 	}
 }
 
+func (c *controller) emitEvent(sessionID string, event *logs.LogStreamEvent) {
+	eventKey := "core/logs/event/" + sessionID
+	data, err := json.Marshal(event)
+	if err != nil {
+		c.logger.Errorw(context.Background(), "failed to marshal log event", "error", err)
+		return
+	}
+	c.emitter.Emit(eventKey, string(data))
+}
+
 func (c *controller) bufferLine(sessionID string, line logs.LogLine) {
+	c.captureLine(sessionID, line)
+	entry, ok := c.processLine(sessionID, line)
+	if !ok {
+		return
+	}
+
 	c.batchMux.Lock()
 	defer c.batchMux.Unlock()
 
 	batch, ok := c.batches[sessionID]
 	if !ok {
 		batch = &logBatch{
-			lines: make([]logs.LogLine, 0, BatchMaxSize),
+			lines: make([]LogEntry, 0, BatchMaxSize),
 		}
 		c.batches[sessionID] = batch
 	}
 
-	batch.lines = append(batch.lines, line)
+	batch.lines = append(batch.lines, entry)
 
 	if len(batch.lines) >= BatchMaxSize {
 		c.flushBatchLocked(sessionID, batch)
@@ -174,12 +310,29 @@ func (c *controller) flushBatchLocked(sessionID string, batch *logBatch) {
 	if err != nil {
 		c.logger.Errorw(context.Background(), "failed to marshal log batch", "error", err)
 	} else {
-		c.app.Event.Emit(eventKey, string(data))
+		c.emitter.Emit(eventKey, string(data))
 	}
 
 	batch.lines = batch.lines[:0]
 }
 
+// flushSession emits the lines batched for a session and drops its batch.
+func (c *controller) flushSession(sessionID string) {
+	c.batchMux.Lock()
+	defer c.batchMux.Unlock()
+
+	batch, ok := c.batches[sessionID]
+	if !ok {
+		return
+	}
+	if len(batch.lines) > 0 {
+		c.flushBatchLocked(sessionID, batch)
+	} else if batch.timer != nil {
+		batch.timer.Stop()
+	}
+	delete(c.batches, sessionID)
+}
+
 // ================================ Controller Lifecycle ================================ //
 
 func (c *controller) OnPluginInit(pluginID string, meta config.PluginMeta) {
@@ -496,6 +649,19 @@ func (c *controller) CloseSession(sessionID string) error {
 	defer span.End()
 	span.SetAttributes(attribute.String("session_id", sessionID))
 
+	if c.isAggregate(sessionID) {
+		err := c.closeAggregate(sessionID)
+		if err != nil {
+			telemetryutil.RecordError(span, err)
+		}
+		return err
+	}
+	if strings.HasPrefix(sessionID, capturedSessionPrefix) {
+		c.closeCapturedSession(sessionID)
+		c.flushSession(sessionID)
+		return nil
+	}
+
 	c.mu.RLock()
 	index, ok := c.sessionIndex[sessionID]
 	if !ok {
@@ -530,9 +696,13 @@ func (c *controller) CloseSession(sessionID string) error {
 		delete(c.batches, sessionID)
 	}
 	c.batchMux.Unlock()
+	c.dropPipeline(sessionID)
+	c.captureClose(sessionID)
+	c.dropAlertWatch(sessionID)
 
 	c.mu.Lock()
 	delete(c.sessionIndex, sessionID)
+	delete(c.aggregateOf, sessionID)
 	c.mu.Unlock()
 	return nil
 }
@@ -545,6 +715,22 @@ func (c *controller) SendCommand(sessionID string, cmd logs.LogStreamCommand) er
 		attribute.Int("command", int(cmd)),
 	)
 
+	if c.isAggregate(sessionID) {
+		err := c.sendAggregateCommand(sessionID, cmd)
+		if err != nil {
+			telemetryutil.RecordError(span, err)
+		}
+		return err
+	}
+	if c.isCapturedSession(sessionID) {
+		if cmd == logs.StreamCommandClose {
+			c.closeCapturedSession(sessionID)
+			return nil
+		}
+		return apperror.NotImplemented("Session is read-only",
+			"A reopened capture cannot be paused or resumed.")
+	}
+
 	c.mu.Lock()
 	index, ok := c.sessionIndex[sessionID]
 	if !ok {
@@ -0,0 +1,127 @@
+package logs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+)
+
+// mergerMaxPending bounds the lines a lineMerger holds. Past it the earliest
+// lines are released without waiting out the reorder window.
+const mergerMaxPending = 10 * BatchMaxSize
+
+type pendingLine struct {
+	line     logs.LogLine
+	deadline time.Time
+}
+
+// lineMerger merges the lines of several streams into timestamp order. Each
+// line is held for the reorder window after it arrives so that lines from a
+// slower stream can still be placed before it. Lines are released in batches,
+// in order, to release.
+type lineMerger struct {
+	window  time.Duration
+	release func(lines []logs.LogLine)
+
+	mu      sync.Mutex
+	pending []pendingLine // sorted by line timestamp
+	timer   *time.Timer
+	closed  bool
+}
+
+func newLineMerger(window time.Duration, release func(lines []logs.LogLine)) *lineMerger {
+	return &lineMerger{window: window, release: release}
+}
+
+// add queues a line. Lines without a timestamp are stamped with their arrival
+// time.
+func (m *lineMerger) add(line logs.LogLine) {
+	now := time.Now()
+	if line.Timestamp.IsZero() {
+		line.Timestamp = now
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+
+	// Insert after any lines with the same timestamp so that lines of one
+	// stream keep their order.
+	i := sort.Search(len(m.pending), func(i int) bool {
+		return m.pending[i].line.Timestamp.After(line.Timestamp)
+	})
+	m.pending = append(m.pending, pendingLine{})
+	copy(m.pending[i+1:], m.pending[i:])
+	m.pending[i] = pendingLine{line: line, deadline: now.Add(m.window)}
+
+	if len(m.pending) > mergerMaxPending {
+		m.releaseLocked(len(m.pending) - mergerMaxPending)
+	}
+	if m.timer == nil {
+		m.timer = time.AfterFunc(m.window, m.flush)
+	}
+}
+
+// flush releases every line whose reorder window has passed, together with
+// any lines ordered before it.
+func (m *lineMerger) flush() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timer = nil
+	if m.closed {
+		return
+	}
+
+	now := time.Now()
+	cut := 0
+	for i, p := range m.pending {
+		if !p.deadline.After(now) {
+			cut = i + 1
+		}
+	}
+	m.releaseLocked(cut)
+
+	if len(m.pending) == 0 {
+		return
+	}
+	next := m.pending[0].deadline
+	for _, p := range m.pending[1:] {
+		if p.deadline.Before(next) {
+			next = p.deadline
+		}
+	}
+	m.timer = time.AfterFunc(max(next.Sub(now), time.Millisecond), m.flush)
+}
+
+// close releases every pending line and stops the merger. Lines added after
+// close are dropped.
+func (m *lineMerger) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+	m.releaseLocked(len(m.pending))
+	m.closed = true
+}
+
+// releaseLocked releases the first n pending lines. Caller must hold m.mu.
+func (m *lineMerger) releaseLocked(n int) {
+	if n == 0 {
+		return
+	}
+	lines := make([]logs.LogLine, n)
+	for i := range n {
+		lines[i] = m.pending[i].line
+	}
+	m.pending = append(m.pending[:0], m.pending[n:]...)
+	m.release(lines)
+}
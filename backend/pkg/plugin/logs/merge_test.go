@@ -0,0 +1,109 @@
+package logs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineRecorder collects the lines a lineMerger releases.
+type lineRecorder struct {
+	mu      sync.Mutex
+	lines   []logs.LogLine
+	batches int
+}
+
+func (r *lineRecorder) release(lines []logs.LogLine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, lines...)
+	r.batches++
+}
+
+func (r *lineRecorder) contents() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	contents := make([]string, 0, len(r.lines))
+	for _, line := range r.lines {
+		contents = append(contents, line.Content)
+	}
+	return contents
+}
+
+func lineAt(content string, ts time.Time) logs.LogLine {
+	return logs.LogLine{Content: content, Timestamp: ts}
+}
+
+func TestLineMerger_OrdersWithinWindow(t *testing.T) {
+	r := &lineRecorder{}
+	m := newLineMerger(30*time.Millisecond, r.release)
+	base := time.Now()
+
+	m.add(lineAt("b1", base.Add(2*time.Second)))
+	m.add(lineAt("b2", base.Add(4*time.Second)))
+	m.add(lineAt("a1", base.Add(1*time.Second)))
+	m.add(lineAt("a2", base.Add(3*time.Second)))
+	m.add(lineAt("a3", base.Add(3*time.Second)))
+	assert.Empty(t, r.contents(), "lines are held for the reorder window")
+
+	require.Eventually(t, func() bool { return len(r.contents()) == 5 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"a1", "b1", "a2", "a3", "b2"}, r.contents(),
+		"lines are merged by timestamp, equal timestamps keep arrival order")
+}
+
+func TestLineMerger_LateLineAfterRelease(t *testing.T) {
+	r := &lineRecorder{}
+	m := newLineMerger(10*time.Millisecond, r.release)
+	base := time.Now()
+
+	m.add(lineAt("first", base.Add(2*time.Second)))
+	require.Eventually(t, func() bool { return len(r.contents()) == 1 }, time.Second, time.Millisecond)
+
+	m.add(lineAt("late", base.Add(time.Second)))
+	require.Eventually(t, func() bool { return len(r.contents()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"first", "late"}, r.contents(),
+		"a line later than the window is released rather than dropped")
+}
+
+func TestLineMerger_StampsMissingTimestamp(t *testing.T) {
+	r := &lineRecorder{}
+	m := newLineMerger(time.Hour, r.release)
+
+	before := time.Now()
+	m.add(logs.LogLine{Content: "untimed"})
+	m.close()
+
+	require.Len(t, r.lines, 1)
+	assert.False(t, r.lines[0].Timestamp.Before(before))
+}
+
+func TestLineMerger_BoundsPending(t *testing.T) {
+	r := &lineRecorder{}
+	m := newLineMerger(time.Hour, r.release)
+	base := time.Now()
+
+	for i := range mergerMaxPending + 5 {
+		m.add(lineAt("line", base.Add(time.Duration(i)*time.Millisecond)))
+	}
+	assert.Len(t, r.contents(), 5, "the earliest lines are released once too many are held")
+}
+
+func TestLineMerger_Close(t *testing.T) {
+	r := &lineRecorder{}
+	m := newLineMerger(time.Hour, r.release)
+	base := time.Now()
+
+	m.add(lineAt("second", base.Add(time.Second)))
+	m.add(lineAt("first", base))
+	m.close()
+	assert.Equal(t, []string{"first", "second"}, r.contents(), "closing releases held lines")
+	assert.Equal(t, 1, r.batches)
+
+	m.add(lineAt("dropped", base))
+	m.close()
+	assert.Len(t, r.contents(), 2, "lines after close are dropped")
+}
@@ -0,0 +1,256 @@
+package logs
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+)
+
+// Line formats recognised by parseLine.
+const (
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+	FormatNginx  = "nginx"
+	FormatKlog   = "klog"
+)
+
+var (
+	// klogPattern matches the klog header: Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+	klogPattern = regexp.MustCompile(`^([IWEF])(\d{4}) (\d{2}:\d{2}:\d{2}\.\d+)\s+(\d+) ([^\]\s]+:\d+)\] ?(.*)$`)
+	// nginxAccessPattern matches the nginx "combined" access log format.
+	nginxAccessPattern = regexp.MustCompile(`^(\S+) - (\S+) \[([^\]]+)\] "(\S+) (\S+) ([^"]+)" (\d{3}) (\d+|-) "([^"]*)" "([^"]*)"`)
+	// nginxErrorPattern matches the nginx error log format.
+	nginxErrorPattern = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[(\w+)\] (\d+)#(\d+): (.*)$`)
+)
+
+// klogLevels maps klog severity letters to levels.
+var klogLevels = map[string]logs.LogLevel{
+	"I": logs.LogLevelInfo, "W": logs.LogLevelWarn, "E": logs.LogLevelError, "F": logs.LogLevelFatal,
+}
+
+// levelKeys are the fields a structured line's level is read from, in order.
+var levelKeys = []string{"level", "lvl", "severity", "loglevel", "log.level"}
+
+// parseLine detects the format of a log line and extracts its fields and
+// level. It returns an empty format for lines in no known format.
+func parseLine(content string) (format string, fields map[string]string, level logs.LogLevel) {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return "", nil, logs.LogLevelUnspecified
+	}
+
+	switch {
+	case trimmed[0] == '{':
+		if fields = parseJSONFields(trimmed); fields != nil {
+			return FormatJSON, fields, fieldLevel(fields)
+		}
+	case klogPattern.MatchString(trimmed):
+		fields, level = parseKlog(trimmed)
+		return FormatKlog, fields, level
+	case nginxAccessPattern.MatchString(trimmed):
+		fields, level = parseNginxAccess(trimmed)
+		return FormatNginx, fields, level
+	case nginxErrorPattern.MatchString(trimmed):
+		m := nginxErrorPattern.FindStringSubmatch(trimmed)
+		fields = map[string]string{"time": m[1], "level": m[2], "pid": m[3], "tid": m[4], "msg": m[5]}
+		return FormatNginx, fields, parseLevel(m[2])
+	}
+
+	if fields = parseLogfmt(trimmed); fields != nil {
+		return FormatLogfmt, fields, fieldLevel(fields)
+	}
+	return "", nil, logs.LogLevelUnspecified
+}
+
+// parseJSONFields flattens a JSON object into dotted keys. It returns nil if
+// the line is not a JSON object.
+func parseJSONFields(content string) map[string]string {
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil || obj == nil {
+		return nil
+	}
+	fields := make(map[string]string, len(obj))
+	flattenJSON(fields, "", obj)
+	return fields
+}
+
+func flattenJSON(fields map[string]string, prefix string, obj map[string]any) {
+	for key, value := range obj {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flattenJSON(fields, key, v)
+		case string:
+			fields[key] = v
+		case json.Number:
+			fields[key] = v.String()
+		case bool:
+			if v {
+				fields[key] = "true"
+			} else {
+				fields[key] = "false"
+			}
+		case nil:
+			fields[key] = ""
+		default:
+			data, _ := json.Marshal(v)
+			fields[key] = string(data)
+		}
+	}
+}
+
+// parseLogfmt parses a line of space separated key=value pairs. It returns
+// nil unless the line has at least two pairs and nothing else.
+func parseLogfmt(content string) map[string]string {
+	fields := scanLogfmt(content)
+	if len(fields) < 2 {
+		return nil
+	}
+	return fields
+}
+
+// scanLogfmt parses space separated key=value pairs, with values optionally
+// quoted. It returns nil if any token is not a pair.
+func scanLogfmt(content string) map[string]string {
+	fields := make(map[string]string)
+	for i := 0; i < len(content); {
+		if content[i] == ' ' || content[i] == '\t' {
+			i++
+			continue
+		}
+		eq := strings.IndexAny(content[i:], "= \t\"")
+		if eq <= 0 || content[i+eq] != '=' {
+			return nil
+		}
+		key := content[i : i+eq]
+		i += eq + 1
+
+		var value string
+		if i < len(content) && content[i] == '"' {
+			end, unquoted, ok := scanQuoted(content, i)
+			if !ok {
+				return nil
+			}
+			value, i = unquoted, end
+		} else {
+			end := strings.IndexAny(content[i:], " \t")
+			if end < 0 {
+				end = len(content) - i
+			}
+			value, i = content[i:i+end], i+end
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// scanQuoted reads the double-quoted string starting at content[start],
+// returning the index after its closing quote and its unescaped value.
+func scanQuoted(content string, start int) (int, string, bool) {
+	var b strings.Builder
+	for i := start + 1; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '\\':
+			if i+1 < len(content) {
+				i++
+				switch content[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(content[i])
+				}
+			}
+		case '"':
+			return i + 1, b.String(), true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return 0, "", false
+}
+
+func parseKlog(content string) (map[string]string, logs.LogLevel) {
+	m := klogPattern.FindStringSubmatch(content)
+	fields := map[string]string{
+		"severity": m[1],
+		"date":     m[2],
+		"time":     m[3],
+		"thread":   m[4],
+		"caller":   m[5],
+		"msg":      m[6],
+	}
+	// Structured klog messages carry their message quoted, followed by
+	// key="value" pairs.
+	if strings.HasPrefix(m[6], `"`) {
+		if end, msg, ok := scanQuoted(m[6], 0); ok {
+			fields["msg"] = msg
+			for key, value := range scanLogfmt(m[6][end:]) {
+				fields[key] = value
+			}
+		}
+	}
+	return fields, klogLevels[m[1]]
+}
+
+func parseNginxAccess(content string) (map[string]string, logs.LogLevel) {
+	m := nginxAccessPattern.FindStringSubmatch(content)
+	fields := map[string]string{
+		"remote_addr":     m[1],
+		"remote_user":     m[2],
+		"time_local":      m[3],
+		"method":          m[4],
+		"path":            m[5],
+		"protocol":        m[6],
+		"status":          m[7],
+		"body_bytes_sent": m[8],
+		"http_referer":    m[9],
+		"http_user_agent": m[10],
+	}
+	level := logs.LogLevelInfo
+	switch m[7][0] {
+	case '5':
+		level = logs.LogLevelError
+	case '4':
+		level = logs.LogLevelWarn
+	}
+	return fields, level
+}
+
+// fieldLevel reads the level of a structured line from its fields.
+func fieldLevel(fields map[string]string) logs.LogLevel {
+	for _, key := range levelKeys {
+		if value, ok := fields[key]; ok {
+			if level := parseLevel(value); level != logs.LogLevelUnspecified {
+				return level
+			}
+		}
+	}
+	return logs.LogLevelUnspecified
+}
+
+// parseLevel maps a level name, as written by common loggers, to a level.
+func parseLevel(name string) logs.LogLevel {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "trace", "trc":
+		return logs.LogLevelTrace
+	case "debug", "dbg", "d":
+		return logs.LogLevelDebug
+	case "info", "inf", "information", "notice", "i":
+		return logs.LogLevelInfo
+	case "warn", "warning", "wrn", "w":
+		return logs.LogLevelWarn
+	case "error", "err", "eror", "crit", "e":
+		return logs.LogLevelError
+	case "fatal", "critical", "panic", "emerg", "alert", "f":
+		return logs.LogLevelFatal
+	}
+	return logs.LogLevelUnspecified
+}
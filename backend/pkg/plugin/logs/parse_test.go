@@ -0,0 +1,103 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		format  string
+		level   logs.LogLevel
+		fields  map[string]string
+	}{
+		{
+			name:    "json",
+			content: `{"level":"warn","msg":"slow query","took":1.5,"db":{"name":"orders"},"ok":false,"tags":["a"]}`,
+			format:  FormatJSON,
+			level:   logs.LogLevelWarn,
+			fields: map[string]string{
+				"level": "warn", "msg": "slow query", "took": "1.5", "db.name": "orders", "ok": "false", "tags": `["a"]`,
+			},
+		},
+		{
+			name:    "json without level",
+			content: `{"message":"started"}`,
+			format:  FormatJSON,
+			fields:  map[string]string{"message": "started"},
+		},
+		{
+			name:    "logfmt",
+			content: `time=2024-01-01T00:00:00Z level=error msg="connection refused" retry=3`,
+			format:  FormatLogfmt,
+			level:   logs.LogLevelError,
+			fields: map[string]string{
+				"time": "2024-01-01T00:00:00Z", "level": "error", "msg": "connection refused", "retry": "3",
+			},
+		},
+		{
+			name:    "klog",
+			content: `E0102 15:04:05.123456    1234 controller.go:42] failed to sync`,
+			format:  FormatKlog,
+			level:   logs.LogLevelError,
+			fields: map[string]string{
+				"severity": "E", "date": "0102", "time": "15:04:05.123456", "thread": "1234",
+				"caller": "controller.go:42", "msg": "failed to sync",
+			},
+		},
+		{
+			name:    "structured klog",
+			content: `I0102 15:04:05.123456       1 main.go:7] "Pod started" pod="default/web" attempt=2`,
+			format:  FormatKlog,
+			level:   logs.LogLevelInfo,
+			fields: map[string]string{
+				"severity": "I", "date": "0102", "time": "15:04:05.123456", "thread": "1",
+				"caller": "main.go:7", "msg": "Pod started", "pod": "default/web", "attempt": "2",
+			},
+		},
+		{
+			name:    "nginx access",
+			content: `10.0.0.1 - - [10/Oct/2024:13:55:36 +0000] "GET /api/users HTTP/1.1" 503 612 "-" "curl/8.0"`,
+			format:  FormatNginx,
+			level:   logs.LogLevelError,
+			fields: map[string]string{
+				"remote_addr": "10.0.0.1", "remote_user": "-", "time_local": "10/Oct/2024:13:55:36 +0000",
+				"method": "GET", "path": "/api/users", "protocol": "HTTP/1.1", "status": "503",
+				"body_bytes_sent": "612", "http_referer": "-", "http_user_agent": "curl/8.0",
+			},
+		},
+		{
+			name:    "nginx error",
+			content: `2024/10/10 13:55:36 [warn] 7#7: *1 upstream response is buffered`,
+			format:  FormatNginx,
+			level:   logs.LogLevelWarn,
+			fields: map[string]string{
+				"time": "2024/10/10 13:55:36", "level": "warn", "pid": "7", "tid": "7",
+				"msg": "*1 upstream response is buffered",
+			},
+		},
+		{name: "plain text", content: "server listening on :8080"},
+		{name: "text with one pair", content: "retrying in=5s"},
+		{name: "broken json", content: `{"level":`},
+		{name: "empty", content: "   "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, fields, level := parseLine(tt.content)
+			assert.Equal(t, tt.format, format)
+			assert.Equal(t, tt.level, level)
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, logs.LogLevelWarn, parseLevel(" WARNING "))
+	assert.Equal(t, logs.LogLevelFatal, parseLevel("panic"))
+	assert.Equal(t, logs.LogLevelDebug, parseLevel("DBG"))
+	assert.Equal(t, logs.LogLevelUnspecified, parseLevel("loud"))
+}
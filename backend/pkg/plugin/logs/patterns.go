@@ -0,0 +1,277 @@
+package logs
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+// TemplateWildcard stands for a variable slot in a LogTemplate.
+const TemplateWildcard = "<*>"
+
+// Parameters of the template miner, after the defaults of Drain.
+const (
+	// drainPrefixTokens is the number of leading tokens lines are routed by
+	// before they are compared with templates.
+	drainPrefixTokens = 2
+	// drainSimilarity is the fraction of tokens a line must share with a
+	// template to join it.
+	drainSimilarity = 0.4
+	// drainMaxChildren bounds the branches of a routing node; further
+	// tokens share a wildcard branch.
+	drainMaxChildren = 100
+	// maxSessionTemplates bounds the templates kept per session. The least
+	// recently seen template is dropped to make room for a new one.
+	maxSessionTemplates = 1000
+)
+
+// LogTemplate is a pattern lines of a session follow, with TemplateWildcard
+// standing for the tokens that vary between them.
+type LogTemplate struct {
+	ID       int    `json:"id"`
+	Template string `json:"template"`
+	Count    int64  `json:"count"`
+	// Sample is the first line that created the template.
+	Sample    string    `json:"sample"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// PatternOptions selects the templates ListSessionPatterns returns.
+type PatternOptions struct {
+	// Limit caps the number of templates returned; zero returns them all.
+	Limit int `json:"limit,omitempty"`
+	// Since only returns templates first seen after it, to tell what is new
+	// in a session.
+	Since time.Time `json:"since,omitempty"`
+}
+
+// ListSessionPatterns returns the templates mined from a session's lines,
+// most frequent first. The session's pipeline must mine patterns.
+func (c *controller) ListSessionPatterns(sessionID string, opts PatternOptions) ([]LogTemplate, error) {
+	if !c.hasSession(sessionID) {
+		return nil, apperror.SessionNotFound(sessionID)
+	}
+	c.pipelineMu.RLock()
+	p := c.pipelines[sessionID]
+	c.pipelineMu.RUnlock()
+	if p == nil || p.miner == nil {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Patterns are not mined",
+			"Enable patterns in the session's pipeline to group its lines into templates.")
+	}
+	return p.miner.templates(opts), nil
+}
+
+// templateMiner groups lines into templates online with Drain (He et al.,
+// "Drain: An Online Log Parsing Approach with Fixed Depth Tree"). Lines are
+// routed by their token count and first tokens to a short list of
+// templates, and join the most similar one, whose differing tokens become
+// wildcards, or start a new one.
+type templateMiner struct {
+	mu       sync.Mutex
+	root     map[int]*drainNode // by token count
+	clusters map[int]*templateCluster
+	nextID   int
+	seq      uint64 // orders clusters by when they were last seen
+}
+
+type drainNode struct {
+	children map[string]*drainNode
+	clusters []*templateCluster // set on leaves
+}
+
+type templateCluster struct {
+	LogTemplate
+	tokens   []string
+	leaf     *drainNode
+	lastSeen uint64
+}
+
+func newTemplateMiner() *templateMiner {
+	return &templateMiner{
+		root:     make(map[int]*drainNode),
+		clusters: make(map[int]*templateCluster),
+	}
+}
+
+// add mines a line and returns the ID of its template.
+func (m *templateMiner) add(content string, at time.Time) int {
+	tokens := tokenize(content)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	leaf := m.leaf(tokens)
+	if cluster := bestCluster(leaf.clusters, tokens); cluster != nil {
+		for i, token := range cluster.tokens {
+			if token != tokens[i] {
+				cluster.tokens[i] = TemplateWildcard
+			}
+		}
+		cluster.Template = strings.Join(cluster.tokens, " ")
+		cluster.Count++
+		cluster.LastSeen = at
+		cluster.lastSeen = m.seq
+		return cluster.ID
+	}
+
+	if len(m.clusters) >= maxSessionTemplates {
+		m.evict()
+	}
+	m.nextID++
+	cluster := &templateCluster{
+		LogTemplate: LogTemplate{
+			ID:        m.nextID,
+			Template:  strings.Join(tokens, " "),
+			Count:     1,
+			Sample:    content,
+			FirstSeen: at,
+			LastSeen:  at,
+		},
+		tokens:   tokens,
+		leaf:     leaf,
+		lastSeen: m.seq,
+	}
+	leaf.clusters = append(leaf.clusters, cluster)
+	m.clusters[cluster.ID] = cluster
+	return cluster.ID
+}
+
+// leaf routes tokens to the leaf holding the templates they may join,
+// creating the path as needed. Caller must hold m.mu.
+func (m *templateMiner) leaf(tokens []string) *drainNode {
+	node, ok := m.root[len(tokens)]
+	if !ok {
+		node = &drainNode{children: make(map[string]*drainNode)}
+		m.root[len(tokens)] = node
+	}
+	for _, token := range tokens[:min(drainPrefixTokens, len(tokens))] {
+		if hasDigit(token) {
+			token = TemplateWildcard
+		}
+		child, ok := node.children[token]
+		if !ok {
+			if len(node.children) >= drainMaxChildren {
+				token = TemplateWildcard
+				child = node.children[token]
+			}
+			if child == nil {
+				child = &drainNode{children: make(map[string]*drainNode)}
+				node.children[token] = child
+			}
+		}
+		node = child
+	}
+	return node
+}
+
+// evict drops the least recently seen template. Caller must hold m.mu.
+func (m *templateMiner) evict() {
+	var oldest *templateCluster
+	for _, cluster := range m.clusters {
+		if oldest == nil || cluster.lastSeen < oldest.lastSeen {
+			oldest = cluster
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	oldest.leaf.clusters = slices.DeleteFunc(oldest.leaf.clusters, func(c *templateCluster) bool { return c == oldest })
+	delete(m.clusters, oldest.ID)
+}
+
+// templates returns the templates selected by opts, most frequent first.
+func (m *templateMiner) templates(opts PatternOptions) []LogTemplate {
+	m.mu.Lock()
+	templates := make([]LogTemplate, 0, len(m.clusters))
+	for _, cluster := range m.clusters {
+		if opts.Since.IsZero() || cluster.FirstSeen.After(opts.Since) {
+			templates = append(templates, cluster.LogTemplate)
+		}
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(templates, func(a, b LogTemplate) int {
+		if n := cmp.Compare(b.Count, a.Count); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if opts.Limit > 0 && len(templates) > opts.Limit {
+		templates = templates[:opts.Limit]
+	}
+	return templates
+}
+
+// bestCluster returns the template most similar to tokens, if it is similar
+// enough. Ties go to the template with more wildcards, which has already
+// absorbed more variation.
+func bestCluster(clusters []*templateCluster, tokens []string) *templateCluster {
+	var best *templateCluster
+	bestSim, bestParams := -1.0, -1
+	for _, cluster := range clusters {
+		sim, params := similarity(cluster.tokens, tokens)
+		if sim > bestSim || (sim == bestSim && params > bestParams) {
+			best, bestSim, bestParams = cluster, sim, params
+		}
+	}
+	if best == nil || bestSim < drainSimilarity {
+		return nil
+	}
+	return best
+}
+
+// similarity returns the fraction of a template's tokens a line shares,
+// not counting wildcards, and the number of wildcards.
+func similarity(template, tokens []string) (float64, int) {
+	if len(template) == 0 {
+		return 1, 0
+	}
+	same, params := 0, 0
+	for i, token := range template {
+		switch token {
+		case TemplateWildcard:
+			params++
+		case tokens[i]:
+			same++
+		}
+	}
+	return float64(same) / float64(len(template)), params
+}
+
+// tokenize splits a line on whitespace, replacing tokens that are plainly
+// values, like numbers, IDs and addresses, with a wildcard.
+func tokenize(content string) []string {
+	tokens := strings.Fields(content)
+	for i, token := range tokens {
+		if isValueToken(token) {
+			tokens[i] = TemplateWildcard
+		}
+	}
+	return tokens
+}
+
+// isValueToken reports whether a token is made only of hex digits and
+// separators and has a decimal digit: 42, 10.0.0.1, 0x1f, a UUID.
+func isValueToken(token string) bool {
+	if !hasDigit(token) {
+		return false
+	}
+	for _, r := range strings.TrimPrefix(token, "0x") {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+		case strings.ContainsRune(".:-_/,", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func hasDigit(s string) bool {
+	return strings.ContainsAny(s, "0123456789")
+}
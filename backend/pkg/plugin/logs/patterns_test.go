@@ -0,0 +1,161 @@
+package logs
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+func TestTemplateMiner_GroupsSimilarLines(t *testing.T) {
+	m := newTemplateMiner()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	login := m.add("user 42 logged in from 10.0.0.1", start)
+	assert.Equal(t, login, m.add("user 7 logged in from 10.0.0.2", start.Add(time.Second)))
+	reset := m.add("connection reset by peer", start.Add(2*time.Second))
+	assert.NotEqual(t, login, reset)
+	failed := m.add("failed to connect to db-primary: timeout", start.Add(3*time.Second))
+	assert.Equal(t, failed, m.add("failed to connect to cache: timeout", start.Add(4*time.Second)))
+	assert.Equal(t, login, m.add("user 9 logged in from unknown", start.Add(5*time.Second)))
+
+	templates := m.templates(PatternOptions{})
+	require.Len(t, templates, 3)
+	assert.Equal(t, LogTemplate{
+		ID:        login,
+		Template:  "user <*> logged in from <*>",
+		Count:     3,
+		Sample:    "user 42 logged in from 10.0.0.1",
+		FirstSeen: start,
+		LastSeen:  start.Add(5 * time.Second),
+	}, templates[0])
+	assert.Equal(t, "failed to connect to <*> timeout", templates[1].Template)
+	assert.Equal(t, int64(2), templates[1].Count)
+	assert.Equal(t, "connection reset by peer", templates[2].Template)
+
+	assert.Len(t, m.templates(PatternOptions{Limit: 2}), 2)
+	recent := m.templates(PatternOptions{Since: start.Add(time.Second)})
+	require.Len(t, recent, 2, "only templates first seen after Since")
+	assert.Equal(t, failed, recent[0].ID)
+}
+
+func TestTemplateMiner_ValueTokens(t *testing.T) {
+	for token, value := range map[string]bool{
+		"42":                                   true,
+		"10.0.0.1:8080":                        true,
+		"0x1f":                                 true,
+		"550e8400-e29b-41d4-a716-446655440000": true,
+		"2024-01-01":                           true,
+		"12ms":                                 false,
+		"v2":                                   false,
+		"deadbeef":                             false,
+		"error":                                false,
+	} {
+		assert.Equal(t, value, isValueToken(token), token)
+	}
+	assert.Equal(t, []string{"GET", "/api", "<*>", "took", "12ms"}, tokenize("GET /api 200 took 12ms"))
+}
+
+func TestTemplateMiner_EvictsLeastRecentlySeen(t *testing.T) {
+	m := newTemplateMiner()
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	word := func(i int) string {
+		var b strings.Builder
+		for range 3 {
+			b.WriteByte(byte('a' + i%26))
+			i /= 26
+		}
+		return b.String()
+	}
+
+	for i := range maxSessionTemplates {
+		m.add(word(i), at)
+	}
+	m.add(word(0), at) // template 1 is seen again, template 2 is now the oldest
+	m.add(word(maxSessionTemplates), at)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Len(t, m.clusters, maxSessionTemplates)
+	assert.Contains(t, m.clusters, 1)
+	assert.NotContains(t, m.clusters, 2)
+	assert.Contains(t, m.clusters, maxSessionTemplates+1)
+}
+
+func TestSessionPatterns_MinesAndFilters(t *testing.T) {
+	c, _ := newAggregateController(t)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	state, err := c.SetSessionPipeline(session.ID, LogPipeline{Patterns: true})
+	require.NoError(t, err)
+	assert.True(t, state.Pipeline.Patterns)
+	for _, content := range []string{
+		"GET /healthz 200 1ms",
+		"GET /healthz 200 2ms",
+		"GET /healthz 200 1ms",
+		"payment 981 declined",
+	} {
+		c.bufferLine(session.ID, logs.LogLine{Content: content})
+	}
+
+	lines := batched(c, session.ID)
+	require.Len(t, lines, 4)
+	health, payment := lines[0].TemplateID, lines[3].TemplateID
+	assert.NotZero(t, health)
+	assert.Equal(t, health, lines[1].TemplateID)
+	assert.NotEqual(t, health, payment)
+	assert.Empty(t, lines[0].Fields, "fields are only emitted when parsing")
+
+	patterns, err := c.ListSessionPatterns(session.ID, PatternOptions{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, patterns, 1)
+	assert.Equal(t, health, patterns[0].ID)
+	assert.Equal(t, int64(3), patterns[0].Count)
+
+	// Hiding a template keeps the templates mined so far.
+	c.flushSession(session.ID)
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{HideTemplates: []int{health}})
+	require.NoError(t, err)
+	c.bufferLine(session.ID, logs.LogLine{Content: "GET /healthz 200 3ms"})
+	c.bufferLine(session.ID, logs.LogLine{Content: "payment 982 declined"})
+	lines = batched(c, session.ID)
+	require.Len(t, lines, 1)
+	assert.Equal(t, payment, lines[0].TemplateID)
+
+	c.flushSession(session.ID)
+	state, err = c.SetSessionPipeline(session.ID, LogPipeline{Templates: []int{health}})
+	require.NoError(t, err)
+	assert.True(t, state.Pipeline.Patterns, "filtering by template mines patterns")
+	c.bufferLine(session.ID, logs.LogLine{Content: "GET /healthz 200 4ms"})
+	c.bufferLine(session.ID, logs.LogLine{Content: "payment 983 declined"})
+	lines = batched(c, session.ID)
+	require.Len(t, lines, 1)
+	assert.Equal(t, health, lines[0].TemplateID)
+
+	patterns, err = c.ListSessionPatterns(session.ID, PatternOptions{})
+	require.NoError(t, err)
+	require.Len(t, patterns, 2)
+	assert.Equal(t, int64(5), patterns[0].Count)
+}
+
+func TestSessionPatterns_Errors(t *testing.T) {
+	var target *apperror.AppError
+	c, _ := newAggregateController(t)
+
+	_, err := c.ListSessionPatterns("missing", PatternOptions{})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+	_, err = c.ListSessionPatterns(session.ID, PatternOptions{})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type, "patterns are mined on request")
+}
@@ -0,0 +1,218 @@
+package logs
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+// LogEntry is a log line as it is emitted on "core/logs/lines/<sessionID>":
+// the plugin's line, plus the format and fields parsed from it when the
+// session's pipeline parses lines.
+type LogEntry struct {
+	logs.LogLine
+	Format string            `json:"format,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+	// TemplateID is the line's template when the pipeline mines patterns.
+	TemplateID int `json:"templateId,omitempty"`
+}
+
+// field looks a query field up in the entry.
+func (e *LogEntry) field(name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "content":
+		return e.Content, true
+	case "message", "msg":
+		for _, key := range []string{"msg", "message"} {
+			if value, ok := e.Fields[key]; ok {
+				return value, true
+			}
+		}
+		return e.Content, true
+	case "source":
+		return e.SourceID, true
+	case "format":
+		return e.Format, e.Format != ""
+	}
+	if value, ok := e.Fields[name]; ok {
+		return value, true
+	}
+	value, ok := e.Labels[name]
+	return value, ok
+}
+
+// LogPipeline configures how a session's lines are processed on the backend
+// before they are batched and emitted.
+type LogPipeline struct {
+	// Parse emits the format and fields parsed from each line, and sets the
+	// level of lines the plugin did not give one.
+	Parse bool `json:"parse"`
+	// Query drops the lines it does not match. See Query for the syntax.
+	Query string `json:"query"`
+	// Patterns groups the session's lines into templates, listed by
+	// ListSessionPatterns, and emits each line with its template ID.
+	Patterns bool `json:"patterns"`
+	// Templates keeps only the lines of these templates, and HideTemplates
+	// drops the lines of these, collapsing repetitive lines. Setting either
+	// mines patterns.
+	Templates     []int `json:"templates,omitempty"`
+	HideTemplates []int `json:"hideTemplates,omitempty"`
+}
+
+// LogPipelineStats counts the lines a pipeline has processed.
+type LogPipelineStats struct {
+	Lines   int64 `json:"lines"`
+	Emitted int64 `json:"emitted"`
+	Dropped int64 `json:"dropped"`
+}
+
+// LogPipelineState is a session's pipeline and what it has done since it was
+// set.
+type LogPipelineState struct {
+	Pipeline LogPipeline      `json:"pipeline"`
+	Stats    LogPipelineStats `json:"stats"`
+}
+
+type pipeline struct {
+	config LogPipeline
+	query  *Query
+	miner  *templateMiner // nil unless the pipeline mines patterns
+
+	lines   atomic.Int64
+	dropped atomic.Int64
+}
+
+// process parses and filters a line, reporting whether it is kept.
+func (p *pipeline) process(line logs.LogLine) (LogEntry, bool) {
+	p.lines.Add(1)
+	entry := parseEntry(line)
+	if p.miner != nil {
+		at := line.Timestamp
+		if at.IsZero() {
+			at = time.Now()
+		}
+		entry.TemplateID = p.miner.add(line.Content, at)
+	}
+
+	if (p.query != nil && !p.query.Match(&entry)) || !p.showsTemplate(entry.TemplateID) {
+		p.dropped.Add(1)
+		return LogEntry{}, false
+	}
+	if !p.config.Parse {
+		return LogEntry{LogLine: line, TemplateID: entry.TemplateID}, true
+	}
+	return entry, true
+}
+
+// showsTemplate reports whether the template filters keep a template's lines.
+func (p *pipeline) showsTemplate(id int) bool {
+	if len(p.config.Templates) > 0 && !slices.Contains(p.config.Templates, id) {
+		return false
+	}
+	return !slices.Contains(p.config.HideTemplates, id)
+}
+
+// parseEntry parses a line's content into an entry, taking the level from
+// the content when the plugin did not give one.
+func parseEntry(line logs.LogLine) LogEntry {
+	entry := LogEntry{LogLine: line}
+	format, fields, level := parseLine(line.Content)
+	entry.Format, entry.Fields = format, fields
+	if entry.Level == logs.LogLevelUnspecified {
+		entry.Level = level
+	}
+	return entry
+}
+
+func (p *pipeline) state() *LogPipelineState {
+	lines, dropped := p.lines.Load(), p.dropped.Load()
+	return &LogPipelineState{
+		Pipeline: p.config,
+		Stats:    LogPipelineStats{Lines: lines, Emitted: lines - dropped, Dropped: dropped},
+	}
+}
+
+// SetSessionPipeline sets how a session's lines are processed before they
+// are emitted, resetting its stats. A pipeline that neither parses, filters
+// nor mines patterns removes it, so lines are emitted as the plugin sent
+// them. A session that keeps mining patterns keeps its templates.
+func (c *controller) SetSessionPipeline(sessionID string, config LogPipeline) (*LogPipelineState, error) {
+	if !c.hasSession(sessionID) {
+		return nil, apperror.SessionNotFound(sessionID)
+	}
+
+	if len(config.Templates) > 0 || len(config.HideTemplates) > 0 {
+		config.Patterns = true
+	}
+	p := &pipeline{config: config}
+	if strings.TrimSpace(config.Query) != "" {
+		query, err := ParseQuery(config.Query)
+		if err != nil {
+			return nil, apperror.New(apperror.TypeValidation, 400, "Invalid log query", err.Error())
+		}
+		p.query = query
+	}
+
+	c.pipelineMu.Lock()
+	defer c.pipelineMu.Unlock()
+	if config.Patterns {
+		if prev, ok := c.pipelines[sessionID]; ok && prev.miner != nil {
+			p.miner = prev.miner
+		} else {
+			p.miner = newTemplateMiner()
+		}
+	}
+	if !config.Parse && p.query == nil && p.miner == nil {
+		delete(c.pipelines, sessionID)
+	} else {
+		c.pipelines[sessionID] = p
+	}
+	return p.state(), nil
+}
+
+// GetSessionPipeline returns a session's pipeline and its stats.
+func (c *controller) GetSessionPipeline(sessionID string) (*LogPipelineState, error) {
+	if !c.hasSession(sessionID) {
+		return nil, apperror.SessionNotFound(sessionID)
+	}
+	c.pipelineMu.RLock()
+	defer c.pipelineMu.RUnlock()
+	if p, ok := c.pipelines[sessionID]; ok {
+		return p.state(), nil
+	}
+	return &LogPipelineState{}, nil
+}
+
+// processLine runs a line through its session's pipeline, if it has one.
+func (c *controller) processLine(sessionID string, line logs.LogLine) (LogEntry, bool) {
+	c.pipelineMu.RLock()
+	p, ok := c.pipelines[sessionID]
+	c.pipelineMu.RUnlock()
+	if !ok {
+		return LogEntry{LogLine: line}, true
+	}
+	return p.process(line)
+}
+
+func (c *controller) dropPipeline(sessionID string) {
+	c.pipelineMu.Lock()
+	delete(c.pipelines, sessionID)
+	c.pipelineMu.Unlock()
+}
+
+// hasSession reports whether sessionID is a plugin or aggregate session, or
+// a reopened capture.
+func (c *controller) hasSession(sessionID string) bool {
+	c.mu.RLock()
+	_, ok := c.sessionIndex[sessionID]
+	if !ok {
+		_, ok = c.aggregates[sessionID]
+	}
+	c.mu.RUnlock()
+	return ok || c.isCapturedSession(sessionID)
+}
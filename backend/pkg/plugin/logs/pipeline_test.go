@@ -0,0 +1,117 @@
+package logs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+// batched returns the lines batched for a session and not yet flushed.
+func batched(c *controller, sessionID string) []LogEntry {
+	c.batchMux.Lock()
+	defer c.batchMux.Unlock()
+	if batch, ok := c.batches[sessionID]; ok {
+		return append([]LogEntry(nil), batch.lines...)
+	}
+	return nil
+}
+
+func TestSessionPipeline_FiltersAndParses(t *testing.T) {
+	c, _ := newAggregateController(t)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	state, err := c.SetSessionPipeline(session.ID, LogPipeline{Parse: true, Query: "level>=warn"})
+	require.NoError(t, err)
+	assert.True(t, state.Pipeline.Parse)
+
+	for _, content := range []string{
+		`level=info msg="request served"`,
+		`level=error msg="request failed" status=500`,
+		`plain text without a level`,
+	} {
+		c.bufferLine(session.ID, logs.LogLine{SessionID: session.ID, Content: content})
+	}
+
+	lines := batched(c, session.ID)
+	require.Len(t, lines, 1)
+	assert.Equal(t, logs.LogLevelError, lines[0].Level)
+	assert.Equal(t, FormatLogfmt, lines[0].Format)
+	assert.Equal(t, "500", lines[0].Fields["status"])
+
+	state, err = c.GetSessionPipeline(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, LogPipelineStats{Lines: 3, Emitted: 1, Dropped: 2}, state.Stats)
+}
+
+func TestSessionPipeline_FilterWithoutParse(t *testing.T) {
+	c, _ := newAggregateController(t)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{Query: "status>=500"})
+	require.NoError(t, err)
+	c.bufferLine(session.ID, logs.LogLine{Content: `{"status":200}`})
+	c.bufferLine(session.ID, logs.LogLine{Content: `{"status":502}`})
+
+	lines := batched(c, session.ID)
+	require.Len(t, lines, 1)
+	assert.Equal(t, `{"status":502}`, lines[0].Content)
+	assert.Empty(t, lines[0].Fields, "fields are only emitted when parsing")
+	assert.Equal(t, logs.LogLevelUnspecified, lines[0].Level)
+}
+
+func TestSessionPipeline_Remove(t *testing.T) {
+	c, _ := newAggregateController(t)
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{Query: "nothing-matches-this"})
+	require.NoError(t, err)
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{})
+	require.NoError(t, err)
+	c.bufferLine(session.ID, logs.LogLine{Content: "kept"})
+	assert.Len(t, batched(c, session.ID), 1, "an empty pipeline emits lines verbatim")
+
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{Parse: true})
+	require.NoError(t, err)
+	require.NoError(t, c.CloseSession(session.ID))
+	c.pipelineMu.RLock()
+	assert.Empty(t, c.pipelines, "closing a session drops its pipeline")
+	c.pipelineMu.RUnlock()
+}
+
+func TestSessionPipeline_AggregateSession(t *testing.T) {
+	c, _ := newAggregateController(t)
+	session, err := c.CreateAggregateSession(aggregateSources())
+	require.NoError(t, err)
+
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{Query: "/refused/"})
+	require.NoError(t, err)
+	c.bufferLine(session.ID, logs.LogLine{Content: "connection refused"})
+	c.bufferLine(session.ID, logs.LogLine{Content: "connected"})
+	assert.Len(t, batched(c, session.ID), 1)
+}
+
+func TestSessionPipeline_Errors(t *testing.T) {
+	c, _ := newAggregateController(t)
+	var target *apperror.AppError
+
+	_, err := c.SetSessionPipeline("missing", LogPipeline{Parse: true})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+	_, err = c.GetSessionPipeline("missing")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeSessionNotFound, target.Type)
+
+	session, err := c.CreateSession("kubernetes", "prod", logs.CreateSessionOptions{})
+	require.NoError(t, err)
+	_, err = c.SetSessionPipeline(session.ID, LogPipeline{Query: "status>=(500"})
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type)
+}
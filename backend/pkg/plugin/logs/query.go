@@ -0,0 +1,389 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+)
+
+// Query is a compiled log query. A query is a sequence of terms, joined with
+// "and" (the default when terms are only separated by spaces) or "or",
+// negated with "not", and grouped with parentheses. A term is one of:
+//
+//	error                 the line contains the text, ignoring case
+//	"connection refused"  the same, for text with spaces or operators
+//	/timeout \d+ms/       the line matches the regular expression
+//	field=value           a field compared with =, !=, <, <=, > or >=
+//	field~regexp          a field matched (~) or not matched (!~)
+//
+// Fields are the fields parsed from the line, then its labels. "level"
+// compares by severity, so level>=warn keeps warnings and worse; "message"
+// is the parsed message, or the whole line; "content" is the whole line
+// and "source" the ID of the source the line came from. Values compare as
+// numbers when both sides are numbers.
+type Query struct {
+	expr string
+	root queryNode
+}
+
+// queryNode is a node of a compiled query.
+type queryNode interface {
+	match(e *LogEntry) bool
+}
+
+type (
+	andNode  []queryNode
+	orNode   []queryNode
+	notNode  struct{ node queryNode }
+	textNode struct{ lower string }
+	reNode   struct{ re *regexp.Regexp }
+	cmpNode  struct {
+		field string
+		op    string
+		value string
+		re    *regexp.Regexp
+		level logs.LogLevel
+	}
+)
+
+// ParseQuery compiles a query expression.
+func ParseQuery(expr string) (*Query, error) {
+	p := &queryParser{src: expr}
+	p.skipSpace()
+	if p.done() {
+		return nil, fmt.Errorf("query is empty")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	return &Query{expr: expr, root: root}, nil
+}
+
+// String returns the query's expression.
+func (q *Query) String() string { return q.expr }
+
+// Match reports whether an entry matches the query.
+func (q *Query) Match(e *LogEntry) bool { return q.root.match(e) }
+
+func (n andNode) match(e *LogEntry) bool {
+	for _, node := range n {
+		if !node.match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) match(e *LogEntry) bool {
+	for _, node := range n {
+		if node.match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n notNode) match(e *LogEntry) bool { return !n.node.match(e) }
+
+func (n textNode) match(e *LogEntry) bool {
+	return strings.Contains(strings.ToLower(e.Content), n.lower)
+}
+
+func (n reNode) match(e *LogEntry) bool { return n.re.MatchString(e.Content) }
+
+func (n cmpNode) match(e *LogEntry) bool {
+	if n.field == "level" && n.re == nil {
+		if e.Level == logs.LogLevelUnspecified {
+			return n.op == "!="
+		}
+		return compareOrdered(n.op, int(e.Level), int(n.level))
+	}
+
+	value, ok := e.field(n.field)
+	switch n.op {
+	case "~":
+		return ok && n.re.MatchString(value)
+	case "!~":
+		return !ok || !n.re.MatchString(value)
+	case "!=":
+		return !ok || !valuesEqual(value, n.value)
+	case "=":
+		return ok && valuesEqual(value, n.value)
+	}
+	if !ok {
+		return false
+	}
+	a, aErr := strconv.ParseFloat(value, 64)
+	b, bErr := strconv.ParseFloat(n.value, 64)
+	if aErr == nil && bErr == nil {
+		return compareOrdered(n.op, a, b)
+	}
+	return compareOrdered(n.op, value, n.value)
+}
+
+func valuesEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, xErr := strconv.ParseFloat(a, 64)
+	y, yErr := strconv.ParseFloat(b, 64)
+	return xErr == nil && yErr == nil && x == y
+}
+
+func compareOrdered[T int | float64 | string](op string, a, b T) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// queryParser is a recursive descent parser over a query expression.
+type queryParser struct {
+	src string
+	pos int
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("query position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *queryParser) done() bool { return p.pos >= len(p.src) }
+
+func (p *queryParser) skipSpace() {
+	for !p.done() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+// keyword consumes one of words if it is next, as a whole word.
+func (p *queryParser) keyword(words ...string) bool {
+	for _, word := range words {
+		end := p.pos + len(word)
+		if end > len(p.src) || !strings.EqualFold(p.src[p.pos:end], word) {
+			continue
+		}
+		symbol := !isWordByte(word[0])
+		if symbol || end == len(p.src) || !isWordByte(p.src[end]) {
+			p.pos = end
+			p.skipSpace()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	var nodes orNode
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if !p.keyword("or", "||") {
+			break
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	var nodes andNode
+	for {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if p.keyword("and", "&&") {
+			continue
+		}
+		if p.done() || p.src[p.pos] == ')' || p.peekOr() {
+			break
+		}
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *queryParser) peekOr() bool {
+	pos := p.pos
+	ok := p.keyword("or", "||")
+	p.pos = pos
+	return ok
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.done() {
+		return nil, p.errorf("expected a term")
+	}
+	if p.keyword("not") || (p.src[p.pos] == '!' && !strings.HasPrefix(p.src[p.pos:], "!=") && p.keyword("!")) {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node: node}, nil
+	}
+	if p.src[p.pos] == '(' {
+		p.pos++
+		p.skipSpace()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.src[p.pos] != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		p.skipSpace()
+		return node, nil
+	}
+	return p.parseTerm()
+}
+
+func (p *queryParser) parseTerm() (queryNode, error) {
+	switch p.src[p.pos] {
+	case '/':
+		pattern, err := p.readRegexp()
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.errorf("invalid regular expression: %v", err)
+		}
+		p.skipSpace()
+		return reNode{re: re}, nil
+	case '"':
+		text, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		return textNode{lower: strings.ToLower(text)}, nil
+	}
+
+	start := p.pos
+	for !p.done() && isWordByte(p.src[p.pos]) {
+		p.pos++
+	}
+	word := p.src[start:p.pos]
+	if word == "" {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	op := p.readOperator()
+	if op == "" {
+		p.skipSpace()
+		return textNode{lower: strings.ToLower(word)}, nil
+	}
+	return p.parseComparison(word, op)
+}
+
+func (p *queryParser) parseComparison(field, op string) (queryNode, error) {
+	var value string
+	var err error
+	switch {
+	case p.done():
+		return nil, p.errorf("expected a value after %s%s", field, op)
+	case p.src[p.pos] == '"':
+		value, err = p.readQuoted()
+	case p.src[p.pos] == '/' && (op == "~" || op == "!~"):
+		value, err = p.readRegexp()
+	default:
+		start := p.pos
+		for !p.done() && !strings.ContainsRune(" \t\n()", rune(p.src[p.pos])) {
+			p.pos++
+		}
+		value = p.src[start:p.pos]
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+
+	node := cmpNode{field: field, op: op, value: value}
+	if strings.EqualFold(field, "level") {
+		node.field = "level"
+	}
+	switch {
+	case op == "~" || op == "!~":
+		if node.re, err = regexp.Compile(value); err != nil {
+			return nil, p.errorf("invalid regular expression: %v", err)
+		}
+	case node.field == "level":
+		node.level = parseLevel(value)
+		if n, numErr := strconv.Atoi(value); numErr == nil {
+			node.level = logs.LogLevel(n)
+		}
+		if node.level == logs.LogLevelUnspecified {
+			return nil, p.errorf("unknown level %q", value)
+		}
+	}
+	return node, nil
+}
+
+// readOperator consumes a comparison operator directly after a field name.
+func (p *queryParser) readOperator() string {
+	for _, op := range []string{"!=", "!~", ">=", "<=", "=", "~", ">", "<"} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *queryParser) readQuoted() (string, error) {
+	end, value, ok := scanQuoted(p.src, p.pos)
+	if !ok {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos = end
+	return value, nil
+}
+
+// readRegexp consumes a /.../ literal, in which \/ is a literal slash.
+func (p *queryParser) readRegexp() (string, error) {
+	var b strings.Builder
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch {
+		case p.src[i] == '\\' && i+1 < len(p.src) && p.src[i+1] == '/':
+			b.WriteByte('/')
+			i++
+		case p.src[i] == '/':
+			p.pos = i + 1
+			return b.String(), nil
+		default:
+			b.WriteByte(p.src[i])
+		}
+	}
+	return "", p.errorf("unterminated regular expression")
+}
+
+// isWordByte reports whether c may appear in a field name or bare word.
+func isWordByte(c byte) bool {
+	return !strings.ContainsRune(" \t\n()\"=!<>~|&", rune(c))
+}
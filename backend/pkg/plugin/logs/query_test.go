@@ -0,0 +1,106 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery_Match(t *testing.T) {
+	entry := &LogEntry{
+		LogLine: logs.LogLine{
+			SourceID: "web-0",
+			Content:  `{"level":"error","msg":"GET /api/users failed","status":503,"took":"12.5"}`,
+			Level:    logs.LogLevelError,
+			Labels:   map[string]string{"container": "nginx"},
+		},
+		Format: FormatJSON,
+		Fields: map[string]string{"level": "error", "msg": "GET /api/users failed", "status": "503", "took": "12.5"},
+	}
+
+	tests := []struct {
+		query string
+		match bool
+	}{
+		{query: "failed", match: true},
+		{query: "FAILED", match: true},
+		{query: "timeout", match: false},
+		{query: `"users failed"`, match: true},
+		{query: `/GET \/api\/\w+/`, match: true},
+		{query: "status=503", match: true},
+		{query: "status=503.0", match: true},
+		{query: "status!=503", match: false},
+		{query: "status>=500", match: true},
+		{query: "status<500", match: false},
+		{query: "took>9", match: true}, // numeric, not lexical
+		{query: "missing=1", match: false},
+		{query: "missing!=1", match: true},
+		{query: `message~"^GET /api"`, match: true},
+		{query: "message~/^GET \\/api/", match: true},
+		{query: "msg!~users", match: false},
+		{query: "container=nginx", match: true},
+		{query: "source=web-0", match: true},
+		{query: "format=json", match: true},
+		{query: "level>=warn", match: true},
+		{query: "LEVEL>=fatal", match: false},
+		{query: "level=error", match: true},
+		{query: "level=5", match: true},
+		{query: "level~err", match: true},
+		{query: "failed status=503", match: true},
+		{query: "failed and status=404", match: false},
+		{query: "status=404 or level>=error", match: true},
+		{query: "status=404 || status=500 || status=503", match: true},
+		{query: "not timeout", match: true},
+		{query: "!failed", match: false},
+		{query: "!(status=404 or status=500)", match: true},
+		{query: "(status=404 or failed) and level>=error", match: true},
+		{query: "status=404 or failed and timeout", match: false},
+		{query: "android", match: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseQuery(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, q.Match(entry))
+		})
+	}
+}
+
+func TestQuery_UnspecifiedLevel(t *testing.T) {
+	entry := &LogEntry{LogLine: logs.LogLine{Content: "plain"}}
+	for query, match := range map[string]bool{"level>=trace": false, "level!=error": true} {
+		q, err := ParseQuery(query)
+		require.NoError(t, err)
+		assert.Equal(t, match, q.Match(entry), query)
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	tests := []struct {
+		query  string
+		errMsg string
+	}{
+		{query: "", errMsg: "empty"},
+		{query: "  ", errMsg: "empty"},
+		{query: "status=", errMsg: "expected a value"},
+		{query: "(status=500", errMsg: "expected )"},
+		{query: "status=500)", errMsg: "unexpected"},
+		{query: `"open`, errMsg: "unterminated string"},
+		{query: "/open", errMsg: "unterminated regular expression"},
+		{query: "/a(/", errMsg: "invalid regular expression"},
+		{query: `msg~"a("`, errMsg: "invalid regular expression"},
+		{query: "level>=loud", errMsg: "unknown level"},
+		{query: "failed and", errMsg: "expected a term"},
+		{query: "failed or", errMsg: "expected a term"},
+		{query: "=500", errMsg: "unexpected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	logssdk "github.com/omniviewdev/plugin-sdk/pkg/v1/logs"
 	"github.com/wailsapp/wails/v3/pkg/application"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/logs/capture"
 )
 
 // ServiceWrapper is an explicit delegation wrapper around logs.Controller.
@@ -54,6 +56,60 @@ func (s *ServiceWrapper) SendCommand(sessionID string, cmd logssdk.LogStreamComm
 func (s *ServiceWrapper) UpdateSessionOptions(sessionID string, opts logssdk.LogSessionOptions) (*logssdk.LogSession, error) {
 	return s.Ctrl.UpdateSessionOptions(sessionID, opts)
 }
+func (s *ServiceWrapper) CreateAggregateSession(sources []AggregateSource) (*AggregateSession, error) {
+	return s.Ctrl.CreateAggregateSession(sources)
+}
+func (s *ServiceWrapper) GetAggregateSession(id string) (*AggregateSession, error) {
+	return s.Ctrl.GetAggregateSession(id)
+}
+func (s *ServiceWrapper) ListAggregateSessions() ([]*AggregateSession, error) {
+	return s.Ctrl.ListAggregateSessions()
+}
+func (s *ServiceWrapper) SetSessionPipeline(sessionID string, config LogPipeline) (*LogPipelineState, error) {
+	return s.Ctrl.SetSessionPipeline(sessionID, config)
+}
+func (s *ServiceWrapper) GetSessionPipeline(sessionID string) (*LogPipelineState, error) {
+	return s.Ctrl.GetSessionPipeline(sessionID)
+}
+func (s *ServiceWrapper) ListSessionPatterns(sessionID string, opts PatternOptions) ([]LogTemplate, error) {
+	return s.Ctrl.ListSessionPatterns(sessionID, opts)
+}
+func (s *ServiceWrapper) StartCapture(sessionID string, opts CaptureOptions) (capture.Info, error) {
+	return s.Ctrl.StartCapture(sessionID, opts)
+}
+func (s *ServiceWrapper) StopCapture(sessionID string) (capture.Info, error) {
+	return s.Ctrl.StopCapture(sessionID)
+}
+func (s *ServiceWrapper) ListCaptures() ([]capture.Info, error) {
+	return s.Ctrl.ListCaptures()
+}
+func (s *ServiceWrapper) GetCapture(captureID string) (capture.Info, error) {
+	return s.Ctrl.GetCapture(captureID)
+}
+func (s *ServiceWrapper) ExportCapture(captureID string, format capture.Format, path string) error {
+	return s.Ctrl.ExportCapture(captureID, format, path)
+}
+func (s *ServiceWrapper) DeleteCapture(captureID string) error {
+	return s.Ctrl.DeleteCapture(captureID)
+}
+func (s *ServiceWrapper) OpenCapture(captureID string, pipeline LogPipeline) (string, error) {
+	return s.Ctrl.OpenCapture(captureID, pipeline)
+}
+func (s *ServiceWrapper) ReplayCapture(sessionID string) error {
+	return s.Ctrl.ReplayCapture(sessionID)
+}
+func (s *ServiceWrapper) ListAlertRules() ([]AlertRule, error) {
+	return s.Ctrl.ListAlertRules()
+}
+func (s *ServiceWrapper) SaveAlertRule(rule AlertRule) (*AlertRule, error) {
+	return s.Ctrl.SaveAlertRule(rule)
+}
+func (s *ServiceWrapper) DeleteAlertRule(ruleID string) error {
+	return s.Ctrl.DeleteAlertRule(ruleID)
+}
+func (s *ServiceWrapper) ListAlerts() ([]LogAlert, error) {
+	return s.Ctrl.ListAlerts()
+}
 func (s *ServiceWrapper) ListPlugins() ([]string, error) {
 	return s.Ctrl.ListPlugins()
 }
@@ -29,11 +29,15 @@ var tracer = otel.Tracer("omniview.networker")
 const (
 	PortForwardSessionCreated = "core/networker/portforward/created"
 	PortForwardSessionClosed  = "core/networker/portforward/closed"
+	// PortForwardProfileChanged carries a ProfileStatus whenever a
+	// port-forward profile changes state.
+	PortForwardProfileChanged = "core/networker/portforward/profile"
 )
 
 func init() {
 	application.RegisterEvent[*networker.PortForwardSession](PortForwardSessionCreated)
 	application.RegisterEvent[*networker.PortForwardSession](PortForwardSessionClosed)
+	application.RegisterEvent[ProfileStatus](PortForwardProfileChanged)
 }
 
 type Controller interface {
@@ -70,6 +74,30 @@ type Controller interface {
 
 	// ClosePortForwardSession closes a port forward session
 	ClosePortForwardSession(sessionID string) (*networker.PortForwardSession, error)
+
+	// ListPortForwardProfiles returns a plugin's saved port-forward profiles
+	ListPortForwardProfiles(pluginID string) ([]PortForwardProfile, error)
+
+	// SavePortForwardProfile creates or updates a port-forward profile
+	SavePortForwardProfile(profile PortForwardProfile) (*PortForwardProfile, error)
+
+	// DeletePortForwardProfile stops and removes a port-forward profile
+	DeletePortForwardProfile(pluginID, profileID string) error
+
+	// StartPortForwardProfile starts a port-forward profile, keeping its session open
+	StartPortForwardProfile(pluginID, profileID string) (*ProfileStatus, error)
+
+	// StopPortForwardProfile stops a port-forward profile and closes its session
+	StopPortForwardProfile(pluginID, profileID string) (*ProfileStatus, error)
+
+	// GetPortForwardProfileStatus returns the state of a port-forward profile
+	GetPortForwardProfileStatus(pluginID, profileID string) (*ProfileStatus, error)
+
+	// ListPortForwardProfileStatuses returns the state of every running port-forward profile
+	ListPortForwardProfileStatuses() ([]ProfileStatus, error)
+
+	// HandleConnectionStatus starts and suspends profiles as connections come up and go down
+	HandleConnectionStatus(pluginID, connectionID, status string)
 }
 
 // make it easy for us to lookup sessions by ID, without having to know
@@ -79,17 +107,41 @@ type sessionIndex struct {
 	connectionID string
 }
 
+type controllerOptions struct {
+	profiles *ProfileStore
+}
+
+// ControllerOption configures the networker controller.
+type ControllerOption func(*controllerOptions)
+
+// WithProfileStore enables port-forward profiles, persisted in store.
+// Without it the profile methods return a not-implemented error.
+func WithProfileStore(store *ProfileStore) ControllerOption {
+	return func(o *controllerOptions) { o.profiles = store }
+}
+
 func NewController(
 	logger logging.Logger,
 	sp pkgsettings.Provider,
 	resourceClient resource.Service,
+	opts ...ControllerOption,
 ) Controller {
+	var cfg controllerOptions
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	profileCtx, profileCancel := context.WithCancel(context.Background())
 	return &controller{
 		logger:           logger.Named("NetworkerController"),
 		settingsProvider: sp,
 		sessionIndex:     make(map[string]sessionIndex),
 		stops:            make(map[string]chan struct{}),
 		resourceClient:   resourceClient,
+		profiles:         cfg.profiles,
+		profileRunners:   make(map[string]*profileRunner),
+		profileCtx:       profileCtx,
+		profileCancel:    profileCancel,
 	}
 }
 
@@ -106,6 +158,14 @@ type controller struct {
 	clients      map[string]NetworkerProvider
 	sessionIndex map[string]sessionIndex
 	stops        map[string]chan struct{}
+
+	// port-forward profiles; profiles is nil when they are disabled
+	profiles       *ProfileStore
+	profileMu      sync.Mutex
+	profileRunners map[string]*profileRunner // running profiles by ID
+	profileCtx     context.Context
+	profileCancel  context.CancelFunc
+	profileWG      sync.WaitGroup
 }
 
 // ServiceStartup initialises the controller during application startup.
@@ -117,6 +177,7 @@ func (c *controller) ServiceStartup(ctx context.Context, options application.Ser
 
 // ServiceShutdown cleans up resources during application shutdown.
 func (c *controller) ServiceShutdown() error {
+	c.stopProfileLoops()
 	return nil
 }
 
@@ -198,6 +259,7 @@ func (c *controller) OnPluginStop(pluginID string, meta config.PluginMeta) error
 	provider, ok := c.clients[pluginID]
 	delete(c.clients, pluginID)
 	c.mu.Unlock()
+	c.suspendProfiles(func(p PortForwardProfile) bool { return p.PluginID == pluginID })
 	if ok {
 		provider.StopAll()
 	}
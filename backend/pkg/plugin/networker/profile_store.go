@@ -0,0 +1,148 @@
+package networker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/networker"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+// profilesKey is the data store key holding all of a plugin's port-forward
+// profiles as one list.
+const profilesKey = "omniview.networker.profiles"
+
+// ErrProfileNotFound is returned when a port-forward profile does not exist.
+var ErrProfileNotFound = errors.New("port-forward profile not found")
+
+// PortForwardProfile is a saved port forward to a resource of a connection.
+// Unlike a session it outlives the application: a started profile keeps a
+// session open, re-establishing it when it fails, until it is stopped.
+type PortForwardProfile struct {
+	ID           string `json:"id"`
+	PluginID     string `json:"pluginID"`
+	ConnectionID string `json:"connectionID"`
+	Name         string `json:"name"`
+	// ResourceKey, ResourceID and Namespace identify the target resource.
+	// Its current data is looked up each time a session is started.
+	ResourceKey string                        `json:"resourceKey"`
+	ResourceID  string                        `json:"resourceID"`
+	Namespace   string                        `json:"namespace,omitempty"`
+	LocalPort   int32                         `json:"localPort"`
+	RemotePort  int32                         `json:"remotePort"`
+	Protocol    networker.PortForwardProtocol `json:"protocol"`
+	// AutoStart starts the profile whenever its connection comes up.
+	AutoStart bool      `json:"autoStart"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks that a profile names its target and ports.
+func (p *PortForwardProfile) Validate() error {
+	switch {
+	case p.PluginID == "":
+		return errors.New("a plugin is required")
+	case p.ConnectionID == "":
+		return errors.New("a connection is required")
+	case p.ResourceKey == "" || p.ResourceID == "":
+		return errors.New("a target resource is required")
+	case p.RemotePort <= 0 || p.RemotePort > 65535:
+		return fmt.Errorf("remote port %d is out of range", p.RemotePort)
+	case p.LocalPort < 0 || p.LocalPort > 65535:
+		return fmt.Errorf("local port %d is out of range", p.LocalPort)
+	case !p.Protocol.Valid():
+		return fmt.Errorf("unsupported protocol %q", p.Protocol)
+	}
+	return nil
+}
+
+// ProfileStore persists port-forward profiles, all of a plugin's profiles
+// under a single key of its data store.
+type ProfileStore struct {
+	data plugindata.Store
+	mu   sync.Mutex
+}
+
+// NewProfileStore creates a ProfileStore backed by data.
+func NewProfileStore(data plugindata.Store) *ProfileStore {
+	return &ProfileStore{data: data}
+}
+
+// List returns a plugin's profiles in the order they were created.
+func (s *ProfileStore) List(pluginID string) ([]PortForwardProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(pluginID)
+}
+
+// Load returns a profile, or ErrProfileNotFound.
+func (s *ProfileStore) Load(pluginID, id string) (*PortForwardProfile, error) {
+	profiles, err := s.List(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(profiles, func(p PortForwardProfile) bool { return p.ID == id })
+	if i < 0 {
+		return nil, ErrProfileNotFound
+	}
+	return &profiles[i], nil
+}
+
+// Save adds profile, or replaces the profile with the same ID.
+func (s *ProfileStore) Save(profile *PortForwardProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profiles, err := s.read(profile.PluginID)
+	if err != nil {
+		return err
+	}
+	if i := slices.IndexFunc(profiles, func(p PortForwardProfile) bool { return p.ID == profile.ID }); i >= 0 {
+		profiles[i] = *profile
+	} else {
+		profiles = append(profiles, *profile)
+	}
+	if err := s.data.Set(profile.PluginID, profilesKey, profiles); err != nil {
+		return fmt.Errorf("write port-forward profiles: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a profile, or returns ErrProfileNotFound.
+func (s *ProfileStore) Delete(pluginID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profiles, err := s.read(pluginID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(profiles, func(p PortForwardProfile) bool { return p.ID == id })
+	if i < 0 {
+		return ErrProfileNotFound
+	}
+	if err := s.data.Set(pluginID, profilesKey, slices.Delete(profiles, i, i+1)); err != nil {
+		return fmt.Errorf("write port-forward profiles: %w", err)
+	}
+	return nil
+}
+
+// read loads a plugin's profiles. Caller must hold s.mu.
+func (s *ProfileStore) read(pluginID string) ([]PortForwardProfile, error) {
+	value, err := s.data.Get(pluginID, profilesKey)
+	if err != nil {
+		return nil, fmt.Errorf("read port-forward profiles: %w", err)
+	}
+	profiles := []PortForwardProfile{}
+	if value == nil {
+		return profiles, nil
+	}
+	if err := plugindata.Decode(value, &profiles); err != nil {
+		return nil, fmt.Errorf("decode port-forward profiles: %w", err)
+	}
+	return profiles, nil
+}
@@ -0,0 +1,91 @@
+package networker
+
+import (
+	"testing"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/networker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+func validProfile() PortForwardProfile {
+	return PortForwardProfile{
+		PluginID:     "kubernetes",
+		ConnectionID: "prod",
+		Name:         "postgres",
+		ResourceKey:  "core::v1::Pod",
+		ResourceID:   "postgres-0",
+		Namespace:    "db",
+		LocalPort:    5432,
+		RemotePort:   5432,
+		Protocol:     networker.PortForwardProtocolTCP,
+	}
+}
+
+func TestPortForwardProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*PortForwardProfile)
+		errMsg string
+	}{
+		{name: "valid", modify: func(*PortForwardProfile) {}},
+		{name: "any local port", modify: func(p *PortForwardProfile) { p.LocalPort = 0 }},
+		{name: "no plugin", modify: func(p *PortForwardProfile) { p.PluginID = "" }, errMsg: "plugin"},
+		{name: "no connection", modify: func(p *PortForwardProfile) { p.ConnectionID = "" }, errMsg: "connection"},
+		{name: "no resource", modify: func(p *PortForwardProfile) { p.ResourceID = "" }, errMsg: "resource"},
+		{name: "no remote port", modify: func(p *PortForwardProfile) { p.RemotePort = 0 }, errMsg: "remote port"},
+		{name: "local port too high", modify: func(p *PortForwardProfile) { p.LocalPort = 70000 }, errMsg: "local port"},
+		{name: "bad protocol", modify: func(p *PortForwardProfile) { p.Protocol = "SCTP" }, errMsg: "protocol"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := validProfile()
+			tt.modify(&p)
+			err := p.Validate()
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestProfileStore(t *testing.T) {
+	s := NewProfileStore(plugindata.NewMemory())
+
+	profiles, err := s.List("kubernetes")
+	require.NoError(t, err)
+	assert.Empty(t, profiles)
+
+	first := validProfile()
+	first.ID = "pf-1"
+	second := validProfile()
+	second.ID = "pf-2"
+	second.Name = "redis"
+	require.NoError(t, s.Save(&first))
+	require.NoError(t, s.Save(&second))
+
+	first.AutoStart = true
+	require.NoError(t, s.Save(&first))
+	profiles, err = s.List("kubernetes")
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.True(t, profiles[0].AutoStart, "saving an existing profile keeps its position")
+	assert.Equal(t, networker.PortForwardProtocolTCP, profiles[0].Protocol)
+
+	loaded, err := s.Load("kubernetes", "pf-2")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", loaded.Name)
+	_, err = s.Load("aws", "pf-2")
+	require.ErrorIs(t, err, ErrProfileNotFound, "profiles are per plugin")
+
+	require.NoError(t, s.Delete("kubernetes", "pf-1"))
+	require.ErrorIs(t, s.Delete("kubernetes", "pf-1"), ErrProfileNotFound)
+	profiles, err = s.List("kubernetes")
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+}
@@ -0,0 +1,586 @@
+package networker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+
+	sdktypes "github.com/omniviewdev/plugin-sdk/pkg/types"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/networker"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+)
+
+// ProfileLabel is the label identifying the profile a port-forward session
+// was started for.
+const ProfileLabel = "omniview.dev/portforward-profile"
+
+// ProfileState is the state of a port-forward profile.
+type ProfileState string
+
+const (
+	// ProfileStopped is a profile that is not running.
+	ProfileStopped ProfileState = "STOPPED"
+	// ProfileWaiting is a running profile whose connection is down. Its
+	// session is started when the connection comes up.
+	ProfileWaiting ProfileState = "WAITING"
+	// ProfileStarting is a profile whose session is being started.
+	ProfileStarting ProfileState = "STARTING"
+	// ProfileActive is a profile with an active session.
+	ProfileActive ProfileState = "ACTIVE"
+	// ProfileReconnecting is a profile whose session failed, or could not be
+	// started, waiting to try again.
+	ProfileReconnecting ProfileState = "RECONNECTING"
+)
+
+// ProfileStatus is the state of a port-forward profile and its session.
+type ProfileStatus struct {
+	ProfileID    string       `json:"profileID"`
+	PluginID     string       `json:"pluginID"`
+	ConnectionID string       `json:"connectionID"`
+	State        ProfileState `json:"state"`
+	// SessionID and LocalPort are those of the active session.
+	SessionID string `json:"sessionID,omitempty"`
+	LocalPort int32  `json:"localPort,omitempty"`
+	// Attempt counts the attempts to start a session since the profile last
+	// had a stable one.
+	Attempt int `json:"attempt"`
+	// Error is why the last session failed or could not be started.
+	Error string `json:"error,omitempty"`
+	// NextAttemptAt is when a reconnecting profile tries again.
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// Reconnect timing. Variables so tests can shorten them.
+var (
+	// profileBackoffMin and profileBackoffMax bound the delay before a
+	// failed session is re-established; the delay doubles with each
+	// consecutive failure. A session that stays up for profileBackoffMax
+	// resets it.
+	profileBackoffMin = time.Second
+	profileBackoffMax = time.Minute
+	// profilePollInterval is how often an active session's state is checked.
+	profilePollInterval = 5 * time.Second
+)
+
+// profileShutdownTimeout bounds the wait for profile loops to finish on
+// shutdown.
+const profileShutdownTimeout = 5 * time.Second
+
+// errSessionClosed ends a profile whose session was closed through
+// ClosePortForwardSession.
+var errSessionClosed = errors.New("session closed")
+
+// profileRunner keeps a profile's session open while the profile runs.
+type profileRunner struct {
+	profile PortForwardProfile
+	emit    func(ProfileStatus)
+
+	mu     sync.Mutex
+	status ProfileStatus
+	// cancel stops the current reconnect loop; nil while waiting for the
+	// connection.
+	cancel context.CancelFunc
+	// wake cuts a reconnect delay short.
+	wake chan struct{}
+}
+
+// set updates the runner's status, unless loopCtx, the context of the loop
+// making the update, is done.
+func (r *profileRunner) set(loopCtx context.Context, update func(*ProfileStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if loopCtx != nil && loopCtx.Err() != nil {
+		return
+	}
+	update(&r.status)
+	r.status.UpdatedAt = time.Now()
+	r.emit(r.status)
+}
+
+func (r *profileRunner) snapshot() ProfileStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// ListPortForwardProfiles returns a plugin's port-forward profiles in the
+// order they were created.
+func (c *controller) ListPortForwardProfiles(pluginID string) ([]PortForwardProfile, error) {
+	if err := c.requireProfiles(); err != nil {
+		return nil, err
+	}
+	profiles, err := c.profiles.List(pluginID)
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to list port-forward profiles")
+	}
+	return profiles, nil
+}
+
+// SavePortForwardProfile creates a profile, when its ID is empty, or replaces
+// the profile with its ID, restarting it if it is running. It returns the
+// profile as saved.
+func (c *controller) SavePortForwardProfile(profile PortForwardProfile) (*PortForwardProfile, error) {
+	if err := c.requireProfiles(); err != nil {
+		return nil, err
+	}
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Protocol == "" {
+		profile.Protocol = networker.PortForwardProtocolTCP
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid port-forward profile", err.Error())
+	}
+
+	now := time.Now()
+	if profile.ID == "" {
+		profile.ID = uuid.NewString()
+		profile.CreatedAt = now
+	} else {
+		existing, err := c.profiles.Load(profile.PluginID, profile.ID)
+		if err != nil {
+			return nil, profileError(err, profile.ID)
+		}
+		profile.CreatedAt = existing.CreatedAt
+	}
+	profile.UpdatedAt = now
+	if err := c.profiles.Save(&profile); err != nil {
+		return nil, profileError(err, profile.ID)
+	}
+
+	if c.stopProfile(profile.ID) {
+		c.startProfile(profile)
+	}
+	return &profile, nil
+}
+
+// DeletePortForwardProfile stops a profile and removes it.
+func (c *controller) DeletePortForwardProfile(pluginID, profileID string) error {
+	if err := c.requireProfiles(); err != nil {
+		return err
+	}
+	if err := c.profiles.Delete(pluginID, profileID); err != nil {
+		return profileError(err, profileID)
+	}
+	c.stopProfile(profileID)
+	return nil
+}
+
+// StartPortForwardProfile starts a profile: a session is opened for it and
+// re-established with backoff whenever it fails, until the profile is
+// stopped. Starting a running profile does nothing. State changes are
+// emitted as PortForwardProfileChanged events.
+func (c *controller) StartPortForwardProfile(pluginID, profileID string) (*ProfileStatus, error) {
+	if err := c.requireProfiles(); err != nil {
+		return nil, err
+	}
+	profile, err := c.profiles.Load(pluginID, profileID)
+	if err != nil {
+		return nil, profileError(err, profileID)
+	}
+	status := c.startProfile(*profile)
+	return &status, nil
+}
+
+// StopPortForwardProfile stops a profile and closes its session.
+func (c *controller) StopPortForwardProfile(pluginID, profileID string) (*ProfileStatus, error) {
+	if err := c.requireProfiles(); err != nil {
+		return nil, err
+	}
+	profile, err := c.profiles.Load(pluginID, profileID)
+	if err != nil {
+		return nil, profileError(err, profileID)
+	}
+	c.stopProfile(profileID)
+	status := stoppedStatus(*profile)
+	return &status, nil
+}
+
+// GetPortForwardProfileStatus returns the state of a profile.
+func (c *controller) GetPortForwardProfileStatus(pluginID, profileID string) (*ProfileStatus, error) {
+	if err := c.requireProfiles(); err != nil {
+		return nil, err
+	}
+	profile, err := c.profiles.Load(pluginID, profileID)
+	if err != nil {
+		return nil, profileError(err, profileID)
+	}
+	c.profileMu.Lock()
+	runner, ok := c.profileRunners[profileID]
+	c.profileMu.Unlock()
+	status := stoppedStatus(*profile)
+	if ok {
+		status = runner.snapshot()
+	}
+	return &status, nil
+}
+
+// ListPortForwardProfileStatuses returns the state of every running profile.
+func (c *controller) ListPortForwardProfileStatuses() ([]ProfileStatus, error) {
+	c.profileMu.Lock()
+	defer c.profileMu.Unlock()
+	statuses := make([]ProfileStatus, 0, len(c.profileRunners))
+	for _, runner := range c.profileRunners {
+		statuses = append(statuses, runner.snapshot())
+	}
+	slices.SortFunc(statuses, func(a, b ProfileStatus) int { return strings.Compare(a.ProfileID, b.ProfileID) })
+	return statuses, nil
+}
+
+// HandleConnectionStatus starts the auto-start profiles of a connection
+// that came up and resumes its waiting profiles; profiles of a connection
+// that was stopped wait for it to come back.
+func (c *controller) HandleConnectionStatus(pluginID, connectionID, status string) {
+	switch sdktypes.ConnectionStatusCode(status) {
+	case sdktypes.ConnectionStatusConnected:
+		c.resumeProfiles(pluginID, connectionID)
+	case sdktypes.ConnectionStatusDisconnected:
+		c.suspendProfiles(func(p PortForwardProfile) bool {
+			return p.PluginID == pluginID && p.ConnectionID == connectionID
+		})
+	default:
+	}
+}
+
+// resumeProfiles starts the auto-start and waiting profiles of a connection
+// that came up, and retries reconnecting ones right away.
+func (c *controller) resumeProfiles(pluginID, connectionID string) {
+	if c.profiles == nil {
+		return
+	}
+	profiles, err := c.profiles.List(pluginID)
+	if err != nil {
+		c.logger.Errorw(context.Background(), "failed to load port-forward profiles",
+			"pluginID", pluginID, "error", err)
+		return
+	}
+
+	c.profileMu.Lock()
+	defer c.profileMu.Unlock()
+	for _, profile := range profiles {
+		if profile.ConnectionID != connectionID {
+			continue
+		}
+		runner, ok := c.profileRunners[profile.ID]
+		switch {
+		case !ok && profile.AutoStart:
+			c.startProfileLocked(profile)
+		case ok:
+			runner.mu.Lock()
+			waiting := runner.cancel == nil
+			runner.mu.Unlock()
+			if waiting {
+				c.runProfileLocked(runner)
+			} else {
+				select {
+				case runner.wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// suspendProfiles stops the sessions of the running profiles matching
+// match, leaving them waiting for their connection.
+func (c *controller) suspendProfiles(match func(PortForwardProfile) bool) {
+	c.profileMu.Lock()
+	defer c.profileMu.Unlock()
+	for _, runner := range c.profileRunners {
+		if !match(runner.profile) {
+			continue
+		}
+		runner.mu.Lock()
+		if runner.cancel != nil {
+			runner.cancel()
+			runner.cancel = nil
+		}
+		runner.status.State = ProfileWaiting
+		runner.status.SessionID = ""
+		runner.status.LocalPort = 0
+		runner.status.Attempt = 0
+		runner.status.NextAttemptAt = nil
+		runner.status.UpdatedAt = time.Now()
+		runner.emit(runner.status)
+		runner.mu.Unlock()
+	}
+}
+
+// startProfile starts a profile that is not running and returns its status.
+func (c *controller) startProfile(profile PortForwardProfile) ProfileStatus {
+	c.profileMu.Lock()
+	defer c.profileMu.Unlock()
+	if runner, ok := c.profileRunners[profile.ID]; ok {
+		return runner.snapshot()
+	}
+	return c.startProfileLocked(profile).snapshot()
+}
+
+// startProfileLocked creates a profile's runner and starts its reconnect
+// loop. Caller must hold c.profileMu.
+func (c *controller) startProfileLocked(profile PortForwardProfile) *profileRunner {
+	runner := &profileRunner{
+		profile: profile,
+		emit:    c.emitProfileStatus,
+		status:  stoppedStatus(profile),
+		wake:    make(chan struct{}, 1),
+	}
+	c.profileRunners[profile.ID] = runner
+	c.runProfileLocked(runner)
+	return runner
+}
+
+// runProfileLocked starts a runner's reconnect loop. Caller must hold
+// c.profileMu.
+func (c *controller) runProfileLocked(runner *profileRunner) {
+	ctx, cancel := context.WithCancel(c.profileCtx)
+	runner.mu.Lock()
+	runner.cancel = cancel
+	runner.mu.Unlock()
+	c.profileWG.Add(1)
+	go func() {
+		defer c.profileWG.Done()
+		c.runProfile(ctx, runner)
+	}()
+}
+
+// stopProfile stops a running profile, reporting whether it was running.
+func (c *controller) stopProfile(profileID string) bool {
+	c.profileMu.Lock()
+	runner, ok := c.profileRunners[profileID]
+	delete(c.profileRunners, profileID)
+	c.profileMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	runner.mu.Lock()
+	if runner.cancel != nil {
+		runner.cancel()
+		runner.cancel = nil
+	}
+	runner.status = stoppedStatus(runner.profile)
+	runner.status.UpdatedAt = time.Now()
+	runner.emit(runner.status)
+	runner.mu.Unlock()
+	return true
+}
+
+// runProfile keeps a session open for a profile until ctx is done,
+// re-establishing it with backoff when it fails.
+func (c *controller) runProfile(ctx context.Context, runner *profileRunner) {
+	profile := runner.profile
+	attempt := 0
+	for {
+		attempt++
+		runner.set(ctx, func(s *ProfileStatus) {
+			s.State = ProfileStarting
+			s.Attempt = attempt
+			s.NextAttemptAt = nil
+		})
+
+		session, err := c.startProfileSession(profile)
+		if err == nil {
+			if ctx.Err() != nil {
+				c.closeProfileSession(session.ID)
+				return
+			}
+			started := time.Now()
+			runner.set(ctx, func(s *ProfileStatus) {
+				s.State = ProfileActive
+				s.SessionID = session.ID
+				s.LocalPort = session.LocalPort
+				s.Error = ""
+			})
+			err = c.watchProfileSession(ctx, session.ID)
+			if ctx.Err() != nil {
+				c.closeProfileSession(session.ID)
+				return
+			}
+			if errors.Is(err, errSessionClosed) {
+				c.logger.Infow(ctx, "port-forward profile session closed; stopping profile",
+					"profileID", profile.ID, "sessionID", session.ID)
+				c.stopProfile(profile.ID)
+				return
+			}
+			c.closeProfileSession(session.ID)
+			if time.Since(started) >= profileBackoffMax {
+				attempt = 1
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay := profileBackoff(attempt)
+		next := time.Now().Add(delay)
+		c.logger.Warnw(ctx, "port-forward profile session failed; reconnecting",
+			"profileID", profile.ID, "attempt", attempt, "delay", delay, "error", err)
+		runner.set(ctx, func(s *ProfileStatus) {
+			s.State = ProfileReconnecting
+			s.SessionID = ""
+			s.LocalPort = 0
+			s.Error = err.Error()
+			s.NextAttemptAt = &next
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-runner.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// profileBackoff returns the delay before the attempt after the given
+// number of consecutive failed attempts.
+func profileBackoff(attempt int) time.Duration {
+	delay := profileBackoffMin
+	for i := 1; i < attempt && delay < profileBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, profileBackoffMax)
+}
+
+// startProfileSession starts a session for a profile, with the current data
+// of its target resource.
+func (c *controller) startProfileSession(profile PortForwardProfile) (*networker.PortForwardSession, error) {
+	if c.resourceClient == nil {
+		return nil, errors.New("resources are unavailable")
+	}
+	result, err := c.resourceClient.Get(profile.PluginID, profile.ConnectionID, profile.ResourceKey,
+		resource.GetInput{ID: profile.ResourceID, Namespace: profile.Namespace})
+	if err != nil {
+		return nil, fmt.Errorf("look up %s %s: %w", profile.ResourceKey, profile.ResourceID, err)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(result.Result, &data); err != nil {
+		return nil, fmt.Errorf("decode %s %s: %w", profile.ResourceKey, profile.ResourceID, err)
+	}
+
+	return c.StartResourcePortForwardingSession(profile.PluginID, profile.ConnectionID,
+		networker.PortForwardSessionOptions{
+			ConnectionType: networker.PortForwardConnectionTypeResource,
+			Connection: networker.PortForwardResourceConnection{
+				PluginID:     profile.PluginID,
+				ConnectionID: profile.ConnectionID,
+				ResourceKey:  profile.ResourceKey,
+				ResourceID:   profile.ResourceID,
+				ResourceData: data,
+			},
+			Protocol:   profile.Protocol,
+			LocalPort:  profile.LocalPort,
+			RemotePort: profile.RemotePort,
+			Labels:     map[string]string{ProfileLabel: profile.ID},
+		})
+}
+
+// watchProfileSession polls a session until it fails or stops, or ctx is
+// done. It returns errSessionClosed if the session was closed through
+// ClosePortForwardSession.
+func (c *controller) watchProfileSession(ctx context.Context, sessionID string) error {
+	ticker := time.NewTicker(profilePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		c.mu.RLock()
+		_, tracked := c.sessionIndex[sessionID]
+		c.mu.RUnlock()
+		if !tracked {
+			return errSessionClosed
+		}
+		session, err := c.GetPortForwardSession(sessionID)
+		if err != nil {
+			return fmt.Errorf("session lost: %w", err)
+		}
+		switch session.State {
+		case networker.SessionStateFailed:
+			return errors.New("session failed")
+		case networker.SessionStateStopped:
+			return errors.New("session stopped")
+		default:
+		}
+	}
+}
+
+// closeProfileSession closes a session of a profile that is being stopped
+// or replaced.
+func (c *controller) closeProfileSession(sessionID string) {
+	if _, err := c.ClosePortForwardSession(sessionID); err != nil {
+		c.logger.Debugw(context.Background(), "failed to close port-forward profile session",
+			"sessionID", sessionID, "error", err)
+		c.mu.Lock()
+		delete(c.sessionIndex, sessionID)
+		c.mu.Unlock()
+	}
+}
+
+// emitProfileStatus notifies the frontend of a profile's state.
+func (c *controller) emitProfileStatus(status ProfileStatus) {
+	if c.app != nil {
+		c.app.Event.Emit(PortForwardProfileChanged, status)
+	}
+}
+
+// stopProfileLoops ends the reconnect loops of all profiles, waiting a
+// bounded time for them to finish. Profiles are left running, so auto-start
+// profiles start again with their connections on the next run.
+func (c *controller) stopProfileLoops() {
+	c.profileCancel()
+	done := make(chan struct{})
+	go func() {
+		c.profileWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(profileShutdownTimeout):
+	}
+}
+
+// requireProfiles checks that profiles are enabled.
+func (c *controller) requireProfiles() error {
+	if c.profiles == nil {
+		return apperror.NotImplemented(
+			"Port-forward profiles unavailable",
+			"Port-forward profiles are not enabled.",
+		)
+	}
+	return nil
+}
+
+func stoppedStatus(profile PortForwardProfile) ProfileStatus {
+	return ProfileStatus{
+		ProfileID:    profile.ID,
+		PluginID:     profile.PluginID,
+		ConnectionID: profile.ConnectionID,
+		State:        ProfileStopped,
+	}
+}
+
+// profileError converts a ProfileStore error into an app error.
+func profileError(err error, profileID string) error {
+	if errors.Is(err, ErrProfileNotFound) {
+		return apperror.NotFound("Port-forward profile not found",
+			fmt.Sprintf("Port-forward profile %s does not exist.", profileID))
+	}
+	return apperror.Internal(err, "Failed to access port-forward profiles")
+}
@@ -0,0 +1,277 @@
+package networker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	"github.com/omniviewdev/plugin-sdk/pkg/types"
+	"github.com/omniviewdev/plugin-sdk/pkg/v1/networker"
+	sdkresource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource"
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+// fakeResources serves connections and resources to the controller.
+type fakeResources struct {
+	resource.Service
+}
+
+func (fakeResources) GetConnection(_, connectionID string) (types.Connection, error) {
+	return types.Connection{ID: connectionID}, nil
+}
+
+func (fakeResources) Get(_, _, _ string, input sdkresource.GetInput) (*sdkresource.GetResult, error) {
+	data, err := json.Marshal(map[string]any{"name": input.ID, "namespace": input.Namespace})
+	return &sdkresource.GetResult{Result: data, Success: true}, err
+}
+
+// fakeProvider is a networker plugin whose sessions can be failed and whose
+// starts can be made to fail.
+type fakeProvider struct {
+	NetworkerProvider
+
+	mu         sync.Mutex
+	sessions   map[string]*networker.PortForwardSession
+	started    []networker.PortForwardSessionOptions
+	failStarts int
+	nextID     int
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{sessions: make(map[string]*networker.PortForwardSession)}
+}
+
+func (p *fakeProvider) StartPortForwardSession(
+	_ *types.PluginContext,
+	opts networker.PortForwardSessionOptions,
+) (*networker.PortForwardSession, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failStarts > 0 {
+		p.failStarts--
+		return nil, errors.New("pod not ready")
+	}
+	p.nextID++
+	p.started = append(p.started, opts)
+	session := &networker.PortForwardSession{
+		ID:         fmt.Sprintf("session-%d", p.nextID),
+		State:      networker.SessionStateActive,
+		Labels:     opts.Labels,
+		LocalPort:  opts.LocalPort,
+		RemotePort: opts.RemotePort,
+	}
+	p.sessions[session.ID] = session
+	copied := *session
+	return &copied, nil
+}
+
+func (p *fakeProvider) GetPortForwardSession(_ *types.PluginContext, id string) (*networker.PortForwardSession, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	session, ok := p.sessions[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (p *fakeProvider) ClosePortForwardSession(_ *types.PluginContext, id string) (*networker.PortForwardSession, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	session, ok := p.sessions[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	delete(p.sessions, id)
+	session.State = networker.SessionStateStopped
+	copied := *session
+	return &copied, nil
+}
+
+func (p *fakeProvider) fail(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sessions[id].State = networker.SessionStateFailed
+}
+
+func (p *fakeProvider) open() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]string, 0, len(p.sessions))
+	for id := range p.sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// newProfileController returns a controller with profiles enabled, a fake
+// kubernetes plugin and short reconnect timings.
+func newProfileController(t *testing.T) (*controller, *fakeProvider) {
+	t.Helper()
+	minDelay, maxDelay, poll := profileBackoffMin, profileBackoffMax, profilePollInterval
+	profileBackoffMin, profileBackoffMax, profilePollInterval = 5*time.Millisecond, 40*time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() {
+		profileBackoffMin, profileBackoffMax, profilePollInterval = minDelay, maxDelay, poll
+	})
+
+	c := NewController(logging.NewNop(), nil, fakeResources{},
+		WithProfileStore(NewProfileStore(plugindata.NewMemory()))).(*controller)
+	t.Cleanup(func() { _ = c.ServiceShutdown() })
+	provider := newFakeProvider()
+	c.clients = map[string]NetworkerProvider{"kubernetes": provider}
+	return c, provider
+}
+
+// waitForState waits for a profile to reach state and returns its status.
+func waitForState(t *testing.T, c *controller, profile *PortForwardProfile, state ProfileState) ProfileStatus {
+	t.Helper()
+	var status *ProfileStatus
+	require.Eventually(t, func() bool {
+		var err error
+		status, err = c.GetPortForwardProfileStatus(profile.PluginID, profile.ID)
+		return err == nil && status.State == state
+	}, 5*time.Second, time.Millisecond, "profile never reached %s", state)
+	return *status
+}
+
+func TestPortForwardProfile_StartAndStop(t *testing.T) {
+	c, provider := newProfileController(t)
+	profile, err := c.SavePortForwardProfile(validProfile())
+	require.NoError(t, err)
+
+	_, err = c.StartPortForwardProfile("kubernetes", profile.ID)
+	require.NoError(t, err)
+	status := waitForState(t, c, profile, ProfileActive)
+	assert.Equal(t, int32(5432), status.LocalPort)
+	assert.Equal(t, 1, status.Attempt)
+
+	provider.mu.Lock()
+	opts := provider.started[0]
+	provider.mu.Unlock()
+	assert.Equal(t, profile.ID, opts.Labels[ProfileLabel])
+	target, ok := opts.Connection.(networker.PortForwardResourceConnection)
+	require.True(t, ok)
+	assert.Equal(t, "postgres-0", target.ResourceID)
+	assert.Equal(t, "db", target.ResourceData["namespace"], "the resource's current data is looked up")
+
+	statuses, err := c.ListPortForwardProfileStatuses()
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+
+	stopped, err := c.StopPortForwardProfile("kubernetes", profile.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ProfileStopped, stopped.State)
+	require.Eventually(t, func() bool { return len(provider.open()) == 0 }, 5*time.Second, time.Millisecond,
+		"stopping a profile closes its session")
+	statuses, err = c.ListPortForwardProfileStatuses()
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
+
+func TestPortForwardProfile_ReconnectsFailedSession(t *testing.T) {
+	c, provider := newProfileController(t)
+	profile, err := c.SavePortForwardProfile(validProfile())
+	require.NoError(t, err)
+	_, err = c.StartPortForwardProfile("kubernetes", profile.ID)
+	require.NoError(t, err)
+	first := waitForState(t, c, profile, ProfileActive)
+
+	provider.fail(first.SessionID)
+	require.Eventually(t, func() bool {
+		status, statusErr := c.GetPortForwardProfileStatus("kubernetes", profile.ID)
+		return statusErr == nil && status.State == ProfileActive && status.SessionID != first.SessionID
+	}, 5*time.Second, time.Millisecond)
+	assert.NotContains(t, provider.open(), first.SessionID, "the failed session is closed")
+}
+
+func TestPortForwardProfile_RetriesFailedStart(t *testing.T) {
+	c, provider := newProfileController(t)
+	provider.failStarts = 2
+	profile, err := c.SavePortForwardProfile(validProfile())
+	require.NoError(t, err)
+
+	_, err = c.StartPortForwardProfile("kubernetes", profile.ID)
+	require.NoError(t, err)
+	status := waitForState(t, c, profile, ProfileActive)
+	assert.Equal(t, 3, status.Attempt)
+	assert.Empty(t, status.Error)
+}
+
+func TestPortForwardProfile_ClosedSessionStopsProfile(t *testing.T) {
+	c, _ := newProfileController(t)
+	profile, err := c.SavePortForwardProfile(validProfile())
+	require.NoError(t, err)
+	_, err = c.StartPortForwardProfile("kubernetes", profile.ID)
+	require.NoError(t, err)
+	status := waitForState(t, c, profile, ProfileActive)
+
+	_, err = c.ClosePortForwardSession(status.SessionID)
+	require.NoError(t, err)
+	waitForState(t, c, profile, ProfileStopped)
+}
+
+func TestHandleConnectionStatus(t *testing.T) {
+	c, provider := newProfileController(t)
+	auto := validProfile()
+	auto.AutoStart = true
+	autoProfile, err := c.SavePortForwardProfile(auto)
+	require.NoError(t, err)
+	manual := validProfile()
+	manual.Name = "manual"
+	manualProfile, err := c.SavePortForwardProfile(manual)
+	require.NoError(t, err)
+
+	c.HandleConnectionStatus("kubernetes", "prod", string(types.ConnectionStatusConnected))
+	waitForState(t, c, autoProfile, ProfileActive)
+	status, err := c.GetPortForwardProfileStatus("kubernetes", manualProfile.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ProfileStopped, status.State, "only auto-start profiles start with the connection")
+
+	c.HandleConnectionStatus("kubernetes", "prod", string(types.ConnectionStatusDisconnected))
+	waitForState(t, c, autoProfile, ProfileWaiting)
+	require.Eventually(t, func() bool { return len(provider.open()) == 0 }, 5*time.Second, time.Millisecond)
+
+	c.HandleConnectionStatus("kubernetes", "staging", string(types.ConnectionStatusConnected))
+	status, err = c.GetPortForwardProfileStatus("kubernetes", autoProfile.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ProfileWaiting, status.State, "other connections do not resume the profile")
+
+	c.HandleConnectionStatus("kubernetes", "prod", string(types.ConnectionStatusConnected))
+	waitForState(t, c, autoProfile, ProfileActive)
+}
+
+func TestPortForwardProfiles_Errors(t *testing.T) {
+	var target *apperror.AppError
+
+	_, err := newTestController().ListPortForwardProfiles("kubernetes")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeNotImplemented, target.Type, "profiles need a store")
+
+	c, _ := newProfileController(t)
+	_, err = c.StartPortForwardProfile("kubernetes", "missing")
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeResourceNotFound, target.Type)
+
+	invalid := validProfile()
+	invalid.RemotePort = 0
+	_, err = c.SavePortForwardProfile(invalid)
+	require.True(t, errors.As(err, &target))
+	assert.Equal(t, apperror.TypeValidation, target.Type)
+}
+
+func TestProfileBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, profileBackoff(1))
+	assert.Equal(t, 2*time.Second, profileBackoff(2))
+	assert.Equal(t, 8*time.Second, profileBackoff(4))
+	assert.Equal(t, time.Minute, profileBackoff(20))
+}
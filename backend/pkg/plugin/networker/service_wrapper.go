@@ -10,7 +10,7 @@ import (
 // ServiceWrapper is an explicit delegation wrapper around networker.Controller.
 // Excluded: OnPluginInit, OnPluginStart, OnPluginStop, OnPluginShutdown,
 //
-//	OnPluginDestroy
+//	OnPluginDestroy, HandleConnectionStatus
 type ServiceWrapper struct {
 	Ctrl Controller
 }
@@ -50,6 +50,27 @@ func (s *ServiceWrapper) StartResourcePortForwardingSession(pluginID, connection
 func (s *ServiceWrapper) ClosePortForwardSession(sessionID string) (*networkersdk.PortForwardSession, error) {
 	return s.Ctrl.ClosePortForwardSession(sessionID)
 }
+func (s *ServiceWrapper) ListPortForwardProfiles(pluginID string) ([]PortForwardProfile, error) {
+	return s.Ctrl.ListPortForwardProfiles(pluginID)
+}
+func (s *ServiceWrapper) SavePortForwardProfile(profile PortForwardProfile) (*PortForwardProfile, error) {
+	return s.Ctrl.SavePortForwardProfile(profile)
+}
+func (s *ServiceWrapper) DeletePortForwardProfile(pluginID, profileID string) error {
+	return s.Ctrl.DeletePortForwardProfile(pluginID, profileID)
+}
+func (s *ServiceWrapper) StartPortForwardProfile(pluginID, profileID string) (*ProfileStatus, error) {
+	return s.Ctrl.StartPortForwardProfile(pluginID, profileID)
+}
+func (s *ServiceWrapper) StopPortForwardProfile(pluginID, profileID string) (*ProfileStatus, error) {
+	return s.Ctrl.StopPortForwardProfile(pluginID, profileID)
+}
+func (s *ServiceWrapper) GetPortForwardProfileStatus(pluginID, profileID string) (*ProfileStatus, error) {
+	return s.Ctrl.GetPortForwardProfileStatus(pluginID, profileID)
+}
+func (s *ServiceWrapper) ListPortForwardProfileStatuses() ([]ProfileStatus, error) {
+	return s.Ctrl.ListPortForwardProfileStatuses()
+}
 func (s *ServiceWrapper) ListPlugins() ([]string, error) {
 	return s.Ctrl.ListPlugins()
 }
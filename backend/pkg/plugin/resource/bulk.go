@@ -0,0 +1,363 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+// BulkOperationType is the operation applied to every target of a bulk request.
+type BulkOperationType string
+
+const (
+	BulkDelete BulkOperationType = "delete"
+	BulkPatch  BulkOperationType = "patch"
+	BulkAction BulkOperationType = "action"
+)
+
+// BulkState is the lifecycle state of a bulk operation.
+type BulkState string
+
+const (
+	BulkRunning   BulkState = "running"
+	BulkCompleted BulkState = "completed"
+	BulkCancelled BulkState = "cancelled"
+)
+
+// BulkItemStatus is the outcome of a single bulk item.
+type BulkItemStatus string
+
+const (
+	BulkItemPending   BulkItemStatus = "pending"
+	BulkItemSucceeded BulkItemStatus = "succeeded"
+	BulkItemFailed    BulkItemStatus = "failed"
+	BulkItemCancelled BulkItemStatus = "cancelled"
+)
+
+const (
+	defaultBulkConcurrency = 4
+	maxBulkConcurrency     = 16
+	// maxRetainedBulkOperations bounds how many finished operations are kept
+	// for GetBulkOperation.
+	maxRetainedBulkOperations = 32
+)
+
+// BulkTarget identifies one resource of a bulk request.
+type BulkTarget struct {
+	ResourceKey string `json:"resourceKey"`
+	Namespace   string `json:"namespace,omitempty"`
+	ID          string `json:"id"`
+}
+
+// BulkRequest describes a bulk operation over a list of resources on one
+// connection.
+type BulkRequest struct {
+	Operation BulkOperationType `json:"operation"`
+	Targets   []BulkTarget      `json:"targets"`
+
+	// Patch is a JSON merge patch (RFC 7386) applied to the current state of
+	// every target (BulkPatch).
+	Patch json.RawMessage `json:"patch,omitempty"`
+
+	// ActionID and Params select the action run on every target (BulkAction).
+	ActionID string                 `json:"actionID,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+
+	// GracePeriodSeconds is passed to every Delete (BulkDelete).
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+
+	// Concurrency is the number of targets processed at once. Defaults to 4,
+	// capped at 16.
+	Concurrency int `json:"concurrency,omitempty"`
+}
+
+// BulkItemResult is the outcome of one target.
+type BulkItemResult struct {
+	Index   int                `json:"index"`
+	Target  BulkTarget         `json:"target"`
+	Status  BulkItemStatus     `json:"status"`
+	Message string             `json:"message,omitempty"`
+	Error   *apperror.AppError `json:"error,omitempty"`
+}
+
+// BulkSummary is the state of a bulk operation. Items are in target order.
+type BulkSummary struct {
+	OperationID  string            `json:"operationID"`
+	Operation    BulkOperationType `json:"operation"`
+	PluginID     string            `json:"pluginID"`
+	ConnectionID string            `json:"connectionID"`
+	State        BulkState         `json:"state"`
+	Total        int               `json:"total"`
+	Succeeded    int               `json:"succeeded"`
+	Failed       int               `json:"failed"`
+	Cancelled    int               `json:"cancelled"`
+	Items        []BulkItemResult  `json:"items"`
+	// StartedAt and FinishedAt are RFC 3339 timestamps; FinishedAt is empty
+	// while the operation is running.
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
+
+// BulkEvent is emitted on "bulk/<operationID>". A "progress" event carries
+// the item that just finished; the final "complete" event carries the summary.
+type BulkEvent struct {
+	Type      string          `json:"type"` // "progress", "complete"
+	Item      *BulkItemResult `json:"item,omitempty"`
+	Completed int             `json:"completed"`
+	Total     int             `json:"total"`
+	Summary   *BulkSummary    `json:"summary,omitempty"`
+}
+
+// bulkEventKey returns the emitter key for a bulk operation's events.
+func bulkEventKey(operationID string) string {
+	return "bulk/" + operationID
+}
+
+// validate checks a request before any work is started.
+func (r BulkRequest) validate() error {
+	invalid := func(detail string) error {
+		return apperror.New(apperror.TypeValidation, 400, "Invalid bulk request", detail)
+	}
+	switch r.Operation {
+	case BulkDelete:
+	case BulkPatch:
+		if len(r.Patch) == 0 {
+			return invalid("A patch operation requires a patch.")
+		}
+		var patch map[string]json.RawMessage
+		if err := json.Unmarshal(r.Patch, &patch); err != nil || patch == nil {
+			return invalid("The patch must be a JSON object.")
+		}
+	case BulkAction:
+		if r.ActionID == "" {
+			return invalid("An action operation requires an action ID.")
+		}
+	default:
+		return invalid(fmt.Sprintf("Unknown operation %q (must be \"delete\", \"patch\", or \"action\").", r.Operation))
+	}
+	if len(r.Targets) == 0 {
+		return invalid("At least one target is required.")
+	}
+	for i, t := range r.Targets {
+		if t.ResourceKey == "" || t.ID == "" {
+			return invalid(fmt.Sprintf("Target %d is missing a resource key or ID.", i))
+		}
+	}
+	return nil
+}
+
+func (r BulkRequest) concurrency() int {
+	switch {
+	case r.Concurrency <= 0:
+		return defaultBulkConcurrency
+	case r.Concurrency > maxBulkConcurrency:
+		return maxBulkConcurrency
+	default:
+		return r.Concurrency
+	}
+}
+
+// bulkOperation is a running or finished bulk operation.
+type bulkOperation struct {
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	summary BulkSummary
+}
+
+// snapshot returns a copy of the summary that is safe to hand out.
+func (op *bulkOperation) snapshot() BulkSummary {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	s := op.summary
+	s.Items = append([]BulkItemResult(nil), op.summary.Items...)
+	return s
+}
+
+// finish records an item outcome and returns it with the completed count.
+func (op *bulkOperation) finish(item BulkItemResult) (BulkItemResult, int) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.summary.Items[item.Index] = item
+	switch item.Status {
+	case BulkItemSucceeded:
+		op.summary.Succeeded++
+	case BulkItemFailed:
+		op.summary.Failed++
+	case BulkItemCancelled:
+		op.summary.Cancelled++
+	}
+	return item, op.summary.Succeeded + op.summary.Failed + op.summary.Cancelled
+}
+
+// bulkManager tracks bulk operations by ID.
+type bulkManager = opTable[*bulkOperation]
+
+func newBulkManager() *bulkManager {
+	return newOpTable[*bulkOperation](maxRetainedBulkOperations)
+}
+
+// runBulk processes every target of op with bounded concurrency, emitting a
+// progress event per item and a final complete event. Once ctx is cancelled
+// no new items are started and the remaining ones are reported as cancelled.
+func (c *controller) runBulk(ctx context.Context, operationID string, op *bulkOperation, pluginID, connectionID string, req BulkRequest) {
+	defer op.cancel()
+	eventKey := bulkEventKey(operationID)
+	total := len(req.Targets)
+
+	report := func(item BulkItemResult) {
+		item, completed := op.finish(item)
+		c.emitter.Emit(eventKey, BulkEvent{Type: "progress", Item: &item, Completed: completed, Total: total})
+	}
+
+	sem := make(chan struct{}, req.concurrency())
+	var wg sync.WaitGroup
+	for i, target := range req.Targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			report(BulkItemResult{Index: i, Target: target, Status: BulkItemCancelled, Error: apperror.Cancelled()})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			report(c.runBulkItem(ctx, pluginID, connectionID, req, i, target))
+		}()
+	}
+	wg.Wait()
+
+	op.mu.Lock()
+	op.summary.State = BulkCompleted
+	if ctx.Err() != nil {
+		op.summary.State = BulkCancelled
+	}
+	op.summary.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	op.mu.Unlock()
+
+	c.bulk.markFinished(operationID)
+	summary := op.snapshot()
+	c.emitter.Emit(eventKey, BulkEvent{Type: "complete", Completed: total, Total: total, Summary: &summary})
+}
+
+// runBulkItem applies the request's operation to a single target.
+func (c *controller) runBulkItem(ctx context.Context, pluginID, connectionID string, req BulkRequest, index int, target BulkTarget) BulkItemResult {
+	item := BulkItemResult{Index: index, Target: target, Status: BulkItemSucceeded}
+
+	var err error
+	switch req.Operation {
+	case BulkDelete:
+		_, err = c.deleteResource(ctx, pluginID, connectionID, target.ResourceKey, resource.DeleteInput{
+			ID: target.ID, Namespace: target.Namespace, GracePeriodSeconds: req.GracePeriodSeconds,
+		})
+	case BulkPatch:
+		err = c.patchResource(ctx, pluginID, connectionID, target, req.Patch)
+	case BulkAction:
+		var result *resource.ActionResult
+		result, err = c.executeAction(ctx, pluginID, connectionID, target.ResourceKey, req.ActionID, resource.ActionInput{
+			ID: target.ID, Namespace: target.Namespace, Params: req.Params,
+		})
+		if err == nil && result != nil {
+			item.Message = result.Message
+			if !result.Success {
+				item.Status = BulkItemFailed
+				item.Error = apperror.New(apperror.TypeInternal, 500, "Action failed", result.Message)
+			}
+		}
+	}
+	if err != nil {
+		item.Status = BulkItemFailed
+		if ctx.Err() != nil {
+			item.Status = BulkItemCancelled
+			item.Error = apperror.Cancelled()
+			return item
+		}
+		var appErr *apperror.AppError
+		if !errors.As(err, &appErr) {
+			appErr = apperror.Internal(err, "Bulk operation failed")
+		}
+		item.Error = appErr
+	}
+	return item
+}
+
+// patchResource fetches a target, applies a merge patch to it and updates it
+// with the result, so fields the patch does not mention are kept.
+func (c *controller) patchResource(ctx context.Context, pluginID, connectionID string, target BulkTarget, patch json.RawMessage) error {
+	current, err := c.getResource(ctx, pluginID, connectionID, target.ResourceKey, resource.GetInput{
+		ID: target.ID, Namespace: target.Namespace,
+	})
+	if err != nil {
+		return err
+	}
+	if current == nil || len(current.Result) == 0 {
+		return apperror.NotFound("Resource not found", fmt.Sprintf("%s %q returned no data to patch.", target.ResourceKey, target.ID))
+	}
+	merged, err := mergePatch(current.Result, patch)
+	if err != nil {
+		return apperror.Internal(err, "Failed to apply patch")
+	}
+	_, err = c.updateResource(ctx, pluginID, connectionID, target.ResourceKey, resource.UpdateInput{
+		ID: target.ID, Namespace: target.Namespace, Input: merged,
+	})
+	return err
+}
+
+// mergePatch applies an RFC 7386 JSON merge patch to a document: objects are
+// merged recursively, null removes a field and any other value replaces it.
+// Numbers are kept as written so integers beyond float64 precision survive.
+func mergePatch(original, patch json.RawMessage) (json.RawMessage, error) {
+	doc, err := decodeJSONValue(original)
+	if err != nil {
+		return nil, fmt.Errorf("decoding resource: %w", err)
+	}
+	p, err := decodeJSONValue(patch)
+	if err != nil {
+		return nil, fmt.Errorf("decoding patch: %w", err)
+	}
+	return json.Marshal(mergeValue(doc, p))
+}
+
+// decodeJSONValue decodes a single JSON value with numbers as json.Number.
+func decodeJSONValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{}, len(p))
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergeValue(t[key], value)
+	}
+	return t
+}
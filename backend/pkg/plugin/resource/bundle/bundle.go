@@ -0,0 +1,251 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Bundle header values.
+const (
+	APIVersion = "omniview.dev/v1"
+	Kind       = "ResourceBundle"
+)
+
+// Format is the serialization of a bundle.
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Metadata records where a bundle was exported from.
+type Metadata struct {
+	PluginID     string    `json:"pluginID" yaml:"pluginID"`
+	ConnectionID string    `json:"connectionID" yaml:"connectionID"`
+	ExportedAt   time.Time `json:"exportedAt" yaml:"exportedAt"`
+}
+
+// Item is one exported resource. Data is the resource payload with
+// server-managed fields stripped.
+type Item struct {
+	ResourceKey string         `json:"resourceKey" yaml:"resourceKey"`
+	Namespace   string         `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	ID          string         `json:"id,omitempty" yaml:"id,omitempty"`
+	Data        map[string]any `json:"data" yaml:"data"`
+}
+
+// Bundle is a portable set of resources that can be re-applied to another
+// connection.
+type Bundle struct {
+	APIVersion string   `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string   `json:"kind" yaml:"kind"`
+	Metadata   Metadata `json:"metadata" yaml:"metadata"`
+	Items      []Item   `json:"items" yaml:"items"`
+}
+
+// New returns an empty bundle for the given source connection.
+func New(pluginID, connectionID string, exportedAt time.Time) *Bundle {
+	return &Bundle{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Metadata:   Metadata{PluginID: pluginID, ConnectionID: connectionID, ExportedAt: exportedAt},
+		Items:      []Item{},
+	}
+}
+
+// Encode serializes the bundle as YAML or indented JSON.
+func (b *Bundle) Encode(format Format) (string, error) {
+	switch format {
+	case FormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(b); err != nil {
+			return "", err
+		}
+		if err := enc.Close(); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	case FormatJSON:
+		out, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	default:
+		return "", fmt.Errorf("unsupported bundle format: %q (must be \"yaml\" or \"json\")", format)
+	}
+}
+
+// Decode parses a YAML or JSON bundle and validates its header and items.
+func Decode(data string) (*Bundle, error) {
+	var b Bundle
+	if strings.HasPrefix(strings.TrimSpace(data), "{") {
+		if err := json.Unmarshal([]byte(data), &b); err != nil {
+			return nil, fmt.Errorf("parse JSON bundle: %w", err)
+		}
+	} else if err := yaml.Unmarshal([]byte(data), &b); err != nil {
+		return nil, fmt.Errorf("parse YAML bundle: %w", err)
+	}
+
+	if b.APIVersion != APIVersion || b.Kind != Kind {
+		return nil, fmt.Errorf("not a resource bundle: expected %s %s, got %q %q", APIVersion, Kind, b.APIVersion, b.Kind)
+	}
+	for i, item := range b.Items {
+		if item.ResourceKey == "" {
+			return nil, fmt.Errorf("item %d: missing resourceKey", i)
+		}
+		if item.Data == nil {
+			return nil, fmt.Errorf("item %d: missing data", i)
+		}
+	}
+	return &b, nil
+}
+
+// ============================================================================
+// Payload paths
+// ============================================================================
+
+// Paths locates identity fields within resource payloads, as dot-separated
+// object paths.
+type Paths struct {
+	ID        string `json:"id,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Labels    string `json:"labels,omitempty"`
+}
+
+// DefaultPaths matches Kubernetes-style object metadata.
+var DefaultPaths = Paths{
+	ID:        "metadata.name",
+	Namespace: "metadata.namespace",
+	Labels:    "metadata.labels",
+}
+
+// WithDefaults fills unset paths from DefaultPaths.
+func (p Paths) WithDefaults() Paths {
+	if p.ID == "" {
+		p.ID = DefaultPaths.ID
+	}
+	if p.Namespace == "" {
+		p.Namespace = DefaultPaths.Namespace
+	}
+	if p.Labels == "" {
+		p.Labels = DefaultPaths.Labels
+	}
+	return p
+}
+
+// DefaultStripFields are server-managed fields removed from exported
+// payloads so they can be created on another connection. Paths that do not
+// exist in a payload are ignored.
+var DefaultStripFields = []string{
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.managedFields",
+	"metadata.selfLink",
+	"status",
+}
+
+// Lookup returns the value at a dot-separated path.
+func Lookup(data map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	var cur any = data
+	for _, part := range parts {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// LookupString returns the string at path, or "" if it is missing or not a
+// string.
+func LookupString(data map[string]any, path string) string {
+	v, _ := Lookup(data, path)
+	s, _ := v.(string)
+	return s
+}
+
+// LookupLabels returns the string-valued entries of the object at path.
+func LookupLabels(data map[string]any, path string) map[string]string {
+	v, _ := Lookup(data, path)
+	obj, _ := v.(map[string]any)
+	labels := make(map[string]string, len(obj))
+	for k, v := range obj {
+		if s, ok := v.(string); ok {
+			labels[k] = s
+		}
+	}
+	return labels
+}
+
+// Set assigns value at path if every parent object exists. It reports
+// whether the value was set.
+func Set(data map[string]any, path string, value any) bool {
+	parts := strings.Split(path, ".")
+	obj := data
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := obj[part].(map[string]any)
+		if !ok {
+			return false
+		}
+		obj = next
+	}
+	obj[parts[len(parts)-1]] = value
+	return true
+}
+
+// Strip removes the given paths from data in place.
+func Strip(data map[string]any, paths []string) {
+	for _, path := range paths {
+		parts := strings.Split(path, ".")
+		obj := data
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := obj[part].(map[string]any)
+			if !ok {
+				obj = nil
+				break
+			}
+			obj = next
+		}
+		if obj != nil {
+			delete(obj, parts[len(parts)-1])
+		}
+	}
+}
+
+// MatchLabels reports whether labels contains every key/value of selector.
+func MatchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// DecodePayload decodes a JSON resource payload into an object.
+func DecodePayload(raw json.RawMessage) (map[string]any, error) {
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, errors.New("payload is not a JSON object")
+	}
+	return data, nil
+}
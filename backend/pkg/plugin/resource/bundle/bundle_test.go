@@ -0,0 +1,112 @@
+package bundle
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBundle() *Bundle {
+	b := New("kubernetes", "prod", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	b.Items = append(b.Items, Item{
+		ResourceKey: "core::v1::ConfigMap",
+		Namespace:   "default",
+		ID:          "app-config",
+		Data: map[string]any{
+			"metadata": map[string]any{"name": "app-config", "namespace": "default"},
+			"data":     map[string]any{"LOG_LEVEL": "debug", "REPLICAS": float64(3)},
+		},
+	})
+	return b
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatYAML, FormatJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			b := testBundle()
+			out, err := b.Encode(format)
+			require.NoError(t, err)
+
+			got, err := Decode(out)
+			require.NoError(t, err)
+			assert.Equal(t, b.Metadata, got.Metadata)
+			require.Len(t, got.Items, 1)
+
+			// Compare payloads through JSON, since YAML decodes integers as int.
+			want, _ := json.Marshal(b.Items[0].Data)
+			have, _ := json.Marshal(got.Items[0].Data)
+			assert.JSONEq(t, string(want), string(have))
+			assert.Equal(t, "app-config", got.Items[0].ID)
+		})
+	}
+}
+
+func TestEncode_UnknownFormat(t *testing.T) {
+	_, err := testBundle().Encode("toml")
+	assert.Error(t, err)
+}
+
+func TestDecode_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"wrong kind":     "apiVersion: omniview.dev/v1\nkind: Other\nitems: []\n",
+		"missing key":    "apiVersion: omniview.dev/v1\nkind: ResourceBundle\nitems:\n- data: {}\n",
+		"missing data":   `{"apiVersion":"omniview.dev/v1","kind":"ResourceBundle","items":[{"resourceKey":"a"}]}`,
+		"malformed json": `{"apiVersion":`,
+		"malformed yaml": "apiVersion: [",
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Decode(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPaths(t *testing.T) {
+	data := map[string]any{
+		"metadata": map[string]any{
+			"name":            "web",
+			"uid":             "u1",
+			"resourceVersion": "42",
+			"labels":          map[string]any{"app": "web", "tier": "frontend", "n": float64(1)},
+		},
+		"status": map[string]any{"phase": "Running"},
+	}
+
+	p := Paths{ID: "metadata.name"}.WithDefaults()
+	assert.Equal(t, DefaultPaths, p)
+	assert.Equal(t, "web", LookupString(data, p.ID))
+	assert.Equal(t, "", LookupString(data, p.Namespace))
+	assert.Equal(t, map[string]string{"app": "web", "tier": "frontend"}, LookupLabels(data, p.Labels))
+
+	assert.True(t, MatchLabels(LookupLabels(data, p.Labels), map[string]string{"app": "web"}))
+	assert.True(t, MatchLabels(LookupLabels(data, p.Labels), nil))
+	assert.False(t, MatchLabels(LookupLabels(data, p.Labels), map[string]string{"app": "api"}))
+
+	assert.True(t, Set(data, "metadata.namespace", "staging"))
+	assert.False(t, Set(data, "spec.replicas", 2))
+	assert.Equal(t, "staging", LookupString(data, "metadata.namespace"))
+
+	Strip(data, append(DefaultStripFields, "spec.missing.path"))
+	assert.Equal(t, map[string]any{
+		"metadata": map[string]any{
+			"name":      "web",
+			"namespace": "staging",
+			"labels":    map[string]any{"app": "web", "tier": "frontend", "n": float64(1)},
+		},
+	}, data)
+}
+
+func TestDecodePayload(t *testing.T) {
+	data, err := DecodePayload(json.RawMessage(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": float64(1)}, data)
+
+	_, err = DecodePayload(json.RawMessage(`null`))
+	assert.Error(t, err)
+	_, err = DecodePayload(json.RawMessage(`[1]`))
+	assert.Error(t, err)
+}
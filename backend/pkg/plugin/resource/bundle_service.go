@@ -0,0 +1,303 @@
+package resource
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/bundle"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
+)
+
+// ExportSelection selects the resources written to a bundle.
+type ExportSelection struct {
+	// ResourceKeys are the resource types to export. Required.
+	ResourceKeys []string `json:"resourceKeys"`
+	// Namespaces limits the export to these namespaces; empty exports all.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// LabelSelector keeps only resources carrying every key/value pair.
+	LabelSelector map[string]string `json:"labelSelector,omitempty"`
+	// Paths locates name, namespace and labels in payloads. Unset paths
+	// default to Kubernetes-style metadata.
+	Paths bundle.Paths `json:"paths,omitempty"`
+	// StripFields are payload paths removed before writing. Defaults to
+	// bundle.DefaultStripFields.
+	StripFields []string `json:"stripFields,omitempty"`
+}
+
+// ConflictPolicy decides what an import does with a resource that already
+// exists on the target connection with different contents.
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictFail      ConflictPolicy = "fail"
+)
+
+// ImportOptions controls how a bundle is applied.
+type ImportOptions struct {
+	// DryRun plans every item without calling Create or Update.
+	DryRun bool `json:"dryRun"`
+	// Conflict defaults to ConflictSkip.
+	Conflict ConflictPolicy `json:"conflict,omitempty"`
+	// Namespace, if set, replaces the namespace of every namespaced item.
+	Namespace string `json:"namespace,omitempty"`
+	// Paths locates name and namespace in payloads, as for export.
+	Paths bundle.Paths `json:"paths,omitempty"`
+}
+
+// ImportAction is what an import does (or would do) with an item.
+type ImportAction string
+
+const (
+	ImportCreate ImportAction = "create"
+	ImportUpdate ImportAction = "update"
+	ImportSkip   ImportAction = "skip"
+)
+
+// ImportItemStatus is the outcome of an imported item.
+type ImportItemStatus string
+
+const (
+	ImportPlanned   ImportItemStatus = "planned"
+	ImportSucceeded ImportItemStatus = "succeeded"
+	ImportSkipped   ImportItemStatus = "skipped"
+	ImportFailed    ImportItemStatus = "failed"
+)
+
+// ImportItemResult is the outcome of one bundle item.
+type ImportItemResult struct {
+	Index       int              `json:"index"`
+	ResourceKey string           `json:"resourceKey"`
+	Namespace   string           `json:"namespace,omitempty"`
+	ID          string           `json:"id,omitempty"`
+	Action      ImportAction     `json:"action"`
+	Status      ImportItemStatus `json:"status"`
+	// Reason explains a skip.
+	Reason string `json:"reason,omitempty"`
+	// Changes is the difference between the live resource and the bundle
+	// item, for items that already exist.
+	Changes []history.Change   `json:"changes,omitempty"`
+	Error   *apperror.AppError `json:"error,omitempty"`
+}
+
+// ImportReport summarizes an import. Items are in bundle order.
+type ImportReport struct {
+	DryRun  bool               `json:"dryRun"`
+	Created int                `json:"created"`
+	Updated int                `json:"updated"`
+	Skipped int                `json:"skipped"`
+	Failed  int                `json:"failed"`
+	Items   []ImportItemResult `json:"items"`
+}
+
+// BundleService exports resources from a connection as portable YAML or JSON
+// bundles and replays them onto another connection. Exposed to the frontend
+// via Wails binding.
+type BundleService struct {
+	ctrl Controller
+}
+
+// NewBundleService creates a new BundleService.
+func NewBundleService(ctrl Controller) *BundleService {
+	return &BundleService{ctrl: ctrl}
+}
+
+// ExportResources lists the selected resources and returns them as a bundle
+// encoded in format ("yaml" or "json"). Items are sorted by resource key,
+// namespace and ID.
+func (s *BundleService) ExportResources(pluginID, connectionID string, selection ExportSelection, format bundle.Format) (string, error) {
+	if format != bundle.FormatYAML && format != bundle.FormatJSON {
+		return "", apperror.New(apperror.TypeValidation, 400, "Invalid bundle format",
+			fmt.Sprintf("Unknown format %q (must be \"yaml\" or \"json\").", format))
+	}
+	if len(selection.ResourceKeys) == 0 {
+		return "", apperror.New(apperror.TypeValidation, 400, "Invalid export selection",
+			"At least one resource key is required.")
+	}
+	if !s.ctrl.HasPlugin(pluginID) {
+		return "", apperror.PluginNotFound(pluginID)
+	}
+
+	paths := selection.Paths.WithDefaults()
+	strip := selection.StripFields
+	if strip == nil {
+		strip = bundle.DefaultStripFields
+	}
+
+	b := bundle.New(pluginID, connectionID, time.Now().UTC())
+	for _, rk := range selection.ResourceKeys {
+		result, err := s.ctrl.List(pluginID, connectionID, rk, resource.ListInput{Namespaces: selection.Namespaces})
+		if err != nil {
+			return "", err
+		}
+		for _, raw := range result.Result {
+			data, err := bundle.DecodePayload(raw)
+			if err != nil {
+				return "", apperror.Internal(err, fmt.Sprintf("Failed to decode %s payload", rk))
+			}
+			if !bundle.MatchLabels(bundle.LookupLabels(data, paths.Labels), selection.LabelSelector) {
+				continue
+			}
+			bundle.Strip(data, strip)
+			b.Items = append(b.Items, bundle.Item{
+				ResourceKey: rk,
+				Namespace:   bundle.LookupString(data, paths.Namespace),
+				ID:          bundle.LookupString(data, paths.ID),
+				Data:        data,
+			})
+		}
+	}
+	slices.SortFunc(b.Items, func(a, b bundle.Item) int {
+		return cmp.Or(
+			cmp.Compare(a.ResourceKey, b.ResourceKey),
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	out, err := b.Encode(format)
+	if err != nil {
+		return "", apperror.Internal(err, "Failed to encode bundle")
+	}
+	return out, nil
+}
+
+// ImportResources applies a YAML or JSON bundle to a connection. Each item is
+// looked up with Get: missing items are created, and items that exist with
+// different contents are handled according to opts.Conflict. With DryRun set
+// nothing is written and the report describes what would happen.
+//
+// A failed item does not stop the import; its error is recorded in the report.
+func (s *BundleService) ImportResources(pluginID, connectionID, data string, opts ImportOptions) (*ImportReport, error) {
+	switch opts.Conflict {
+	case "":
+		opts.Conflict = ConflictSkip
+	case ConflictSkip, ConflictOverwrite, ConflictFail:
+	default:
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid import options",
+			fmt.Sprintf("Unknown conflict policy %q (must be \"skip\", \"overwrite\", or \"fail\").", opts.Conflict))
+	}
+	b, err := bundle.Decode(data)
+	if err != nil {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid bundle", err.Error())
+	}
+	if !s.ctrl.HasPlugin(pluginID) {
+		return nil, apperror.PluginNotFound(pluginID)
+	}
+
+	paths := opts.Paths.WithDefaults()
+	report := &ImportReport{DryRun: opts.DryRun, Items: make([]ImportItemResult, 0, len(b.Items))}
+	for i, item := range b.Items {
+		result := s.importItem(pluginID, connectionID, i, item, opts, paths)
+		switch {
+		case result.Status == ImportFailed:
+			report.Failed++
+		case result.Status == ImportSkipped:
+			report.Skipped++
+		case result.Action == ImportCreate:
+			report.Created++
+		case result.Action == ImportUpdate:
+			report.Updated++
+		}
+		report.Items = append(report.Items, result)
+	}
+	return report, nil
+}
+
+// importItem plans and, unless this is a dry run, applies one bundle item.
+func (s *BundleService) importItem(pluginID, connectionID string, index int, item bundle.Item, opts ImportOptions, paths bundle.Paths) ImportItemResult {
+	namespace := item.Namespace
+	if opts.Namespace != "" && namespace != "" {
+		namespace = opts.Namespace
+		bundle.Set(item.Data, paths.Namespace, namespace)
+	}
+	result := ImportItemResult{Index: index, ResourceKey: item.ResourceKey, Namespace: namespace, ID: item.ID}
+	fail := func(err error) ImportItemResult {
+		result.Status = ImportFailed
+		var appErr *apperror.AppError
+		if !errors.As(err, &appErr) {
+			appErr = apperror.Internal(err, "Import failed")
+		}
+		result.Error = appErr
+		return result
+	}
+
+	payload, err := json.Marshal(item.Data)
+	if err != nil {
+		result.Action = ImportCreate
+		return fail(err)
+	}
+
+	var live *resource.GetResult
+	if item.ID != "" {
+		live, err = s.ctrl.Get(pluginID, connectionID, item.ResourceKey, resource.GetInput{ID: item.ID, Namespace: namespace})
+		var appErr *apperror.AppError
+		if err != nil && !(errors.As(err, &appErr) && appErr.Type == apperror.TypeResourceNotFound) {
+			result.Action = ImportCreate
+			return fail(err)
+		}
+	}
+
+	if live == nil || len(live.Result) == 0 {
+		result.Action = ImportCreate
+		if opts.DryRun {
+			result.Status = ImportPlanned
+			return result
+		}
+		if _, err := s.ctrl.Create(pluginID, connectionID, item.ResourceKey, resource.CreateInput{Input: payload, Namespace: namespace}); err != nil {
+			return fail(err)
+		}
+		result.Status = ImportSucceeded
+		return result
+	}
+
+	// Compare against the live object with the same server-managed fields
+	// stripped as on export, so only meaningful differences count.
+	current, err := bundle.DecodePayload(live.Result)
+	if err != nil {
+		result.Action = ImportUpdate
+		return fail(err)
+	}
+	bundle.Strip(current, bundle.DefaultStripFields)
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		result.Action = ImportUpdate
+		return fail(err)
+	}
+	if result.Changes, err = history.Diff(currentJSON, payload); err != nil {
+		result.Action = ImportUpdate
+		return fail(err)
+	}
+
+	switch {
+	case len(result.Changes) == 0:
+		result.Action, result.Status, result.Reason = ImportSkip, ImportSkipped, "Already up to date."
+		return result
+	case opts.Conflict == ConflictSkip:
+		result.Action, result.Status, result.Reason = ImportSkip, ImportSkipped, "Exists with different contents."
+		return result
+	case opts.Conflict == ConflictFail:
+		result.Action = ImportUpdate
+		return fail(apperror.New(apperror.TypeResourceConflict, 409, "Resource already exists",
+			fmt.Sprintf("%s %q already exists with different contents.", item.ResourceKey, item.ID)))
+	}
+
+	result.Action = ImportUpdate
+	if opts.DryRun {
+		result.Status = ImportPlanned
+		return result
+	}
+	if _, err := s.ctrl.Update(pluginID, connectionID, item.ResourceKey, resource.UpdateInput{Input: payload, ID: item.ID, Namespace: namespace}); err != nil {
+		return fail(err)
+	}
+	result.Status = ImportSucceeded
+	return result
+}
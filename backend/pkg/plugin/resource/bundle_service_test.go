@@ -0,0 +1,233 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/bundle"
+)
+
+// bundleFixture is a plugin backed by an in-memory set of ConfigMaps keyed
+// by "namespace/name".
+type bundleFixture struct {
+	svc *BundleService
+
+	mu      sync.Mutex
+	objects map[string]string
+	creates []string
+	updates []string
+}
+
+func newBundleFixture(t *testing.T) *bundleFixture {
+	t.Helper()
+	ctrl, _ := newTestControllerWithEmitter(t)
+	f := &bundleFixture{svc: NewBundleService(ctrl), objects: make(map[string]string)}
+
+	notFound := &resource.ResourceOperationError{Code: "NOT_FOUND", Title: "Not found", Message: "not found"}
+	nameOf := func(input json.RawMessage) string {
+		data, err := bundle.DecodePayload(input)
+		require.NoError(t, err)
+		return bundle.LookupString(data, "metadata.namespace") + "/" + bundle.LookupString(data, "metadata.name")
+	}
+	registerMockPlugin(ctrl, "plugin-a", &mockProvider{
+		ListFunc: func(_ context.Context, _ string, input resource.ListInput) (*resource.ListResult, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			result := &resource.ListResult{Success: true}
+			for _, obj := range f.objects {
+				data, _ := bundle.DecodePayload(json.RawMessage(obj))
+				ns := bundle.LookupString(data, "metadata.namespace")
+				if len(input.Namespaces) > 0 && !slices.Contains(input.Namespaces, ns) {
+					continue
+				}
+				result.Result = append(result.Result, json.RawMessage(obj))
+			}
+			return result, nil
+		},
+		GetFunc: func(_ context.Context, _ string, input resource.GetInput) (*resource.GetResult, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			obj, ok := f.objects[input.Namespace+"/"+input.ID]
+			if !ok {
+				return nil, notFound
+			}
+			return &resource.GetResult{Success: true, Result: json.RawMessage(obj)}, nil
+		},
+		CreateFunc: func(_ context.Context, _ string, input resource.CreateInput) (*resource.CreateResult, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			name := nameOf(input.Input)
+			f.objects[name] = string(input.Input)
+			f.creates = append(f.creates, name)
+			return &resource.CreateResult{Success: true, Result: input.Input}, nil
+		},
+		UpdateFunc: func(_ context.Context, _ string, input resource.UpdateInput) (*resource.UpdateResult, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			name := input.Namespace + "/" + input.ID
+			f.objects[name] = string(input.Input)
+			f.updates = append(f.updates, name)
+			return &resource.UpdateResult{Success: true, Result: input.Input}, nil
+		},
+	})
+	return f
+}
+
+func (f *bundleFixture) put(namespace, name, app, level string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[namespace+"/"+name] = `{"metadata":{"name":"` + name + `","namespace":"` + namespace +
+		`","uid":"uid-` + name + `","resourceVersion":"7","labels":{"app":"` + app + `"}},"data":{"level":"` + level + `"}}`
+}
+
+func TestBundleService_Export(t *testing.T) {
+	f := newBundleFixture(t)
+	f.put("default", "web", "web", "info")
+	f.put("default", "api", "api", "info")
+	f.put("kube-system", "dns", "web", "warn")
+
+	out, err := f.svc.ExportResources("plugin-a", "conn-1", ExportSelection{
+		ResourceKeys:  []string{"core::v1::ConfigMap"},
+		Namespaces:    []string{"default"},
+		LabelSelector: map[string]string{"app": "web"},
+	}, bundle.FormatYAML)
+	require.NoError(t, err)
+
+	b, err := bundle.Decode(out)
+	require.NoError(t, err)
+	assert.Equal(t, "plugin-a", b.Metadata.PluginID)
+	assert.Equal(t, "conn-1", b.Metadata.ConnectionID)
+	require.Len(t, b.Items, 1)
+	item := b.Items[0]
+	assert.Equal(t, "core::v1::ConfigMap", item.ResourceKey)
+	assert.Equal(t, "default", item.Namespace)
+	assert.Equal(t, "web", item.ID)
+	assert.Equal(t, "", bundle.LookupString(item.Data, "metadata.uid"), "server-managed fields are stripped")
+	assert.Equal(t, "", bundle.LookupString(item.Data, "metadata.resourceVersion"))
+	assert.Equal(t, "info", bundle.LookupString(item.Data, "data.level"))
+}
+
+func TestBundleService_Export_Validation(t *testing.T) {
+	f := newBundleFixture(t)
+
+	_, err := f.svc.ExportResources("plugin-a", "conn-1", ExportSelection{}, bundle.FormatJSON)
+	assertAppErrorType(t, err, apperror.TypeValidation)
+
+	_, err = f.svc.ExportResources("plugin-a", "conn-1", ExportSelection{ResourceKeys: []string{"a"}}, "xml")
+	assertAppErrorType(t, err, apperror.TypeValidation)
+
+	_, err = f.svc.ExportResources("missing", "conn-1", ExportSelection{ResourceKeys: []string{"a"}}, bundle.FormatJSON)
+	assertAppErrorType(t, err, apperror.TypePluginNotFound)
+}
+
+func TestBundleService_Import(t *testing.T) {
+	src := newBundleFixture(t)
+	src.put("default", "web", "web", "debug")
+	src.put("default", "api", "api", "info")
+	src.put("default", "same", "same", "info")
+	exported, err := src.svc.ExportResources("plugin-a", "prod", ExportSelection{
+		ResourceKeys: []string{"core::v1::ConfigMap"},
+	}, bundle.FormatJSON)
+	require.NoError(t, err)
+
+	newTarget := func() *bundleFixture {
+		dst := newBundleFixture(t)
+		dst.put("default", "web", "web", "info")   // exists with different contents
+		dst.put("default", "same", "same", "info") // exists and matches
+		return dst
+	}
+
+	t.Run("dry run plans without writing", func(t *testing.T) {
+		dst := newTarget()
+		report, err := dst.svc.ImportResources("plugin-a", "staging", exported, ImportOptions{DryRun: true, Conflict: ConflictOverwrite})
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, 1, report.Skipped)
+		assert.Empty(t, dst.creates)
+		assert.Empty(t, dst.updates)
+
+		byID := importItemsByID(report)
+		assert.Equal(t, ImportCreate, byID["api"].Action)
+		assert.Equal(t, ImportPlanned, byID["api"].Status)
+		assert.Equal(t, ImportUpdate, byID["web"].Action)
+		require.Len(t, byID["web"].Changes, 1)
+		assert.Equal(t, "/data/level", byID["web"].Changes[0].Path)
+		assert.Equal(t, ImportSkipped, byID["same"].Status)
+	})
+
+	t.Run("skip leaves conflicting resources alone", func(t *testing.T) {
+		dst := newTarget()
+		report, err := dst.svc.ImportResources("plugin-a", "staging", exported, ImportOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 2, report.Skipped)
+		assert.Equal(t, []string{"default/api"}, dst.creates)
+		assert.Empty(t, dst.updates)
+	})
+
+	t.Run("overwrite updates conflicting resources", func(t *testing.T) {
+		dst := newTarget()
+		report, err := dst.svc.ImportResources("plugin-a", "staging", exported, ImportOptions{Conflict: ConflictOverwrite})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		assert.Equal(t, []string{"default/web"}, dst.updates)
+		assert.Contains(t, dst.objects["default/web"], `"level":"debug"`)
+	})
+
+	t.Run("fail reports conflicts per item", func(t *testing.T) {
+		dst := newTarget()
+		report, err := dst.svc.ImportResources("plugin-a", "staging", exported, ImportOptions{Conflict: ConflictFail})
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, 1, report.Created)
+		web := importItemsByID(report)["web"]
+		require.NotNil(t, web.Error)
+		assert.Equal(t, apperror.TypeResourceConflict, web.Error.Type)
+	})
+
+	t.Run("namespace override", func(t *testing.T) {
+		dst := newTarget()
+		report, err := dst.svc.ImportResources("plugin-a", "staging", exported, ImportOptions{Namespace: "qa"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Created)
+		assert.ElementsMatch(t, []string{"qa/api", "qa/same", "qa/web"}, dst.creates)
+		assert.Contains(t, dst.objects["qa/web"], `"namespace":"qa"`)
+	})
+}
+
+func TestBundleService_Import_Validation(t *testing.T) {
+	f := newBundleFixture(t)
+
+	_, err := f.svc.ImportResources("plugin-a", "conn-1", "kind: Nope\n", ImportOptions{})
+	assertAppErrorType(t, err, apperror.TypeValidation)
+
+	_, err = f.svc.ImportResources("plugin-a", "conn-1", "", ImportOptions{Conflict: "merge"})
+	assertAppErrorType(t, err, apperror.TypeValidation)
+}
+
+func importItemsByID(report *ImportReport) map[string]ImportItemResult {
+	items := make(map[string]ImportItemResult, len(report.Items))
+	for _, item := range report.Items {
+		items[item.ID] = item
+	}
+	return items
+}
+
+func assertAppErrorType(t *testing.T, err error, want string) {
+	t.Helper()
+	var appErr *apperror.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, want, appErr.Type)
+}
@@ -23,8 +23,10 @@ import (
 
 	"github.com/omniviewdev/omniview/backend/pkg/apperror"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/graph"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/indexer"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/search"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/telemetryutil"
 	plugintypes "github.com/omniviewdev/omniview/backend/pkg/plugin/types"
 	"github.com/omniviewdev/omniview/internal/appstate"
@@ -41,6 +43,9 @@ const PluginName = "resource"
 const (
 	EventConnectionStatus = "connection/status"
 	EventWatchState       = "watch/STATE"
+	// EventActionOperation carries an OperationInfo whenever a streaming
+	// action starts or finishes.
+	EventActionOperation = "action/operation"
 )
 
 // ConnectionStatusPayload is emitted when a connection's status changes.
@@ -64,6 +69,7 @@ type Controller interface {
 	plugintypes.ConnectedController
 	Service
 	SetCrashCallback(cb func(pluginID string))
+	SetConnectionStatusCallback(cb func(status ConnectionStatusPayload))
 	Graph() *graph.RelationshipGraph
 }
 
@@ -91,15 +97,27 @@ type controller struct {
 	autoConnectMu       sync.Mutex
 	autoConnectAttempts map[string]string
 
-	subs *subscriptionManager
+	subs       *subscriptionManager
+	bulk       *bulkManager
+	operations *opTable[*actionOperation]
 
 	// Resource registry and relationship graph
 	registryStore registry.RegistryStore
 	dispatcher    *indexer.Dispatcher
 	graph         *graph.RelationshipGraph
+	searchIndex   *search.Indexer
+
+	// Revision history (optional). Fed only by watch events, on its own
+	// dispatcher so disk writes never delay the graph and search indexers.
+	historyStore      *history.Store
+	historyDispatcher *indexer.Dispatcher
+	historyPruneStop  chan struct{}
+	historyPruneDone  chan struct{}
 
 	onCrashCallback func(pluginID string)
 	pluginStoreFn   func(pluginID string) (*appstate.ScopedRoot, error)
+
+	onConnectionStatus func(status ConnectionStatusPayload)
 }
 
 // compile-time assertions
@@ -108,13 +126,49 @@ var (
 	_ Service    = (*controller)(nil)
 )
 
+// controllerOptions holds optional configuration for NewController.
+type controllerOptions struct {
+	registryStore registry.RegistryStore
+	historyStore  *history.Store
+}
+
+// ControllerOption configures the resource controller.
+type ControllerOption func(*controllerOptions)
+
+// WithRegistryStore sets the backing store for the resource registry.
+// Defaults to an in-memory store. Passing a registry.PersistentStore keeps
+// the last-known inventory across restarts.
+func WithRegistryStore(store registry.RegistryStore) ControllerOption {
+	return func(o *controllerOptions) { o.registryStore = store }
+}
+
+// WithHistoryStore enables per-resource revision history backed by store.
+// The controller takes ownership and closes it on shutdown.
+func WithHistoryStore(store *history.Store) ControllerOption {
+	return func(o *controllerOptions) { o.historyStore = store }
+}
+
 // NewController creates a new resource Controller.
 // pluginStoreFn returns a ScopedRoot for the given plugin's store directory.
-func NewController(logger logging.Logger, sp pkgsettings.Provider, pluginStoreFn func(string) (*appstate.ScopedRoot, error)) Controller {
-	store := registry.NewMemoryStore()
+func NewController(logger logging.Logger, sp pkgsettings.Provider, pluginStoreFn func(string) (*appstate.ScopedRoot, error), opts ...ControllerOption) Controller {
+	var cfg controllerOptions
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	store := cfg.registryStore
+	if store == nil {
+		store = registry.NewMemoryStore()
+	}
 	g := graph.NewRelationshipGraph()
 	graphIndexer := graph.NewGraphIndexer(g, store)
-	dispatcher := indexer.NewDispatcher([]indexer.ResourceIndexer{graphIndexer})
+	searchIndex := search.NewIndexer()
+	dispatcher := indexer.NewDispatcher([]indexer.ResourceIndexer{graphIndexer, searchIndex})
+
+	var historyDispatcher *indexer.Dispatcher
+	if cfg.historyStore != nil {
+		historyDispatcher = indexer.NewDispatcher([]indexer.ResourceIndexer{cfg.historyStore})
+	}
 
 	return &controller{
 		logger:              logger.Named("ResourceController"),
@@ -123,9 +177,14 @@ func NewController(logger logging.Logger, sp pkgsettings.Provider, pluginStoreFn
 		connections:         make(map[string][]types.Connection),
 		autoConnectAttempts: make(map[string]string),
 		subs:                newSubscriptionManager(),
+		bulk:                newBulkManager(),
+		operations:          newOpTable[*actionOperation](maxRetainedOperations),
 		registryStore:       store,
 		dispatcher:          dispatcher,
 		graph:               g,
+		searchIndex:         searchIndex,
+		historyStore:        cfg.historyStore,
+		historyDispatcher:   historyDispatcher,
 		pluginStoreFn:       pluginStoreFn,
 	}
 }
@@ -135,6 +194,23 @@ func (c *controller) SetCrashCallback(cb func(pluginID string)) {
 	c.onCrashCallback = cb
 }
 
+// SetConnectionStatusCallback sets the function called whenever a
+// connection is started or stopped, with the payload of its
+// EventConnectionStatus event.
+func (c *controller) SetConnectionStatusCallback(cb func(status ConnectionStatusPayload)) {
+	c.onConnectionStatus = cb
+}
+
+// emitConnectionStatus emits an EventConnectionStatus event and passes it to
+// the connection status callback. The callback runs synchronously so that a
+// start followed by a stop reaches it in that order.
+func (c *controller) emitConnectionStatus(status ConnectionStatusPayload) {
+	c.emitter.Emit(EventConnectionStatus, status)
+	if c.onConnectionStatus != nil {
+		c.onConnectionStatus(status)
+	}
+}
+
 // Graph returns the underlying RelationshipGraph for use by external services.
 func (c *controller) Graph() *graph.RelationshipGraph {
 	return c.graph
@@ -151,6 +227,10 @@ func (c *controller) Run(ctx context.Context) {
 		c.emitter = NoopEmitter{}
 	}
 	c.dispatcher.Start()
+	if c.historyDispatcher != nil {
+		c.historyDispatcher.Start()
+		c.startHistoryPruner()
+	}
 }
 
 // ServiceStartup is called by the Wails v3 runtime when the application starts.
@@ -158,12 +238,34 @@ func (c *controller) ServiceStartup(ctx context.Context, options application.Ser
 	c.app = application.Get()
 	c.emitter = newAppEmitter(c.app)
 	c.dispatcher.Start()
+	if c.historyDispatcher != nil {
+		c.historyDispatcher.Start()
+		c.startHistoryPruner()
+	}
 	return nil
 }
 
 // ServiceShutdown is called by the Wails v3 runtime when the application shuts down.
 func (c *controller) ServiceShutdown() error {
+	for _, op := range c.operations.list() {
+		op.cancel()
+	}
+	for _, op := range c.bulk.list() {
+		op.cancel()
+	}
 	c.dispatcher.Stop()
+	if ps, ok := c.registryStore.(registry.PersistentStore); ok {
+		if err := ps.Close(); err != nil {
+			c.logger.Errorw(context.Background(), "failed to close registry store", "error", err)
+		}
+	}
+	if c.historyDispatcher != nil {
+		c.stopHistoryPruner()
+		c.historyDispatcher.Stop()
+		if err := c.historyStore.Close(); err != nil {
+			c.logger.Errorw(context.Background(), "failed to close history store", "error", err)
+		}
+	}
 	return nil
 }
 
@@ -258,6 +360,7 @@ func (c *controller) OnPluginStart(pluginID string, meta config.PluginMeta, back
 	// Bootstrap relationship declarations for the graph indexer.
 	bootstrapCtx, bootstrapCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer bootstrapCancel()
+	var matchTargets []graph.MatchTarget
 	if resourceTypes := provider.GetResourceTypes(bootstrapCtx, ""); resourceTypes != nil {
 		for rk := range resourceTypes {
 			decls, err := provider.GetRelationships(bootstrapCtx, rk)
@@ -265,12 +368,21 @@ func (c *controller) OnPluginStart(pluginID string, meta config.PluginMeta, back
 				logger.Warnw(bootstrapCtx, "failed to get relationships", "resourceKey", rk, "error", err)
 				continue
 			}
+			for _, decl := range decls {
+				if err := graph.ValidateMatchKey(decl); err != nil {
+					logger.Warnw(bootstrapCtx, "ignoring invalid relationship declaration", "resourceKey", rk, "targetResourceKey", decl.TargetResourceKey, "error", err)
+				}
+			}
 			if len(decls) > 0 {
 				c.graph.SetDeclarations(pluginID, rk, decls)
+				matchTargets = append(matchTargets, graph.MatchTargets(decls)...)
 				logger.Debugw(bootstrapCtx, "cached relationship declarations", "resourceKey", rk, "count", len(decls))
 			}
 		}
 	}
+	if len(matchTargets) > 0 {
+		go c.reindexMatchTargets(matchTargets)
+	}
 
 	// Start watch event listener (uses WatchEventSink callback pattern).
 	sink := &engineWatchSink{
@@ -278,6 +390,7 @@ func (c *controller) OnPluginStart(pluginID string, meta config.PluginMeta, back
 		ctrl:       c,
 		store:      c.registryStore,
 		dispatcher: c.dispatcher,
+		history:    c.historyDispatcher,
 	}
 	watchReady := make(chan struct{})
 	go c.listenForWatchEvents(pluginID, provider, watchCtx, sink, watchReady)
@@ -342,20 +455,81 @@ func (c *controller) OnPluginStop(pluginID string, meta config.PluginMeta) error
 // declarations for the given plugin and its connections. Called during both
 // plugin stop and plugin restart (to avoid stale state leaking into the
 // replacement).
+//
+// Entries in a persistent registry store are kept so the last-known inventory
+// survives the restart; stale ones are pruned when their watch re-syncs.
 func (c *controller) cleanupPluginGraphState(pluginID string, conns []types.Connection) {
+	_, persistent := c.registryStore.(registry.PersistentStore)
 	for _, conn := range conns {
-		removed := c.registryStore.DeleteByConnection(pluginID, conn.ID)
-		for _, entry := range removed {
-			c.dispatcher.Enqueue(indexer.Event{
-				Type:  indexer.EventDelete,
-				Entry: entry,
-			})
+		if persistent {
+			c.graph.RemoveEdgesForConnection(pluginID, conn.ID)
+			continue
 		}
-		c.graph.RemoveEdgesForConnection(pluginID, conn.ID)
+		c.dropConnectionEntries(pluginID, conn.ID)
 	}
 	c.graph.ClearDeclarationsForPlugin(pluginID)
 }
 
+// reindexMatchTargets re-indexes the stored entries targeted by newly set
+// MethodMatchKey declarations. Entries indexed before the declarations
+// existed were never registered as match targets, so without this they would
+// stay unlinked until their next watch update.
+func (c *controller) reindexMatchTargets(targets []graph.MatchTarget) {
+	c.connsMu.RLock()
+	conns := make(map[string][]types.Connection, len(c.connections))
+	for pluginID, pcs := range c.connections {
+		conns[pluginID] = append([]types.Connection(nil), pcs...)
+	}
+	c.connsMu.RUnlock()
+
+	for _, t := range targets {
+		for pluginID, pcs := range conns {
+			if t.PluginID != "" && t.PluginID != pluginID {
+				continue
+			}
+			for _, conn := range pcs {
+				c.reindexStoredEntries(pluginID, conn.ID, t.ResourceKey)
+			}
+		}
+	}
+}
+
+// reindexStoredEntries sends an update with a freshly listed payload for each
+// stored entry of one resource type on one connection.
+func (c *controller) reindexStoredEntries(pluginID, connectionID, resourceKey string) {
+	entries := c.registryStore.ScanByResourceKey(pluginID, connectionID, resourceKey)
+	if len(entries) == 0 {
+		return
+	}
+	payloads, err := listPayloads(c, pluginID, connectionID, resourceKey)
+	if err != nil {
+		c.logger.Debugw(context.Background(), "failed to list match targets for re-indexing",
+			"pluginID", pluginID, "connectionID", connectionID, "resourceKey", resourceKey, "error", err)
+		return
+	}
+	for _, entry := range entries {
+		raw, ok := payloads[payloadKey{namespace: entry.Namespace, id: entry.ID}]
+		if !ok {
+			continue
+		}
+		old := entry
+		c.dispatcher.Enqueue(indexer.Event{Type: indexer.EventUpdate, Entry: entry, Old: &old, Raw: raw})
+	}
+}
+
+// dropConnectionEntries deletes a connection's registry entries, persisted
+// or not, and its graph edges, and tells the indexers the entries are gone.
+func (c *controller) dropConnectionEntries(pluginID, connectionID string) {
+	removed := c.registryStore.DeleteByConnection(pluginID, connectionID)
+	for _, entry := range removed {
+		c.dispatcher.Enqueue(indexer.Event{
+			Type:  indexer.EventDelete,
+			Entry: entry,
+		})
+	}
+	c.graph.RemoveEdgesForConnection(pluginID, connectionID)
+}
+
 func (c *controller) OnPluginShutdown(pluginID string, meta config.PluginMeta) error {
 	return c.OnPluginStop(pluginID, meta)
 }
@@ -363,9 +537,19 @@ func (c *controller) OnPluginShutdown(pluginID string, meta config.PluginMeta) e
 func (c *controller) OnPluginDestroy(pluginID string, meta config.PluginMeta) error {
 	logger := c.logger.With(logging.Any("pluginID", pluginID))
 	logger.Debugw(context.Background(), "OnPluginDestroy")
-	if storeRoot, err := c.pluginStoreFn(pluginID); err != nil {
+	storeRoot, err := c.pluginStoreFn(pluginID)
+	if err != nil {
 		logger.Errorw(context.Background(), "failed to resolve plugin store root", "error", err)
-	} else if err := removeLocalStore(storeRoot); err != nil {
+		return nil
+	}
+
+	// A persistent registry outlives OnPluginStop; drop every connection the
+	// uninstalled plugin has entries for, saved or not.
+	for _, connectionID := range c.registryStore.ConnectionIDs(pluginID) {
+		c.dropConnectionEntries(pluginID, connectionID)
+	}
+
+	if err := removeLocalStore(storeRoot); err != nil {
 		logger.Errorw(context.Background(), "failed to remove local store", "error", err)
 	}
 	return nil
@@ -438,7 +622,12 @@ func toAppError(err error, pluginID string) error {
 // ============================================================================
 
 func (c *controller) Get(pluginID, connectionID, key string, input resource.GetInput) (*resource.GetResult, error) {
-	ctx, span := tracer.Start(context.Background(), "resource.Get")
+	return c.getResource(context.Background(), pluginID, connectionID, key, input)
+}
+
+// getResource is Get with a caller-supplied context.
+func (c *controller) getResource(ctx context.Context, pluginID, connectionID, key string, input resource.GetInput) (*resource.GetResult, error) {
+	ctx, span := tracer.Start(ctx, "resource.Get")
 	defer span.End()
 	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID), attribute.String("resource_key", key))
 	provider, ctx, err := c.withSession(ctx, pluginID, connectionID)
@@ -517,7 +706,13 @@ func (c *controller) Create(pluginID, connectionID, key string, input resource.C
 }
 
 func (c *controller) Update(pluginID, connectionID, key string, input resource.UpdateInput) (*resource.UpdateResult, error) {
-	ctx, span := tracer.Start(context.Background(), "resource.Update")
+	return c.updateResource(context.Background(), pluginID, connectionID, key, input)
+}
+
+// updateResource is Update with a caller-supplied context, used by bulk
+// operations so cancellation reaches the plugin call.
+func (c *controller) updateResource(ctx context.Context, pluginID, connectionID, key string, input resource.UpdateInput) (*resource.UpdateResult, error) {
+	ctx, span := tracer.Start(ctx, "resource.Update")
 	defer span.End()
 	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID), attribute.String("resource_key", key))
 	provider, ctx, err := c.withSession(ctx, pluginID, connectionID)
@@ -539,7 +734,12 @@ func (c *controller) Update(pluginID, connectionID, key string, input resource.U
 }
 
 func (c *controller) Delete(pluginID, connectionID, key string, input resource.DeleteInput) (*resource.DeleteResult, error) {
-	ctx, span := tracer.Start(context.Background(), "resource.Delete")
+	return c.deleteResource(context.Background(), pluginID, connectionID, key, input)
+}
+
+// deleteResource is Delete with a caller-supplied context.
+func (c *controller) deleteResource(ctx context.Context, pluginID, connectionID, key string, input resource.DeleteInput) (*resource.DeleteResult, error) {
+	ctx, span := tracer.Start(ctx, "resource.Delete")
 	defer span.End()
 	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID), attribute.String("resource_key", key))
 	provider, ctx, err := c.withSession(ctx, pluginID, connectionID)
@@ -598,7 +798,7 @@ func (c *controller) StartConnection(pluginID, connectionID string) (types.Conne
 	if conn.Connection != nil && conn.Connection.Name != "" {
 		connName = conn.Connection.Name
 	}
-	c.emitter.Emit(EventConnectionStatus, ConnectionStatusPayload{
+	c.emitConnectionStatus(ConnectionStatusPayload{
 		PluginID:     pluginID,
 		ConnectionID: connectionID,
 		Status:       string(conn.Status),
@@ -630,7 +830,7 @@ func (c *controller) StopConnection(pluginID, connectionID string) (types.Connec
 	c.connections[pluginID] = mergeConnections(c.connections[pluginID], []types.Connection{conn})
 	c.connsMu.Unlock()
 
-	c.emitter.Emit(EventConnectionStatus, ConnectionStatusPayload{
+	c.emitConnectionStatus(ConnectionStatusPayload{
 		PluginID:     pluginID,
 		ConnectionID: connectionID,
 		Status:       "DISCONNECTED",
@@ -830,7 +1030,17 @@ func (c *controller) UpdateConnection(pluginID string, connection types.Connecti
 	return types.Connection{}, apperror.ConnectionNotFound(pluginID, connection.ID)
 }
 
+// RemoveConnection removes a connection and drops everything the registry
+// knows about it, including entries kept by a persistent store.
 func (c *controller) RemoveConnection(pluginID, connectionID string) error {
+	if err := c.removeConnection(pluginID, connectionID); err != nil {
+		return err
+	}
+	c.dropConnectionEntries(pluginID, connectionID)
+	return nil
+}
+
+func (c *controller) removeConnection(pluginID, connectionID string) error {
 	c.connsMu.Lock()
 	defer c.connsMu.Unlock()
 	conns, ok := c.connections[pluginID]
@@ -1165,7 +1375,12 @@ func (c *controller) GetActions(pluginID, connectionID, key string) ([]resource.
 }
 
 func (c *controller) ExecuteAction(pluginID, connectionID, key, actionID string, input resource.ActionInput) (*resource.ActionResult, error) {
-	ctx, span := tracer.Start(context.Background(), "resource.ExecuteAction")
+	return c.executeAction(context.Background(), pluginID, connectionID, key, actionID, input)
+}
+
+// executeAction is ExecuteAction with a caller-supplied context.
+func (c *controller) executeAction(ctx context.Context, pluginID, connectionID, key, actionID string, input resource.ActionInput) (*resource.ActionResult, error) {
+	ctx, span := tracer.Start(ctx, "resource.ExecuteAction")
 	defer span.End()
 	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID), attribute.String("resource_key", key), attribute.String("action_id", actionID))
 	provider, ctx, err := c.withSession(ctx, pluginID, connectionID)
@@ -1194,31 +1409,127 @@ func (c *controller) StreamAction(pluginID, connectionID, key, actionID string,
 	}
 
 	operationID := fmt.Sprintf("op_%s_%s_%d", actionID, input.ID, time.Now().UnixNano())
-	eventStream := make(chan resource.ActionEvent, 16)
+	// The operation outlives this call, so its context is detached from the
+	// request span and cancelled only by CancelOperation or shutdown.
+	opCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	op := &actionOperation{
+		cancel: cancel,
+		info: OperationInfo{
+			OperationID:  operationID,
+			PluginID:     pluginID,
+			ConnectionID: connectionID,
+			ResourceKey:  key,
+			ActionID:     actionID,
+			ResourceID:   input.ID,
+			Namespace:    input.Namespace,
+			State:        OperationRunning,
+			StartedAt:    time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	c.operations.add(operationID, op)
+	c.emitter.Emit(EventActionOperation, op.snapshot())
+	go c.runStreamAction(opCtx, op, provider, key, actionID, input)
 
-	go func() {
-		streamErr := provider.StreamAction(ctx, key, actionID, input, eventStream)
-		if streamErr != nil {
-			c.logger.Errorw(ctx, "RPC failed", "op", "StreamAction", "pluginID", pluginID,
-				"key", key, "actionID", actionID, "error", streamErr)
-			eventKey := fmt.Sprintf("action/stream/%s", operationID)
-			c.emitter.Emit(eventKey, resource.ActionEvent{
-				Type: "error",
-				Data: map[string]interface{}{"message": streamErr.Error()},
-			})
-		}
-	}()
+	return operationID, nil
+}
 
-	go func() {
-		eventKey := fmt.Sprintf("action/stream/%s", operationID)
-		for event := range eventStream {
-			c.emitter.Emit(eventKey, event)
-		}
-	}()
+// ListOperations returns running and recently finished streaming actions,
+// oldest first.
+func (c *controller) ListOperations() ([]OperationInfo, error) {
+	ops := c.operations.list()
+	infos := make([]OperationInfo, 0, len(ops))
+	for _, op := range ops {
+		infos = append(infos, op.snapshot())
+	}
+	return infos, nil
+}
+
+// CancelOperation cancels a running streaming action. The provider's context
+// is cancelled and the operation ends with a terminal error event marked as
+// cancelled. Cancelling a finished operation is a no-op.
+func (c *controller) CancelOperation(operationID string) error {
+	op, ok := c.operations.get(operationID)
+	if !ok {
+		return apperror.NotFound("Operation not found",
+			fmt.Sprintf("Operation %q does not exist or has expired.", operationID))
+	}
+	op.cancel()
+	return nil
+}
+
+// ============================================================================
+// Bulk operations
+// ============================================================================
 
+// StartBulkOperation validates req and starts applying it to every target in
+// the background. It returns an operation ID; per-item progress and the final
+// summary are emitted on "bulk/<operationID>" (see BulkEvent).
+func (c *controller) StartBulkOperation(pluginID, connectionID string, req BulkRequest) (string, error) {
+	_, span := tracer.Start(context.Background(), "resource.StartBulkOperation")
+	defer span.End()
+	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID),
+		attribute.String("operation", string(req.Operation)), attribute.Int("targets", len(req.Targets)))
+	if err := req.validate(); err != nil {
+		telemetryutil.RecordError(span, err)
+		return "", err
+	}
+	if _, err := c.getProvider(pluginID); err != nil {
+		telemetryutil.RecordError(span, err)
+		return "", err
+	}
+
+	operationID := fmt.Sprintf("bulk_%s_%d", req.Operation, time.Now().UnixNano())
+	ctx, cancel := context.WithCancel(context.Background())
+	items := make([]BulkItemResult, len(req.Targets))
+	for i, t := range req.Targets {
+		items[i] = BulkItemResult{Index: i, Target: t, Status: BulkItemPending}
+	}
+	op := &bulkOperation{
+		cancel: cancel,
+		summary: BulkSummary{
+			OperationID:  operationID,
+			Operation:    req.Operation,
+			PluginID:     pluginID,
+			ConnectionID: connectionID,
+			State:        BulkRunning,
+			Total:        len(req.Targets),
+			Items:        items,
+			StartedAt:    time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	c.bulk.add(operationID, op)
+	go c.runBulk(ctx, operationID, op, pluginID, connectionID, req)
 	return operationID, nil
 }
 
+// CancelBulkOperation stops a running bulk operation. Items already in flight
+// are cancelled through their context; items not yet started are reported as
+// cancelled. Cancelling a finished operation is a no-op.
+func (c *controller) CancelBulkOperation(operationID string) error {
+	op, ok := c.bulk.get(operationID)
+	if !ok {
+		return bulkNotFound(operationID)
+	}
+	op.cancel()
+	return nil
+}
+
+// GetBulkOperation returns the current summary of a running or recently
+// finished bulk operation.
+func (c *controller) GetBulkOperation(operationID string) (*BulkSummary, error) {
+	op, ok := c.bulk.get(operationID)
+	if !ok {
+		return nil, bulkNotFound(operationID)
+	}
+	summary := op.snapshot()
+	return &summary, nil
+}
+
+func bulkNotFound(operationID string) *apperror.AppError {
+	return apperror.NotFound("Bulk operation not found",
+		fmt.Sprintf("Bulk operation %q does not exist or has expired.", operationID))
+}
+
 // ============================================================================
 // Editor Schemas
 // ============================================================================
@@ -1278,6 +1589,133 @@ func (c *controller) ResolveRelationships(pluginID, connectionID, key, id, names
 	return result, nil
 }
 
+// ============================================================================
+// Registry
+// ============================================================================
+
+// ListKnownResources returns the registry entries for a resource type on a
+// connection. Unlike List, it never calls the plugin, so with a persistent
+// registry store it serves the last-known inventory before watches catch up.
+func (c *controller) ListKnownResources(pluginID, connectionID, resourceKey string) ([]registry.ResourceEntry, error) {
+	entries := c.registryStore.ScanByResourceKey(pluginID, connectionID, resourceKey)
+	slices.SortFunc(entries, func(a, b registry.ResourceEntry) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.ID, b.ID))
+	})
+	if entries == nil {
+		entries = []registry.ResourceEntry{}
+	}
+	return entries, nil
+}
+
+// ============================================================================
+// Search
+// ============================================================================
+
+// SearchResources queries the search index across every plugin and
+// connection. See search.ParseQuery for the query syntax. limit <= 0 returns
+// all matches.
+func (c *controller) SearchResources(query string, limit int) ([]search.Result, error) {
+	_, span := tracer.Start(context.Background(), "resource.SearchResources")
+	defer span.End()
+	span.SetAttributes(attribute.String("query", query), attribute.Int("limit", limit))
+	results, err := c.searchIndex.Search(query, limit)
+	if err != nil {
+		appErr := apperror.New(apperror.TypeValidation, 400, "Invalid search query", err.Error())
+		telemetryutil.RecordError(span, appErr)
+		return nil, appErr
+	}
+	span.SetAttributes(attribute.Int("result_count", len(results)))
+	return results, nil
+}
+
+// ============================================================================
+// History
+// ============================================================================
+
+// ListRevisions returns the retained revisions of a resource, oldest first.
+func (c *controller) ListRevisions(pluginID, connectionID, resourceKey, namespace, id string) ([]history.RevisionInfo, error) {
+	_, span := tracer.Start(context.Background(), "resource.ListRevisions")
+	defer span.End()
+	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID), attribute.String("resource_key", resourceKey))
+	if c.historyStore == nil {
+		err := historyUnavailable()
+		telemetryutil.RecordError(span, err)
+		return nil, err
+	}
+	entryKey := historyEntryKey(pluginID, connectionID, resourceKey, namespace, id)
+	revs, err := c.historyStore.List(entryKey)
+	if err != nil {
+		appErr := apperror.Internal(err, "Failed to read resource history")
+		telemetryutil.RecordError(span, appErr)
+		return nil, appErr
+	}
+	return revs, nil
+}
+
+// GetRevision returns a single revision of a resource, including its payload.
+func (c *controller) GetRevision(pluginID, connectionID, resourceKey, namespace, id string, seq uint64) (*history.Revision, error) {
+	_, span := tracer.Start(context.Background(), "resource.GetRevision")
+	defer span.End()
+	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID), attribute.String("resource_key", resourceKey))
+	if c.historyStore == nil {
+		err := historyUnavailable()
+		telemetryutil.RecordError(span, err)
+		return nil, err
+	}
+	rev, err := c.historyStore.Get(historyEntryKey(pluginID, connectionID, resourceKey, namespace, id), seq)
+	if err != nil {
+		appErr := historyError(err, seq)
+		telemetryutil.RecordError(span, appErr)
+		return nil, appErr
+	}
+	return rev, nil
+}
+
+// DiffRevisions returns the JSON changes from revision fromSeq to revision
+// toSeq of a resource.
+func (c *controller) DiffRevisions(pluginID, connectionID, resourceKey, namespace, id string, fromSeq, toSeq uint64) ([]history.Change, error) {
+	_, span := tracer.Start(context.Background(), "resource.DiffRevisions")
+	defer span.End()
+	span.SetAttributes(attribute.String("plugin_id", pluginID), attribute.String("connection_id", connectionID), attribute.String("resource_key", resourceKey))
+	if c.historyStore == nil {
+		err := historyUnavailable()
+		telemetryutil.RecordError(span, err)
+		return nil, err
+	}
+	entryKey := historyEntryKey(pluginID, connectionID, resourceKey, namespace, id)
+	changes, err := c.historyStore.DiffRevisions(entryKey, fromSeq, toSeq)
+	if err != nil {
+		seq := fromSeq
+		if _, getErr := c.historyStore.Get(entryKey, fromSeq); getErr == nil {
+			seq = toSeq
+		}
+		appErr := historyError(err, seq)
+		telemetryutil.RecordError(span, appErr)
+		return nil, appErr
+	}
+	return changes, nil
+}
+
+func historyEntryKey(pluginID, connectionID, resourceKey, namespace, id string) string {
+	return registry.ResourceEntry{
+		PluginID: pluginID, ConnectionID: connectionID,
+		ResourceKey: resourceKey, Namespace: namespace, ID: id,
+	}.EntryKey()
+}
+
+func historyUnavailable() *apperror.AppError {
+	return apperror.NotImplemented("Resource history unavailable",
+		"The resource history store is not enabled or could not be opened.")
+}
+
+func historyError(err error, seq uint64) *apperror.AppError {
+	if errors.Is(err, history.ErrRevisionNotFound) {
+		return apperror.NotFound("Revision not found",
+			fmt.Sprintf("Revision %d does not exist or has been pruned.", seq))
+	}
+	return apperror.Internal(err, "Failed to read resource history")
+}
+
 // ============================================================================
 // Health
 // ============================================================================
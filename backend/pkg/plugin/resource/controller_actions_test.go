@@ -224,3 +224,141 @@ func TestStreamAction_OperationIDFormat(t *testing.T) {
 	assert.Equal(t, "input-42", parts[2])
 	assert.NotEmpty(t, parts[3], "timestamp part should be non-empty")
 }
+
+// ============================================================================
+// Operation registry
+// ============================================================================
+
+// waitForOperation waits for the terminal action/operation event of opID.
+func waitForOperation(t *testing.T, emitter *recordingEmitter, opID string) OperationInfo {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, ev := range emitter.EventsWithKey(EventActionOperation) {
+			info := ev.Data[0].(OperationInfo)
+			if info.OperationID == opID && info.State != OperationRunning {
+				return info
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("operation %s did not finish", opID)
+	return OperationInfo{}
+}
+
+func TestStreamAction_SynthesizesCompleteEvent(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		StreamActionFunc: func(_ context.Context, _, _ string, _ resource.ActionInput, stream chan<- resource.ActionEvent) error {
+			stream <- resource.ActionEvent{Type: "progress"}
+			close(stream)
+			return nil
+		},
+	})
+
+	opID, err := ctrl.StreamAction("p1", "conn-1", "pods", "restart", resource.ActionInput{ID: "r1", Namespace: "ns"})
+	require.NoError(t, err)
+
+	info := waitForOperation(t, emitter, opID)
+	assert.Equal(t, OperationCompleted, info.State)
+	assert.Equal(t, "ns", info.Namespace)
+	assert.NotEmpty(t, info.FinishedAt)
+
+	events := emitter.EventsWithKey("action/stream/" + opID)
+	require.Len(t, events, 2)
+	assert.Equal(t, "complete", events[1].Data[0].(resource.ActionEvent).Type)
+}
+
+func TestStreamAction_PluginTerminalEventIsNotDuplicated(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		StreamActionFunc: func(_ context.Context, _, _ string, _ resource.ActionInput, stream chan<- resource.ActionEvent) error {
+			stream <- resource.ActionEvent{Type: "error", Data: map[string]interface{}{"message": "drain blocked"}}
+			close(stream)
+			return nil
+		},
+	})
+
+	opID, err := ctrl.StreamAction("p1", "conn-1", "nodes", "drain", resource.ActionInput{ID: "n1"})
+	require.NoError(t, err)
+
+	info := waitForOperation(t, emitter, opID)
+	assert.Equal(t, OperationFailed, info.State)
+	assert.Equal(t, "drain blocked", info.Message)
+	assert.Len(t, emitter.EventsWithKey("action/stream/"+opID), 1)
+}
+
+func TestStreamAction_ErrorWithoutClosingStream(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		StreamActionFunc: func(context.Context, string, string, resource.ActionInput, chan<- resource.ActionEvent) error {
+			return errors.New("no connection")
+		},
+	})
+
+	opID, err := ctrl.StreamAction("p1", "conn-1", "pods", "restart", resource.ActionInput{ID: "r1"})
+	require.NoError(t, err)
+
+	info := waitForOperation(t, emitter, opID)
+	assert.Equal(t, OperationFailed, info.State)
+	assert.Equal(t, "no connection", info.Message)
+}
+
+func TestCancelOperation_PropagatesToProvider(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	started := make(chan struct{})
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		StreamActionFunc: func(ctx context.Context, _, _ string, _ resource.ActionInput, stream chan<- resource.ActionEvent) error {
+			defer close(stream)
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	opID, err := ctrl.StreamAction("p1", "conn-1", "pods", "restart", resource.ActionInput{ID: "r1"})
+	require.NoError(t, err)
+	<-started
+
+	ops, err := ctrl.ListOperations()
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	assert.Equal(t, OperationRunning, ops[0].State)
+
+	require.NoError(t, ctrl.CancelOperation(opID))
+	info := waitForOperation(t, emitter, opID)
+	assert.Equal(t, OperationCancelled, info.State)
+
+	ev := emitter.WaitForEvent(t, "action/stream/"+opID, 2*time.Second)
+	actionEv := ev.Data[0].(resource.ActionEvent)
+	assert.Equal(t, "error", actionEv.Type)
+	assert.Equal(t, true, actionEv.Data["cancelled"])
+
+	ops, err = ctrl.ListOperations()
+	require.NoError(t, err)
+	require.Len(t, ops, 1, "finished operations remain listed")
+	assert.Equal(t, OperationCancelled, ops[0].State)
+	assert.NoError(t, ctrl.CancelOperation(opID), "cancelling a finished operation is a no-op")
+}
+
+func TestCancelOperation_NotFound(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+	err := ctrl.CancelOperation("op_missing")
+	var appErr *apperror.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperror.TypeResourceNotFound, appErr.Type)
+}
+
+func TestOpTable_EvictsOldestFinished(t *testing.T) {
+	table := newOpTable[int](2)
+	for i, id := range []string{"a", "b", "c", "d"} {
+		table.add(id, i)
+	}
+	table.markFinished("b")
+	table.markFinished("a")
+	table.markFinished("d")
+
+	assert.Equal(t, []int{0, 2, 3}, table.list(), "b was evicted; running c is kept")
+	_, ok := table.get("b")
+	assert.False(t, ok)
+}
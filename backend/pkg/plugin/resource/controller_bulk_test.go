@@ -0,0 +1,238 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+func bulkTargets(ids ...string) []BulkTarget {
+	targets := make([]BulkTarget, len(ids))
+	for i, id := range ids {
+		targets[i] = BulkTarget{ResourceKey: "pods", Namespace: "default", ID: id}
+	}
+	return targets
+}
+
+// waitForBulkSummary waits for the final complete event of a bulk operation.
+func waitForBulkSummary(t *testing.T, emitter *recordingEmitter, opID string, total int) *BulkSummary {
+	t.Helper()
+	events := emitter.WaitForNEvents(t, bulkEventKey(opID), total+1, 2*time.Second)
+	last, ok := events[len(events)-1].Data[0].(BulkEvent)
+	require.True(t, ok)
+	require.Equal(t, "complete", last.Type)
+	require.NotNil(t, last.Summary)
+	return last.Summary
+}
+
+func TestBulk_DeleteReportsPartialFailure(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		DeleteFunc: func(_ context.Context, _ string, input resource.DeleteInput) (*resource.DeleteResult, error) {
+			if input.ID == "bad" {
+				return nil, &resource.ResourceOperationError{Code: "FORBIDDEN", Title: "Forbidden", Message: "no delete"}
+			}
+			return &resource.DeleteResult{Success: true}, nil
+		},
+	})
+
+	opID, err := ctrl.StartBulkOperation("p1", "conn-1", BulkRequest{Operation: BulkDelete, Targets: bulkTargets("a", "bad", "c")})
+	require.NoError(t, err)
+
+	summary := waitForBulkSummary(t, emitter, opID, 3)
+	assert.Equal(t, BulkCompleted, summary.State)
+	assert.Equal(t, 3, summary.Total)
+	assert.Equal(t, 2, summary.Succeeded)
+	assert.Equal(t, 1, summary.Failed)
+	assert.NotEmpty(t, summary.FinishedAt)
+	require.Len(t, summary.Items, 3)
+	assert.Equal(t, BulkItemSucceeded, summary.Items[0].Status)
+	assert.Equal(t, BulkItemFailed, summary.Items[1].Status)
+	require.NotNil(t, summary.Items[1].Error)
+	assert.Equal(t, apperror.TypeResourceForbidden, summary.Items[1].Error.Type)
+
+	progress := emitter.EventsWithKey(bulkEventKey(opID))[:3]
+	for _, ev := range progress {
+		e := ev.Data[0].(BulkEvent)
+		assert.Equal(t, "progress", e.Type)
+		require.NotNil(t, e.Item)
+		assert.Equal(t, 3, e.Total)
+	}
+
+	got, err := ctrl.GetBulkOperation(opID)
+	require.NoError(t, err)
+	assert.Equal(t, summary.Succeeded, got.Succeeded)
+}
+
+func TestBulk_PatchAndAction(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	var patched atomic.Int32
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		GetFunc: func(_ context.Context, _ string, input resource.GetInput) (*resource.GetResult, error) {
+			return &resource.GetResult{Success: true, Result: json.RawMessage(
+				`{"metadata":{"name":"` + input.ID + `","labels":{"app":"web"}},"spec":{"replicas":3,"paused":true}}`,
+			)}, nil
+		},
+		UpdateFunc: func(_ context.Context, _ string, input resource.UpdateInput) (*resource.UpdateResult, error) {
+			assert.JSONEq(t, `{"metadata":{"name":"`+input.ID+`","labels":{"app":"web"}},"spec":{"replicas":0}}`, string(input.Input),
+				"fields outside the patch are kept")
+			patched.Add(1)
+			return &resource.UpdateResult{Success: true}, nil
+		},
+		ExecuteActionFunc: func(_ context.Context, _, actionID string, input resource.ActionInput) (*resource.ActionResult, error) {
+			assert.Equal(t, "restart", actionID)
+			assert.Equal(t, "now", input.Params["when"])
+			return &resource.ActionResult{Success: input.ID != "b", Message: "restarted " + input.ID}, nil
+		},
+	})
+
+	opID, err := ctrl.StartBulkOperation("p1", "conn-1", BulkRequest{
+		Operation: BulkPatch, Targets: bulkTargets("a", "b"), Patch: json.RawMessage(`{"spec":{"replicas":0,"paused":null}}`),
+	})
+	require.NoError(t, err)
+	summary := waitForBulkSummary(t, emitter, opID, 2)
+	assert.Equal(t, 2, summary.Succeeded)
+	assert.Equal(t, int32(2), patched.Load())
+
+	opID, err = ctrl.StartBulkOperation("p1", "conn-1", BulkRequest{
+		Operation: BulkAction, Targets: bulkTargets("a", "b"), ActionID: "restart", Params: map[string]interface{}{"when": "now"},
+	})
+	require.NoError(t, err)
+	summary = waitForBulkSummary(t, emitter, opID, 2)
+	assert.Equal(t, 1, summary.Succeeded)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, "restarted a", summary.Items[0].Message)
+	require.NotNil(t, summary.Items[1].Error)
+}
+
+func TestMergePatch(t *testing.T) {
+	for name, tt := range map[string]struct{ original, patch, want string }{
+		"nested merge":     {`{"a":{"b":1,"c":2},"d":3}`, `{"a":{"b":9}}`, `{"a":{"b":9,"c":2},"d":3}`},
+		"null removes":     {`{"a":{"b":1,"c":2}}`, `{"a":{"c":null}}`, `{"a":{"b":1}}`},
+		"arrays replace":   {`{"a":[1,2,3]}`, `{"a":[4]}`, `{"a":[4]}`},
+		"object over leaf": {`{"a":"x"}`, `{"a":{"b":null,"c":1}}`, `{"a":{"c":1}}`},
+	} {
+		merged, err := mergePatch(json.RawMessage(tt.original), json.RawMessage(tt.patch))
+		require.NoError(t, err, name)
+		assert.JSONEq(t, tt.want, string(merged), name)
+	}
+}
+
+func TestMergePatch_KeepsLargeIntegers(t *testing.T) {
+	merged, err := mergePatch(
+		json.RawMessage(`{"metadata":{"generation":9007199254740993},"spec":{"replicas":1}}`),
+		json.RawMessage(`{"spec":{"replicas":3,"budget":18446744073709551615}}`),
+	)
+	require.NoError(t, err)
+	assert.Contains(t, string(merged), `"generation":9007199254740993`)
+	assert.Contains(t, string(merged), `"budget":18446744073709551615`)
+	assert.Contains(t, string(merged), `"replicas":3`)
+
+	_, err = mergePatch(json.RawMessage(`{"a":1} {"b":2}`), json.RawMessage(`{}`))
+	assert.Error(t, err, "trailing data is rejected")
+}
+
+func TestBulk_RespectsConcurrency(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	var inFlight, peak atomic.Int32
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		DeleteFunc: func(context.Context, string, resource.DeleteInput) (*resource.DeleteResult, error) {
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			inFlight.Add(-1)
+			return &resource.DeleteResult{Success: true}, nil
+		},
+	})
+
+	opID, err := ctrl.StartBulkOperation("p1", "conn-1", BulkRequest{
+		Operation: BulkDelete, Targets: bulkTargets("a", "b", "c", "d", "e", "f"), Concurrency: 2,
+	})
+	require.NoError(t, err)
+	summary := waitForBulkSummary(t, emitter, opID, 6)
+	assert.Equal(t, 6, summary.Succeeded)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestBulk_Cancel(t *testing.T) {
+	ctrl, emitter := newTestControllerWithEmitter(t)
+	started := make(chan struct{})
+	registerMockPlugin(ctrl, "p1", &mockProvider{
+		DeleteFunc: func(ctx context.Context, _ string, _ resource.DeleteInput) (*resource.DeleteResult, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+
+	opID, err := ctrl.StartBulkOperation("p1", "conn-1", BulkRequest{
+		Operation: BulkDelete, Targets: bulkTargets("a", "b", "c"), Concurrency: 1,
+	})
+	require.NoError(t, err)
+	<-started
+	require.NoError(t, ctrl.CancelBulkOperation(opID))
+
+	summary := waitForBulkSummary(t, emitter, opID, 3)
+	assert.Equal(t, BulkCancelled, summary.State)
+	assert.Equal(t, 3, summary.Cancelled)
+	for _, item := range summary.Items {
+		assert.Equal(t, BulkItemCancelled, item.Status)
+		assert.Equal(t, apperror.TypeCancelled, item.Error.Type)
+	}
+	assert.NoError(t, ctrl.CancelBulkOperation(opID), "cancelling a finished operation is a no-op")
+}
+
+func TestBulk_Validation(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+	registerMockPlugin(ctrl, "p1", &mockProvider{})
+
+	for name, req := range map[string]BulkRequest{
+		"unknown operation": {Operation: "scale", Targets: bulkTargets("a")},
+		"no targets":        {Operation: BulkDelete},
+		"missing patch":     {Operation: BulkPatch, Targets: bulkTargets("a")},
+		"non-object patch":  {Operation: BulkPatch, Targets: bulkTargets("a"), Patch: json.RawMessage(`[1]`)},
+		"missing action":    {Operation: BulkAction, Targets: bulkTargets("a")},
+		"incomplete target": {Operation: BulkDelete, Targets: []BulkTarget{{ResourceKey: "pods"}}},
+	} {
+		_, err := ctrl.StartBulkOperation("p1", "conn-1", req)
+		var appErr *apperror.AppError
+		require.True(t, errors.As(err, &appErr), name)
+		assert.Equal(t, apperror.TypeValidation, appErr.Type, name)
+	}
+
+	_, err := ctrl.StartBulkOperation("missing", "conn-1", BulkRequest{Operation: BulkDelete, Targets: bulkTargets("a")})
+	assert.Error(t, err)
+
+	_, err = ctrl.GetBulkOperation("nope")
+	var appErr *apperror.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperror.TypeResourceNotFound, appErr.Type)
+}
+
+func TestBulkManager_RetainsRecentFinished(t *testing.T) {
+	m := newBulkManager()
+	for i := range maxRetainedBulkOperations + 2 {
+		id := string(rune('A' + i))
+		m.add(id, &bulkOperation{})
+		m.markFinished(id)
+	}
+	_, ok := m.get("A")
+	assert.False(t, ok)
+	_, ok = m.get("C")
+	assert.True(t, ok)
+}
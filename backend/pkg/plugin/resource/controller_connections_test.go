@@ -328,6 +328,37 @@ func TestStopConnection_EmitsDisconnected(t *testing.T) {
 	assert.Equal(t, "DISCONNECTED", payload.Status)
 }
 
+func TestConnectionStatusCallback(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+	mock := &mockProvider{
+		StartConnectionFunc: func(_ context.Context, id string) (types.ConnectionStatus, error) {
+			return types.ConnectionStatus{
+				Status:     types.ConnectionStatusConnected,
+				Connection: &types.Connection{ID: id, Name: "MyCluster"},
+			}, nil
+		},
+		StopConnectionFunc: func(_ context.Context, id string) (types.Connection, error) {
+			return types.Connection{ID: id, Name: "MyCluster"}, nil
+		},
+	}
+	registerMockPlugin(ctrl, "p1", mock)
+
+	statuses := make(chan ConnectionStatusPayload, 2)
+	ctrl.SetConnectionStatusCallback(func(status ConnectionStatusPayload) { statuses <- status })
+
+	_, err := ctrl.StartConnection("p1", "conn-1")
+	require.NoError(t, err)
+	_, err = ctrl.StopConnection("p1", "conn-1")
+	require.NoError(t, err)
+
+	// Both calls have returned, so the callback has seen both, in order.
+	require.Len(t, statuses, 2)
+	status := <-statuses
+	assert.Equal(t, "conn-1", status.ConnectionID)
+	assert.Equal(t, string(types.ConnectionStatusConnected), status.Status)
+	assert.Equal(t, "DISCONNECTED", (<-statuses).Status)
+}
+
 // ============================================================================
 // LoadConnections (with provider delegation)
 // ============================================================================
@@ -0,0 +1,132 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/types"
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+	pkgsettings "github.com/omniviewdev/plugin-sdk/settings"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/indexer"
+)
+
+// useHistory enables revision history on the test controller and sink.
+func useHistory(t *testing.T, ctrl *controller, sink *engineWatchSink) {
+	t.Helper()
+	store, err := history.Open(filepath.Join(t.TempDir(), "history.db"))
+	require.NoError(t, err)
+	disp := indexer.NewDispatcher([]indexer.ResourceIndexer{store})
+	disp.Start()
+	t.Cleanup(func() {
+		disp.Stop()
+		store.Close()
+	})
+	ctrl.historyStore = store
+	ctrl.historyDispatcher = disp
+	sink.history = disp
+}
+
+func TestHistory_RecordsWatchEventsAndDiffs(t *testing.T) {
+	sink, ctrl, _ := newSinkTestSetup(t)
+	useHistory(t, ctrl, sink)
+
+	sink.OnAdd(resource.WatchAddPayload{Connection: "conn-1", Key: "apps::v1::Deployment", Namespace: "default", ID: "web",
+		Data: json.RawMessage(`{"spec":{"replicas":1}}`)})
+	sink.OnUpdate(resource.WatchUpdatePayload{Connection: "conn-1", Key: "apps::v1::Deployment", Namespace: "default", ID: "web",
+		Data: json.RawMessage(`{"spec":{"replicas":3}}`)})
+	sink.OnDelete(resource.WatchDeletePayload{Connection: "conn-1", Key: "apps::v1::Deployment", Namespace: "default", ID: "web"})
+	ctrl.historyDispatcher.Flush()
+
+	revs, err := ctrl.ListRevisions("plugin-a", "conn-1", "apps::v1::Deployment", "default", "web")
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	assert.Equal(t, history.OpDelete, revs[2].Op)
+
+	rev, err := ctrl.GetRevision("plugin-a", "conn-1", "apps::v1::Deployment", "default", "web", revs[0].Seq)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"spec":{"replicas":1}}`, string(rev.Data))
+
+	changes, err := ctrl.DiffRevisions("plugin-a", "conn-1", "apps::v1::Deployment", "default", "web", revs[0].Seq, revs[1].Seq)
+	require.NoError(t, err)
+	assert.Equal(t, []history.Change{
+		{Op: history.ChangeReplace, Path: "/spec/replicas", OldValue: float64(1), NewValue: float64(3)},
+	}, changes)
+}
+
+func TestHistory_RegistryTeardownIsNotRecorded(t *testing.T) {
+	sink, ctrl, _ := newSinkTestSetup(t)
+	useHistory(t, ctrl, sink)
+
+	sink.OnAdd(resource.WatchAddPayload{Connection: "conn-1", Key: "pods", ID: "pod-1", Data: json.RawMessage(`{"a":1}`)})
+	ctrl.cleanupPluginGraphState("plugin-a", []types.Connection{{ID: "conn-1"}})
+	ctrl.dispatcher.Flush()
+	ctrl.historyDispatcher.Flush()
+
+	revs, err := ctrl.ListRevisions("plugin-a", "conn-1", "pods", "", "pod-1")
+	require.NoError(t, err)
+	require.Len(t, revs, 1)
+	assert.Equal(t, history.OpAdd, revs[0].Op)
+}
+
+func TestHistory_PruneAppliesRetentionSettings(t *testing.T) {
+	sink, ctrl, _ := newSinkTestSetup(t)
+	useHistory(t, ctrl, sink)
+	sp := pkgsettings.NewProvider(pkgsettings.ProviderOpts{
+		Logger: zap.NewNop().Sugar(),
+		PluginSettings: []pkgsettings.Category{{
+			ID: "general",
+			Settings: map[string]pkgsettings.Setting{
+				"historyMaxRevisions": {ID: "historyMaxRevisions", Type: pkgsettings.Integer, Default: 20},
+				"historyMaxAge":       {ID: "historyMaxAge", Type: pkgsettings.Integer, Default: 24},
+			},
+		}},
+	})
+	ctrl.settingsProvider = sp
+
+	for _, data := range []string{`{"a":1}`, `{"a":2}`, `{"a":3}`} {
+		sink.OnUpdate(resource.WatchUpdatePayload{Connection: "conn-1", Key: "pods", ID: "pod-1", Data: json.RawMessage(data)})
+	}
+	ctrl.historyDispatcher.Flush()
+
+	require.NoError(t, sp.SetSetting(HistoryMaxRevisionsSetting, 2))
+	ctrl.pruneHistory()
+	revs, err := ctrl.ListRevisions("plugin-a", "conn-1", "pods", "", "pod-1")
+	require.NoError(t, err)
+	assert.Len(t, revs, 2)
+
+	require.NoError(t, sp.SetSetting(HistoryMaxRevisionsSetting, 1))
+	ctrl.startHistoryPruner()
+	ctrl.stopHistoryPruner()
+	revs, err = ctrl.ListRevisions("plugin-a", "conn-1", "pods", "", "pod-1")
+	require.NoError(t, err)
+	assert.Len(t, revs, 1, "the pruner prunes once when it starts")
+}
+
+func TestHistory_RevisionNotFound(t *testing.T) {
+	sink, ctrl, _ := newSinkTestSetup(t)
+	useHistory(t, ctrl, sink)
+
+	_, err := ctrl.GetRevision("plugin-a", "conn-1", "pods", "", "pod-1", 42)
+	var appErr *apperror.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperror.TypeResourceNotFound, appErr.Type)
+	assert.Contains(t, appErr.Detail, "42")
+}
+
+func TestHistory_Disabled(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+
+	_, err := ctrl.ListRevisions("plugin-a", "conn-1", "pods", "", "pod-1")
+	var appErr *apperror.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperror.TypeNotImplemented, appErr.Type)
+}
@@ -0,0 +1,200 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/plugin-sdk/pkg/config"
+	"github.com/omniviewdev/plugin-sdk/pkg/types"
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/graph"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/indexer"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+)
+
+// usePersistentRegistry swaps the test controller's store for a BoltStore.
+func usePersistentRegistry(t *testing.T, ctrl *controller) *registry.BoltStore {
+	t.Helper()
+	store, err := registry.OpenBoltStore(filepath.Join(t.TempDir(), "registry.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	ctrl.registryStore = store
+	return store
+}
+
+func TestListKnownResources_SortedAndNonNil(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+
+	entries, err := ctrl.ListKnownResources("plugin-a", "conn-1", "core::v1::Pod")
+	require.NoError(t, err)
+	assert.NotNil(t, entries)
+	assert.Empty(t, entries)
+
+	ctrl.registryStore.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "kube-system", ID: "dns"})
+	ctrl.registryStore.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "web-b"})
+	ctrl.registryStore.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "web-a"})
+
+	entries, err = ctrl.ListKnownResources("plugin-a", "conn-1", "core::v1::Pod")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []string{"web-a", "web-b", "dns"}, []string{entries[0].ID, entries[1].ID, entries[2].ID})
+}
+
+func TestCleanupPluginGraphState_PersistentStoreKeepsEntries(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+	store := usePersistentRegistry(t, ctrl)
+
+	store.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "pod-1"})
+
+	ctrl.cleanupPluginGraphState("plugin-a", []types.Connection{{ID: "conn-1"}})
+
+	_, ok := store.Get("plugin-a", "conn-1", "core::v1::Pod", "default", "pod-1")
+	assert.True(t, ok, "persistent registry entries should survive plugin stop")
+}
+
+func TestCleanupPluginGraphState_MemoryStoreDropsEntries(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+
+	ctrl.registryStore.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "pod-1"})
+
+	ctrl.cleanupPluginGraphState("plugin-a", []types.Connection{{ID: "conn-1"}})
+
+	_, ok := ctrl.registryStore.Get("plugin-a", "conn-1", "core::v1::Pod", "default", "pod-1")
+	assert.False(t, ok)
+}
+
+func TestSink_Resync_PrunesUnseenPersistentEntries(t *testing.T) {
+	sink, ctrl, _ := newSinkTestSetup(t)
+	store := usePersistentRegistry(t, ctrl)
+	sink.store = store
+
+	// Inventory restored from a previous run.
+	store.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "pods", ID: "still-here"})
+	store.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "pods", ID: "deleted-offline"})
+	store.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "services", ID: "untouched"})
+
+	sink.OnStateChange(resource.WatchStateEvent{Connection: "conn-1", ResourceKey: "pods", State: resource.WatchStateSyncing})
+	sink.OnAdd(resource.WatchAddPayload{Connection: "conn-1", Key: "pods", ID: "still-here"})
+	sink.OnAdd(resource.WatchAddPayload{Connection: "conn-1", Key: "pods", ID: "new-pod"})
+	sink.OnStateChange(resource.WatchStateEvent{Connection: "conn-1", ResourceKey: "pods", State: resource.WatchStateSynced})
+
+	ids := map[string]bool{}
+	for _, e := range store.ScanByResourceKey("plugin-a", "conn-1", "pods") {
+		ids[e.ID] = true
+	}
+	assert.Equal(t, map[string]bool{"still-here": true, "new-pod": true}, ids)
+
+	_, ok := store.Get("plugin-a", "conn-1", "services", "", "untouched")
+	assert.True(t, ok, "other resource keys must not be pruned")
+}
+
+func TestSink_Resync_SyncedWithoutSyncingIsNoop(t *testing.T) {
+	sink, ctrl, _ := newSinkTestSetup(t)
+	store := usePersistentRegistry(t, ctrl)
+	sink.store = store
+
+	store.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "pods", ID: "pod-1"})
+
+	sink.OnStateChange(resource.WatchStateEvent{Connection: "conn-1", ResourceKey: "pods", State: resource.WatchStateSynced})
+
+	_, ok := store.Get("plugin-a", "conn-1", "pods", "", "pod-1")
+	assert.True(t, ok)
+}
+
+func TestRemoveConnection_DropsPersistentEntries(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+	store := usePersistentRegistry(t, ctrl)
+	ctrl.connections["plugin-a"] = []types.Connection{{ID: "conn-1"}, {ID: "conn-2"}}
+
+	store.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "pod-1"})
+	store.Put(registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-2", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "pod-2"})
+	ctrl.dispatcher.Enqueue(indexer.Event{Type: indexer.EventAdd, Entry: registry.ResourceEntry{
+		PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "pod-1",
+	}})
+	ctrl.dispatcher.Flush()
+	results, err := ctrl.searchIndex.Search("pod-1", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+
+	require.NoError(t, ctrl.RemoveConnection("plugin-a", "conn-1"))
+	ctrl.dispatcher.Flush()
+
+	entries, err := ctrl.ListKnownResources("plugin-a", "conn-1", "core::v1::Pod")
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a removed connection's persisted entries are deleted")
+	_, ok := store.Get("plugin-a", "conn-2", "core::v1::Pod", "default", "pod-2")
+	assert.True(t, ok, "other connections keep their entries")
+	results, err = ctrl.searchIndex.Search("pod-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results, "the indexers are told the entries are gone")
+}
+
+func TestOnPluginDestroy_DropsPersistentEntries(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+	store := usePersistentRegistry(t, ctrl)
+
+	// conn-2 was never saved to the local store.
+	pod := registry.ResourceEntry{PluginID: "plugin-a", ConnectionID: "conn-2", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "pod-1"}
+	store.Put(pod)
+	store.Put(registry.ResourceEntry{PluginID: "plugin-b", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", Namespace: "default", ID: "pod-2"})
+	ctrl.dispatcher.Enqueue(indexer.Event{Type: indexer.EventAdd, Entry: pod})
+	ctrl.dispatcher.Flush()
+
+	require.NoError(t, ctrl.OnPluginDestroy("plugin-a", config.PluginMeta{}))
+	ctrl.dispatcher.Flush()
+
+	assert.Empty(t, store.ConnectionIDs("plugin-a"))
+	assert.Equal(t, []string{"conn-1"}, store.ConnectionIDs("plugin-b"), "other plugins keep their entries")
+	results, err := ctrl.searchIndex.Search("pod-1", 10)
+	require.NoError(t, err)
+	assert.Empty(t, results, "the indexers are told the entries are gone")
+}
+
+func TestReindexMatchTargets_LinksStoredTargets(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+	registerMockPlugin(ctrl, "aws", &mockProvider{
+		ListFunc: func(context.Context, string, resource.ListInput) (*resource.ListResult, error) {
+			return &resource.ListResult{Success: true, Result: []json.RawMessage{
+				json.RawMessage(`{"InstanceId":"i-0abc123"}`),
+			}}, nil
+		},
+		GetResourceDefinitionFunc: func(context.Context, string) (resource.ResourceDefinition, error) {
+			return resource.ResourceDefinition{IDAccessor: "InstanceId"}, nil
+		},
+	})
+	ctrl.connections["aws"] = []types.Connection{{ID: "acct-1"}}
+
+	// The instance was indexed before any plugin declared a match against it.
+	instance := registry.ResourceEntry{PluginID: "aws", ConnectionID: "acct-1", ResourceKey: "ec2::v1::Instance", ID: "i-0abc123"}
+	ctrl.registryStore.Put(instance)
+	ctrl.dispatcher.Enqueue(indexer.Event{Type: indexer.EventAdd, Entry: instance, Raw: json.RawMessage(`{"InstanceId":"i-0abc123"}`)})
+	ctrl.dispatcher.Flush()
+
+	decls := []resource.RelationshipDescriptor{{
+		Type:              resource.RelRunsOn,
+		TargetResourceKey: "ec2::v1::Instance",
+		Extractor: &resource.RelationshipExtractor{
+			Method:        graph.MethodMatchKey,
+			FieldPath:     "spec.providerID",
+			LabelSelector: map[string]string{graph.MatchOptTargetFieldPath: "InstanceId", graph.MatchOptPattern: `(i-[0-9a-f]+)$`},
+		},
+	}}
+	ctrl.graph.SetDeclarations("k8s", "core::v1::Node", decls)
+	node := registry.ResourceEntry{PluginID: "k8s", ConnectionID: "eks", ResourceKey: "core::v1::Node", ID: "ip-10-0-1-5"}
+	ctrl.dispatcher.Enqueue(indexer.Event{Type: indexer.EventAdd, Entry: node, Raw: json.RawMessage(`{"spec":{"providerID":"aws:///us-east-1a/i-0abc123"}}`)})
+	ctrl.dispatcher.Flush()
+	require.Empty(t, ctrl.graph.EdgesFrom(node.EntryKey()), "the instance is not a match target yet")
+
+	ctrl.reindexMatchTargets(graph.MatchTargets(decls))
+	ctrl.dispatcher.Flush()
+
+	edges := ctrl.graph.EdgesFrom(node.EntryKey())
+	require.Len(t, edges, 1)
+	assert.Equal(t, instance.EntryKey(), edges[0].Target.Key())
+}
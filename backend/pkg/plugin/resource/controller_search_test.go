@@ -0,0 +1,47 @@
+package resource
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+func TestSearchResources_IndexesWatchEvents(t *testing.T) {
+	sink, ctrl, _ := newSinkTestSetup(t)
+
+	sink.OnAdd(resource.WatchAddPayload{Connection: "conn-1", Key: "core::v1::Pod", Namespace: "prod", ID: "payments-api",
+		Data: json.RawMessage(`{"metadata":{"name":"payments-api"}}`)})
+	sink.OnAdd(resource.WatchAddPayload{Connection: "conn-2", Key: "core::v1::Pod", Namespace: "prod", ID: "payments-worker"})
+	sink.OnAdd(resource.WatchAddPayload{Connection: "conn-1", Key: "core::v1::Pod", Namespace: "dev", ID: "frontend"})
+	ctrl.dispatcher.Flush()
+
+	results, err := ctrl.SearchResources("name:payments-* ns:prod", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "conn-1", results[0].Entry.ConnectionID)
+	assert.Equal(t, "conn-2", results[1].Entry.ConnectionID)
+
+	sink.OnDelete(resource.WatchDeletePayload{Connection: "conn-2", Key: "core::v1::Pod", Namespace: "prod", ID: "payments-worker"})
+	ctrl.dispatcher.Flush()
+
+	results, err = ctrl.SearchResources("name:payments-*", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "payments-api", results[0].Entry.ID)
+}
+
+func TestSearchResources_InvalidQuery(t *testing.T) {
+	ctrl, _ := newTestControllerWithEmitter(t)
+
+	_, err := ctrl.SearchResources("ns:", 0)
+	var appErr *apperror.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperror.TypeValidation, appErr.Type)
+}
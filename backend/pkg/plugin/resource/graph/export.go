@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ExportFormat selects the serialization used by Subgraph.Export.
+type ExportFormat string
+
+const (
+	FormatDOT       ExportFormat = "dot"
+	FormatMermaid   ExportFormat = "mermaid"
+	FormatJSONGraph ExportFormat = "json"
+)
+
+// Subgraph is a self-contained slice of the relationship graph suitable for
+// export. Nodes and Edges are sorted for stable output.
+type Subgraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// NewSubgraph builds a Subgraph from edges plus any extra nodes (e.g. an
+// isolated root). Endpoints of every edge are included as nodes.
+func NewSubgraph(edges []GraphEdge, nodes ...GraphNode) *Subgraph {
+	byKey := make(map[string]GraphNode, len(nodes)+2*len(edges))
+	for _, n := range nodes {
+		byKey[n.Key()] = n
+	}
+	seen := make(map[string]bool, len(edges))
+	sg := &Subgraph{Edges: make([]GraphEdge, 0, len(edges))}
+	for _, e := range edges {
+		byKey[e.Source.Key()] = e.Source
+		byKey[e.Target.Key()] = e.Target
+		if ek := edgeKey(e); !seen[ek] {
+			seen[ek] = true
+			sg.Edges = append(sg.Edges, e)
+		}
+	}
+
+	sg.Nodes = make([]GraphNode, 0, len(byKey))
+	for _, n := range byKey {
+		sg.Nodes = append(sg.Nodes, n)
+	}
+	slices.SortFunc(sg.Nodes, func(a, b GraphNode) int { return cmp.Compare(a.Key(), b.Key()) })
+	slices.SortFunc(sg.Edges, func(a, b GraphEdge) int { return cmp.Compare(edgeKey(a), edgeKey(b)) })
+	return sg
+}
+
+// SubgraphFrom returns the subgraph reachable from ref within maxHops hops in
+// the given direction. The root is always included. maxHops is capped at 10.
+func (g *RelationshipGraph) SubgraphFrom(ref GraphNode, maxHops int, direction Direction) (*Subgraph, error) {
+	br, err := g.GetBlastRadius(ref, maxHops, direction, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewSubgraph(br.Edges, ref), nil
+}
+
+// ConnectionSubgraph returns every edge with at least one endpoint in the
+// given plugin connection, including cross-connection edges.
+func (g *RelationshipGraph) ConnectionSubgraph(pluginID, connectionID string) *Subgraph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	prefix := pluginID + "/" + connectionID + "/"
+	var edges []GraphEdge
+	for srcKey, out := range g.forward {
+		srcIn := strings.HasPrefix(srcKey, prefix)
+		for _, e := range out {
+			if srcIn || strings.HasPrefix(e.Target.Key(), prefix) {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return NewSubgraph(edges)
+}
+
+// Export serializes the subgraph in the given format.
+func (sg *Subgraph) Export(format ExportFormat) (string, error) {
+	switch format {
+	case FormatDOT:
+		return sg.DOT(), nil
+	case FormatMermaid:
+		return sg.Mermaid(), nil
+	case FormatJSONGraph:
+		return sg.JSONGraph()
+	default:
+		return "", fmt.Errorf("unsupported export format: %q (must be \"dot\", \"mermaid\", or \"json\")", format)
+	}
+}
+
+// DOT renders the subgraph as a Graphviz digraph. Node IDs are GraphNode keys;
+// each edge carries its label and a "type" attribute with the relationship type.
+func (sg *Subgraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph omniview {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range sg.Nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, resource_key=%s];\n",
+			dotQuote(n.Key()), dotQuote(n.ResourceKey+"\n"+nodeName(n)), dotQuote(n.ResourceKey))
+	}
+	for _, e := range sg.Edges {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, type=%s];\n",
+			dotQuote(e.Source.Key()), dotQuote(e.Target.Key()), dotQuote(edgeText(e)), dotQuote(string(e.Type)))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the subgraph as a Mermaid flowchart. Mermaid node IDs must
+// be simple identifiers, so nodes are numbered in sorted order.
+func (sg *Subgraph) Mermaid() string {
+	ids := make(map[string]string, len(sg.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range sg.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n.Key()] = id
+		fmt.Fprintf(&b, "  %s[\"%s<br/>%s\"]\n", id, mermaidEscape(n.ResourceKey), mermaidEscape(nodeName(n)))
+	}
+	for _, e := range sg.Edges {
+		fmt.Fprintf(&b, "  %s -->|\"%s\"| %s\n", ids[e.Source.Key()], mermaidEscape(edgeText(e)), ids[e.Target.Key()])
+	}
+	return b.String()
+}
+
+// jsonGraphDocument is a JSON Graph Format (v2) document.
+// See https://jsongraphformat.info.
+type jsonGraphDocument struct {
+	Graph jsonGraph `json:"graph"`
+}
+
+type jsonGraph struct {
+	Directed bool                     `json:"directed"`
+	Type     string                   `json:"type"`
+	Nodes    map[string]jsonGraphNode `json:"nodes"`
+	Edges    []jsonGraphEdge          `json:"edges"`
+}
+
+type jsonGraphNode struct {
+	Label    string    `json:"label"`
+	Metadata GraphNode `json:"metadata"`
+}
+
+type jsonGraphEdge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Relation string `json:"relation"`
+	Label    string `json:"label,omitempty"`
+}
+
+// JSONGraph renders the subgraph as an indented JSON Graph Format document.
+// Node IDs are GraphNode keys and node metadata carries the full GraphNode.
+func (sg *Subgraph) JSONGraph() (string, error) {
+	doc := jsonGraphDocument{Graph: jsonGraph{
+		Directed: true,
+		Type:     "omniview.resource",
+		Nodes:    make(map[string]jsonGraphNode, len(sg.Nodes)),
+		Edges:    make([]jsonGraphEdge, 0, len(sg.Edges)),
+	}}
+	for _, n := range sg.Nodes {
+		doc.Graph.Nodes[n.Key()] = jsonGraphNode{Label: nodeName(n), Metadata: n}
+	}
+	for _, e := range sg.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, jsonGraphEdge{
+			Source:   e.Source.Key(),
+			Target:   e.Target.Key(),
+			Relation: string(e.Type),
+			Label:    e.Label,
+		})
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// nodeName is the human-readable part of a node label.
+func nodeName(n GraphNode) string {
+	if n.Namespace == "" {
+		return n.ID
+	}
+	return n.Namespace + "/" + n.ID
+}
+
+// edgeText combines an edge's label and relationship type for diagram output.
+func edgeText(e GraphEdge) string {
+	if e.Label == "" || e.Label == string(e.Type) {
+		return string(e.Type)
+	}
+	return e.Label + " (" + string(e.Type) + ")"
+}
+
+func dotQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
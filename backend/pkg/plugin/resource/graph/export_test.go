@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+)
+
+func TestNewSubgraph_DedupesAndSorts(t *testing.T) {
+	a := node("k8s", "c1", "core::v1::Pod", "default", "b")
+	b := node("k8s", "c1", "core::v1::Node", "", "a")
+	e := GraphEdge{Source: a, Target: b, Type: resource.RelRunsOn, Label: "runs on"}
+
+	sg := NewSubgraph([]GraphEdge{e, e}, a)
+	if len(sg.Edges) != 1 {
+		t.Fatalf("expected 1 edge, got %d", len(sg.Edges))
+	}
+	if len(sg.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(sg.Nodes))
+	}
+	if sg.Nodes[0].Key() > sg.Nodes[1].Key() {
+		t.Fatal("nodes not sorted by key")
+	}
+}
+
+func TestSubgraphFrom_IncludesIsolatedRoot(t *testing.T) {
+	g := NewRelationshipGraph()
+	root := node("k8s", "c1", "core::v1::Pod", "default", "lonely")
+
+	sg, err := g.SubgraphFrom(root, 3, Both)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sg.Nodes) != 1 || sg.Nodes[0].ID != "lonely" {
+		t.Fatalf("expected only the root, got %+v", sg.Nodes)
+	}
+	if len(sg.Edges) != 0 {
+		t.Fatalf("expected no edges, got %d", len(sg.Edges))
+	}
+}
+
+func TestSubgraphFrom_Hops(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+
+	sg, err := g.SubgraphFrom(deploy, 2, Outgoing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// deploy -> rs -> pod1, pod2
+	if len(sg.Nodes) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(sg.Nodes))
+	}
+	if len(sg.Edges) != 3 {
+		t.Fatalf("expected 3 edges, got %d", len(sg.Edges))
+	}
+}
+
+func TestConnectionSubgraph_IncludesCrossConnectionEdges(t *testing.T) {
+	g := buildTestGraph()
+	ext := node("aws", "acct", "ec2::v1::Instance", "", "i-1")
+	worker := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	other := node("k8s", "c2", "core::v1::Pod", "default", "x")
+	g.AddEdge(GraphEdge{Source: worker, Target: ext, Type: resource.RelRunsOn, Label: "backed by"})
+	g.AddEdge(GraphEdge{Source: other, Target: other, Type: resource.RelUses})
+
+	sg := g.ConnectionSubgraph("k8s", "c1")
+	if len(sg.Edges) != 9 {
+		t.Fatalf("expected 8 in-connection edges plus 1 cross edge, got %d", len(sg.Edges))
+	}
+	for _, n := range sg.Nodes {
+		if n.ConnectionID == "c2" {
+			t.Fatalf("unexpected node from another connection: %+v", n)
+		}
+	}
+}
+
+func TestSubgraph_DOT(t *testing.T) {
+	pod := node("k8s", "c1", "core::v1::Pod", "default", `we"b`)
+	n := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	sg := NewSubgraph([]GraphEdge{{Source: pod, Target: n, Type: resource.RelRunsOn, Label: "runs on"}})
+
+	out := sg.DOT()
+	for _, want := range []string{
+		"digraph omniview {",
+		`"k8s/c1/core::v1::Node//worker-1" [label="core::v1::Node\nworker-1", resource_key="core::v1::Node"];`,
+		`label="core::v1::Pod\ndefault/we\"b"`,
+		`-> "k8s/c1/core::v1::Node//worker-1" [label="runs on (runs_on)", type="runs_on"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("DOT output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSubgraph_Mermaid(t *testing.T) {
+	pod := node("k8s", "c1", "core::v1::Pod", "default", "web")
+	n := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	sg := NewSubgraph([]GraphEdge{{Source: pod, Target: n, Type: resource.RelRunsOn}})
+
+	out := sg.Mermaid()
+	// Nodes are numbered in key order: Node sorts before Pod.
+	for _, want := range []string{
+		"flowchart LR\n",
+		`n0["core::v1::Node<br/>worker-1"]`,
+		`n1["core::v1::Pod<br/>default/web"]`,
+		`n1 -->|"runs_on"| n0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("Mermaid output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestSubgraph_JSONGraph(t *testing.T) {
+	pod := node("k8s", "c1", "core::v1::Pod", "default", "web")
+	n := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	sg := NewSubgraph([]GraphEdge{{Source: pod, Target: n, Type: resource.RelRunsOn, Label: "runs on"}})
+
+	out, err := sg.JSONGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Graph struct {
+			Directed bool `json:"directed"`
+			Nodes    map[string]struct {
+				Label    string    `json:"label"`
+				Metadata GraphNode `json:"metadata"`
+			} `json:"nodes"`
+			Edges []struct {
+				Source   string `json:"source"`
+				Target   string `json:"target"`
+				Relation string `json:"relation"`
+				Label    string `json:"label"`
+			} `json:"edges"`
+		} `json:"graph"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Graph.Directed {
+		t.Fatal("expected directed graph")
+	}
+	if got := doc.Graph.Nodes[pod.Key()].Metadata.ResourceKey; got != "core::v1::Pod" {
+		t.Fatalf("expected node resource key, got %q", got)
+	}
+	if len(doc.Graph.Edges) != 1 || doc.Graph.Edges[0].Relation != "runs_on" || doc.Graph.Edges[0].Label != "runs on" {
+		t.Fatalf("unexpected edges %+v", doc.Graph.Edges)
+	}
+}
+
+func TestSubgraph_Export_UnknownFormat(t *testing.T) {
+	if _, err := NewSubgraph(nil).Export("png"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"maps"
+	"slices"
 	"strings"
 	"sync"
 
@@ -16,6 +17,13 @@ type RelationshipGraph struct {
 	pluginDecls   map[string][]declMapKey
 	selectorCache map[string]map[string]string
 	strings       *stringInterner
+
+	// Match-key index for cross-connection relationships (see MethodMatchKey).
+	matchTargets    map[string]map[string]GraphNode   // match key → nodeKey → target
+	matchSources    map[string]map[string]matchSource // match key → source ID → source
+	matchKeysByNode map[string][]string               // nodeKey → match keys it registered
+	matchEdges      map[string]struct{}               // edgeKeys created by match keys
+	matchSpecs      map[declMapKey][]*matchSpec       // parsed MethodMatchKey declarations
 }
 
 func NewRelationshipGraph() *RelationshipGraph {
@@ -26,6 +34,12 @@ func NewRelationshipGraph() *RelationshipGraph {
 		pluginDecls:   make(map[string][]declMapKey),
 		selectorCache: make(map[string]map[string]string),
 		strings:       newStringInterner(),
+
+		matchTargets:    make(map[string]map[string]GraphNode),
+		matchSources:    make(map[string]map[string]matchSource),
+		matchKeysByNode: make(map[string][]string),
+		matchEdges:      make(map[string]struct{}),
+		matchSpecs:      make(map[declMapKey][]*matchSpec),
 	}
 }
 
@@ -36,7 +50,10 @@ func edgeKey(e GraphEdge) string {
 func (g *RelationshipGraph) AddEdge(edge GraphEdge) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	g.addEdgeLocked(edge)
+}
 
+func (g *RelationshipGraph) addEdgeLocked(edge GraphEdge) {
 	srcKey := edge.Source.Key()
 	tgtKey := edge.Target.Key()
 
@@ -82,6 +99,7 @@ func (g *RelationshipGraph) RemoveEdgesForNode(nodeKey string) {
 
 	for _, edge := range g.forward[nodeKey] {
 		g.releaseEdgeStrings(edge)
+		delete(g.matchEdges, edgeKey(edge))
 		tgtKey := edge.Target.Key()
 		g.reverse[tgtKey] = removeEdgeFrom(g.reverse[tgtKey], nodeKey, true)
 		if len(g.reverse[tgtKey]) == 0 {
@@ -92,6 +110,7 @@ func (g *RelationshipGraph) RemoveEdgesForNode(nodeKey string) {
 
 	for _, edge := range g.reverse[nodeKey] {
 		g.releaseEdgeStrings(edge)
+		delete(g.matchEdges, edgeKey(edge))
 		srcKey := edge.Source.Key()
 		g.forward[srcKey] = removeEdgeFrom(g.forward[srcKey], nodeKey, false)
 		if len(g.forward[srcKey]) == 0 {
@@ -101,6 +120,7 @@ func (g *RelationshipGraph) RemoveEdgesForNode(nodeKey string) {
 	delete(g.reverse, nodeKey)
 
 	delete(g.selectorCache, nodeKey)
+	g.unregisterMatchNodeLocked(nodeKey)
 }
 
 func (g *RelationshipGraph) RemoveEdgesForConnection(pluginID, connectionID string) {
@@ -122,6 +142,7 @@ func (g *RelationshipGraph) RemoveEdgesForConnection(pluginID, connectionID stri
 	for _, nodeKey := range sourceKeys {
 		for _, edge := range g.forward[nodeKey] {
 			g.releaseEdgeStrings(edge)
+			delete(g.matchEdges, edgeKey(edge))
 			tgtKey := edge.Target.Key()
 			g.reverse[tgtKey] = removeEdgeFrom(g.reverse[tgtKey], nodeKey, true)
 			if len(g.reverse[tgtKey]) == 0 {
@@ -138,9 +159,32 @@ func (g *RelationshipGraph) RemoveEdgesForConnection(pluginID, connectionID stri
 	// nodes in this connection. We keep the forward entries and their
 	// interned strings (the external source still exists), but remove the
 	// reverse-index bookkeeping since the target node is gone.
-	for key := range g.reverse {
-		if strings.HasPrefix(key, prefix) {
-			delete(g.reverse, key)
+	//
+	// Match-key edges are the exception: they were inferred from the target's
+	// data, so they go with it and are re-linked if the target reappears.
+	for key, edges := range g.reverse {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		for _, edge := range edges {
+			ek := edgeKey(edge)
+			if _, ok := g.matchEdges[ek]; !ok {
+				continue
+			}
+			delete(g.matchEdges, ek)
+			g.releaseEdgeStrings(edge)
+			srcKey := edge.Source.Key()
+			g.forward[srcKey] = slices.DeleteFunc(g.forward[srcKey], func(e GraphEdge) bool { return edgeKey(e) == ek })
+			if len(g.forward[srcKey]) == 0 {
+				delete(g.forward, srcKey)
+			}
+		}
+		delete(g.reverse, key)
+	}
+
+	for nodeKey := range g.matchKeysByNode {
+		if strings.HasPrefix(nodeKey, prefix) {
+			g.unregisterMatchNodeLocked(nodeKey)
 		}
 	}
 }
@@ -156,6 +200,21 @@ func (g *RelationshipGraph) SetDeclarations(pluginID, resourceKey string, decls
 	dk := declMapKey{pluginID, resourceKey}
 	g.declarations[dk] = decls
 	g.pluginDecls[pluginID] = append(g.pluginDecls[pluginID], dk)
+	if old := g.matchSpecs[dk]; len(old) > 0 {
+		g.unregisterMatchSpecsLocked(old)
+	}
+
+	var specs []*matchSpec
+	for _, decl := range decls {
+		if spec, err := parseMatchSpec(decl); spec != nil && err == nil {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) > 0 {
+		g.matchSpecs[dk] = specs
+	} else {
+		delete(g.matchSpecs, dk)
+	}
 }
 
 // GetDeclarations returns declarations for a resource key across all plugins.
@@ -174,8 +233,14 @@ func (g *RelationshipGraph) GetDeclarations(resourceKey string) []resource.Relat
 func (g *RelationshipGraph) ClearDeclarationsForPlugin(pluginID string) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	var specs []*matchSpec
 	for _, dk := range g.pluginDecls[pluginID] {
 		delete(g.declarations, dk)
+		specs = append(specs, g.matchSpecs[dk]...)
+		delete(g.matchSpecs, dk)
+	}
+	if len(specs) > 0 {
+		g.unregisterMatchSpecsLocked(specs)
 	}
 	delete(g.pluginDecls, pluginID)
 }
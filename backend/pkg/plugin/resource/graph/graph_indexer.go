@@ -37,11 +37,6 @@ func (gi *GraphIndexer) OnDelete(entry registry.ResourceEntry) {
 }
 
 func (gi *GraphIndexer) extractEdges(entry registry.ResourceEntry, raw json.RawMessage) {
-	decls := gi.graph.GetDeclarations(entry.ResourceKey)
-	if len(decls) == 0 {
-		return
-	}
-
 	sourceNode := GraphNode{
 		PluginID:     entry.PluginID,
 		ConnectionID: entry.ConnectionID,
@@ -50,6 +45,13 @@ func (gi *GraphIndexer) extractEdges(entry registry.ResourceEntry, raw json.RawM
 		Namespace:    entry.Namespace,
 	}
 
+	gi.extractMatchKeys(sourceNode, raw)
+
+	decls := gi.graph.GetDeclarations(entry.ResourceKey)
+	if len(decls) == 0 {
+		return
+	}
+
 	for _, decl := range decls {
 		if decl.Extractor == nil {
 			continue
@@ -168,6 +170,26 @@ func (gi *GraphIndexer) extractLabelSelector(source GraphNode, entry registry.Re
 	}
 }
 
+// extractMatchKeys links node to resources in any plugin or connection via
+// MethodMatchKey declarations, both as a declaring source and as a target.
+// Events without a payload register no match keys.
+func (gi *GraphIndexer) extractMatchKeys(node GraphNode, raw json.RawMessage) {
+	if len(raw) == 0 {
+		return
+	}
+	for _, spec := range gi.graph.matchSpecsTargeting(node.PluginID, node.ResourceKey) {
+		for _, v := range spec.targetValues(raw) {
+			gi.graph.linkMatchTarget(spec.key(v), node)
+		}
+	}
+	for _, spec := range gi.graph.matchSpecsFor(node.ResourceKey) {
+		src := matchSource{node: node, spec: spec}
+		for _, v := range spec.sourceValues(raw) {
+			gi.graph.linkMatchSource(spec.key(v), src)
+		}
+	}
+}
+
 // CheckLabelSelectorsForEntry checks if any cached selectors match the labels
 // on a newly added/updated entry.
 func (gi *GraphIndexer) CheckLabelSelectorsForEntry(entry registry.ResourceEntry) {
@@ -455,3 +455,302 @@ func TestGraphIndexer_FieldPath_NamespacedTargetDefault(t *testing.T) {
 		t.Errorf("expected namespace 'production', got %q", edges[0].Target.Namespace)
 	}
 }
+
+// eksNodeDecl links a Kubernetes Node to the EC2 instance backing it.
+func eksNodeDecl(opts map[string]string) []resource.RelationshipDescriptor {
+	sel := map[string]string{
+		MatchOptTargetFieldPath: "InstanceId",
+		MatchOptPattern:         `(i-[0-9a-f]+)$`,
+	}
+	for k, v := range opts {
+		sel[k] = v
+	}
+	return []resource.RelationshipDescriptor{{
+		Type:              resource.RelRunsOn,
+		TargetResourceKey: "ec2::v1::Instance",
+		Label:             "backed by",
+		Extractor: &resource.RelationshipExtractor{
+			Method:        MethodMatchKey,
+			FieldPath:     "spec.providerID",
+			LabelSelector: sel,
+		},
+	}}
+}
+
+func crossEntry(plugin, conn, key, id string) registry.ResourceEntry {
+	return registry.ResourceEntry{PluginID: plugin, ConnectionID: conn, ResourceKey: key, ID: id}
+}
+
+var (
+	eksNodeRaw     = json.RawMessage(`{"spec":{"providerID":"aws:///us-east-1a/i-0abc123"}}`)
+	ec2InstanceRaw = json.RawMessage(`{"InstanceId":"i-0abc123","PrivateIpAddress":"10.0.1.5"}`)
+)
+
+// TestGraphIndexer_MatchKey_SourceThenTarget links a Node to an instance that
+// arrives later from another plugin.
+func TestGraphIndexer_MatchKey_SourceThenTarget(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	instEntry := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123")
+
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 {
+		t.Fatal("expected no edge before the instance is indexed")
+	}
+
+	idx.OnAdd(instEntry, ec2InstanceRaw)
+
+	edges := g.EdgesFrom(nodeEntry.EntryKey())
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 edge, got %d", len(edges))
+	}
+	if edges[0].Target.PluginID != "aws" || edges[0].Target.ConnectionID != "acct-1" || edges[0].Target.ID != "i-0abc123" {
+		t.Fatalf("unexpected target %+v", edges[0].Target)
+	}
+	if edges[0].Type != resource.RelRunsOn || edges[0].Label != "backed by" {
+		t.Fatalf("unexpected edge %+v", edges[0])
+	}
+}
+
+// TestGraphIndexer_MatchKey_TargetThenSource links a Node to an instance that
+// was indexed first.
+func TestGraphIndexer_MatchKey_TargetThenSource(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+
+	instEntry := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123")
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+
+	idx.OnAdd(instEntry, ec2InstanceRaw)
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+
+	if len(g.EdgesTo(instEntry.EntryKey())) != 1 {
+		t.Fatalf("expected 1 incoming edge on the instance, got %d", len(g.EdgesTo(instEntry.EntryKey())))
+	}
+}
+
+// TestGraphIndexer_MatchKey_ArrayValues matches any of several source values.
+func TestGraphIndexer_MatchKey_ArrayValues(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", []resource.RelationshipDescriptor{{
+		Type:              resource.RelRunsOn,
+		TargetResourceKey: "ec2::v1::Instance",
+		Label:             "same ip",
+		Extractor: &resource.RelationshipExtractor{
+			Method:        MethodMatchKey,
+			FieldPath:     "status.addresses.#.address",
+			LabelSelector: map[string]string{MatchOptTargetFieldPath: "PrivateIpAddress"},
+		},
+	}})
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	idx.OnAdd(nodeEntry, json.RawMessage(`{"status":{"addresses":[{"address":"ip-10-0-1-5.internal"},{"address":"10.0.1.5"}]}}`))
+	idx.OnAdd(crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123"), ec2InstanceRaw)
+	idx.OnAdd(crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-other"), json.RawMessage(`{"PrivateIpAddress":"10.0.9.9"}`))
+
+	edges := g.EdgesFrom(nodeEntry.EntryKey())
+	if len(edges) != 1 || edges[0].Target.ID != "i-0abc123" {
+		t.Fatalf("expected a single edge to i-0abc123, got %+v", edges)
+	}
+}
+
+// TestGraphIndexer_MatchKey_TargetPlugin ignores targets from other plugins.
+func TestGraphIndexer_MatchKey_TargetPlugin(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(map[string]string{MatchOptTargetPlugin: "aws"}))
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+	idx.OnAdd(crossEntry("mock-cloud", "x", "ec2::v1::Instance", "i-0abc123"), ec2InstanceRaw)
+
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 {
+		t.Fatal("expected targets from other plugins to be ignored")
+	}
+}
+
+// TestGraphIndexer_MatchKey_IncomingDirection reverses the edge.
+func TestGraphIndexer_MatchKey_IncomingDirection(t *testing.T) {
+	g, _, idx := setupIndexer()
+	decls := eksNodeDecl(nil)
+	decls[0].Direction = resource.EdgeIncoming
+	g.SetDeclarations("k8s", "core::v1::Node", decls)
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	instEntry := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123")
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+	idx.OnAdd(instEntry, ec2InstanceRaw)
+
+	if len(g.EdgesFrom(instEntry.EntryKey())) != 1 {
+		t.Fatal("expected the edge to originate at the instance")
+	}
+}
+
+// TestGraphIndexer_MatchKey_UpdateAndDelete relinks on update and unlinks on delete.
+func TestGraphIndexer_MatchKey_UpdateAndDelete(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	instA := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123")
+	instB := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0def456")
+	idx.OnAdd(instA, ec2InstanceRaw)
+	idx.OnAdd(instB, json.RawMessage(`{"InstanceId":"i-0def456"}`))
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+
+	// The node is replaced by a different instance.
+	idx.OnUpdate(nodeEntry, nodeEntry, json.RawMessage(`{"spec":{"providerID":"aws:///us-east-1a/i-0def456"}}`))
+	edges := g.EdgesFrom(nodeEntry.EntryKey())
+	if len(edges) != 1 || edges[0].Target.ID != "i-0def456" {
+		t.Fatalf("expected edge to i-0def456 after update, got %+v", edges)
+	}
+
+	idx.OnDelete(instB)
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 {
+		t.Fatal("expected edge removed with its target")
+	}
+
+	// The target coming back relinks to the still-registered source.
+	idx.OnAdd(instB, json.RawMessage(`{"InstanceId":"i-0def456"}`))
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 1 {
+		t.Fatal("expected edge restored when the target reappears")
+	}
+}
+
+// TestGraphIndexer_MatchKey_RemoveTargetConnection drops cross-connection
+// edges when the target's connection goes away and relinks when it returns.
+func TestGraphIndexer_MatchKey_RemoveTargetConnection(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	instEntry := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123")
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+	idx.OnAdd(instEntry, ec2InstanceRaw)
+
+	g.RemoveEdgesForConnection("aws", "acct-1")
+
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 {
+		t.Fatal("expected cross-connection edge removed with the target connection")
+	}
+	if len(g.EdgesTo(instEntry.EntryKey())) != 0 {
+		t.Fatal("expected no reverse entries left for the removed connection")
+	}
+
+	idx.OnAdd(instEntry, ec2InstanceRaw)
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 1 {
+		t.Fatal("expected edge relinked when the connection comes back")
+	}
+}
+
+// TestGraphIndexer_MatchKey_RemoveSourceConnection drops the source's edges
+// and its match registrations.
+func TestGraphIndexer_MatchKey_RemoveSourceConnection(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	instEntry := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123")
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+	idx.OnAdd(instEntry, ec2InstanceRaw)
+
+	g.RemoveEdgesForConnection("k8s", "eks-prod")
+
+	if len(g.EdgesTo(instEntry.EntryKey())) != 0 {
+		t.Fatal("expected cross-connection edge removed with the source connection")
+	}
+
+	// A re-delivered instance must not resurrect the removed source.
+	idx.OnUpdate(instEntry, instEntry, ec2InstanceRaw)
+	if len(g.EdgesTo(instEntry.EntryKey())) != 0 {
+		t.Fatal("expected removed source to stay unlinked")
+	}
+}
+
+// TestGraphIndexer_MatchKey_InvalidDeclarationIgnored skips declarations
+// missing a target path or with a bad pattern.
+func TestGraphIndexer_MatchKey_InvalidDeclarationIgnored(t *testing.T) {
+	g, _, idx := setupIndexer()
+	decls := eksNodeDecl(map[string]string{MatchOptPattern: "("})
+	g.SetDeclarations("k8s", "core::v1::Node", decls)
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	idx.OnAdd(crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123"), ec2InstanceRaw)
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 {
+		t.Fatal("expected invalid declaration to be ignored")
+	}
+}
+
+// TestGraphIndexer_MatchKey_ClearDeclarationsDropsSources stops linking new
+// targets to sources of a plugin whose declarations were cleared.
+func TestGraphIndexer_MatchKey_ClearDeclarationsDropsSources(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+
+	g.ClearDeclarationsForPlugin("k8s")
+	if len(g.matchSources) != 0 || len(g.matchKeysByNode) != 0 {
+		t.Fatalf("expected match sources dropped, got %d sources and %d nodes", len(g.matchSources), len(g.matchKeysByNode))
+	}
+
+	idx.OnAdd(crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123"), ec2InstanceRaw)
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 {
+		t.Fatal("expected no edge from a source whose declarations were cleared")
+	}
+}
+
+// TestGraphIndexer_MatchKey_ClearDeclarationsDropsEdges removes the edges the
+// cleared declarations created, leaving the target linkable again later.
+func TestGraphIndexer_MatchKey_ClearDeclarationsDropsEdges(t *testing.T) {
+	g, _, idx := setupIndexer()
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	instEntry := crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123")
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+	idx.OnAdd(instEntry, ec2InstanceRaw)
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 1 {
+		t.Fatal("expected the node linked before clearing")
+	}
+
+	g.ClearDeclarationsForPlugin("k8s")
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 || len(g.EdgesTo(instEntry.EntryKey())) != 0 {
+		t.Fatal("expected the match edge removed with its declaration")
+	}
+	if len(g.matchEdges) != 0 {
+		t.Fatalf("expected no match edges left, got %d", len(g.matchEdges))
+	}
+
+	g.SetDeclarations("k8s", "core::v1::Node", eksNodeDecl(nil))
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 1 {
+		t.Fatal("expected the node relinked once declared again")
+	}
+}
+
+// TestGraphIndexer_MatchKey_UnknownOptionIgnored rejects declarations whose
+// options contain a key the method does not define.
+func TestGraphIndexer_MatchKey_UnknownOptionIgnored(t *testing.T) {
+	g, _, idx := setupIndexer()
+	decls := eksNodeDecl(map[string]string{"app": "web"})
+	if err := ValidateMatchKey(decls[0]); err == nil {
+		t.Fatal("expected an unknown option to be reported")
+	}
+	g.SetDeclarations("k8s", "core::v1::Node", decls)
+
+	nodeEntry := crossEntry("k8s", "eks-prod", "core::v1::Node", "ip-10-0-1-5")
+	idx.OnAdd(crossEntry("aws", "acct-1", "ec2::v1::Instance", "i-0abc123"), ec2InstanceRaw)
+	idx.OnAdd(nodeEntry, eksNodeRaw)
+
+	if len(g.EdgesFrom(nodeEntry.EntryKey())) != 0 {
+		t.Fatal("expected declaration with an unknown option to be ignored")
+	}
+	if err := ValidateMatchKey(eksNodeDecl(nil)[0]); err != nil {
+		t.Fatalf("expected a valid declaration, got %v", err)
+	}
+}
@@ -0,0 +1,410 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+)
+
+// MethodMatchKey is the RelationshipExtractor method for relationships that
+// cross connection and plugin boundaries. Instead of naming the target by ID,
+// the declaring (source) resource and the target resource each expose a value
+// — an ARN, provider ID, IP address, etc. — and an edge is created wherever
+// they are equal, regardless of which plugin or connection the target lives in.
+//
+// Extractor.FieldPath is the gjson path to the value on the source payload.
+// Extractor.LabelSelector carries the match options, and only these keys:
+//
+//	targetFieldPath  (required) gjson path to the value on the target payload
+//	targetPlugin     (optional) only match targets from this plugin
+//	pattern          (optional) regexp applied to source values
+//	targetPattern    (optional) regexp applied to target values
+//
+// Patterns normalize values before comparison: the first capture group is
+// used if present, otherwise the whole match; values that do not match are
+// ignored. For example, an EKS Node with spec.providerID
+// "aws:///us-east-1a/i-0abc" links to an ec2::v1::Instance with InstanceId
+// "i-0abc" using pattern "(i-[0-9a-f]+)$" and targetFieldPath "InstanceId".
+//
+// Declarations with a missing path, an invalid pattern or an unknown option
+// key are ignored; ValidateMatchKey reports why.
+//
+// Targets are indexed as they are added or updated. Targets indexed before the
+// declaring plugin registered its declarations are re-indexed by the
+// controller once the declarations are set (see MatchTargets).
+const MethodMatchKey = "matchKey"
+
+// Option keys for MethodMatchKey declarations, read from Extractor.LabelSelector.
+const (
+	MatchOptTargetFieldPath = "targetFieldPath"
+	MatchOptTargetPlugin    = "targetPlugin"
+	MatchOptPattern         = "pattern"
+	MatchOptTargetPattern   = "targetPattern"
+)
+
+var matchOptKeys = []string{MatchOptTargetFieldPath, MatchOptTargetPlugin, MatchOptPattern, MatchOptTargetPattern}
+
+// ValidateMatchKey reports why a MethodMatchKey declaration would be ignored.
+// It returns nil for valid declarations and for declarations using any other
+// method.
+func ValidateMatchKey(decl resource.RelationshipDescriptor) error {
+	_, err := parseMatchSpec(decl)
+	return err
+}
+
+// MatchTarget names the resources a MethodMatchKey declaration links to.
+// PluginID is empty when targets from any plugin are matched.
+type MatchTarget struct {
+	PluginID    string
+	ResourceKey string
+}
+
+// MatchTargets returns the distinct targets of the valid MethodMatchKey
+// declarations in decls.
+func MatchTargets(decls []resource.RelationshipDescriptor) []MatchTarget {
+	var targets []MatchTarget
+	for _, decl := range decls {
+		spec, err := parseMatchSpec(decl)
+		if spec == nil || err != nil {
+			continue
+		}
+		t := MatchTarget{PluginID: spec.targetPlugin, ResourceKey: decl.TargetResourceKey}
+		if !slices.Contains(targets, t) {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// matchSpec is a parsed MethodMatchKey declaration.
+type matchSpec struct {
+	decl              resource.RelationshipDescriptor
+	sourcePath        string
+	targetPath        string
+	targetPlugin      string
+	sourcePattern     *regexp.Regexp
+	targetPattern     *regexp.Regexp
+	targetPatternExpr string
+}
+
+// parseMatchSpec validates a MethodMatchKey declaration. It returns nil and
+// no error for declarations using any other method.
+func parseMatchSpec(decl resource.RelationshipDescriptor) (*matchSpec, error) {
+	if decl.Extractor == nil || decl.Extractor.Method != MethodMatchKey {
+		return nil, nil
+	}
+	opts := decl.Extractor.LabelSelector
+	for k := range opts {
+		if !slices.Contains(matchOptKeys, k) {
+			return nil, fmt.Errorf("unknown %s option %q", MethodMatchKey, k)
+		}
+	}
+	spec := &matchSpec{
+		decl:              decl,
+		sourcePath:        decl.Extractor.FieldPath,
+		targetPath:        opts[MatchOptTargetFieldPath],
+		targetPlugin:      opts[MatchOptTargetPlugin],
+		targetPatternExpr: opts[MatchOptTargetPattern],
+	}
+	switch {
+	case spec.sourcePath == "":
+		return nil, fmt.Errorf("%s declaration has no field path", MethodMatchKey)
+	case spec.targetPath == "":
+		return nil, fmt.Errorf("%s declaration has no %s option", MethodMatchKey, MatchOptTargetFieldPath)
+	case decl.TargetResourceKey == "":
+		return nil, fmt.Errorf("%s declaration has no target resource key", MethodMatchKey)
+	}
+	var err error
+	if p := opts[MatchOptPattern]; p != "" {
+		if spec.sourcePattern, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid %s option: %w", MatchOptPattern, err)
+		}
+	}
+	if p := opts[MatchOptTargetPattern]; p != "" {
+		if spec.targetPattern, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid %s option: %w", MatchOptTargetPattern, err)
+		}
+	}
+	return spec, nil
+}
+
+// key returns the match-index key for value. Sources and targets of the same
+// declaration produce identical keys for equal normalized values.
+func (s *matchSpec) key(value string) string {
+	return s.decl.TargetResourceKey + "\x00" + s.targetPlugin + "\x00" + s.targetPath + "\x00" + s.targetPatternExpr + "\x00" + value
+}
+
+func (s *matchSpec) sourceValues(raw []byte) []string {
+	return extractMatchValues(raw, s.sourcePath, s.sourcePattern)
+}
+
+func (s *matchSpec) targetValues(raw []byte) []string {
+	return extractMatchValues(raw, s.targetPath, s.targetPattern)
+}
+
+func extractMatchValues(raw []byte, path string, pattern *regexp.Regexp) []string {
+	result := gjson.GetBytes(raw, path)
+	if !result.Exists() {
+		return nil
+	}
+	var values []string
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if pattern != nil {
+			m := pattern.FindStringSubmatch(v)
+			switch {
+			case m == nil:
+				return
+			case len(m) > 1:
+				v = m[1]
+			default:
+				v = m[0]
+			}
+		}
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	if result.IsArray() {
+		result.ForEach(func(_, value gjson.Result) bool {
+			add(value.String())
+			return true
+		})
+	} else {
+		add(result.String())
+	}
+	return values
+}
+
+// matchSource is a source node waiting for targets with a given match key.
+type matchSource struct {
+	node GraphNode
+	spec *matchSpec
+}
+
+// edge returns the edge between the source and target, honoring the
+// declaration's direction.
+func (m matchSource) edge(target GraphNode) GraphEdge {
+	decl := m.spec.decl
+	if decl.Direction == resource.EdgeIncoming {
+		return GraphEdge{Source: target, Target: m.node, Type: decl.Type, Label: decl.Label}
+	}
+	return GraphEdge{Source: m.node, Target: target, Type: decl.Type, Label: decl.Label}
+}
+
+// matchSpecsFor returns the MethodMatchKey declarations made by resources of
+// the given key, across all plugins (mirroring GetDeclarations).
+func (g *RelationshipGraph) matchSpecsFor(resourceKey string) []*matchSpec {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var specs []*matchSpec
+	for dk, s := range g.matchSpecs {
+		if dk.resourceKey == resourceKey {
+			specs = append(specs, s...)
+		}
+	}
+	return specs
+}
+
+// matchSpecsTargeting returns the MethodMatchKey declarations, from any
+// plugin, whose targets include resources of the given plugin and key.
+func (g *RelationshipGraph) matchSpecsTargeting(pluginID, resourceKey string) []*matchSpec {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var specs []*matchSpec
+	for _, s := range g.matchSpecs {
+		for _, spec := range s {
+			if spec.decl.TargetResourceKey != resourceKey {
+				continue
+			}
+			if spec.targetPlugin != "" && spec.targetPlugin != pluginID {
+				continue
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// linkMatchSource registers src under key and links it to every target already
+// registered under key.
+func (g *RelationshipGraph) linkMatchSource(key string, src matchSource) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	srcKey := src.node.Key()
+	sources := g.matchSources[key]
+	if sources == nil {
+		sources = make(map[string]matchSource)
+		g.matchSources[key] = sources
+	}
+	sources[srcKey+"\x00"+string(src.spec.decl.Type)+"\x00"+src.spec.decl.Label] = src
+	g.matchKeysByNode[srcKey] = appendUnique(g.matchKeysByNode[srcKey], key)
+
+	for tgtKey, target := range g.matchTargets[key] {
+		if tgtKey != srcKey {
+			g.addMatchEdgeLocked(src.edge(target))
+		}
+	}
+}
+
+// linkMatchTarget registers target under key and links it to every source
+// already registered under key.
+func (g *RelationshipGraph) linkMatchTarget(key string, target GraphNode) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tgtKey := target.Key()
+	targets := g.matchTargets[key]
+	if targets == nil {
+		targets = make(map[string]GraphNode)
+		g.matchTargets[key] = targets
+	}
+	targets[tgtKey] = target
+	g.matchKeysByNode[tgtKey] = appendUnique(g.matchKeysByNode[tgtKey], key)
+
+	for _, src := range g.matchSources[key] {
+		if src.node.Key() != tgtKey {
+			g.addMatchEdgeLocked(src.edge(target))
+		}
+	}
+}
+
+func (g *RelationshipGraph) addMatchEdgeLocked(edge GraphEdge) {
+	g.matchEdges[edgeKey(edge)] = struct{}{}
+	g.addEdgeLocked(edge)
+}
+
+// unregisterMatchNodeLocked drops every match-index registration for nodeKey.
+// Caller must hold g.mu for writing.
+func (g *RelationshipGraph) unregisterMatchNodeLocked(nodeKey string) {
+	for _, key := range g.matchKeysByNode[nodeKey] {
+		if targets, ok := g.matchTargets[key]; ok {
+			delete(targets, nodeKey)
+			if len(targets) == 0 {
+				delete(g.matchTargets, key)
+			}
+		}
+		if sources, ok := g.matchSources[key]; ok {
+			for id, src := range sources {
+				if src.node.Key() == nodeKey {
+					delete(sources, id)
+				}
+			}
+			if len(sources) == 0 {
+				delete(g.matchSources, key)
+			}
+		}
+	}
+	delete(g.matchKeysByNode, nodeKey)
+}
+
+// unregisterMatchSpecsLocked drops the sources registered under specs, for
+// declarations that are being removed, along with the edges they created.
+// Caller must hold g.mu for writing.
+func (g *RelationshipGraph) unregisterMatchSpecsLocked(specs []*matchSpec) {
+	for key, sources := range g.matchSources {
+		var removed []matchSource
+		for id, src := range sources {
+			if !slices.Contains(specs, src.spec) {
+				continue
+			}
+			delete(sources, id)
+			removed = append(removed, src)
+			g.dropNodeMatchKeyLocked(src.node.Key(), key)
+		}
+		for _, src := range removed {
+			g.unlinkMatchSourceLocked(key, src)
+		}
+		if len(sources) == 0 {
+			delete(g.matchSources, key)
+		}
+	}
+}
+
+// unlinkMatchSourceLocked removes the edges src created to the targets
+// registered under key, keeping any edge a remaining source under key also
+// implies.
+func (g *RelationshipGraph) unlinkMatchSourceLocked(key string, src matchSource) {
+	srcKey := src.node.Key()
+	for tgtKey, target := range g.matchTargets[key] {
+		if tgtKey == srcKey {
+			continue
+		}
+		edge := src.edge(target)
+		if !g.matchEdgeImpliedLocked(key, target, edgeKey(edge)) {
+			g.removeMatchEdgeLocked(edge)
+		}
+	}
+}
+
+// matchEdgeImpliedLocked reports whether a source still registered under key
+// links to target with the edge ek.
+func (g *RelationshipGraph) matchEdgeImpliedLocked(key string, target GraphNode, ek string) bool {
+	for _, src := range g.matchSources[key] {
+		if src.node.Key() != target.Key() && edgeKey(src.edge(target)) == ek {
+			return true
+		}
+	}
+	return false
+}
+
+// removeMatchEdgeLocked removes an edge created by a match key. Edges that were
+// not created by a match key are left alone. Caller must hold g.mu for writing.
+func (g *RelationshipGraph) removeMatchEdgeLocked(edge GraphEdge) {
+	ek := edgeKey(edge)
+	if _, ok := g.matchEdges[ek]; !ok {
+		return
+	}
+	delete(g.matchEdges, ek)
+
+	srcKey := edge.Source.Key()
+	tgtKey := edge.Target.Key()
+	g.forward[srcKey] = slices.DeleteFunc(g.forward[srcKey], func(e GraphEdge) bool {
+		if edgeKey(e) != ek {
+			return false
+		}
+		g.releaseEdgeStrings(e)
+		return true
+	})
+	if len(g.forward[srcKey]) == 0 {
+		delete(g.forward, srcKey)
+	}
+	g.reverse[tgtKey] = slices.DeleteFunc(g.reverse[tgtKey], func(e GraphEdge) bool { return edgeKey(e) == ek })
+	if len(g.reverse[tgtKey]) == 0 {
+		delete(g.reverse, tgtKey)
+	}
+}
+
+// dropNodeMatchKeyLocked removes key from nodeKey's registered match keys
+// once the node is neither a source nor a target under it.
+func (g *RelationshipGraph) dropNodeMatchKeyLocked(nodeKey, key string) {
+	if _, ok := g.matchTargets[key][nodeKey]; ok {
+		return
+	}
+	for _, src := range g.matchSources[key] {
+		if src.node.Key() == nodeKey {
+			return
+		}
+	}
+	keys := slices.DeleteFunc(g.matchKeysByNode[nodeKey], func(k string) bool { return k == key })
+	if len(keys) == 0 {
+		delete(g.matchKeysByNode, nodeKey)
+		return
+	}
+	g.matchKeysByNode[nodeKey] = keys
+}
+
+func appendUnique(keys []string, key string) []string {
+	for _, k := range keys {
+		if k == key {
+			return keys
+		}
+	}
+	return append(keys, key)
+}
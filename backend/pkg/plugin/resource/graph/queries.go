@@ -1,5 +1,13 @@
 package graph
 
+import (
+	"cmp"
+	"slices"
+)
+
+// maxBlastRadiusHops caps GetBlastRadius traversal depth.
+const maxBlastRadiusHops = 10
+
 // GetRelated returns edges connected to the given node, filtered by direction and type.
 func (g *RelationshipGraph) GetRelated(ref GraphNode, direction Direction, relType *RelationshipType) ([]GraphEdge, error) {
 	g.mu.RLock()
@@ -89,6 +97,27 @@ func (g *RelationshipGraph) GetDependencyTree(ref GraphNode, maxDepth int) (*Dep
 	}, nil
 }
 
+// GetReverseDependencyTree follows ALL incoming edge types recursively from
+// root, i.e. everything that depends on root. Each DependencyNode's Edge keeps
+// its original orientation, so the dependent is Edge.Source.
+// maxDepth is capped at 5 to prevent explosion.
+func (g *RelationshipGraph) GetReverseDependencyTree(ref GraphNode, maxDepth int) (*DependencyTree, error) {
+	if maxDepth > 5 {
+		maxDepth = 5
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	visited := map[string]bool{ref.Key(): true}
+	children := g.buildParents(ref.Key(), maxDepth, 0, visited)
+
+	return &DependencyTree{
+		Root:     ref,
+		Children: children,
+	}, nil
+}
+
 func (g *RelationshipGraph) buildChildren(nodeKey string, maxDepth, currentDepth int, visited map[string]bool) []DependencyNode {
 	if currentDepth >= maxDepth {
 		return nil
@@ -110,3 +139,152 @@ func (g *RelationshipGraph) buildChildren(nodeKey string, maxDepth, currentDepth
 	}
 	return children
 }
+
+func (g *RelationshipGraph) buildParents(nodeKey string, maxDepth, currentDepth int, visited map[string]bool) []DependencyNode {
+	if currentDepth >= maxDepth {
+		return nil
+	}
+
+	var children []DependencyNode
+	for _, e := range g.reverse[nodeKey] {
+		srcKey := e.Source.Key()
+		if visited[srcKey] {
+			continue
+		}
+		visited[srcKey] = true
+
+		child := DependencyNode{
+			Edge:     e,
+			Children: g.buildParents(srcKey, maxDepth, currentDepth+1, visited),
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+// ShortestPath returns the edges along a shortest path from one node to
+// another, in traversal order. direction controls which way edges may be
+// walked: Outgoing follows Source→Target, Incoming follows Target→Source, and
+// Both ignores orientation. relTypes restricts the edges considered (empty
+// means all). Returns an empty path when from == to and nil when no path
+// exists.
+func (g *RelationshipGraph) ShortestPath(from, to GraphNode, direction Direction, relTypes []RelationshipType) ([]GraphEdge, error) {
+	fromKey, toKey := from.Key(), to.Key()
+	if fromKey == toKey {
+		return []GraphEdge{}, nil
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	// prev records, for every visited node, the edge used to reach it.
+	prev := map[string]GraphEdge{}
+	visited := map[string]bool{fromKey: true}
+	frontier := []string{fromKey}
+
+	for len(frontier) > 0 {
+		var nextFrontier []string
+		for _, nodeKey := range frontier {
+			for _, step := range g.neighborsLocked(nodeKey, direction, relTypes) {
+				if visited[step.key] {
+					continue
+				}
+				visited[step.key] = true
+				prev[step.key] = step.edge
+				if step.key == toKey {
+					return buildPath(prev, fromKey, toKey), nil
+				}
+				nextFrontier = append(nextFrontier, step.key)
+			}
+		}
+		frontier = nextFrontier
+	}
+
+	return nil, nil
+}
+
+// GetBlastRadius returns every node reachable from ref within maxHops hops,
+// following edges in the given direction and restricted to relTypes (empty
+// means all), along with the edges traversed. Nodes are ordered by distance.
+// maxHops is capped at 10.
+func (g *RelationshipGraph) GetBlastRadius(ref GraphNode, maxHops int, direction Direction, relTypes []RelationshipType) (*BlastRadius, error) {
+	if maxHops > maxBlastRadiusHops {
+		maxHops = maxBlastRadiusHops
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	result := &BlastRadius{Root: ref, Nodes: []BlastRadiusNode{}, Edges: []GraphEdge{}}
+	visited := map[string]bool{ref.Key(): true}
+	seenEdges := map[string]bool{}
+	frontier := []string{ref.Key()}
+
+	for depth := 1; depth <= maxHops && len(frontier) > 0; depth++ {
+		var nextFrontier []string
+		for _, nodeKey := range frontier {
+			for _, step := range g.neighborsLocked(nodeKey, direction, relTypes) {
+				if ek := edgeKey(step.edge); !seenEdges[ek] {
+					seenEdges[ek] = true
+					result.Edges = append(result.Edges, step.edge)
+				}
+				if visited[step.key] {
+					continue
+				}
+				visited[step.key] = true
+				result.Nodes = append(result.Nodes, BlastRadiusNode{Node: step.node, Distance: depth})
+				nextFrontier = append(nextFrontier, step.key)
+			}
+		}
+		frontier = nextFrontier
+	}
+
+	slices.SortStableFunc(result.Nodes, func(a, b BlastRadiusNode) int {
+		return cmp.Or(cmp.Compare(a.Distance, b.Distance), cmp.Compare(a.Node.Key(), b.Node.Key()))
+	})
+	return result, nil
+}
+
+// traversalStep is an edge walked from a node and the node it leads to.
+type traversalStep struct {
+	edge GraphEdge
+	node GraphNode
+	key  string
+}
+
+// neighborsLocked returns the steps available from nodeKey. Caller must hold g.mu.
+func (g *RelationshipGraph) neighborsLocked(nodeKey string, direction Direction, relTypes []RelationshipType) []traversalStep {
+	var steps []traversalStep
+	if direction == Outgoing || direction == Both {
+		for _, e := range g.forward[nodeKey] {
+			if len(relTypes) == 0 || slices.Contains(relTypes, e.Type) {
+				steps = append(steps, traversalStep{edge: e, node: e.Target, key: e.Target.Key()})
+			}
+		}
+	}
+	if direction == Incoming || direction == Both {
+		for _, e := range g.reverse[nodeKey] {
+			if len(relTypes) == 0 || slices.Contains(relTypes, e.Type) {
+				steps = append(steps, traversalStep{edge: e, node: e.Source, key: e.Source.Key()})
+			}
+		}
+	}
+	return steps
+}
+
+// buildPath walks prev back from toKey to fromKey and returns the edges in
+// traversal order.
+func buildPath(prev map[string]GraphEdge, fromKey, toKey string) []GraphEdge {
+	var path []GraphEdge
+	for key := toKey; key != fromKey; {
+		e := prev[key]
+		path = append(path, e)
+		if e.Target.Key() == key {
+			key = e.Source.Key()
+		} else {
+			key = e.Target.Key()
+		}
+	}
+	slices.Reverse(path)
+	return path
+}
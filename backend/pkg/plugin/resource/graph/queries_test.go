@@ -106,3 +106,127 @@ func TestGetDependencyTree_MaxDepthCap(t *testing.T) {
 	if err != nil { t.Fatal(err) }
 	if tree == nil { t.Fatal("expected non-nil tree") }
 }
+
+func TestGetReverseDependencyTree(t *testing.T) {
+	g := buildTestGraph()
+	nodeN := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	tree, err := g.GetReverseDependencyTree(nodeN, 5)
+	if err != nil { t.Fatal(err) }
+	if len(tree.Children) != 2 { t.Fatalf("expected 2 pods, got %d", len(tree.Children)) }
+	// Both pods are owned by the same RS; the second pod's subtree must not repeat it.
+	total := 0
+	var walk func([]DependencyNode)
+	walk = func(nodes []DependencyNode) {
+		for _, n := range nodes {
+			total++
+			walk(n.Children)
+		}
+	}
+	walk(tree.Children)
+	// pod1, pod2, rs, svc, deploy
+	if total != 5 { t.Fatalf("expected 5 dependents, got %d", total) }
+	if tree.Children[0].Edge.Target.ID != "worker-1" { t.Fatalf("edge orientation should be preserved, got target %s", tree.Children[0].Edge.Target.ID) }
+}
+
+func TestGetReverseDependencyTree_Leafless(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+	tree, err := g.GetReverseDependencyTree(deploy, 3)
+	if err != nil { t.Fatal(err) }
+	if len(tree.Children) != 0 { t.Fatalf("expected no dependents, got %d", len(tree.Children)) }
+}
+
+func TestShortestPath_Outgoing(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+	nodeN := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	path, err := g.ShortestPath(deploy, nodeN, Outgoing, nil)
+	if err != nil { t.Fatal(err) }
+	if len(path) != 3 { t.Fatalf("expected 3 hops, got %d", len(path)) }
+	if path[0].Source.ID != "web" || path[2].Target.ID != "worker-1" { t.Fatalf("unexpected path %+v", path) }
+	for i := 1; i < len(path); i++ {
+		if path[i-1].Target.Key() != path[i].Source.Key() { t.Fatalf("path is not contiguous at %d", i) }
+	}
+}
+
+func TestShortestPath_DirectionMatters(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+	nodeN := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+
+	path, err := g.ShortestPath(nodeN, deploy, Outgoing, nil)
+	if err != nil { t.Fatal(err) }
+	if path != nil { t.Fatalf("expected no outgoing path, got %d edges", len(path)) }
+
+	path, err = g.ShortestPath(nodeN, deploy, Incoming, nil)
+	if err != nil { t.Fatal(err) }
+	if len(path) != 3 { t.Fatalf("expected 3 hops, got %d", len(path)) }
+	if path[0].Target.ID != "worker-1" { t.Fatalf("first edge should point at the start node, got %s", path[0].Target.ID) }
+}
+
+func TestShortestPath_BothUsesShortcut(t *testing.T) {
+	g := buildTestGraph()
+	svc := node("k8s", "c1", "core::v1::Service", "default", "web-svc")
+	secret := node("k8s", "c1", "core::v1::Secret", "default", "db-creds")
+	path, err := g.ShortestPath(secret, svc, Both, nil)
+	if err != nil { t.Fatal(err) }
+	if len(path) != 2 { t.Fatalf("expected secret<-pod1<-svc (2 hops), got %d", len(path)) }
+}
+
+func TestShortestPath_FilterByType(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+	nodeN := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	path, err := g.ShortestPath(deploy, nodeN, Outgoing, []RelationshipType{resource.RelOwns})
+	if err != nil { t.Fatal(err) }
+	if path != nil { t.Fatalf("expected no owns-only path, got %d edges", len(path)) }
+}
+
+func TestShortestPath_SameNode(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+	path, err := g.ShortestPath(deploy, deploy, Both, nil)
+	if err != nil { t.Fatal(err) }
+	if path == nil || len(path) != 0 { t.Fatalf("expected empty non-nil path, got %v", path) }
+}
+
+func TestGetBlastRadius_Incoming(t *testing.T) {
+	g := buildTestGraph()
+	nodeN := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	br, err := g.GetBlastRadius(nodeN, 1, Incoming, nil)
+	if err != nil { t.Fatal(err) }
+	if len(br.Nodes) != 2 { t.Fatalf("expected 2 pods at 1 hop, got %d", len(br.Nodes)) }
+	for _, n := range br.Nodes {
+		if n.Distance != 1 || n.Node.ResourceKey != "core::v1::Pod" { t.Fatalf("unexpected node %+v", n) }
+	}
+	if len(br.Edges) != 2 { t.Fatalf("expected 2 edges, got %d", len(br.Edges)) }
+}
+
+func TestGetBlastRadius_BothDirectionsOrderedByDistance(t *testing.T) {
+	g := buildTestGraph()
+	nodeN := node("k8s", "c1", "core::v1::Node", "", "worker-1")
+	br, err := g.GetBlastRadius(nodeN, 2, Both, nil)
+	if err != nil { t.Fatal(err) }
+	// hop 1: pod1, pod2; hop 2: rs, svc, secret
+	if len(br.Nodes) != 5 { t.Fatalf("expected 5 nodes, got %d", len(br.Nodes)) }
+	for i := 1; i < len(br.Nodes); i++ {
+		if br.Nodes[i-1].Distance > br.Nodes[i].Distance { t.Fatal("nodes not ordered by distance") }
+	}
+	if br.Nodes[4].Distance != 2 { t.Fatalf("expected farthest distance 2, got %d", br.Nodes[4].Distance) }
+}
+
+func TestGetBlastRadius_FilterByType(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+	br, err := g.GetBlastRadius(deploy, 10, Both, []RelationshipType{resource.RelOwns})
+	if err != nil { t.Fatal(err) }
+	if len(br.Nodes) != 3 { t.Fatalf("expected rs + 2 pods, got %d", len(br.Nodes)) }
+}
+
+func TestGetBlastRadius_ZeroHops(t *testing.T) {
+	g := buildTestGraph()
+	deploy := node("k8s", "c1", "apps::v1::Deployment", "default", "web")
+	br, err := g.GetBlastRadius(deploy, 0, Both, nil)
+	if err != nil { t.Fatal(err) }
+	if br.Nodes == nil || len(br.Nodes) != 0 { t.Fatalf("expected empty non-nil nodes, got %v", br.Nodes) }
+}
@@ -56,3 +56,16 @@ type DependencyNode struct {
 	Edge     GraphEdge        `json:"edge"`
 	Children []DependencyNode `json:"children"`
 }
+
+// BlastRadius is the set of nodes reachable from Root within a hop limit.
+type BlastRadius struct {
+	Root  GraphNode         `json:"root"`
+	Nodes []BlastRadiusNode `json:"nodes"`
+	Edges []GraphEdge       `json:"edges"`
+}
+
+// BlastRadiusNode is a node in a BlastRadius with its hop distance from the root.
+type BlastRadiusNode struct {
+	Node     GraphNode `json:"node"`
+	Distance int       `json:"distance"`
+}
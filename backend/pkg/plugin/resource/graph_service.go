@@ -27,16 +27,9 @@ func (s *GraphService) GetRelated(
 		ResourceKey: resourceKey, ID: id, Namespace: namespace,
 	}
 
-	var dir graph.Direction
-	switch direction {
-	case "outgoing":
-		dir = graph.Outgoing
-	case "incoming":
-		dir = graph.Incoming
-	case "both":
-		dir = graph.Both
-	default:
-		return nil, fmt.Errorf("invalid direction: %q (must be \"outgoing\", \"incoming\", or \"both\")", direction)
+	dir, err := parseDirection(direction)
+	if err != nil {
+		return nil, err
 	}
 
 	var rt *graph.RelationshipType
@@ -71,3 +64,101 @@ func (s *GraphService) GetDependencyTree(
 	}
 	return s.graph.GetDependencyTree(ref, maxDepth)
 }
+
+// GetReverseDependencyTree follows all incoming edges recursively, returning
+// everything that depends on the given node.
+func (s *GraphService) GetReverseDependencyTree(
+	pluginID, connectionID, resourceKey, namespace, id string,
+	maxDepth int,
+) (*graph.DependencyTree, error) {
+	ref := graph.GraphNode{
+		PluginID: pluginID, ConnectionID: connectionID,
+		ResourceKey: resourceKey, ID: id, Namespace: namespace,
+	}
+	return s.graph.GetReverseDependencyTree(ref, maxDepth)
+}
+
+// ShortestPath returns the edges along a shortest path between two nodes, or
+// nil if they are not connected.
+func (s *GraphService) ShortestPath(
+	from, to graph.GraphNode,
+	direction string, // "outgoing", "incoming", "both"
+	relTypes []string, // optional filter; empty means all
+) ([]graph.GraphEdge, error) {
+	dir, err := parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	return s.graph.ShortestPath(from, to, dir, toRelationshipTypes(relTypes))
+}
+
+// GetBlastRadius returns all nodes reachable within maxHops of the given node.
+func (s *GraphService) GetBlastRadius(
+	pluginID, connectionID, resourceKey, namespace, id string,
+	maxHops int,
+	direction string, // "outgoing", "incoming", "both"
+	relTypes []string, // optional filter; empty means all
+) (*graph.BlastRadius, error) {
+	ref := graph.GraphNode{
+		PluginID: pluginID, ConnectionID: connectionID,
+		ResourceKey: resourceKey, ID: id, Namespace: namespace,
+	}
+	dir, err := parseDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+	return s.graph.GetBlastRadius(ref, maxHops, dir, toRelationshipTypes(relTypes))
+}
+
+// ExportSubgraph serializes the subgraph within maxHops of the given node.
+// format is "dot", "mermaid" or "json" (JSON Graph Format).
+func (s *GraphService) ExportSubgraph(
+	pluginID, connectionID, resourceKey, namespace, id string,
+	maxHops int,
+	direction string, // "outgoing", "incoming", "both"
+	format string,
+) (string, error) {
+	ref := graph.GraphNode{
+		PluginID: pluginID, ConnectionID: connectionID,
+		ResourceKey: resourceKey, ID: id, Namespace: namespace,
+	}
+	dir, err := parseDirection(direction)
+	if err != nil {
+		return "", err
+	}
+	sg, err := s.graph.SubgraphFrom(ref, maxHops, dir)
+	if err != nil {
+		return "", err
+	}
+	return sg.Export(graph.ExportFormat(format))
+}
+
+// ExportConnection serializes every edge touching the given connection.
+// format is "dot", "mermaid" or "json" (JSON Graph Format).
+func (s *GraphService) ExportConnection(pluginID, connectionID, format string) (string, error) {
+	return s.graph.ConnectionSubgraph(pluginID, connectionID).Export(graph.ExportFormat(format))
+}
+
+func parseDirection(direction string) (graph.Direction, error) {
+	switch direction {
+	case "outgoing":
+		return graph.Outgoing, nil
+	case "incoming":
+		return graph.Incoming, nil
+	case "both":
+		return graph.Both, nil
+	default:
+		return 0, fmt.Errorf("invalid direction: %q (must be \"outgoing\", \"incoming\", or \"both\")", direction)
+	}
+}
+
+func toRelationshipTypes(relTypes []string) []graph.RelationshipType {
+	if len(relTypes) == 0 {
+		return nil
+	}
+	out := make([]graph.RelationshipType, len(relTypes))
+	for i, t := range relTypes {
+		out[i] = graph.RelationshipType(t)
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package resource
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/graph"
@@ -84,3 +85,119 @@ func TestGraphService_GetDependencyTree(t *testing.T) {
 		t.Fatalf("expected 1 child, got %d", len(tree.Children))
 	}
 }
+
+func TestGraphService_GetRelated_InvalidDirection(t *testing.T) {
+	svc := NewGraphService(graph.NewRelationshipGraph())
+
+	if _, err := svc.GetRelated("k8s", "c1", "core::v1::Pod", "default", "nginx", "sideways", nil); err == nil {
+		t.Fatal("expected error for invalid direction")
+	}
+}
+
+func TestGraphService_GetReverseDependencyTree(t *testing.T) {
+	g := graph.NewRelationshipGraph()
+	svc := NewGraphService(g)
+
+	pod := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Pod", ID: "nginx", Namespace: "default"}
+	node := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Node", ID: "worker-1"}
+	g.AddEdge(graph.GraphEdge{Source: pod, Target: node, Type: sdkresource.RelRunsOn, Label: "runs on"})
+
+	tree, err := svc.GetReverseDependencyTree("k8s", "c1", "core::v1::Node", "", "worker-1", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Children) != 1 || tree.Children[0].Edge.Source.ID != "nginx" {
+		t.Fatalf("expected nginx as the only dependent, got %+v", tree.Children)
+	}
+}
+
+func TestGraphService_ShortestPath(t *testing.T) {
+	g := graph.NewRelationshipGraph()
+	svc := NewGraphService(g)
+
+	svcNode := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Service", ID: "web", Namespace: "default"}
+	pod := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Pod", ID: "nginx", Namespace: "default"}
+	node := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Node", ID: "worker-1"}
+	g.AddEdge(graph.GraphEdge{Source: svcNode, Target: pod, Type: sdkresource.RelExposes, Label: "exposes"})
+	g.AddEdge(graph.GraphEdge{Source: pod, Target: node, Type: sdkresource.RelRunsOn, Label: "runs on"})
+
+	path, err := svc.ShortestPath(svcNode, node, "outgoing", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(path))
+	}
+
+	path, err = svc.ShortestPath(svcNode, node, "outgoing", []string{string(sdkresource.RelRunsOn)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != nil {
+		t.Fatalf("expected no path when filtering out exposes, got %d edges", len(path))
+	}
+
+	if _, err := svc.ShortestPath(svcNode, node, "", nil); err == nil {
+		t.Fatal("expected error for invalid direction")
+	}
+}
+
+func TestGraphService_GetBlastRadius(t *testing.T) {
+	g := graph.NewRelationshipGraph()
+	svc := NewGraphService(g)
+
+	subnet := graph.GraphNode{PluginID: "aws", ConnectionID: "a1", ResourceKey: "ec2::v1::Subnet", ID: "subnet-1"}
+	inst := graph.GraphNode{PluginID: "aws", ConnectionID: "a1", ResourceKey: "ec2::v1::Instance", ID: "i-1"}
+	lb := graph.GraphNode{PluginID: "aws", ConnectionID: "a1", ResourceKey: "elb::v1::LoadBalancer", ID: "lb-1"}
+	g.AddEdge(graph.GraphEdge{Source: inst, Target: subnet, Type: sdkresource.RelMemberOf, Label: "in subnet"})
+	g.AddEdge(graph.GraphEdge{Source: lb, Target: inst, Type: sdkresource.RelUses, Label: "targets"})
+
+	br, err := svc.GetBlastRadius("aws", "a1", "ec2::v1::Subnet", "", "subnet-1", 3, "incoming", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(br.Nodes) != 2 {
+		t.Fatalf("expected instance and load balancer, got %d nodes", len(br.Nodes))
+	}
+	if br.Nodes[0].Node.ID != "i-1" || br.Nodes[1].Distance != 2 {
+		t.Fatalf("unexpected blast radius %+v", br.Nodes)
+	}
+}
+
+func TestGraphService_ExportSubgraph(t *testing.T) {
+	g := graph.NewRelationshipGraph()
+	svc := NewGraphService(g)
+
+	pod := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Pod", ID: "nginx", Namespace: "default"}
+	node := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Node", ID: "worker-1"}
+	g.AddEdge(graph.GraphEdge{Source: pod, Target: node, Type: sdkresource.RelRunsOn, Label: "runs on"})
+
+	out, err := svc.ExportSubgraph("k8s", "c1", "core::v1::Pod", "default", "nginx", 2, "outgoing", "mermaid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `-->|"runs on (runs_on)"|`) {
+		t.Fatalf("unexpected mermaid output:\n%s", out)
+	}
+
+	if _, err := svc.ExportSubgraph("k8s", "c1", "core::v1::Pod", "default", "nginx", 2, "outgoing", "svg"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestGraphService_ExportConnection(t *testing.T) {
+	g := graph.NewRelationshipGraph()
+	svc := NewGraphService(g)
+
+	pod := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Pod", ID: "nginx", Namespace: "default"}
+	node := graph.GraphNode{PluginID: "k8s", ConnectionID: "c1", ResourceKey: "core::v1::Node", ID: "worker-1"}
+	g.AddEdge(graph.GraphEdge{Source: pod, Target: node, Type: sdkresource.RelRunsOn, Label: "runs on"})
+
+	out, err := svc.ExportConnection("k8s", "c1", "dot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"k8s/c1/core::v1::Pod/default/nginx" -> "k8s/c1/core::v1::Node//worker-1"`) {
+		t.Fatalf("unexpected DOT output:\n%s", out)
+	}
+}
@@ -0,0 +1,72 @@
+package resource
+
+import (
+	"context"
+	"time"
+)
+
+// HistoryMaxRevisionsSetting is the setting holding how many revisions of a
+// resource the revision history keeps.
+const HistoryMaxRevisionsSetting = "general.historyMaxRevisions"
+
+// HistoryMaxAgeSetting is the setting holding how many hours revisions are
+// kept in the revision history.
+const HistoryMaxAgeSetting = "general.historyMaxAge"
+
+// historyPruneInterval is how often the whole revision history is pruned.
+// Writes only prune the resource they touch, so resources that stop changing
+// would otherwise never expire.
+const historyPruneInterval = 10 * time.Minute
+
+// startHistoryPruner prunes the revision history right away, so changed
+// retention settings apply from startup, then on a timer until
+// stopHistoryPruner is called.
+func (c *controller) startHistoryPruner() {
+	if c.historyStore == nil || c.historyPruneStop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	c.historyPruneStop, c.historyPruneDone = stop, done
+	go func() {
+		defer close(done)
+		c.pruneHistory()
+		ticker := time.NewTicker(historyPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.pruneHistory()
+			}
+		}
+	}()
+}
+
+// stopHistoryPruner stops the pruner and waits for a prune in progress.
+func (c *controller) stopHistoryPruner() {
+	if c.historyPruneStop == nil {
+		return
+	}
+	close(c.historyPruneStop)
+	<-c.historyPruneDone
+	c.historyPruneStop = nil
+}
+
+// pruneHistory applies the retention settings to the revision history and
+// prunes it.
+func (c *controller) pruneHistory() {
+	maxRevisions, maxAge := 0, 0
+	if c.settingsProvider != nil {
+		if value, err := c.settingsProvider.GetInt(HistoryMaxRevisionsSetting); err == nil {
+			maxRevisions = value
+		}
+		if value, err := c.settingsProvider.GetInt(HistoryMaxAgeSetting); err == nil {
+			maxAge = value
+		}
+	}
+	c.historyStore.SetRetention(maxRevisions, time.Duration(maxAge)*time.Hour)
+	if err := c.historyStore.Prune(); err != nil {
+		c.logger.Warnw(context.Background(), "failed to prune resource history", "error", err)
+	}
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChangeOp is the kind of a single JSON change, named after RFC 6902 operations.
+type ChangeOp string
+
+const (
+	ChangeAdd     ChangeOp = "add"
+	ChangeRemove  ChangeOp = "remove"
+	ChangeReplace ChangeOp = "replace"
+)
+
+// Change is one difference between two JSON documents. Path is an RFC 6901
+// JSON Pointer ("" is the whole document).
+type Change struct {
+	Op       ChangeOp `json:"op"`
+	Path     string   `json:"path"`
+	OldValue any      `json:"oldValue,omitempty"`
+	NewValue any      `json:"newValue,omitempty"`
+}
+
+// Diff computes the changes that turn from into to. Objects are compared key by
+// key and arrays index by index; any other difference replaces the value.
+// An empty document (e.g. a delete revision) is absent, so diffing against it
+// yields a single add or remove of the whole document. Changes within an
+// object are ordered by key.
+func Diff(from, to json.RawMessage) ([]Change, error) {
+	a, err := decodeDoc(from)
+	if err != nil {
+		return nil, fmt.Errorf("decode old document: %w", err)
+	}
+	b, err := decodeDoc(to)
+	if err != nil {
+		return nil, fmt.Errorf("decode new document: %w", err)
+	}
+	changes := []Change{}
+	switch {
+	case len(from) == 0 && len(to) == 0:
+	case len(from) == 0:
+		changes = append(changes, Change{Op: ChangeAdd, Path: "", NewValue: b})
+	case len(to) == 0:
+		changes = append(changes, Change{Op: ChangeRemove, Path: "", OldValue: a})
+	default:
+		diffValues("", a, b, &changes)
+	}
+	return changes, nil
+}
+
+func decodeDoc(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func diffValues(path string, a, b any, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]any:
+		if bv, ok := b.(map[string]any); ok {
+			diffObjects(path, av, bv, changes)
+			return
+		}
+	case []any:
+		if bv, ok := b.([]any); ok {
+			diffArrays(path, av, bv, changes)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Op: ChangeReplace, Path: path, OldValue: a, NewValue: b})
+	}
+}
+
+func diffObjects(path string, a, b map[string]any, changes *[]Change) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		child := path + "/" + escapePointer(k)
+		av, inA := a[k]
+		bv, inB := b[k]
+		switch {
+		case !inA:
+			*changes = append(*changes, Change{Op: ChangeAdd, Path: child, NewValue: bv})
+		case !inB:
+			*changes = append(*changes, Change{Op: ChangeRemove, Path: child, OldValue: av})
+		default:
+			diffValues(child, av, bv, changes)
+		}
+	}
+}
+
+func diffArrays(path string, a, b []any, changes *[]Change) {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		child := path + "/" + strconv.Itoa(i)
+		switch {
+		case i >= len(a):
+			*changes = append(*changes, Change{Op: ChangeAdd, Path: child, NewValue: b[i]})
+		case i >= len(b):
+			*changes = append(*changes, Change{Op: ChangeRemove, Path: child, OldValue: a[i]})
+		default:
+			diffValues(child, a[i], b[i], changes)
+		}
+	}
+}
+
+// escapePointer escapes a key for use as an RFC 6901 reference token.
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,74 @@
+package history
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff_Objects(t *testing.T) {
+	changes, err := Diff(
+		json.RawMessage(`{"spec":{"replicas":2,"paused":false},"metadata":{"labels":{"a":"1"}}}`),
+		json.RawMessage(`{"spec":{"replicas":3},"metadata":{"labels":{"a":"1","b/c":"2"}}}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Op: ChangeAdd, Path: "/metadata/labels/b~1c", NewValue: "2"},
+		{Op: ChangeRemove, Path: "/spec/paused", OldValue: false},
+		{Op: ChangeReplace, Path: "/spec/replicas", OldValue: float64(2), NewValue: float64(3)},
+	}, changes)
+}
+
+func TestDiff_Arrays(t *testing.T) {
+	changes, err := Diff(
+		json.RawMessage(`{"containers":[{"image":"web:1"},{"image":"sidecar:1"}]}`),
+		json.RawMessage(`{"containers":[{"image":"web:2"}]}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Op: ChangeReplace, Path: "/containers/0/image", OldValue: "web:1", NewValue: "web:2"},
+		{Op: ChangeRemove, Path: "/containers/1", OldValue: map[string]any{"image": "sidecar:1"}},
+	}, changes)
+}
+
+func TestDiff_NullIsAValue(t *testing.T) {
+	changes, err := Diff(json.RawMessage(`{"a":null}`), json.RawMessage(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Op: ChangeReplace, Path: "/a", NewValue: float64(1)}}, changes)
+}
+
+func TestDiff_TypeChangeReplaces(t *testing.T) {
+	changes, err := Diff(json.RawMessage(`{"a":{"b":1}}`), json.RawMessage(`{"a":[1]}`))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ChangeReplace, changes[0].Op)
+	assert.Equal(t, "/a", changes[0].Path)
+}
+
+func TestDiff_EmptyDocuments(t *testing.T) {
+	changes, err := Diff(nil, json.RawMessage(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Op: ChangeAdd, Path: "", NewValue: map[string]any{"a": float64(1)}}}, changes)
+
+	changes, err = Diff(json.RawMessage(`{"a":1}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, ChangeRemove, changes[0].Op)
+
+	changes, err = Diff(nil, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, changes)
+	assert.Empty(t, changes)
+}
+
+func TestDiff_Identical(t *testing.T) {
+	changes, err := Diff(json.RawMessage(`{"a":[1,2]}`), json.RawMessage(`{ "a": [1, 2] }`))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiff_InvalidJSON(t *testing.T) {
+	_, err := Diff(json.RawMessage(`{`), json.RawMessage(`{}`))
+	assert.Error(t, err)
+}
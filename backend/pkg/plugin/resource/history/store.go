@@ -0,0 +1,408 @@
+package history
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/boltdb"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+)
+
+// Default retention limits.
+const (
+	DefaultMaxRevisions = 20
+	DefaultMaxAge       = 24 * time.Hour
+)
+
+var revisionsBucket = []byte("revisions")
+
+// keySep separates the entry key from the big-endian sequence number in
+// revision keys. NUL cannot appear in entry keys, so a prefix scan on
+// "entryKey\x00" never matches another resource.
+const keySep = 0x00
+
+// Op is the kind of change a revision records.
+type Op string
+
+const (
+	OpAdd    Op = "add"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+// Revision is a recorded version of a resource.
+type Revision struct {
+	Seq  uint64          `json:"seq"`
+	Op   Op              `json:"op"`
+	Time time.Time       `json:"time"`
+	Hash string          `json:"hash"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// RevisionInfo describes a revision without its payload.
+type RevisionInfo struct {
+	Seq  uint64    `json:"seq"`
+	Op   Op        `json:"op"`
+	Time time.Time `json:"time"`
+	Size int       `json:"size"`
+}
+
+// ErrRevisionNotFound is returned when a revision does not exist or has been
+// pruned.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+type config struct {
+	maxRevisions int
+	maxAge       time.Duration
+	now          func() time.Time
+}
+
+// Option configures a Store.
+type Option func(*config)
+
+// WithMaxRevisions sets how many revisions are kept per resource. Values <= 0
+// keep the default.
+func WithMaxRevisions(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.maxRevisions = n
+		}
+	}
+}
+
+// WithMaxAge sets how long revisions are kept. The newest revision of a
+// resource is kept regardless of age so it can serve as a diff baseline,
+// unless it is a delete: a resource deleted longer ago than the limit is
+// dropped entirely. Values <= 0 keep the default.
+func WithMaxAge(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.maxAge = d
+		}
+	}
+}
+
+// Store is a bounded, per-resource revision history persisted to a bbolt
+// database. It implements indexer.ResourceIndexer so it can be fed from a
+// Dispatcher; identical consecutive payloads are recorded once.
+//
+// Revisions live in a single bucket keyed by "entryKey\x00<seq>" where seq is
+// a big-endian store-wide sequence, so a resource's history is a contiguous,
+// ordered range.
+type Store struct {
+	db *bolt.DB
+
+	mu  sync.RWMutex // guards cfg
+	cfg config
+}
+
+// Open opens (or creates) a history database at path and prunes revisions
+// outside the retention limits. If the file is corrupt, it is renamed with a
+// .corrupt.<unix-timestamp> suffix and a fresh database is created — losing
+// history is preferable to failing startup.
+func Open(path string, opts ...Option) (*Store, error) {
+	cfg := config{
+		maxRevisions: DefaultMaxRevisions,
+		maxAge:       DefaultMaxAge,
+		now:          time.Now,
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	db, err := boltdb.Open(path, "history", &bolt.Options{Timeout: 1 * time.Second, NoSync: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(revisionsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize history bucket: %w", err)
+	}
+
+	s := &Store{db: db, cfg: cfg}
+	if err := s.Prune(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close flushes pending writes to disk and closes the database.
+// It is safe to call on a nil Store.
+func (s *Store) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	if err := s.db.Sync(); err != nil {
+		s.db.Close()
+		return fmt.Errorf("sync history database: %w", err)
+	}
+	return s.db.Close()
+}
+
+// ============================================================================
+// indexer.ResourceIndexer
+// ============================================================================
+
+func (s *Store) Name() string { return "history" }
+
+func (s *Store) OnAdd(entry registry.ResourceEntry, raw json.RawMessage) {
+	s.record(entry, OpAdd, raw)
+}
+
+func (s *Store) OnUpdate(_, new_ registry.ResourceEntry, raw json.RawMessage) {
+	s.record(new_, OpUpdate, raw)
+}
+
+func (s *Store) OnDelete(entry registry.ResourceEntry) {
+	s.record(entry, OpDelete, nil)
+}
+
+func (s *Store) record(e registry.ResourceEntry, op Op, raw json.RawMessage) {
+	if err := s.Record(e.EntryKey(), op, raw); err != nil {
+		log.Printf("history: failed to record %s revision for %s: %v", op, e.EntryKey(), err)
+	}
+}
+
+// ============================================================================
+// Writes
+// ============================================================================
+
+// Record appends a revision for the resource identified by entryKey and
+// enforces retention for it. Add and update revisions without a payload, and
+// those identical to the resource's latest revision, are skipped.
+func (s *Store) Record(entryKey string, op Op, raw json.RawMessage) error {
+	if op != OpDelete && len(raw) == 0 {
+		return nil
+	}
+
+	var hash string
+	if len(raw) > 0 {
+		sum := sha256.Sum256(raw)
+		hash = hex.EncodeToString(sum[:])
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(revisionsBucket)
+		prefix := revisionPrefix(entryKey)
+
+		if last, ok := lastRevision(b, prefix); ok {
+			if last.Op == OpDelete && op == OpDelete {
+				return nil
+			}
+			if op != OpDelete && last.Op != OpDelete && last.Hash == hash {
+				return nil
+			}
+		} else if op == OpDelete {
+			// Nothing known about this resource; a bare tombstone adds nothing.
+			return nil
+		}
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(Revision{
+			Seq:  seq,
+			Op:   op,
+			Time: s.retention().now().UTC(),
+			Hash: hash,
+			Data: raw,
+		})
+		if err != nil {
+			return err
+		}
+		if err := b.Put(revisionKey(prefix, seq), data); err != nil {
+			return err
+		}
+		return s.pruneResource(b, prefix, s.retention())
+	})
+}
+
+// SetRetention changes the retention limits, taking effect on the next write
+// or Prune. Values <= 0 restore the defaults.
+func (s *Store) SetRetention(maxRevisions int, maxAge time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.maxRevisions, s.cfg.maxAge = DefaultMaxRevisions, DefaultMaxAge
+	WithMaxRevisions(maxRevisions)(&s.cfg)
+	WithMaxAge(maxAge)(&s.cfg)
+}
+
+func (s *Store) retention() config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// Prune enforces retention for every resource. Record only prunes the
+// resource it writes, so Prune should run periodically to expire resources
+// that are no longer changing.
+func (s *Store) Prune() error {
+	cfg := s.retention()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(revisionsBucket)
+		var prefixes [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; {
+			i := bytes.IndexByte(k, keySep)
+			if i < 0 {
+				k, _ = c.Next()
+				continue
+			}
+			prefix := append([]byte(nil), k[:i+1]...)
+			prefixes = append(prefixes, prefix)
+			// Jump past this resource's range.
+			k, _ = c.Seek(append(append([]byte(nil), k[:i]...), keySep+1))
+		}
+		for _, prefix := range prefixes {
+			if err := s.pruneResource(b, prefix, cfg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// pruneResource deletes revisions under prefix beyond the count limit or
+// older than the age limit. The newest one is kept unless it is an expired
+// delete, in which case the resource's whole history goes.
+func (s *Store) pruneResource(b *bolt.Bucket, prefix []byte, cfg config) error {
+	type item struct {
+		key  []byte
+		time time.Time
+		op   Op
+	}
+	var items []item
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		var rev Revision
+		if err := json.Unmarshal(v, &rev); err != nil {
+			// Unreadable revisions are dropped by treating them as expired.
+			items = append(items, item{key: append([]byte(nil), k...)})
+			continue
+		}
+		items = append(items, item{key: append([]byte(nil), k...), time: rev.Time, op: rev.Op})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	cutoff := cfg.now().Add(-cfg.maxAge)
+	keep := items[:len(items)-1]
+	if newest := items[len(items)-1]; newest.op == OpDelete && newest.time.Before(cutoff) {
+		keep = items
+	}
+	overflow := len(items) - cfg.maxRevisions
+	for i, it := range keep {
+		if i < overflow || it.time.Before(cutoff) {
+			if err := b.Delete(it.key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// Reads
+// ============================================================================
+
+// List returns the retained revisions of a resource, oldest first.
+func (s *Store) List(entryKey string) ([]RevisionInfo, error) {
+	infos := []RevisionInfo{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		prefix := revisionPrefix(entryKey)
+		c := tx.Bucket(revisionsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var rev Revision
+			if err := json.Unmarshal(v, &rev); err != nil {
+				continue
+			}
+			infos = append(infos, RevisionInfo{Seq: rev.Seq, Op: rev.Op, Time: rev.Time, Size: len(rev.Data)})
+		}
+		return nil
+	})
+	return infos, err
+}
+
+// Get returns a single revision of a resource.
+func (s *Store) Get(entryKey string, seq uint64) (*Revision, error) {
+	var rev *Revision
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(revisionsBucket).Get(revisionKey(revisionPrefix(entryKey), seq))
+		if v == nil {
+			return ErrRevisionNotFound
+		}
+		rev = &Revision{}
+		return json.Unmarshal(v, rev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// DiffRevisions returns the changes from revision fromSeq to revision toSeq of
+// a resource. A delete revision compares as null.
+func (s *Store) DiffRevisions(entryKey string, fromSeq, toSeq uint64) ([]Change, error) {
+	from, err := s.Get(entryKey, fromSeq)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.Get(entryKey, toSeq)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(from.Data, to.Data)
+}
+
+// ============================================================================
+// Keys
+// ============================================================================
+
+func revisionPrefix(entryKey string) []byte {
+	return append([]byte(entryKey), keySep)
+}
+
+func revisionKey(prefix []byte, seq uint64) []byte {
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], seq)
+	return key
+}
+
+// lastRevision returns the newest revision under prefix.
+func lastRevision(b *bolt.Bucket, prefix []byte) (Revision, bool) {
+	c := b.Cursor()
+	end := append(append([]byte(nil), prefix[:len(prefix)-1]...), keySep+1)
+	k, v := c.Seek(end)
+	if k == nil {
+		k, v = c.Last()
+	} else {
+		k, v = c.Prev()
+	}
+	if k == nil || !bytes.HasPrefix(k, prefix) {
+		return Revision{}, false
+	}
+	var rev Revision
+	if err := json.Unmarshal(v, &rev); err != nil {
+		return Revision{}, false
+	}
+	return rev, true
+}
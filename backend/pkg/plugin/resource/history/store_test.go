@@ -0,0 +1,261 @@
+package history
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+)
+
+// fakeClock is a controllable time source for retention tests.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func withClock(c *fakeClock) Option {
+	return func(cfg *config) { cfg.now = c.now }
+}
+
+func openTestStore(t *testing.T, opts ...Option) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := Open(path, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+var deployment = registry.ResourceEntry{
+	PluginID: "k8s", ConnectionID: "prod", ResourceKey: "apps::v1::Deployment", Namespace: "default", ID: "web",
+}
+
+func TestStore_RecordAndList(t *testing.T) {
+	s, _ := openTestStore(t)
+	key := deployment.EntryKey()
+
+	s.OnAdd(deployment, json.RawMessage(`{"spec":{"replicas":1}}`))
+	s.OnUpdate(deployment, deployment, json.RawMessage(`{"spec":{"replicas":2}}`))
+	s.OnDelete(deployment)
+
+	revs, err := s.List(key)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	assert.Equal(t, []Op{OpAdd, OpUpdate, OpDelete}, []Op{revs[0].Op, revs[1].Op, revs[2].Op})
+	assert.Less(t, revs[0].Seq, revs[1].Seq)
+	assert.Equal(t, 0, revs[2].Size)
+
+	rev, err := s.Get(key, revs[1].Seq)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"spec":{"replicas":2}}`, string(rev.Data))
+}
+
+func TestStore_ListUnknownIsEmpty(t *testing.T) {
+	s, _ := openTestStore(t)
+
+	revs, err := s.List("nope")
+	require.NoError(t, err)
+	assert.NotNil(t, revs)
+	assert.Empty(t, revs)
+}
+
+func TestStore_SkipsDuplicatesAndEmptyPayloads(t *testing.T) {
+	s, _ := openTestStore(t)
+	key := deployment.EntryKey()
+
+	s.OnAdd(deployment, json.RawMessage(`{"a":1}`))
+	s.OnAdd(deployment, json.RawMessage(`{"a":1}`)) // watch resync
+	s.OnUpdate(deployment, deployment, nil)
+	s.OnDelete(deployment)
+	s.OnDelete(deployment)
+
+	revs, err := s.List(key)
+	require.NoError(t, err)
+	assert.Len(t, revs, 2)
+}
+
+func TestStore_DeleteOfUnknownResourceIsSkipped(t *testing.T) {
+	s, _ := openTestStore(t)
+
+	s.OnDelete(deployment)
+
+	revs, err := s.List(deployment.EntryKey())
+	require.NoError(t, err)
+	assert.Empty(t, revs)
+}
+
+func TestStore_ResourcesAreIsolated(t *testing.T) {
+	s, _ := openTestStore(t)
+	other := deployment
+	other.ID = "web-2"
+
+	s.OnAdd(deployment, json.RawMessage(`{"a":1}`))
+	s.OnAdd(other, json.RawMessage(`{"a":2}`))
+
+	revs, err := s.List(deployment.EntryKey())
+	require.NoError(t, err)
+	assert.Len(t, revs, 1)
+}
+
+func TestStore_MaxRevisions(t *testing.T) {
+	s, _ := openTestStore(t, WithMaxRevisions(3))
+	key := deployment.EntryKey()
+
+	for i := range 5 {
+		s.OnAdd(deployment, json.RawMessage(`{"n":`+string(rune('0'+i))+`}`))
+	}
+
+	revs, err := s.List(key)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	rev, err := s.Get(key, revs[0].Seq)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"n":2}`, string(rev.Data))
+}
+
+func TestStore_MaxAgeKeepsNewest(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	s, _ := openTestStore(t, WithMaxAge(time.Hour), withClock(clock))
+	key := deployment.EntryKey()
+
+	s.OnAdd(deployment, json.RawMessage(`{"n":1}`))
+	clock.t = clock.t.Add(30 * time.Minute)
+	s.OnAdd(deployment, json.RawMessage(`{"n":2}`))
+	clock.t = clock.t.Add(45 * time.Minute)
+	s.OnAdd(deployment, json.RawMessage(`{"n":3}`))
+
+	revs, err := s.List(key)
+	require.NoError(t, err)
+	assert.Len(t, revs, 2, "the first revision is older than an hour")
+
+	// Everything expires, but the newest revision stays as a baseline.
+	clock.t = clock.t.Add(24 * time.Hour)
+	require.NoError(t, s.Prune())
+	revs, err = s.List(key)
+	require.NoError(t, err)
+	require.Len(t, revs, 1)
+	rev, err := s.Get(key, revs[0].Seq)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"n":3}`, string(rev.Data))
+}
+
+func TestStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := Open(path)
+	require.NoError(t, err)
+	s.OnAdd(deployment, json.RawMessage(`{"a":1}`))
+	require.NoError(t, s.Close())
+
+	s, err = Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	// A resync after restart with the same payload is not a new revision.
+	s.OnAdd(deployment, json.RawMessage(`{"a":1}`))
+	s.OnAdd(deployment, json.RawMessage(`{"a":2}`))
+
+	revs, err := s.List(deployment.EntryKey())
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+	assert.Less(t, revs[0].Seq, revs[1].Seq)
+}
+
+func TestStore_DiffRevisions(t *testing.T) {
+	s, _ := openTestStore(t)
+	key := deployment.EntryKey()
+
+	s.OnAdd(deployment, json.RawMessage(`{"spec":{"replicas":1,"image":"web:1"}}`))
+	s.OnUpdate(deployment, deployment, json.RawMessage(`{"spec":{"replicas":1,"image":"web:2"}}`))
+	s.OnDelete(deployment)
+	revs, err := s.List(key)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+
+	changes, err := s.DiffRevisions(key, revs[0].Seq, revs[1].Seq)
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Op: ChangeReplace, Path: "/spec/image", OldValue: "web:1", NewValue: "web:2"}}, changes)
+
+	changes, err = s.DiffRevisions(key, revs[1].Seq, revs[2].Seq)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, ChangeRemove, changes[0].Op)
+
+	_, err = s.DiffRevisions(key, revs[0].Seq, 9999)
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+}
+
+func TestOpen_CorruptFileIsReplaced(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history.db")
+	require.NoError(t, os.WriteFile(path, []byte("definitely not a bolt database, but long enough to be read as one...................."), 0o600))
+
+	s, err := Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	matches, err := filepath.Glob(path + ".corrupt.*")
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+}
+
+func TestStore_CloseNil(t *testing.T) {
+	var s *Store
+	assert.NoError(t, s.Close())
+}
+
+func TestStore_PruneDropsExpiredDeletes(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	s, _ := openTestStore(t, WithMaxAge(time.Hour), withClock(clock))
+	pod := deployment
+	pod.ID = "web-7d9f"
+
+	s.OnAdd(deployment, json.RawMessage(`{"n":1}`))
+	s.OnAdd(pod, json.RawMessage(`{"phase":"Running"}`))
+	clock.t = clock.t.Add(30 * time.Minute)
+	s.OnDelete(pod)
+
+	require.NoError(t, s.Prune())
+	revs, err := s.List(pod.EntryKey())
+	require.NoError(t, err)
+	assert.Len(t, revs, 2, "a recent delete keeps the history of the resource")
+
+	clock.t = clock.t.Add(2 * time.Hour)
+	require.NoError(t, s.Prune())
+	revs, err = s.List(pod.EntryKey())
+	require.NoError(t, err)
+	assert.Empty(t, revs, "a resource deleted longer ago than the age limit is dropped")
+	revs, err = s.List(deployment.EntryKey())
+	require.NoError(t, err)
+	assert.Len(t, revs, 1, "live resources keep their newest revision")
+}
+
+func TestStore_SetRetention(t *testing.T) {
+	s, _ := openTestStore(t, WithMaxRevisions(2))
+	key := deployment.EntryKey()
+
+	for i := range 4 {
+		s.OnAdd(deployment, json.RawMessage(`{"n":`+string(rune('0'+i))+`}`))
+	}
+	s.SetRetention(5, 0)
+	for i := range 4 {
+		s.OnAdd(deployment, json.RawMessage(`{"m":`+string(rune('0'+i))+`}`))
+	}
+	revs, err := s.List(key)
+	require.NoError(t, err)
+	assert.Len(t, revs, 5)
+
+	s.SetRetention(1, 0)
+	require.NoError(t, s.Prune())
+	revs, err = s.List(key)
+	require.NoError(t, err)
+	assert.Len(t, revs, 1)
+
+	s.SetRetention(0, 0)
+	assert.Equal(t, DefaultMaxRevisions, s.retention().maxRevisions)
+	assert.Equal(t, DefaultMaxAge, s.retention().maxAge)
+}
@@ -0,0 +1,208 @@
+package resource
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+)
+
+// OperationState is the lifecycle state of a streaming action.
+type OperationState string
+
+const (
+	OperationRunning   OperationState = "running"
+	OperationCompleted OperationState = "completed"
+	OperationFailed    OperationState = "failed"
+	OperationCancelled OperationState = "cancelled"
+)
+
+// maxRetainedOperations bounds how many finished streaming actions are kept
+// for ListOperations.
+const maxRetainedOperations = 50
+
+// OperationInfo describes a streaming action started with StreamAction.
+type OperationInfo struct {
+	OperationID  string         `json:"operationID"`
+	PluginID     string         `json:"pluginID"`
+	ConnectionID string         `json:"connectionID"`
+	ResourceKey  string         `json:"resourceKey"`
+	ActionID     string         `json:"actionID"`
+	ResourceID   string         `json:"resourceID"`
+	Namespace    string         `json:"namespace,omitempty"`
+	State        OperationState `json:"state"`
+	// Message is the error message of a failed operation.
+	Message string `json:"message,omitempty"`
+	// StartedAt and FinishedAt are RFC 3339 timestamps; FinishedAt is empty
+	// while the operation is running.
+	StartedAt  string `json:"startedAt"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
+
+// actionOperation is a running or finished streaming action.
+type actionOperation struct {
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	info OperationInfo
+}
+
+func (op *actionOperation) snapshot() OperationInfo {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.info
+}
+
+// finish moves the operation to a terminal state and returns its final info.
+func (op *actionOperation) finish(state OperationState, message string) OperationInfo {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.info.State = state
+	op.info.Message = message
+	op.info.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	return op.info
+}
+
+// opTable tracks long-running operations by ID in start order. Finished
+// operations are retained, up to limit, so callers can still learn how they
+// ended.
+type opTable[T any] struct {
+	mu       sync.Mutex
+	ops      map[string]T
+	order    []string // operation IDs in start order
+	finished []string // finished operation IDs, oldest first
+	limit    int
+}
+
+func newOpTable[T any](limit int) *opTable[T] {
+	return &opTable[T]{ops: make(map[string]T), limit: limit}
+}
+
+func (t *opTable[T]) add(id string, op T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ops[id] = op
+	t.order = append(t.order, id)
+}
+
+func (t *opTable[T]) get(id string) (T, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	op, ok := t.ops[id]
+	return op, ok
+}
+
+// list returns every tracked operation in start order.
+func (t *opTable[T]) list() []T {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ops := make([]T, 0, len(t.order))
+	for _, id := range t.order {
+		ops = append(ops, t.ops[id])
+	}
+	return ops
+}
+
+// markFinished records that id finished and evicts the oldest finished
+// operations beyond the retention limit.
+func (t *opTable[T]) markFinished(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished = append(t.finished, id)
+	for len(t.finished) > t.limit {
+		evicted := t.finished[0]
+		t.finished = t.finished[1:]
+		delete(t.ops, evicted)
+		t.order = slices.DeleteFunc(t.order, func(id string) bool { return id == evicted })
+	}
+}
+
+// runStreamAction forwards a provider's action events to the emitter until
+// the provider returns, then records the outcome and emits a terminal event.
+//
+// Every operation ends with exactly one terminal "complete" or "error" event
+// on "action/stream/<operationID>": the plugin's own if it sent one, otherwise
+// one synthesized here. Cancellation is reported as an "error" event with
+// data.cancelled set, so existing listeners stop on it.
+func (c *controller) runStreamAction(ctx context.Context, op *actionOperation, provider ResourceProvider, key, actionID string, input resource.ActionInput) {
+	defer op.cancel()
+	operationID, pluginID := op.info.OperationID, op.info.PluginID
+	eventKey := "action/stream/" + operationID
+
+	events := make(chan resource.ActionEvent, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- provider.StreamAction(ctx, key, actionID, input, events)
+	}()
+
+	var terminal *resource.ActionEvent
+	forward := func(event resource.ActionEvent) {
+		if terminal != nil {
+			return
+		}
+		if event.Type == "complete" || event.Type == "error" {
+			terminal = &event
+		}
+		c.emitter.Emit(eventKey, event)
+	}
+
+	// Providers are expected to close the stream, but not all do on early
+	// errors, so the loop ends when the provider returns rather than on close.
+	var streamErr error
+	for running := true; running; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			forward(event)
+		case streamErr = <-done:
+			running = false
+		}
+	}
+	for drained := events == nil; !drained; {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				drained = true
+				continue
+			}
+			forward(event)
+		default:
+			drained = true
+		}
+	}
+
+	var info OperationInfo
+	switch {
+	case ctx.Err() != nil && (terminal == nil || terminal.Type != "complete"):
+		info = op.finish(OperationCancelled, "Cancelled")
+		forward(resource.ActionEvent{
+			Type: "error",
+			Data: map[string]interface{}{"message": info.Message, "cancelled": true},
+		})
+	case streamErr != nil:
+		c.logger.Errorw(ctx, "RPC failed", "op", "StreamAction", "pluginID", pluginID,
+			"key", key, "actionID", actionID, "error", streamErr)
+		info = op.finish(OperationFailed, streamErr.Error())
+		forward(resource.ActionEvent{
+			Type: "error",
+			Data: map[string]interface{}{"message": streamErr.Error()},
+		})
+	case terminal != nil && terminal.Type == "error":
+		message, _ := terminal.Data["message"].(string)
+		info = op.finish(OperationFailed, message)
+	default:
+		info = op.finish(OperationCompleted, "")
+		forward(resource.ActionEvent{
+			Type: "complete",
+			Data: map[string]interface{}{},
+		})
+	}
+
+	c.operations.markFinished(operationID)
+	c.emitter.Emit(EventActionOperation, info)
+}
@@ -0,0 +1,319 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/boltdb"
+)
+
+var (
+	entriesBucket = []byte("entries")
+	labelsBucket  = []byte("labels")
+)
+
+// labelIndexSep separates the "labelKey=labelValue" prefix from the entry key
+// in label index keys. NUL cannot appear in label keys or values, so a prefix
+// scan on "k=v\x00" never matches a different label pair.
+const labelIndexSep = "\x00"
+
+// BoltStore is a RegistryStore persisted to a bbolt database so the last-known
+// inventory survives application restarts.
+//
+// Entries are JSON-encoded in the "entries" bucket keyed by EntryKey. The label
+// index lives in the "labels" bucket as "labelKey=labelValue\x00entryKey" keys
+// with empty values, so ScanByLabel is a prefix scan instead of a full scan.
+//
+// The database is opened with NoSync: the registry is a cache that watches
+// re-populate, so losing the last few writes on a crash is acceptable and
+// avoids an fsync per watch event. Close syncs before closing.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+var _ PersistentStore = (*BoltStore)(nil)
+
+// OpenBoltStore opens (or creates) a registry database at path.
+// If the file is corrupt, it is renamed with a .corrupt.<unix-timestamp> suffix
+// and a fresh database is created in its place — the registry is rebuilt by
+// watches, so discarding it is always safe.
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := boltdb.Open(path, "registry", &bolt.Options{Timeout: 1 * time.Second, NoSync: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(entriesBucket); err != nil {
+			return fmt.Errorf("create entries bucket: %w", err)
+		}
+		if _, err := tx.CreateBucketIfNotExists(labelsBucket); err != nil {
+			return fmt.Errorf("create labels bucket: %w", err)
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize registry buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Close flushes pending writes to disk and closes the database.
+// It is safe to call on a nil BoltStore.
+func (s *BoltStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	if err := s.db.Sync(); err != nil {
+		s.db.Close()
+		return fmt.Errorf("sync registry database: %w", err)
+	}
+	return s.db.Close()
+}
+
+func labelIndexKey(labelKey, labelValue, entryKey string) []byte {
+	return []byte(labelKey + "=" + labelValue + labelIndexSep + entryKey)
+}
+
+func decodeEntry(data []byte) (ResourceEntry, bool) {
+	var entry ResourceEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return ResourceEntry{}, false
+	}
+	return entry, true
+}
+
+// putTx writes entry and its label index, replacing any previous version.
+// Returns the previous entry if one existed.
+func putTx(tx *bolt.Tx, entry ResourceEntry) (*ResourceEntry, error) {
+	key := entry.EntryKey()
+	entries := tx.Bucket(entriesBucket)
+	labels := tx.Bucket(labelsBucket)
+
+	var old *ResourceEntry
+	if data := entries.Get([]byte(key)); data != nil {
+		if prev, ok := decodeEntry(data); ok {
+			old = &prev
+			for k, v := range prev.Labels {
+				if err := labels.Delete(labelIndexKey(k, v, key)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("marshal entry %q: %w", key, err)
+	}
+	if err := entries.Put([]byte(key), data); err != nil {
+		return nil, err
+	}
+	for k, v := range entry.Labels {
+		if err := labels.Put(labelIndexKey(k, v, key), nil); err != nil {
+			return nil, err
+		}
+	}
+	return old, nil
+}
+
+// deleteTx removes the entry stored under key along with its label index.
+func deleteTx(tx *bolt.Tx, key []byte) (*ResourceEntry, error) {
+	entries := tx.Bucket(entriesBucket)
+	data := entries.Get(key)
+	if data == nil {
+		return nil, nil
+	}
+	entry, ok := decodeEntry(data)
+	if ok {
+		labels := tx.Bucket(labelsBucket)
+		for k, v := range entry.Labels {
+			if err := labels.Delete(labelIndexKey(k, v, string(key))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := entries.Delete(key); err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (s *BoltStore) Put(entry ResourceEntry) (old *ResourceEntry, existed bool) {
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		old, err = putTx(tx, entry)
+		return err
+	})
+	return old, old != nil
+}
+
+func (s *BoltStore) PutIfAbsent(entry ResourceEntry) (existing *ResourceEntry, loaded bool) {
+	key := []byte(entry.EntryKey())
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(entriesBucket).Get(key); data != nil {
+			if prev, ok := decodeEntry(data); ok {
+				existing = &prev
+				return nil
+			}
+		}
+		_, err := putTx(tx, entry)
+		return err
+	})
+	return existing, existing != nil
+}
+
+func (s *BoltStore) Get(pluginID, connectionID, resourceKey, namespace, id string) (*ResourceEntry, bool) {
+	key := (ResourceEntry{
+		PluginID: pluginID, ConnectionID: connectionID,
+		ResourceKey: resourceKey, Namespace: namespace, ID: id,
+	}).EntryKey()
+
+	var result *ResourceEntry
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(entriesBucket).Get([]byte(key)); data != nil {
+			if entry, ok := decodeEntry(data); ok {
+				result = &entry
+			}
+		}
+		return nil
+	})
+	return result, result != nil
+}
+
+func (s *BoltStore) Delete(pluginID, connectionID, resourceKey, namespace, id string) (*ResourceEntry, bool) {
+	key := (ResourceEntry{
+		PluginID: pluginID, ConnectionID: connectionID,
+		ResourceKey: resourceKey, Namespace: namespace, ID: id,
+	}).EntryKey()
+
+	var removed *ResourceEntry
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		removed, err = deleteTx(tx, []byte(key))
+		return err
+	})
+	return removed, removed != nil
+}
+
+func (s *BoltStore) DeleteByConnection(pluginID, connectionID string) []ResourceEntry {
+	prefix := []byte(connKey(pluginID, connectionID) + "/")
+
+	var removed []ResourceEntry
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		// Collect keys first — deleting while iterating a cursor skips keys.
+		var keys [][]byte
+		c := tx.Bucket(entriesBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, bytes.Clone(k))
+		}
+		for _, key := range keys {
+			entry, err := deleteTx(tx, key)
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				removed = append(removed, *entry)
+			}
+		}
+		return nil
+	})
+	return removed
+}
+
+func (s *BoltStore) ScanByLabel(pluginID, connectionID, resourceKey string, selector map[string]string) []ResourceEntry {
+	if len(selector) == 0 {
+		return nil
+	}
+
+	// Seed candidates from one selector pair via the label index; the
+	// remaining pairs are checked against each candidate's labels.
+	var seedKey, seedValue string
+	for k, v := range selector {
+		seedKey, seedValue = k, v
+		break
+	}
+	seedPrefix := []byte(seedKey + "=" + seedValue + labelIndexSep)
+	entryPrefix := pluginID + "/" + connectionID + "/" + resourceKey + "/"
+
+	var result []ResourceEntry
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		entries := tx.Bucket(entriesBucket)
+		c := tx.Bucket(labelsBucket).Cursor()
+		for k, _ := c.Seek(seedPrefix); k != nil && bytes.HasPrefix(k, seedPrefix); k, _ = c.Next() {
+			entryKey := k[len(seedPrefix):]
+			if !bytes.HasPrefix(entryKey, []byte(entryPrefix)) || len(entryKey) == len(entryPrefix) {
+				continue
+			}
+			data := entries.Get(entryKey)
+			if data == nil {
+				continue
+			}
+			entry, ok := decodeEntry(data)
+			if !ok || !matchesSelector(entry.Labels, selector) {
+				continue
+			}
+			result = append(result, entry)
+		}
+		return nil
+	})
+	return result
+}
+
+func (s *BoltStore) ScanByResourceKey(pluginID, connectionID, resourceKey string) []ResourceEntry {
+	prefix := []byte(pluginID + "/" + connectionID + "/" + resourceKey + "/")
+
+	var result []ResourceEntry
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(entriesBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if len(k) == len(prefix) {
+				continue
+			}
+			if entry, ok := decodeEntry(v); ok {
+				result = append(result, entry)
+			}
+		}
+		return nil
+	})
+	return result
+}
+
+func matchesSelector(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *BoltStore) ConnectionIDs(pluginID string) []string {
+	prefix := []byte(pluginID + "/")
+
+	var ids []string
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(entriesBucket).Cursor()
+		k, v := c.Seek(prefix)
+		for k != nil && bytes.HasPrefix(k, prefix) {
+			entry, ok := decodeEntry(v)
+			if !ok || entry.PluginID != pluginID {
+				k, v = c.Next()
+				continue
+			}
+			ids = append(ids, entry.ConnectionID)
+			// Skip the rest of the connection: '0' sorts right after '/'.
+			k, v = c.Seek([]byte(connKey(pluginID, entry.ConnectionID) + "0"))
+		}
+		return nil
+	})
+	return ids
+}
@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func openTestBoltStore(t *testing.T) (*BoltStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "registry.db")
+	s, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatalf("OpenBoltStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestBoltStore_PutAndGet(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	entry := makeEntry("plugin-a", "conn-1", "core::v1::Pod", "default", "my-pod", map[string]string{"app": "nginx"})
+
+	old, existed := s.Put(entry)
+	if old != nil || existed {
+		t.Fatalf("expected no previous entry, got existed=%v", existed)
+	}
+
+	got, ok := s.Get("plugin-a", "conn-1", "core::v1::Pod", "default", "my-pod")
+	if !ok {
+		t.Fatal("expected entry to exist after Put")
+	}
+	if got.ID != "my-pod" || got.Labels["app"] != "nginx" {
+		t.Errorf("unexpected entry: %+v", got)
+	}
+	if !got.CreatedAt.Equal(entry.CreatedAt) {
+		t.Errorf("expected CreatedAt %v, got %v", entry.CreatedAt, got.CreatedAt)
+	}
+}
+
+func TestBoltStore_PutReturnsOldAndReindexesLabels(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	entry := makeEntry("p", "c", "core::v1::Pod", "default", "pod-1", map[string]string{"app": "nginx"})
+	s.Put(entry)
+
+	updated := entry
+	updated.Labels = map[string]string{"app": "apache"}
+	old, existed := s.Put(updated)
+	if !existed || old == nil {
+		t.Fatal("expected existed=true with old entry on second Put")
+	}
+	if old.Labels["app"] != "nginx" {
+		t.Errorf("expected old label app=nginx, got %q", old.Labels["app"])
+	}
+
+	if got := s.ScanByLabel("p", "c", "core::v1::Pod", map[string]string{"app": "nginx"}); len(got) != 0 {
+		t.Errorf("expected old label index to be cleaned up, got %d results", len(got))
+	}
+	if got := s.ScanByLabel("p", "c", "core::v1::Pod", map[string]string{"app": "apache"}); len(got) != 1 {
+		t.Errorf("expected 1 result for new label, got %d", len(got))
+	}
+}
+
+func TestBoltStore_PutIfAbsent(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	entry := makeEntry("p", "c", "core::v1::Pod", "default", "pod-1", map[string]string{"app": "nginx"})
+
+	if existing, loaded := s.PutIfAbsent(entry); loaded || existing != nil {
+		t.Fatal("expected first PutIfAbsent to insert")
+	}
+
+	other := entry
+	other.Labels = map[string]string{"app": "apache"}
+	existing, loaded := s.PutIfAbsent(other)
+	if !loaded || existing == nil {
+		t.Fatal("expected second PutIfAbsent to return the existing entry")
+	}
+	if existing.Labels["app"] != "nginx" {
+		t.Errorf("expected existing entry to be unchanged, got %q", existing.Labels["app"])
+	}
+}
+
+func TestBoltStore_Delete(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	s.Put(makeEntry("p", "c", "core::v1::Pod", "default", "pod-1", map[string]string{"app": "nginx"}))
+
+	deleted, ok := s.Delete("p", "c", "core::v1::Pod", "default", "pod-1")
+	if !ok || deleted.ID != "pod-1" {
+		t.Fatalf("expected Delete to return pod-1, got ok=%v entry=%v", ok, deleted)
+	}
+	if _, ok := s.Get("p", "c", "core::v1::Pod", "default", "pod-1"); ok {
+		t.Fatal("expected entry to be gone after Delete")
+	}
+	if got := s.ScanByLabel("p", "c", "core::v1::Pod", map[string]string{"app": "nginx"}); len(got) != 0 {
+		t.Errorf("expected label index to be cleaned up, got %d results", len(got))
+	}
+
+	if deleted, ok := s.Delete("p", "c", "core::v1::Pod", "default", "pod-1"); ok || deleted != nil {
+		t.Errorf("expected second Delete to report not found, got ok=%v", ok)
+	}
+}
+
+func TestBoltStore_DeleteByConnection(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	for i := 0; i < 3; i++ {
+		s.Put(makeEntry("plugin-a", "conn-1", "core::v1::Pod", "default", fmt.Sprintf("pod-%d", i), nil))
+	}
+	// A connection whose ID shares the "conn-1" prefix must not be touched.
+	s.Put(makeEntry("plugin-a", "conn-10", "core::v1::Pod", "default", "pod-other", nil))
+
+	removed := s.DeleteByConnection("plugin-a", "conn-1")
+	if len(removed) != 3 {
+		t.Fatalf("expected 3 removed entries, got %d", len(removed))
+	}
+	if _, ok := s.Get("plugin-a", "conn-10", "core::v1::Pod", "default", "pod-other"); !ok {
+		t.Error("expected conn-10 entry to remain after DeleteByConnection for conn-1")
+	}
+}
+
+func TestBoltStore_ScanByLabel(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	s.Put(makeEntry("p", "c", "core::v1::Pod", "default", "pod-1", map[string]string{"app": "nginx", "env": "prod"}))
+	s.Put(makeEntry("p", "c", "core::v1::Pod", "default", "pod-2", map[string]string{"app": "nginx", "env": "staging"}))
+	s.Put(makeEntry("p", "c", "core::v1::Pod", "default", "pod-3", map[string]string{"app": "apache", "env": "prod"}))
+	s.Put(makeEntry("p", "c", "core::v1::Service", "default", "svc-1", map[string]string{"app": "nginx"}))
+
+	t.Run("single label match scoped to resource key", func(t *testing.T) {
+		if got := s.ScanByLabel("p", "c", "core::v1::Pod", map[string]string{"app": "nginx"}); len(got) != 2 {
+			t.Errorf("expected 2 results for app=nginx, got %d", len(got))
+		}
+	})
+
+	t.Run("multi-label intersection", func(t *testing.T) {
+		got := s.ScanByLabel("p", "c", "core::v1::Pod", map[string]string{"app": "nginx", "env": "prod"})
+		if len(got) != 1 || got[0].ID != "pod-1" {
+			t.Fatalf("expected only pod-1, got %v", got)
+		}
+	})
+
+	t.Run("empty selector returns nil", func(t *testing.T) {
+		if got := s.ScanByLabel("p", "c", "core::v1::Pod", map[string]string{}); got != nil {
+			t.Errorf("expected nil for empty selector, got %v", got)
+		}
+	})
+}
+
+func TestBoltStore_ScanByResourceKey(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	for i := 0; i < 5; i++ {
+		s.Put(makeEntry("p", "c", "core::v1::Pod", "default", fmt.Sprintf("pod-%d", i), nil))
+	}
+	s.Put(makeEntry("p", "c", "core::v1::Service", "default", "svc-1", nil))
+
+	if got := s.ScanByResourceKey("p", "c", "core::v1::Pod"); len(got) != 5 {
+		t.Errorf("expected 5 Pod entries, got %d", len(got))
+	}
+	if got := s.ScanByResourceKey("p", "c", "core::v1::Service"); len(got) != 1 {
+		t.Errorf("expected 1 Service entry, got %d", len(got))
+	}
+}
+
+func TestBoltStore_ConnectionIDs(t *testing.T) {
+	s, _ := openTestBoltStore(t)
+	s.Put(makeEntry("plugin-a", "conn-1", "core::v1::Pod", "default", "pod-1", nil))
+	s.Put(makeEntry("plugin-a", "conn-1", "core::v1::Pod", "default", "pod-2", nil))
+	s.Put(makeEntry("plugin-a", "conn-2", "core::v1::Service", "default", "svc-1", nil))
+	s.Put(makeEntry("plugin-ab", "conn-3", "core::v1::Pod", "default", "pod-3", nil))
+
+	got := s.ConnectionIDs("plugin-a")
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"conn-1", "conn-2"}) {
+		t.Errorf("expected [conn-1 conn-2], got %v", got)
+	}
+	if got := s.ConnectionIDs("plugin-x"); len(got) != 0 {
+		t.Errorf("expected no connections for an unknown plugin, got %v", got)
+	}
+}
+
+func TestBoltStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.db")
+
+	s, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Put(makeEntry("p", "c", "core::v1::Pod", "default", "pod-1", map[string]string{"app": "nginx"}))
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s2, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+
+	if _, ok := s2.Get("p", "c", "core::v1::Pod", "default", "pod-1"); !ok {
+		t.Fatal("expected entry to survive reopen")
+	}
+	if got := s2.ScanByLabel("p", "c", "core::v1::Pod", map[string]string{"app": "nginx"}); len(got) != 1 {
+		t.Errorf("expected label index to survive reopen, got %d results", len(got))
+	}
+}
+
+func TestBoltStore_RecoversFromCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.db")
+	if err := os.WriteFile(path, []byte("definitely not a bbolt database, but long enough to read a header from it"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatalf("expected corruption recovery, got %v", err)
+	}
+	defer s.Close()
+
+	matches, _ := filepath.Glob(path + ".corrupt.*")
+	if len(matches) != 1 {
+		t.Errorf("expected corrupt file to be renamed aside, found %v", matches)
+	}
+}
+
+func TestBoltStore_CloseNil(t *testing.T) {
+	var s *BoltStore
+	if err := s.Close(); err != nil {
+		t.Errorf("expected nil error closing nil store, got %v", err)
+	}
+}
@@ -231,3 +231,19 @@ func (s *MemoryStore) ScanByResourceKey(pluginID, connectionID, resourceKey stri
 	}
 	return result
 }
+
+func (s *MemoryStore) ConnectionIDs(pluginID string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []string
+	for _, keys := range s.byConn {
+		for key := range keys {
+			if entry := s.entries[key]; entry.PluginID == pluginID {
+				ids = append(ids, entry.ConnectionID)
+			}
+			break
+		}
+	}
+	return ids
+}
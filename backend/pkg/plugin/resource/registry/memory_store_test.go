@@ -2,6 +2,7 @@ package registry
 
 import (
 	"fmt"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -237,6 +238,23 @@ func TestMemoryStore_ScanByResourceKey(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_ConnectionIDs(t *testing.T) {
+	s := NewMemoryStore()
+	s.Put(makeEntry("plugin-a", "conn-1", "core::v1::Pod", "default", "pod-1", nil))
+	s.Put(makeEntry("plugin-a", "conn-1", "core::v1::Pod", "default", "pod-2", nil))
+	s.Put(makeEntry("plugin-a", "conn-2", "core::v1::Service", "default", "svc-1", nil))
+	s.Put(makeEntry("plugin-ab", "conn-3", "core::v1::Pod", "default", "pod-3", nil))
+
+	got := s.ConnectionIDs("plugin-a")
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"conn-1", "conn-2"}) {
+		t.Errorf("expected [conn-1 conn-2], got %v", got)
+	}
+	if got := s.ConnectionIDs("plugin-x"); len(got) != 0 {
+		t.Errorf("expected no connections for an unknown plugin, got %v", got)
+	}
+}
+
 func TestMemoryStore_ConcurrentAccess(t *testing.T) {
 	s := NewMemoryStore()
 	const goroutines = 20
@@ -11,4 +11,16 @@ type RegistryStore interface {
 	DeleteByConnection(pluginID, connectionID string) []ResourceEntry
 	ScanByLabel(pluginID, connectionID, resourceKey string, selector map[string]string) []ResourceEntry
 	ScanByResourceKey(pluginID, connectionID, resourceKey string) []ResourceEntry
+	// ConnectionIDs returns the IDs of the plugin's connections that have
+	// entries in the store.
+	ConnectionIDs(pluginID string) []string
+}
+
+// PersistentStore is a RegistryStore whose entries survive process restarts.
+// The controller keeps a persistent store's entries when a plugin stops so the
+// last-known inventory is available immediately on the next start, and closes
+// the store on shutdown.
+type PersistentStore interface {
+	RegistryStore
+	Close() error
 }
@@ -0,0 +1,330 @@
+package search
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+)
+
+// Built-in field names. Every document is indexed under these regardless of
+// the configured payload fields.
+const (
+	FieldID         = "id"
+	FieldNamespace  = "namespace"
+	FieldResource   = "resource"
+	FieldPlugin     = "plugin"
+	FieldConnection = "connection"
+	FieldLabel      = "label"
+)
+
+// textField is the pseudo-field holding full-text tokens from every value.
+const textField = "_"
+
+// Field is a value extracted from the raw resource payload and indexed under
+// Name. Path is a gjson path; array results index every element.
+type Field struct {
+	Name string
+	Path string
+}
+
+// DefaultFields are the payload fields indexed when none are configured.
+//
+//nolint:gochecknoglobals // read-only defaults
+var DefaultFields = []Field{
+	{Name: "name", Path: "metadata.name"},
+	{Name: "kind", Path: "kind"},
+	{Name: "image", Path: "spec.containers.#.image"},
+	{Name: "ip", Path: "status.podIP"},
+}
+
+// Result is a single search hit.
+type Result struct {
+	Entry registry.ResourceEntry `json:"entry"`
+	// Fields lists the field names that matched at least one query term.
+	Fields []string `json:"fields"`
+}
+
+// document is everything indexed for one entry, kept so the entry's terms can
+// be removed on update/delete.
+type document struct {
+	entry registry.ResourceEntry
+	terms map[string]struct{} // "field:value" terms
+}
+
+// Indexer maintains an inverted index over resource IDs, namespaces, labels,
+// and configured payload fields across every plugin and connection. It
+// implements indexer.ResourceIndexer and is kept current by the Dispatcher.
+type Indexer struct {
+	fields []Field
+
+	mu    sync.RWMutex
+	docs  map[string]*document           // entryKey → document
+	terms map[string]map[string]struct{} // "field:value" → set of entryKeys
+}
+
+// NewIndexer creates an Indexer extracting the given payload fields.
+// If no fields are given, DefaultFields is used.
+func NewIndexer(fields ...Field) *Indexer {
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+	return &Indexer{
+		fields: slices.Clone(fields),
+		docs:   make(map[string]*document),
+		terms:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (ix *Indexer) Name() string { return "search" }
+
+func (ix *Indexer) OnAdd(entry registry.ResourceEntry, raw json.RawMessage) {
+	ix.index(entry, raw)
+}
+
+func (ix *Indexer) OnUpdate(old, new_ registry.ResourceEntry, raw json.RawMessage) {
+	if old.EntryKey() != new_.EntryKey() {
+		ix.OnDelete(old)
+	}
+	ix.index(new_, raw)
+}
+
+func (ix *Indexer) OnDelete(entry registry.ResourceEntry) {
+	key := entry.EntryKey()
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(key)
+}
+
+// Len returns the number of indexed entries.
+func (ix *Indexer) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+func (ix *Indexer) index(entry registry.ResourceEntry, raw json.RawMessage) {
+	key := entry.EntryKey()
+	entry.Labels = maps.Clone(entry.Labels)
+	doc := &document{entry: entry, terms: ix.extractTerms(entry, raw)}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	// Events without a payload keep the payload-derived terms from the last
+	// event that had one.
+	if len(raw) == 0 {
+		if prev, ok := ix.docs[key]; ok {
+			for term := range prev.terms {
+				doc.terms[term] = struct{}{}
+			}
+		}
+	}
+
+	ix.removeLocked(key)
+	ix.docs[key] = doc
+	for term := range doc.terms {
+		set := ix.terms[term]
+		if set == nil {
+			set = make(map[string]struct{})
+			ix.terms[term] = set
+		}
+		set[key] = struct{}{}
+	}
+}
+
+func (ix *Indexer) removeLocked(key string) {
+	doc, ok := ix.docs[key]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		if set, ok := ix.terms[term]; ok {
+			delete(set, key)
+			if len(set) == 0 {
+				delete(ix.terms, term)
+			}
+		}
+	}
+	delete(ix.docs, key)
+}
+
+func (ix *Indexer) extractTerms(entry registry.ResourceEntry, raw json.RawMessage) map[string]struct{} {
+	terms := make(map[string]struct{})
+	add := func(field, value string) {
+		value = strings.ToLower(value)
+		if value == "" {
+			return
+		}
+		terms[field+":"+value] = struct{}{}
+		for _, tok := range tokenize(value) {
+			terms[textField+":"+tok] = struct{}{}
+		}
+	}
+
+	add(FieldID, entry.ID)
+	add(FieldNamespace, entry.Namespace)
+	add(FieldResource, entry.ResourceKey)
+	add(FieldPlugin, entry.PluginID)
+	add(FieldConnection, entry.ConnectionID)
+	for k, v := range entry.Labels {
+		add(FieldLabel, k+"="+v)
+	}
+
+	if len(raw) > 0 {
+		for _, f := range ix.fields {
+			result := gjson.GetBytes(raw, f.Path)
+			if !result.Exists() {
+				continue
+			}
+			if result.IsArray() {
+				result.ForEach(func(_, v gjson.Result) bool {
+					add(f.Name, v.String())
+					return true
+				})
+				continue
+			}
+			add(f.Name, result.String())
+		}
+	}
+	return terms
+}
+
+// tokenize splits a lowercased value into alphanumeric tokens, plus the whole
+// value so that full-text terms can match names containing separators.
+func tokenize(value string) []string {
+	tokens := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(tokens) != 1 || tokens[0] != value {
+		tokens = append(tokens, value)
+	}
+	return tokens
+}
+
+// Search evaluates query against the index and returns up to limit results
+// (limit <= 0 means unlimited), sorted by plugin, connection, resource key,
+// namespace and ID. An empty query returns no results.
+func (ix *Indexer) Search(query string, limit int) ([]Result, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Terms) == 0 {
+		return []Result{}, nil
+	}
+
+	ix.mu.RLock()
+	var (
+		candidates map[string]struct{}
+		matched    = make(map[string]map[string]struct{}) // entryKey → matched field names
+	)
+	for _, term := range q.Terms {
+		hits := ix.matchTermLocked(term)
+		if candidates == nil {
+			candidates = make(map[string]struct{}, len(hits))
+			for key := range hits {
+				candidates[key] = struct{}{}
+			}
+		} else {
+			for key := range candidates {
+				if _, ok := hits[key]; !ok {
+					delete(candidates, key)
+				}
+			}
+		}
+		for key, fields := range hits {
+			if _, ok := candidates[key]; !ok {
+				continue
+			}
+			if matched[key] == nil {
+				matched[key] = make(map[string]struct{})
+			}
+			for f := range fields {
+				matched[key][f] = struct{}{}
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+	}
+
+	results := make([]Result, 0, len(candidates))
+	for key := range candidates {
+		doc := ix.docs[key]
+		entry := doc.entry
+		entry.Labels = maps.Clone(entry.Labels)
+		fields := slices.Sorted(maps.Keys(matched[key]))
+		if len(fields) > 1 {
+			fields = slices.DeleteFunc(fields, func(f string) bool { return f == "text" })
+		}
+		results = append(results, Result{Entry: entry, Fields: fields})
+	}
+	ix.mu.RUnlock()
+
+	slices.SortFunc(results, func(a, b Result) int {
+		return cmp.Or(
+			cmp.Compare(a.Entry.PluginID, b.Entry.PluginID),
+			cmp.Compare(a.Entry.ConnectionID, b.Entry.ConnectionID),
+			cmp.Compare(a.Entry.ResourceKey, b.Entry.ResourceKey),
+			cmp.Compare(a.Entry.Namespace, b.Entry.Namespace),
+			cmp.Compare(a.Entry.ID, b.Entry.ID),
+		)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// matchTermLocked returns the entry keys matching term, each with the field
+// names that matched. Caller must hold ix.mu.
+func (ix *Indexer) matchTermLocked(term Term) map[string]map[string]struct{} {
+	hits := make(map[string]map[string]struct{})
+	record := func(field string, keys map[string]struct{}) {
+		if field == textField {
+			field = "text"
+		}
+		for key := range keys {
+			if hits[key] == nil {
+				hits[key] = make(map[string]struct{})
+			}
+			hits[key][field] = struct{}{}
+		}
+	}
+
+	fields := term.fields()
+
+	if !term.IsPattern() {
+		// Whole values are also indexed as text tokens, so an exact
+		// full-text term is a single lookup.
+		if fields == nil {
+			fields = []string{textField}
+		}
+		for _, f := range fields {
+			if keys, ok := ix.terms[f+":"+term.Value]; ok {
+				record(f, keys)
+			}
+		}
+		return hits
+	}
+
+	// Patterns scan the distinct terms, not the documents.
+	for t, keys := range ix.terms {
+		f, v, _ := strings.Cut(t, ":")
+		if fields != nil && !slices.Contains(fields, f) {
+			continue
+		}
+		if term.Match(v) {
+			record(f, keys)
+		}
+	}
+	return hits
+}
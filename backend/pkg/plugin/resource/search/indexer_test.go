@@ -0,0 +1,173 @@
+package search
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+)
+
+func podEntry(pluginID, connectionID, namespace, id string, labels map[string]string) registry.ResourceEntry {
+	return registry.ResourceEntry{
+		PluginID:     pluginID,
+		ConnectionID: connectionID,
+		ResourceKey:  "core::v1::Pod",
+		Namespace:    namespace,
+		ID:           id,
+		Labels:       labels,
+	}
+}
+
+func resultIDs(results []Result) []string {
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.Entry.ID)
+	}
+	return ids
+}
+
+func newPopulatedIndexer(t *testing.T) *Indexer {
+	t.Helper()
+	ix := NewIndexer()
+	ix.OnAdd(podEntry("k8s", "prod", "payments", "payments-api-7f9c", map[string]string{"app": "payments"}),
+		json.RawMessage(`{"kind":"Pod","metadata":{"name":"payments-api-7f9c"},"spec":{"containers":[{"image":"ghcr.io/acme/payments:1.4"}]},"status":{"podIP":"10.0.0.12"}}`))
+	ix.OnAdd(podEntry("k8s", "staging", "payments", "payments-worker-1", map[string]string{"app": "payments-worker"}),
+		json.RawMessage(`{"kind":"Pod","metadata":{"name":"payments-worker-1"}}`))
+	ix.OnAdd(podEntry("k8s", "prod", "web", "frontend-abc", map[string]string{"app": "frontend"}),
+		json.RawMessage(`{"kind":"Pod","metadata":{"name":"frontend-abc"},"status":{"podIP":"10.0.0.13"}}`))
+	ix.OnAdd(registry.ResourceEntry{PluginID: "aws", ConnectionID: "acct-1", ResourceKey: "lambda::v1::Function", ID: "payments-handler"}, nil)
+	return ix
+}
+
+func TestIndexer_NameWildcardAcrossPluginsAndConnections(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("name:payments-*", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments-handler", "payments-api-7f9c", "payments-worker-1"}, resultIDs(results))
+}
+
+func TestIndexer_BareTermMatchesTokens(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("frontend", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frontend-abc"}, resultIDs(results))
+}
+
+func TestIndexer_BareWildcardReportsMatchedFields(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("10.0.0.1?", 0)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, []string{"ip"}, results[0].Fields)
+}
+
+func TestIndexer_FieldTermsAreANDed(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("ns:payments conn:prod", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments-api-7f9c"}, resultIDs(results))
+}
+
+func TestIndexer_LabelAndPayloadFields(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("label:app=payments", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments-api-7f9c"}, resultIDs(results))
+
+	results, err = ix.Search("image:*acme/payments*", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments-api-7f9c"}, resultIDs(results))
+}
+
+func TestIndexer_CaseInsensitive(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("NAME:Frontend-*", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frontend-abc"}, resultIDs(results))
+}
+
+func TestIndexer_Limit(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("payments*", 2)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestIndexer_EmptyQuery(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	results, err := ix.Search("   ", 0)
+	require.NoError(t, err)
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+}
+
+func TestIndexer_InvalidQuery(t *testing.T) {
+	ix := NewIndexer()
+
+	_, err := ix.Search("ns:", 0)
+	assert.Error(t, err)
+	_, err = ix.Search(":value", 0)
+	assert.Error(t, err)
+}
+
+func TestIndexer_UpdateReplacesTerms(t *testing.T) {
+	ix := NewIndexer()
+	old := podEntry("k8s", "prod", "default", "web-1", map[string]string{"version": "v1"})
+	ix.OnAdd(old, json.RawMessage(`{"spec":{"containers":[{"image":"web:1"}]}}`))
+
+	updated := podEntry("k8s", "prod", "default", "web-1", map[string]string{"version": "v2"})
+	ix.OnUpdate(old, updated, json.RawMessage(`{"spec":{"containers":[{"image":"web:2"}]}}`))
+
+	results, err := ix.Search("label:version=v1", 0)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = ix.Search("image:web:2", 0)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, 1, ix.Len())
+}
+
+func TestIndexer_UpdateWithoutPayloadKeepsPayloadTerms(t *testing.T) {
+	ix := NewIndexer()
+	entry := podEntry("k8s", "prod", "default", "web-1", nil)
+	ix.OnAdd(entry, json.RawMessage(`{"status":{"podIP":"10.1.2.3"}}`))
+
+	ix.OnUpdate(entry, entry, nil)
+
+	results, err := ix.Search("ip:10.1.2.3", 0)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestIndexer_Delete(t *testing.T) {
+	ix := newPopulatedIndexer(t)
+
+	ix.OnDelete(podEntry("k8s", "prod", "web", "frontend-abc", nil))
+
+	results, err := ix.Search("frontend", 0)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	assert.Equal(t, 3, ix.Len())
+}
+
+func TestIndexer_CustomFields(t *testing.T) {
+	ix := NewIndexer(Field{Name: "arn", Path: "Arn"})
+	ix.OnAdd(registry.ResourceEntry{PluginID: "aws", ConnectionID: "a", ResourceKey: "ec2::v1::Instance", ID: "i-123"},
+		json.RawMessage(`{"Arn":"arn:aws:ec2:us-east-1:123:instance/i-123"}`))
+
+	results, err := ix.Search("arn:arn:aws:ec2:*", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i-123"}, resultIDs(results))
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fieldAliases maps query field names to the indexed fields they search.
+// "name" also searches IDs because most plugins use the name as the ID and
+// not every payload carries metadata.name.
+//
+//nolint:gochecknoglobals // read-only lookup table
+var fieldAliases = map[string][]string{
+	"name":       {"name", FieldID},
+	"ns":         {FieldNamespace},
+	"type":       {FieldResource},
+	"key":        {FieldResource},
+	"conn":       {FieldConnection},
+	"labels":     {FieldLabel},
+	FieldID:      {FieldID},
+	FieldLabel:   {FieldLabel},
+	FieldPlugin:  {FieldPlugin},
+	"namespace":  {FieldNamespace},
+	"resource":   {FieldResource},
+	"connection": {FieldConnection},
+}
+
+// Query is a parsed search expression. All terms must match (AND).
+type Query struct {
+	Terms []Term
+}
+
+// Term is a single query term. Field is empty for full-text terms.
+// Value is lowercased and may contain '*' (any run) and '?' (any one rune)
+// wildcards.
+type Term struct {
+	Field string
+	Value string
+}
+
+// ParseQuery parses a whitespace-separated list of terms. Each term is either
+// a bare word (full-text, e.g. "payments-*") or "field:value"
+// (e.g. "ns:prod", "label:app=web", "name:payments-*"). Matching is
+// case-insensitive. Unknown field names address configured payload fields.
+func ParseQuery(query string) (Query, error) {
+	var q Query
+	for _, raw := range strings.Fields(query) {
+		raw = strings.ToLower(raw)
+		field, value, hasField := strings.Cut(raw, ":")
+		if !hasField {
+			q.Terms = append(q.Terms, Term{Value: raw})
+			continue
+		}
+		if field == "" {
+			return Query{}, fmt.Errorf("search term %q is missing a field name", raw)
+		}
+		if value == "" {
+			return Query{}, fmt.Errorf("search term %q is missing a value", raw)
+		}
+		q.Terms = append(q.Terms, Term{Field: field, Value: value})
+	}
+	return q, nil
+}
+
+// fields returns the indexed fields this term searches, or nil for full-text.
+func (t Term) fields() []string {
+	if t.Field == "" {
+		return nil
+	}
+	if fields, ok := fieldAliases[t.Field]; ok {
+		return fields
+	}
+	return []string{t.Field}
+}
+
+// IsPattern reports whether the term value contains wildcards.
+func (t Term) IsPattern() bool {
+	return strings.ContainsAny(t.Value, "*?")
+}
+
+// Match reports whether value matches the term's (possibly wildcard) value.
+func (t Term) Match(value string) bool {
+	return globMatch(t.Value, value)
+}
+
+// globMatch matches s against pattern where '*' matches any run of runes
+// (including '/' and ':', unlike path.Match) and '?' matches exactly one.
+func globMatch(pattern, s string) bool {
+	p, v := []rune(pattern), []rune(s)
+	pi, vi := 0, 0
+	star, mark := -1, 0
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == v[vi]):
+			pi++
+			vi++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, vi
+			pi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			vi = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
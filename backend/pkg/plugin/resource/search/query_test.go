@@ -0,0 +1,55 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`payments-*  NS:Prod label:app=web`)
+	require.NoError(t, err)
+	assert.Equal(t, []Term{
+		{Value: "payments-*"},
+		{Field: "ns", Value: "prod"},
+		{Field: "label", Value: "app=web"},
+	}, q.Terms)
+}
+
+func TestParseQuery_ValueMayContainColons(t *testing.T) {
+	q, err := ParseQuery(`arn:arn:aws:iam::123:role/x`)
+	require.NoError(t, err)
+	require.Len(t, q.Terms, 1)
+	assert.Equal(t, "arn", q.Terms[0].Field)
+	assert.Equal(t, "arn:aws:iam::123:role/x", q.Terms[0].Value)
+}
+
+func TestTerm_Fields(t *testing.T) {
+	assert.Nil(t, Term{Value: "x"}.fields())
+	assert.Equal(t, []string{"name", FieldID}, Term{Field: "name", Value: "x"}.fields())
+	assert.Equal(t, []string{FieldNamespace}, Term{Field: "ns", Value: "x"}.fields())
+	assert.Equal(t, []string{"image"}, Term{Field: "image", Value: "x"}.fields())
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"payments-*", "payments-api", true},
+		{"payments-*", "payments-", true},
+		{"payments-*", "payment", false},
+		{"*api*", "payments-api-7f9c", true},
+		{"core::v1::*", "core::v1::Pod", true},
+		{"*/payments:*", "ghcr.io/acme/payments:1.4", true},
+		{"pod-?", "pod-1", true},
+		{"pod-?", "pod-12", false},
+		{"*", "", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, globMatch(tt.pattern, tt.value), "%q vs %q", tt.pattern, tt.value)
+	}
+}
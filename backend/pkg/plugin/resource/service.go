@@ -5,6 +5,10 @@ import (
 
 	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
 	"github.com/omniviewdev/plugin-sdk/pkg/types"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/search"
 )
 
 // Service is the Wails-bound public API for resource operations.
@@ -62,6 +66,13 @@ type Service interface {
 	GetActions(pluginID, connectionID, key string) ([]resource.ActionDescriptor, error)
 	ExecuteAction(pluginID, connectionID, key, actionID string, input resource.ActionInput) (*resource.ActionResult, error)
 	StreamAction(pluginID, connectionID, key, actionID string, input resource.ActionInput) (string, error)
+	ListOperations() ([]OperationInfo, error)
+	CancelOperation(operationID string) error
+
+	// Bulk operations
+	StartBulkOperation(pluginID, connectionID string, req BulkRequest) (string, error)
+	CancelBulkOperation(operationID string) error
+	GetBulkOperation(operationID string) (*BulkSummary, error)
 
 	// Editor schemas
 	GetEditorSchemas(pluginID, connectionID string) ([]resource.EditorSchema, error)
@@ -70,6 +81,17 @@ type Service interface {
 	GetRelationships(pluginID, key string) ([]resource.RelationshipDescriptor, error)
 	ResolveRelationships(pluginID, connectionID, key, id, namespace string) ([]resource.ResolvedRelationship, error)
 
+	// Registry
+	ListKnownResources(pluginID, connectionID, resourceKey string) ([]registry.ResourceEntry, error)
+
+	// Search
+	SearchResources(query string, limit int) ([]search.Result, error)
+
+	// History
+	ListRevisions(pluginID, connectionID, resourceKey, namespace, id string) ([]history.RevisionInfo, error)
+	GetRevision(pluginID, connectionID, resourceKey, namespace, id string, seq uint64) (*history.Revision, error)
+	DiffRevisions(pluginID, connectionID, resourceKey, namespace, id string, fromSeq, toSeq uint64) ([]history.Change, error)
+
 	// Health
 	GetHealth(pluginID, connectionID, key string, data json.RawMessage) (*resource.ResourceHealth, error)
 	GetResourceEvents(pluginID, connectionID, key, id, namespace string, limit int32) ([]resource.ResourceEvent, error)
@@ -7,12 +7,16 @@ import (
 	sdkresource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
 	sdktypes "github.com/omniviewdev/plugin-sdk/pkg/types"
 	"github.com/wailsapp/wails/v3/pkg/application"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/search"
 )
 
 // ServiceWrapper is an explicit delegation wrapper around resource.Controller.
 // Excluded: OnPluginInit, OnPluginStart, OnPluginStop, OnPluginShutdown,
 //
-//	OnPluginDestroy, Run, SetCrashCallback, Graph
+//	OnPluginDestroy, Run, SetCrashCallback, SetConnectionStatusCallback, Graph
 type ServiceWrapper struct {
 	Ctrl Controller
 }
@@ -161,6 +165,23 @@ func (s *ServiceWrapper) ExecuteAction(pluginID, connectionID, key, actionID str
 func (s *ServiceWrapper) StreamAction(pluginID, connectionID, key, actionID string, input sdkresource.ActionInput) (string, error) {
 	return s.Ctrl.StreamAction(pluginID, connectionID, key, actionID, input)
 }
+func (s *ServiceWrapper) ListOperations() ([]OperationInfo, error) {
+	return s.Ctrl.ListOperations()
+}
+func (s *ServiceWrapper) CancelOperation(operationID string) error {
+	return s.Ctrl.CancelOperation(operationID)
+}
+
+// Bulk operations
+func (s *ServiceWrapper) StartBulkOperation(pluginID, connectionID string, req BulkRequest) (string, error) {
+	return s.Ctrl.StartBulkOperation(pluginID, connectionID, req)
+}
+func (s *ServiceWrapper) CancelBulkOperation(operationID string) error {
+	return s.Ctrl.CancelBulkOperation(operationID)
+}
+func (s *ServiceWrapper) GetBulkOperation(operationID string) (*BulkSummary, error) {
+	return s.Ctrl.GetBulkOperation(operationID)
+}
 
 // Editor schemas
 func (s *ServiceWrapper) GetEditorSchemas(pluginID, connectionID string) ([]sdkresource.EditorSchema, error) {
@@ -175,6 +196,27 @@ func (s *ServiceWrapper) ResolveRelationships(pluginID, connectionID, key, id, n
 	return s.Ctrl.ResolveRelationships(pluginID, connectionID, key, id, namespace)
 }
 
+// Registry
+func (s *ServiceWrapper) ListKnownResources(pluginID, connectionID, resourceKey string) ([]registry.ResourceEntry, error) {
+	return s.Ctrl.ListKnownResources(pluginID, connectionID, resourceKey)
+}
+
+// Search
+func (s *ServiceWrapper) SearchResources(query string, limit int) ([]search.Result, error) {
+	return s.Ctrl.SearchResources(query, limit)
+}
+
+// History
+func (s *ServiceWrapper) ListRevisions(pluginID, connectionID, resourceKey, namespace, id string) ([]history.RevisionInfo, error) {
+	return s.Ctrl.ListRevisions(pluginID, connectionID, resourceKey, namespace, id)
+}
+func (s *ServiceWrapper) GetRevision(pluginID, connectionID, resourceKey, namespace, id string, seq uint64) (*history.Revision, error) {
+	return s.Ctrl.GetRevision(pluginID, connectionID, resourceKey, namespace, id, seq)
+}
+func (s *ServiceWrapper) DiffRevisions(pluginID, connectionID, resourceKey, namespace, id string, fromSeq, toSeq uint64) ([]history.Change, error) {
+	return s.Ctrl.DiffRevisions(pluginID, connectionID, resourceKey, namespace, id, fromSeq, toSeq)
+}
+
 // Health
 func (s *ServiceWrapper) GetHealth(pluginID, connectionID, key string, data json.RawMessage) (*sdkresource.ResourceHealth, error) {
 	return s.Ctrl.GetHealth(pluginID, connectionID, key, data)
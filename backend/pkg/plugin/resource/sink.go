@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"sync"
 
 	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
 
@@ -21,6 +22,18 @@ type engineWatchSink struct {
 	ctrl       *controller
 	store      registry.RegistryStore
 	dispatcher *indexer.Dispatcher
+
+	// history receives watch events for the revision history; nil when
+	// history is disabled. Kept separate from dispatcher because registry
+	// teardown also enqueues deletes there, which are not real changes.
+	history *indexer.Dispatcher
+
+	// resync tracks entry keys observed since a resource watch entered
+	// Syncing, keyed by "connectionID/resourceKey". Only used with a
+	// persistent store, whose restored entries may have been deleted while
+	// the app was closed.
+	resyncMu sync.Mutex
+	resync   map[string]map[string]struct{}
 }
 
 var _ resource.WatchEventSink = (*engineWatchSink)(nil)
@@ -63,6 +76,7 @@ func (s *engineWatchSink) OnAdd(p resource.WatchAddPayload) {
 	// 1. Registry update (unconditional)
 	entry := s.entryFromAddPayload(p)
 	old, existed := s.store.Put(entry)
+	s.markSeen(entry)
 
 	// 2. Subscription gate — ONLY controls Wails emit
 	if s.ctrl.isSubscribed(s.pluginID, p.Connection, p.Key) {
@@ -74,15 +88,12 @@ func (s *engineWatchSink) OnAdd(p resource.WatchAddPayload) {
 	rawCopy := make(json.RawMessage, len(p.Data))
 	copy(rawCopy, p.Data)
 
+	event := indexer.Event{Type: indexer.EventAdd, Entry: entry, Raw: rawCopy}
 	if existed {
-		s.dispatcher.Enqueue(indexer.Event{
-			Type: indexer.EventUpdate, Entry: entry, Old: old, Raw: rawCopy,
-		})
-	} else {
-		s.dispatcher.Enqueue(indexer.Event{
-			Type: indexer.EventAdd, Entry: entry, Raw: rawCopy,
-		})
+		event = indexer.Event{Type: indexer.EventUpdate, Entry: entry, Old: old, Raw: rawCopy}
 	}
+	s.dispatcher.Enqueue(event)
+	s.recordHistory(event)
 }
 
 func (s *engineWatchSink) OnUpdate(p resource.WatchUpdatePayload) {
@@ -90,6 +101,7 @@ func (s *engineWatchSink) OnUpdate(p resource.WatchUpdatePayload) {
 
 	entry := s.entryFromUpdatePayload(p)
 	old, _ := s.store.Put(entry)
+	s.markSeen(entry)
 
 	if s.ctrl.isSubscribed(s.pluginID, p.Connection, p.Key) {
 		eventKey := fmt.Sprintf("%s/%s/%s/UPDATE", s.pluginID, p.Connection, p.Key)
@@ -99,9 +111,9 @@ func (s *engineWatchSink) OnUpdate(p resource.WatchUpdatePayload) {
 	rawCopy := make(json.RawMessage, len(p.Data))
 	copy(rawCopy, p.Data)
 
-	s.dispatcher.Enqueue(indexer.Event{
-		Type: indexer.EventUpdate, Entry: entry, Old: old, Raw: rawCopy,
-	})
+	event := indexer.Event{Type: indexer.EventUpdate, Entry: entry, Old: old, Raw: rawCopy}
+	s.dispatcher.Enqueue(event)
+	s.recordHistory(event)
 }
 
 func (s *engineWatchSink) OnDelete(p resource.WatchDeletePayload) {
@@ -115,9 +127,9 @@ func (s *engineWatchSink) OnDelete(p resource.WatchDeletePayload) {
 	}
 
 	if existed && old != nil {
-		s.dispatcher.Enqueue(indexer.Event{
-			Type: indexer.EventDelete, Entry: *old,
-		})
+		event := indexer.Event{Type: indexer.EventDelete, Entry: *old}
+		s.dispatcher.Enqueue(event)
+		s.recordHistory(event)
 	}
 }
 
@@ -125,6 +137,7 @@ func (s *engineWatchSink) OnStateChange(e resource.WatchStateEvent) {
 	// Enrich with plugin identity (Connection is already set by the SDK's
 	// connectionEnrichingSink wrapper).
 	e.PluginID = s.pluginID
+	s.trackResync(e)
 
 	s.ctrl.logger.Infow(context.Background(), "[watch-state] engine sink received",
 		"pluginID", s.pluginID,
@@ -141,3 +154,61 @@ func (s *engineWatchSink) OnStateChange(e resource.WatchStateEvent) {
 	// Global event for footer/status bar aggregation.
 	s.ctrl.emitter.Emit("watch/STATE", e)
 }
+
+// recordHistory forwards a watch event to the revision history, if enabled.
+func (s *engineWatchSink) recordHistory(event indexer.Event) {
+	if s.history != nil {
+		s.history.Enqueue(event)
+	}
+}
+
+// markSeen records that entry was observed during an in-progress resync.
+func (s *engineWatchSink) markSeen(entry registry.ResourceEntry) {
+	s.resyncMu.Lock()
+	defer s.resyncMu.Unlock()
+	if seen, ok := s.resync[entry.ConnectionID+"/"+entry.ResourceKey]; ok {
+		seen[entry.EntryKey()] = struct{}{}
+	}
+}
+
+// trackResync prunes persisted entries that a completed watch sync did not
+// re-observe. Syncing starts a fresh observation window; Synced closes it and
+// deletes every stored entry for that resource key not seen in between.
+func (s *engineWatchSink) trackResync(e resource.WatchStateEvent) {
+	if _, ok := s.store.(registry.PersistentStore); !ok {
+		return
+	}
+	key := e.Connection + "/" + e.ResourceKey
+
+	s.resyncMu.Lock()
+	switch e.State {
+	case resource.WatchStateSyncing:
+		if s.resync == nil {
+			s.resync = make(map[string]map[string]struct{})
+		}
+		s.resync[key] = make(map[string]struct{})
+		s.resyncMu.Unlock()
+		return
+	case resource.WatchStateSynced:
+	default:
+		s.resyncMu.Unlock()
+		return
+	}
+	seen, ok := s.resync[key]
+	delete(s.resync, key)
+	s.resyncMu.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, entry := range s.store.ScanByResourceKey(s.pluginID, e.Connection, e.ResourceKey) {
+		if _, ok := seen[entry.EntryKey()]; ok {
+			continue
+		}
+		if old, existed := s.store.Delete(entry.PluginID, entry.ConnectionID, entry.ResourceKey, entry.Namespace, entry.ID); existed && old != nil {
+			event := indexer.Event{Type: indexer.EventDelete, Entry: *old}
+			s.dispatcher.Enqueue(event)
+			s.recordHistory(event)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
+)
+
+// LiveName is the name used for the live side of a snapshot-vs-live comparison.
+const LiveName = "live"
+
+// Ref identifies a resource within a resource key group.
+type Ref struct {
+	Namespace string `json:"namespace,omitempty"`
+	ID        string `json:"id"`
+}
+
+// ChangedResource is a resource present in both snapshots whose payload or
+// identity differs.
+type ChangedResource struct {
+	Ref
+	// Recreated is set when the resource was deleted and created again under
+	// the same name (its UID changed).
+	Recreated bool             `json:"recreated,omitempty"`
+	Changes   []history.Change `json:"changes"`
+}
+
+// Group holds the differences for one resource key.
+type Group struct {
+	ResourceKey string            `json:"resourceKey"`
+	Added       []Ref             `json:"added"`
+	Removed     []Ref             `json:"removed"`
+	Changed     []ChangedResource `json:"changed"`
+}
+
+// Comparison is the result of comparing two snapshots. Only resource keys
+// with differences have a group.
+type Comparison struct {
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	Added   int     `json:"added"`
+	Removed int     `json:"removed"`
+	Changed int     `json:"changed"`
+	Groups  []Group `json:"groups"`
+}
+
+type resourceID struct {
+	resourceKey, namespace, id string
+}
+
+// Compare reports the resources added, removed and changed between from and
+// to, grouped by resource key. Groups are sorted by resource key and the
+// resources within them by namespace and ID.
+func Compare(from, to *Snapshot) (*Comparison, error) {
+	before := make(map[resourceID]Resource, len(from.Resources))
+	for _, r := range from.Resources {
+		before[resourceID{r.ResourceKey, r.Namespace, r.ID}] = r
+	}
+
+	groups := make(map[string]*Group)
+	group := func(resourceKey string) *Group {
+		g, ok := groups[resourceKey]
+		if !ok {
+			g = &Group{ResourceKey: resourceKey, Added: []Ref{}, Removed: []Ref{}, Changed: []ChangedResource{}}
+			groups[resourceKey] = g
+		}
+		return g
+	}
+
+	result := &Comparison{From: from.Name, To: to.Name, Groups: []Group{}}
+	for _, r := range to.Resources {
+		id := resourceID{r.ResourceKey, r.Namespace, r.ID}
+		old, ok := before[id]
+		if !ok {
+			g := group(r.ResourceKey)
+			g.Added = append(g.Added, Ref{Namespace: r.Namespace, ID: r.ID})
+			result.Added++
+			continue
+		}
+		delete(before, id)
+
+		changed, err := compareResource(old, r)
+		if err != nil {
+			return nil, err
+		}
+		if changed != nil {
+			g := group(r.ResourceKey)
+			g.Changed = append(g.Changed, *changed)
+			result.Changed++
+		}
+	}
+	for _, r := range before {
+		g := group(r.ResourceKey)
+		g.Removed = append(g.Removed, Ref{Namespace: r.Namespace, ID: r.ID})
+		result.Removed++
+	}
+
+	for _, g := range groups {
+		slices.SortFunc(g.Added, compareRefs)
+		slices.SortFunc(g.Removed, compareRefs)
+		slices.SortFunc(g.Changed, func(a, b ChangedResource) int { return compareRefs(a.Ref, b.Ref) })
+		result.Groups = append(result.Groups, *g)
+	}
+	slices.SortFunc(result.Groups, func(a, b Group) int { return cmp.Compare(a.ResourceKey, b.ResourceKey) })
+	return result, nil
+}
+
+// compareResource returns the change between two versions of a resource, or
+// nil if they are the same. Payloads are compared by hash, then diffed, so
+// payloads that differ only in formatting or key order are the same; when
+// either side has no payload only the UID is compared.
+func compareResource(old, cur Resource) (*ChangedResource, error) {
+	recreated := old.UID != "" && cur.UID != "" && old.UID != cur.UID
+	payloadChanged := old.Hash != "" && cur.Hash != "" && old.Hash != cur.Hash
+	if !recreated && !payloadChanged {
+		return nil, nil
+	}
+
+	changes := []history.Change{}
+	if payloadChanged {
+		var err error
+		if changes, err = history.Diff(old.Data, cur.Data); err != nil {
+			return nil, err
+		}
+	}
+	if !recreated && len(changes) == 0 {
+		return nil, nil
+	}
+	return &ChangedResource{
+		Ref:       Ref{Namespace: cur.Namespace, ID: cur.ID},
+		Recreated: recreated,
+		Changes:   changes,
+	}, nil
+}
+
+func compareRefs(a, b Ref) int {
+	return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.ID, b.ID))
+}
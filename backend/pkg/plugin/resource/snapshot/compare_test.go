@@ -0,0 +1,107 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
+)
+
+func pod(ns, id, uid, data string) Resource {
+	return NewResource("core::v1::Pod", ns, id, uid, nil, json.RawMessage(data))
+}
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+	from := testSnapshot("ctx", "before", now,
+		pod("default", "kept", "u1", `{"image":"v1"}`),
+		pod("default", "changed", "u2", `{"image":"v1"}`),
+		pod("default", "removed", "u3", `{}`),
+		NewResource("apps::v1::Deployment", "default", "web", "d1", nil, json.RawMessage(`{"replicas":1}`)),
+	)
+	to := testSnapshot("ctx", "after", now,
+		pod("default", "kept", "u1", `{"image":"v1"}`),
+		pod("default", "changed", "u2", `{"image":"v2"}`),
+		pod("default", "added", "u4", `{}`),
+		NewResource("apps::v1::Deployment", "default", "web", "d1", nil, json.RawMessage(`{"replicas":1}`)),
+	)
+
+	c, err := Compare(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, "before", c.From)
+	assert.Equal(t, "after", c.To)
+	assert.Equal(t, 1, c.Added)
+	assert.Equal(t, 1, c.Removed)
+	assert.Equal(t, 1, c.Changed)
+
+	require.Len(t, c.Groups, 1, "unchanged resource keys have no group")
+	g := c.Groups[0]
+	assert.Equal(t, "core::v1::Pod", g.ResourceKey)
+	assert.Equal(t, []Ref{{Namespace: "default", ID: "added"}}, g.Added)
+	assert.Equal(t, []Ref{{Namespace: "default", ID: "removed"}}, g.Removed)
+	require.Len(t, g.Changed, 1)
+	assert.Equal(t, "changed", g.Changed[0].ID)
+	assert.False(t, g.Changed[0].Recreated)
+	assert.Equal(t, []history.Change{
+		{Op: history.ChangeReplace, Path: "/image", OldValue: "v1", NewValue: "v2"},
+	}, g.Changed[0].Changes)
+}
+
+func TestCompare_Recreated(t *testing.T) {
+	now := time.Now()
+	from := testSnapshot("ctx", "a", now, pod("default", "web", "u1", `{"x":1}`))
+	to := testSnapshot("ctx", "b", now, pod("default", "web", "u2", `{"x":1}`))
+
+	c, err := Compare(from, to)
+	require.NoError(t, err)
+	require.Len(t, c.Groups, 1)
+	require.Len(t, c.Groups[0].Changed, 1)
+	assert.True(t, c.Groups[0].Changed[0].Recreated)
+	assert.Empty(t, c.Groups[0].Changed[0].Changes)
+}
+
+func TestCompare_EquivalentPayloadsAreUnchanged(t *testing.T) {
+	now := time.Now()
+	from := testSnapshot("ctx", "a", now, pod("default", "web", "u1", `{"x":1,"y":[1,2]}`))
+	to := testSnapshot("ctx", "b", now, pod("default", "web", "u1", `{ "y": [1, 2], "x": 1 }`))
+	require.NotEqual(t, from.Resources[0].Hash, to.Resources[0].Hash)
+
+	c, err := Compare(from, to)
+	require.NoError(t, err)
+	assert.Zero(t, c.Changed)
+	assert.Empty(t, c.Groups)
+}
+
+func TestCompare_MissingPayloadComparesByUID(t *testing.T) {
+	now := time.Now()
+	failed := NewResource("core::v1::Pod", "default", "web", "u1", nil, nil)
+	failed.Error = "timeout"
+	from := testSnapshot("ctx", "a", now, pod("default", "web", "u1", `{"x":1}`))
+	to := testSnapshot("ctx", "b", now, failed)
+
+	c, err := Compare(from, to)
+	require.NoError(t, err)
+	assert.Empty(t, c.Groups)
+	assert.NotNil(t, c.Groups)
+}
+
+func TestCompare_GroupsSorted(t *testing.T) {
+	now := time.Now()
+	from := testSnapshot("ctx", "a", now)
+	to := testSnapshot("ctx", "b", now,
+		NewResource("z::Kind", "", "b", "", nil, nil),
+		NewResource("a::Kind", "ns2", "a", "", nil, nil),
+		NewResource("a::Kind", "ns1", "b", "", nil, nil),
+	)
+
+	c, err := Compare(from, to)
+	require.NoError(t, err)
+	require.Len(t, c.Groups, 2)
+	assert.Equal(t, "a::Kind", c.Groups[0].ResourceKey)
+	assert.Equal(t, []Ref{{Namespace: "ns1", ID: "b"}, {Namespace: "ns2", ID: "a"}}, c.Groups[0].Added)
+	assert.Equal(t, "z::Kind", c.Groups[1].ResourceKey)
+}
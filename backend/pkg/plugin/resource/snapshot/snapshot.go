@@ -0,0 +1,195 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+// Snapshots live in the plugin's data store: indexKey holds the Info of every
+// snapshot, and each snapshot's resources are stored under keyPrefix plus a
+// hash of its connection and name.
+const (
+	keyPrefix = "omniview.snapshot."
+	indexKey  = keyPrefix + "index"
+)
+
+var (
+	// ErrNotFound is returned when a named snapshot does not exist.
+	ErrNotFound = errors.New("snapshot not found")
+	// ErrExists is returned when saving a snapshot whose name is already taken
+	// on the connection.
+	ErrExists = errors.New("snapshot already exists")
+)
+
+// Info describes a snapshot without its resources.
+type Info struct {
+	Name         string    `json:"name"`
+	PluginID     string    `json:"pluginID"`
+	ConnectionID string    `json:"connectionID"`
+	CreatedAt    time.Time `json:"createdAt"`
+	// ResourceKeys are the resource types that were captured, so a later
+	// comparison against live state covers the same types.
+	ResourceKeys []string `json:"resourceKeys"`
+	Count        int      `json:"count"`
+}
+
+// Resource is a single captured resource.
+type Resource struct {
+	ResourceKey string            `json:"resourceKey"`
+	Namespace   string            `json:"namespace,omitempty"`
+	ID          string            `json:"id"`
+	UID         string            `json:"uid,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Hash        string            `json:"hash,omitempty"`
+	Data        json.RawMessage   `json:"data,omitempty"`
+	// Error is set when the payload could not be fetched; the resource is
+	// still part of the inventory but is compared by UID only.
+	Error string `json:"error,omitempty"`
+}
+
+// NewResource returns a Resource with Hash computed from data.
+func NewResource(resourceKey, namespace, id, uid string, labels map[string]string, data json.RawMessage) Resource {
+	r := Resource{ResourceKey: resourceKey, Namespace: namespace, ID: id, UID: uid, Labels: labels, Data: data}
+	if len(data) > 0 {
+		sum := sha256.Sum256(data)
+		r.Hash = hex.EncodeToString(sum[:])
+	}
+	return r
+}
+
+// Snapshot is a point-in-time inventory of a connection: every registry
+// entry of the captured resource types together with its raw payload.
+type Snapshot struct {
+	Info
+	Resources []Resource `json:"resources"`
+}
+
+// Store persists snapshots in a plugin data store. Each snapshot is stored under its
+// own key, and a per-plugin index lists them so listing never loads payloads.
+type Store struct {
+	data plugindata.Store
+	mu   sync.Mutex
+}
+
+// NewStore creates a Store backed by data.
+func NewStore(data plugindata.Store) *Store {
+	return &Store{data: data}
+}
+
+// Save persists snap. It returns ErrExists if a snapshot with the same name
+// already exists on the connection.
+func (s *Store) Save(snap *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.readIndex(snap.PluginID)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(index, func(i Info) bool {
+		return i.ConnectionID == snap.ConnectionID && i.Name == snap.Name
+	}) {
+		return ErrExists
+	}
+
+	snap.Count = len(snap.Resources)
+	if err := s.data.Set(snap.PluginID, snapshotKey(snap.ConnectionID, snap.Name), snap); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := s.data.Set(snap.PluginID, indexKey, append(index, snap.Info)); err != nil {
+		return fmt.Errorf("write snapshot index: %w", err)
+	}
+	return nil
+}
+
+// Load returns the named snapshot, or ErrNotFound.
+func (s *Store) Load(pluginID, connectionID, name string) (*Snapshot, error) {
+	value, err := s.data.Get(pluginID, snapshotKey(connectionID, name))
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	if value == nil {
+		return nil, ErrNotFound
+	}
+	// Payloads come back re-encoded with sorted keys, which is why hashes
+	// are recorded at capture time rather than recomputed.
+	var snap Snapshot
+	if err := plugindata.Decode(value, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// List returns the snapshots of a connection, oldest first.
+func (s *Store) List(pluginID, connectionID string) ([]Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.readIndex(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	infos := []Info{}
+	for _, info := range index {
+		if info.ConnectionID == connectionID {
+			infos = append(infos, info)
+		}
+	}
+	slices.SortStableFunc(infos, func(a, b Info) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return infos, nil
+}
+
+// Delete removes the named snapshot, or returns ErrNotFound.
+func (s *Store) Delete(pluginID, connectionID, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.readIndex(pluginID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(index, func(i Info) bool {
+		return i.ConnectionID == connectionID && i.Name == name
+	})
+	if i < 0 {
+		return ErrNotFound
+	}
+	if err := s.data.Set(pluginID, indexKey, slices.Delete(index, i, i+1)); err != nil {
+		return fmt.Errorf("write snapshot index: %w", err)
+	}
+	if err := s.data.Delete(pluginID, snapshotKey(connectionID, name)); err != nil {
+		return fmt.Errorf("delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// readIndex loads a plugin's snapshot index. Caller must hold s.mu.
+func (s *Store) readIndex(pluginID string) ([]Info, error) {
+	value, err := s.data.Get(pluginID, indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot index: %w", err)
+	}
+	var index []Info
+	if value != nil {
+		if err := plugindata.Decode(value, &index); err != nil {
+			return nil, fmt.Errorf("decode snapshot index: %w", err)
+		}
+	}
+	return index, nil
+}
+
+// snapshotKey derives a file-safe data store key. Connection IDs are often
+// ARNs or kubeconfig context names, so the key is a hash rather than the raw
+// name.
+func snapshotKey(connectionID, name string) string {
+	sum := sha256.Sum256([]byte(connectionID + "\x00" + name))
+	return keyPrefix + hex.EncodeToString(sum[:12])
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+func testSnapshot(conn, name string, created time.Time, resources ...Resource) *Snapshot {
+	return &Snapshot{
+		Info:      Info{Name: name, PluginID: "k8s", ConnectionID: conn, CreatedAt: created, ResourceKeys: []string{"core::v1::Pod"}},
+		Resources: resources,
+	}
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	s := NewStore(plugindata.NewMemory())
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	pod := NewResource("core::v1::Pod", "default", "web", "uid-1", map[string]string{"app": "web"}, json.RawMessage(`{"b":1,"a":2}`))
+	require.NoError(t, s.Save(testSnapshot("ctx-a", "pre-deploy", created, pod)))
+
+	snap, err := s.Load("k8s", "ctx-a", "pre-deploy")
+	require.NoError(t, err)
+	assert.Equal(t, "pre-deploy", snap.Name)
+	assert.Equal(t, 1, snap.Count)
+	assert.True(t, created.Equal(snap.CreatedAt))
+	require.Len(t, snap.Resources, 1)
+	assert.Equal(t, pod.Hash, snap.Resources[0].Hash)
+	assert.Equal(t, map[string]string{"app": "web"}, snap.Resources[0].Labels)
+	assert.JSONEq(t, `{"a":2,"b":1}`, string(snap.Resources[0].Data))
+}
+
+func TestStore_NamesAreScopedToConnection(t *testing.T) {
+	s := NewStore(plugindata.NewMemory())
+	now := time.Now()
+	require.NoError(t, s.Save(testSnapshot("ctx-a", "before", now)))
+	require.NoError(t, s.Save(testSnapshot("ctx-b", "before", now)))
+	assert.ErrorIs(t, s.Save(testSnapshot("ctx-a", "before", now)), ErrExists)
+}
+
+func TestStore_ListIsOldestFirst(t *testing.T) {
+	s := NewStore(plugindata.NewMemory())
+	now := time.Now()
+	require.NoError(t, s.Save(testSnapshot("ctx-a", "second", now)))
+	require.NoError(t, s.Save(testSnapshot("ctx-a", "first", now.Add(-time.Hour))))
+	require.NoError(t, s.Save(testSnapshot("ctx-b", "other", now)))
+
+	infos, err := s.List("k8s", "ctx-a")
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "first", infos[0].Name)
+	assert.Equal(t, "second", infos[1].Name)
+
+	infos, err = s.List("k8s", "ctx-missing")
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+	assert.NotNil(t, infos)
+}
+
+func TestStore_Delete(t *testing.T) {
+	data := plugindata.NewMemory()
+	s := NewStore(data)
+	require.NoError(t, s.Save(testSnapshot("ctx-a", "before", time.Now())))
+	require.NoError(t, s.Delete("k8s", "ctx-a", "before"))
+
+	_, err := s.Load("k8s", "ctx-a", "before")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, s.Delete("k8s", "ctx-a", "before"), ErrNotFound)
+	infos, err := s.List("k8s", "ctx-a")
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+	keys, err := data.Keys("k8s")
+	require.NoError(t, err)
+	assert.Equal(t, []string{indexKey}, keys, "only the index remains")
+}
+
+func TestSnapshotKey_IsFileSafe(t *testing.T) {
+	key := snapshotKey("arn:aws:eks:us-east-1:123:cluster/prod", "../../etc")
+	assert.Regexp(t, `^[a-z.]+[0-9a-f]{24}$`, key)
+	assert.NotEqual(t, key, snapshotKey("arn:aws:eks:us-east-1:123:cluster/prod", "other"))
+}
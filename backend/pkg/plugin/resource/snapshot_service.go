@@ -0,0 +1,280 @@
+package resource
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+	"github.com/tidwall/gjson"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/bundle"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/snapshot"
+)
+
+const (
+	// snapshotFetchConcurrency bounds the resource types whose payloads are
+	// fetched at once while capturing.
+	snapshotFetchConcurrency = 8
+	maxSnapshotNameLength    = 128
+)
+
+// SnapshotService captures named point-in-time inventories of a connection
+// and compares them with each other or with live state. Exposed to the
+// frontend via Wails binding.
+//
+// The inventory is the set of registry entries (what the watches have seen)
+// for each captured resource type; payloads are fetched with one List per
+// type so the snapshot records the full resource as the plugin reports it.
+type SnapshotService struct {
+	ctrl  Controller
+	store *snapshot.Store
+}
+
+// NewSnapshotService creates a new SnapshotService.
+func NewSnapshotService(ctrl Controller, store *snapshot.Store) *SnapshotService {
+	return &SnapshotService{ctrl: ctrl, store: store}
+}
+
+// CreateSnapshot captures the current inventory of a connection under name.
+// resourceKeys limits the capture to those resource types; empty captures
+// every type the plugin reports for the connection.
+func (s *SnapshotService) CreateSnapshot(pluginID, connectionID, name string, resourceKeys []string) (snapshot.Info, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxSnapshotNameLength {
+		return snapshot.Info{}, apperror.New(apperror.TypeValidation, 400, "Invalid snapshot name",
+			fmt.Sprintf("Snapshot names must be between 1 and %d characters.", maxSnapshotNameLength))
+	}
+	if _, err := s.store.Load(pluginID, connectionID, name); err == nil {
+		return snapshot.Info{}, snapshotExists(name)
+	}
+
+	snap, err := s.capture(pluginID, connectionID, resourceKeys)
+	if err != nil {
+		return snapshot.Info{}, err
+	}
+	snap.Name = name
+	if err := s.store.Save(snap); err != nil {
+		return snapshot.Info{}, snapshotError(err, name)
+	}
+	return snap.Info, nil
+}
+
+// ListSnapshots returns the snapshots of a connection, oldest first.
+func (s *SnapshotService) ListSnapshots(pluginID, connectionID string) ([]snapshot.Info, error) {
+	infos, err := s.store.List(pluginID, connectionID)
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to list snapshots")
+	}
+	return infos, nil
+}
+
+// GetSnapshot returns a snapshot including its resources.
+func (s *SnapshotService) GetSnapshot(pluginID, connectionID, name string) (*snapshot.Snapshot, error) {
+	snap, err := s.store.Load(pluginID, connectionID, name)
+	if err != nil {
+		return nil, snapshotError(err, name)
+	}
+	return snap, nil
+}
+
+// DeleteSnapshot removes a snapshot.
+func (s *SnapshotService) DeleteSnapshot(pluginID, connectionID, name string) error {
+	if err := s.store.Delete(pluginID, connectionID, name); err != nil {
+		return snapshotError(err, name)
+	}
+	return nil
+}
+
+// CompareSnapshots reports the resources added, removed and changed between
+// two snapshots of a connection, grouped by resource key.
+func (s *SnapshotService) CompareSnapshots(pluginID, connectionID, from, to string) (*snapshot.Comparison, error) {
+	before, err := s.store.Load(pluginID, connectionID, from)
+	if err != nil {
+		return nil, snapshotError(err, from)
+	}
+	after, err := s.store.Load(pluginID, connectionID, to)
+	if err != nil {
+		return nil, snapshotError(err, to)
+	}
+	return compareSnapshots(before, after)
+}
+
+// CompareSnapshotWithLive compares a snapshot against the current state of
+// the connection, capturing the same resource types the snapshot did.
+func (s *SnapshotService) CompareSnapshotWithLive(pluginID, connectionID, name string) (*snapshot.Comparison, error) {
+	before, err := s.store.Load(pluginID, connectionID, name)
+	if err != nil {
+		return nil, snapshotError(err, name)
+	}
+	live, err := s.capture(pluginID, connectionID, before.ResourceKeys)
+	if err != nil {
+		return nil, err
+	}
+	live.Name = snapshot.LiveName
+	return compareSnapshots(before, live)
+}
+
+// capture builds an unsaved snapshot of the connection's current inventory.
+func (s *SnapshotService) capture(pluginID, connectionID string, resourceKeys []string) (*snapshot.Snapshot, error) {
+	if !s.ctrl.HasPlugin(pluginID) {
+		return nil, apperror.PluginNotFound(pluginID)
+	}
+	if len(resourceKeys) == 0 {
+		resourceKeys = slices.Collect(maps.Keys(s.ctrl.GetResourceTypes(pluginID, connectionID)))
+	}
+	resourceKeys = slices.Clone(resourceKeys)
+	slices.Sort(resourceKeys)
+	resourceKeys = slices.Compact(resourceKeys)
+
+	byType := make([][]snapshot.Resource, len(resourceKeys))
+	sem := make(chan struct{}, snapshotFetchConcurrency)
+	var wg sync.WaitGroup
+	for i, rk := range resourceKeys {
+		known, err := s.ctrl.ListKnownResources(pluginID, connectionID, rk)
+		if err != nil {
+			wg.Wait()
+			return nil, err
+		}
+		if len(known) == 0 {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			byType[i] = s.fetchType(pluginID, connectionID, rk, known)
+		}()
+	}
+	wg.Wait()
+	resources := slices.Concat(byType...)
+
+	slices.SortFunc(resources, func(a, b snapshot.Resource) int {
+		return cmp.Or(
+			cmp.Compare(a.ResourceKey, b.ResourceKey),
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	return &snapshot.Snapshot{
+		Info: snapshot.Info{
+			PluginID:     pluginID,
+			ConnectionID: connectionID,
+			CreatedAt:    time.Now().UTC(),
+			ResourceKeys: resourceKeys,
+			Count:        len(resources),
+		},
+		Resources: resources,
+	}, nil
+}
+
+// fetchType returns the snapshot records for the known resources of one
+// type, taking their payloads from a single List. A resource the List does
+// not return is fetched on its own. A failed fetch keeps the resource in the
+// inventory with the error recorded instead of a payload.
+func (s *SnapshotService) fetchType(pluginID, connectionID, resourceKey string, known []registry.ResourceEntry) []snapshot.Resource {
+	payloads, listErr := listPayloads(s.ctrl, pluginID, connectionID, resourceKey)
+	resources := make([]snapshot.Resource, len(known))
+	for i, e := range known {
+		if listErr != nil {
+			resources[i] = snapshotResource(e, nil, listErr)
+			continue
+		}
+		if payload, ok := payloads[payloadKey{namespace: e.Namespace, id: e.ID}]; ok {
+			resources[i] = snapshotResource(e, payload, nil)
+			continue
+		}
+		result, err := s.ctrl.Get(e.PluginID, e.ConnectionID, e.ResourceKey, resource.GetInput{ID: e.ID, Namespace: e.Namespace})
+		if err != nil {
+			resources[i] = snapshotResource(e, nil, err)
+			continue
+		}
+		resources[i] = snapshotResource(e, result.Result, nil)
+	}
+	return resources
+}
+
+// payloadKey locates a listed payload by the namespace and ID of its resource.
+type payloadKey struct {
+	namespace string
+	id        string
+}
+
+// listPayloads returns the payloads of every resource of a type, by
+// namespace and ID as located by the type's definition.
+func listPayloads(ctrl Controller, pluginID, connectionID, resourceKey string) (map[payloadKey]json.RawMessage, error) {
+	result, err := ctrl.List(pluginID, connectionID, resourceKey, resource.ListInput{})
+	if err != nil {
+		return nil, err
+	}
+	paths := bundle.DefaultPaths
+	if def, defErr := ctrl.GetResourceDefinition(pluginID, resourceKey); defErr == nil && def.IDAccessor != "" {
+		paths = bundle.Paths{ID: def.IDAccessor, Namespace: def.NamespaceAccessor}
+	}
+
+	payloads := make(map[payloadKey]json.RawMessage)
+	if result == nil {
+		return payloads, nil
+	}
+	for _, raw := range result.Result {
+		key := payloadKey{id: gjson.GetBytes(raw, paths.ID).String()}
+		if key.id == "" {
+			continue
+		}
+		if paths.Namespace != "" {
+			key.namespace = gjson.GetBytes(raw, paths.Namespace).String()
+		}
+		payloads[key] = raw
+	}
+	return payloads, nil
+}
+
+func snapshotResource(e registry.ResourceEntry, payload json.RawMessage, err error) snapshot.Resource {
+	r := snapshot.NewResource(e.ResourceKey, e.Namespace, e.ID, e.UID, e.Labels, payload)
+	if err != nil {
+		r.Error = errorDetail(err)
+	}
+	return r
+}
+
+func compareSnapshots(from, to *snapshot.Snapshot) (*snapshot.Comparison, error) {
+	result, err := snapshot.Compare(from, to)
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to compare snapshots")
+	}
+	return result, nil
+}
+
+func snapshotExists(name string) *apperror.AppError {
+	return apperror.New(apperror.TypeResourceConflict, 409, "Snapshot already exists",
+		fmt.Sprintf("A snapshot named %q already exists on this connection.", name))
+}
+
+func snapshotError(err error, name string) *apperror.AppError {
+	switch {
+	case errors.Is(err, snapshot.ErrNotFound):
+		return apperror.NotFound("Snapshot not found", fmt.Sprintf("No snapshot named %q exists on this connection.", name))
+	case errors.Is(err, snapshot.ErrExists):
+		return snapshotExists(name)
+	default:
+		return apperror.Internal(err, "Snapshot storage failed")
+	}
+}
+
+// errorDetail returns a human-readable message for err, unwrapping AppErrors
+// whose Error() is a JSON document.
+func errorDetail(err error) string {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return cmp.Or(appErr.Detail, appErr.Title)
+	}
+	return err.Error()
+}
@@ -0,0 +1,205 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/data"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/snapshot"
+	"github.com/omniviewdev/omniview/internal/appstate"
+)
+
+// snapshotFixture is a plugin whose List and Get serve mutable pod payloads.
+type snapshotFixture struct {
+	ctrl *controller
+	svc  *SnapshotService
+
+	mu       sync.Mutex
+	payloads map[string]string // "namespace/id" -> payload
+	lists    int
+	gets     int
+}
+
+func newSnapshotFixture(t *testing.T) *snapshotFixture {
+	t.Helper()
+	ctrl, _ := newTestControllerWithEmitter(t)
+	dataCtrl := data.NewController(logging.NewNop(), appstate.NewTestService(t).PluginData)
+	f := &snapshotFixture{
+		ctrl:     ctrl,
+		svc:      NewSnapshotService(ctrl, snapshot.NewStore(dataCtrl)),
+		payloads: make(map[string]string),
+	}
+	registerMockPlugin(ctrl, "plugin-a", &mockProvider{
+		GetResourceTypesFunc: func(context.Context, string) map[string]resource.ResourceMeta {
+			return map[string]resource.ResourceMeta{"core::v1::Pod": {}, "core::v1::Service": {}}
+		},
+		ListFunc: func(_ context.Context, key string, _ resource.ListInput) (*resource.ListResult, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.lists++
+			result := &resource.ListResult{Success: true}
+			if key != "core::v1::Pod" {
+				return result, nil
+			}
+			for _, payload := range f.payloads {
+				result.Result = append(result.Result, json.RawMessage(payload))
+			}
+			return result, nil
+		},
+		GetFunc: func(_ context.Context, _ string, input resource.GetInput) (*resource.GetResult, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			f.gets++
+			payload, ok := f.payloads[input.Namespace+"/"+input.ID]
+			if !ok {
+				return nil, errors.New("boom")
+			}
+			return &resource.GetResult{Success: true, Result: json.RawMessage(payload)}, nil
+		},
+	})
+	return f
+}
+
+// put registers a pod in the registry and sets its payload to its metadata
+// and spec.
+func (f *snapshotFixture) put(namespace, id, uid, spec string) {
+	f.ctrl.registryStore.Put(registry.ResourceEntry{
+		PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod",
+		Namespace: namespace, ID: id, UID: uid,
+	})
+	f.mu.Lock()
+	f.payloads[namespace+"/"+id] = podPayload(namespace, id, spec)
+	f.mu.Unlock()
+}
+
+func podPayload(namespace, id, spec string) string {
+	return `{"metadata":{"name":"` + id + `","namespace":"` + namespace + `"},"spec":` + spec + `}`
+}
+
+func (f *snapshotFixture) remove(namespace, id string) {
+	f.ctrl.registryStore.Delete("plugin-a", "conn-1", "core::v1::Pod", namespace, id)
+}
+
+func TestSnapshotService_CreateAndGet(t *testing.T) {
+	f := newSnapshotFixture(t)
+	f.put("default", "web", "u1", `{"image":"v1"}`)
+	f.put("default", "api", "u2", `{"image":"v1"}`)
+
+	info, err := f.svc.CreateSnapshot("plugin-a", "conn-1", " pre-deploy ", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "pre-deploy", info.Name)
+	assert.Equal(t, 2, info.Count)
+	assert.Equal(t, []string{"core::v1::Pod", "core::v1::Service"}, info.ResourceKeys)
+
+	snap, err := f.svc.GetSnapshot("plugin-a", "conn-1", "pre-deploy")
+	require.NoError(t, err)
+	require.Len(t, snap.Resources, 2)
+	assert.Equal(t, "api", snap.Resources[0].ID)
+	assert.JSONEq(t, podPayload("default", "api", `{"image":"v1"}`), string(snap.Resources[0].Data))
+
+	infos, err := f.svc.ListSnapshots("plugin-a", "conn-1")
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	assert.Equal(t, 1, f.lists, "one List per type with known resources")
+	assert.Zero(t, f.gets, "listed resources are not fetched one by one")
+}
+
+func TestSnapshotService_FetchErrorIsRecorded(t *testing.T) {
+	f := newSnapshotFixture(t)
+	f.put("default", "web", "u1", `{}`)
+	f.ctrl.registryStore.Put(registry.ResourceEntry{
+		PluginID: "plugin-a", ConnectionID: "conn-1", ResourceKey: "core::v1::Pod", ID: "orphan",
+	})
+
+	_, err := f.svc.CreateSnapshot("plugin-a", "conn-1", "s", []string{"core::v1::Pod"})
+	require.NoError(t, err)
+	snap, err := f.svc.GetSnapshot("plugin-a", "conn-1", "s")
+	require.NoError(t, err)
+	require.Len(t, snap.Resources, 2)
+	assert.Equal(t, "orphan", snap.Resources[0].ID)
+	assert.NotEmpty(t, snap.Resources[0].Error)
+	assert.Empty(t, snap.Resources[0].Data)
+}
+
+func TestSnapshotService_CompareSnapshots(t *testing.T) {
+	f := newSnapshotFixture(t)
+	f.put("default", "web", "u1", `{"image":"v1"}`)
+	f.put("default", "old", "u2", `{}`)
+	_, err := f.svc.CreateSnapshot("plugin-a", "conn-1", "before", nil)
+	require.NoError(t, err)
+
+	f.put("default", "web", "u1", `{"image":"v2"}`)
+	f.remove("default", "old")
+	f.put("default", "new", "u3", `{}`)
+	_, err = f.svc.CreateSnapshot("plugin-a", "conn-1", "after", nil)
+	require.NoError(t, err)
+
+	c, err := f.svc.CompareSnapshots("plugin-a", "conn-1", "before", "after")
+	require.NoError(t, err)
+	require.Len(t, c.Groups, 1)
+	assert.Equal(t, []snapshot.Ref{{Namespace: "default", ID: "new"}}, c.Groups[0].Added)
+	assert.Equal(t, []snapshot.Ref{{Namespace: "default", ID: "old"}}, c.Groups[0].Removed)
+	require.Len(t, c.Groups[0].Changed, 1)
+	assert.Equal(t, "/spec/image", c.Groups[0].Changed[0].Changes[0].Path)
+}
+
+func TestSnapshotService_CompareWithLive(t *testing.T) {
+	f := newSnapshotFixture(t)
+	f.put("default", "web", "u1", `{"image":"v1"}`)
+	_, err := f.svc.CreateSnapshot("plugin-a", "conn-1", "before", []string{"core::v1::Pod"})
+	require.NoError(t, err)
+
+	f.put("default", "web", "u9", `{"image":"v1"}`)
+
+	c, err := f.svc.CompareSnapshotWithLive("plugin-a", "conn-1", "before")
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.LiveName, c.To)
+	require.Len(t, c.Groups, 1)
+	require.Len(t, c.Groups[0].Changed, 1)
+	assert.True(t, c.Groups[0].Changed[0].Recreated)
+}
+
+func TestSnapshotService_Errors(t *testing.T) {
+	f := newSnapshotFixture(t)
+	f.put("default", "web", "u1", `{}`)
+
+	requireType := func(err error, want string) {
+		t.Helper()
+		var appErr *apperror.AppError
+		require.True(t, errors.As(err, &appErr), "expected AppError, got %v", err)
+		assert.Equal(t, want, appErr.Type)
+	}
+
+	_, err := f.svc.CreateSnapshot("plugin-a", "conn-1", "  ", nil)
+	requireType(err, apperror.TypeValidation)
+
+	_, err = f.svc.CreateSnapshot("plugin-a", "conn-1", "dup", nil)
+	require.NoError(t, err)
+	_, err = f.svc.CreateSnapshot("plugin-a", "conn-1", "dup", nil)
+	requireType(err, apperror.TypeResourceConflict)
+
+	_, err = f.svc.GetSnapshot("plugin-a", "conn-1", "missing")
+	requireType(err, apperror.TypeResourceNotFound)
+	_, err = f.svc.CompareSnapshots("plugin-a", "conn-1", "dup", "missing")
+	requireType(err, apperror.TypeResourceNotFound)
+	requireType(f.svc.DeleteSnapshot("plugin-a", "conn-1", "missing"), apperror.TypeResourceNotFound)
+
+	_, err = f.svc.CreateSnapshot("plugin-missing", "conn-1", "s", nil)
+	requireType(err, apperror.TypePluginNotFound)
+
+	require.NoError(t, f.svc.DeleteSnapshot("plugin-a", "conn-1", "dup"))
+}
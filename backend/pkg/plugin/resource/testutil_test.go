@@ -18,6 +18,7 @@ import (
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/graph"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/indexer"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource/search"
 	"github.com/omniviewdev/omniview/internal/appstate"
 )
 
@@ -585,7 +586,8 @@ func newTestControllerWithEmitter(t *testing.T) (*controller, *recordingEmitter)
 	store := registry.NewMemoryStore()
 	g := graph.NewRelationshipGraph()
 	graphIndexer := graph.NewGraphIndexer(g, store)
-	disp := indexer.NewDispatcher([]indexer.ResourceIndexer{graphIndexer})
+	searchIndex := search.NewIndexer()
+	disp := indexer.NewDispatcher([]indexer.ResourceIndexer{graphIndexer, searchIndex})
 	disp.Start()
 	t.Cleanup(disp.Stop)
 
@@ -596,10 +598,13 @@ func newTestControllerWithEmitter(t *testing.T) (*controller, *recordingEmitter)
 		connections:         make(map[string][]types.Connection),
 		autoConnectAttempts: make(map[string]string),
 		subs:                newSubscriptionManager(),
+		bulk:                newBulkManager(),
+		operations:          newOpTable[*actionOperation](maxRetainedOperations),
 		emitter:             emitter,
 		registryStore:       store,
 		dispatcher:          disp,
 		graph:               g,
+		searchIndex:         searchIndex,
 		pluginStoreFn: svc.PluginStore,
 	}
 	return ctrl, emitter
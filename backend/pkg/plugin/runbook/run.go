@@ -0,0 +1,204 @@
+package runbook
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// RunStatus is the state of a runbook run.
+type RunStatus string
+
+const (
+	RunRunning RunStatus = "running"
+	// RunPaused is a run stopped at a failed step, waiting for a Decision.
+	RunPaused    RunStatus = "paused"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunCancelled RunStatus = "cancelled"
+)
+
+// StepStatus is the state of a step in a run.
+type StepStatus string
+
+const (
+	StepPending   StepStatus = "pending"
+	StepRunning   StepStatus = "running"
+	StepSucceeded StepStatus = "succeeded"
+	StepFailed    StepStatus = "failed"
+	StepSkipped   StepStatus = "skipped"
+	StepCancelled StepStatus = "cancelled"
+)
+
+// Decision resumes a paused run.
+type Decision string
+
+const (
+	// DecisionRetry runs the failed step again.
+	DecisionRetry Decision = "retry"
+	// DecisionSkip marks the failed step skipped and goes on with the next.
+	DecisionSkip Decision = "skip"
+	// DecisionAbort ends the run as failed.
+	DecisionAbort Decision = "abort"
+)
+
+const (
+	// maxStepOutput is the number of bytes of output kept per step; earlier
+	// output is dropped.
+	maxStepOutput = 256 * 1024
+	// maxLogEntries bounds a run's log.
+	maxLogEntries = 1000
+)
+
+// StepResult is the outcome of a step in a run.
+type StepResult struct {
+	Index    int        `json:"index"`
+	Name     string     `json:"name"`
+	Type     StepType   `json:"type"`
+	Status   StepStatus `json:"status"`
+	Attempts int        `json:"attempts"`
+	// Output is what the step produced: a shell step's terminal output with
+	// escape sequences removed, an action's message and data, or the last
+	// health a wait observed.
+	Output string `json:"output,omitempty"`
+	// Truncated reports that the start of Output was dropped.
+	Truncated bool `json:"truncated,omitempty"`
+	// ExitCode is set for shell steps that ran to completion.
+	ExitCode *int `json:"exitCode,omitempty"`
+	// SessionID is the terminal session of a shell step, which can be
+	// attached to while the step runs.
+	SessionID string    `json:"sessionID,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"startedAt,omitzero"`
+	EndedAt   time.Time `json:"endedAt,omitzero"`
+}
+
+// LogEntry is a line of a run's log.
+type LogEntry struct {
+	Time time.Time `json:"time"`
+	// Step is the index of the step the entry is about, -1 for the run.
+	Step    int    `json:"step"`
+	Message string `json:"message"`
+}
+
+// RunInfo describes a run without its step output and log.
+type RunInfo struct {
+	ID           string    `json:"id"`
+	RunbookID    string    `json:"runbookID"`
+	RunbookName  string    `json:"runbookName"`
+	PluginID     string    `json:"pluginID"`
+	ConnectionID string    `json:"connectionID"`
+	Status       RunStatus `json:"status"`
+	StartedAt    time.Time `json:"startedAt"`
+	EndedAt      time.Time `json:"endedAt,omitzero"`
+}
+
+// Run is a run of a runbook against a connection.
+type Run struct {
+	RunInfo
+	Params map[string]string `json:"params"`
+	Steps  []StepResult      `json:"steps"`
+	Log    []LogEntry        `json:"log"`
+	// LogTruncated reports that the oldest log entries were dropped.
+	LogTruncated bool `json:"logTruncated,omitempty"`
+}
+
+// clone returns a deep copy of the run.
+func (r *Run) clone() *Run {
+	out := *r
+	out.Params = maps.Clone(r.Params)
+	out.Steps = append([]StepResult(nil), r.Steps...)
+	for i, step := range out.Steps {
+		if step.ExitCode != nil {
+			code := *step.ExitCode
+			out.Steps[i].ExitCode = &code
+		}
+	}
+	out.Log = append([]LogEntry(nil), r.Log...)
+	return &out
+}
+
+// activeRun is a run in progress. The run is only changed through update,
+// which publishes every change.
+type activeRun struct {
+	mu        sync.Mutex
+	run       *Run
+	cancel    context.CancelFunc
+	decisions chan Decision
+	// done is closed once the finished run has been recorded.
+	done chan struct{}
+	// publish is called with a copy of the run after every change.
+	publish func(*Run)
+}
+
+func newActiveRun(run *Run, cancel context.CancelFunc, publish func(*Run)) *activeRun {
+	return &activeRun{
+		run:       run,
+		cancel:    cancel,
+		decisions: make(chan Decision, 1),
+		done:      make(chan struct{}),
+		publish:   publish,
+	}
+}
+
+// snapshot returns a copy of the run.
+func (a *activeRun) snapshot() *Run {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.run.clone()
+}
+
+// update applies fn to the run and publishes the result.
+func (a *activeRun) update(fn func(*Run)) {
+	a.mu.Lock()
+	fn(a.run)
+	snap := a.run.clone()
+	a.mu.Unlock()
+	if a.publish != nil {
+		a.publish(snap)
+	}
+}
+
+// resume hands a decision to the run if it is paused, and reports whether
+// it was.
+func (a *activeRun) resume(decision Decision) bool {
+	a.mu.Lock()
+	if a.run.Status != RunPaused {
+		a.mu.Unlock()
+		return false
+	}
+	// Marking the run as running before handing over the decision keeps a
+	// second call from queueing another one.
+	a.run.Status = RunRunning
+	snap := a.run.clone()
+	a.mu.Unlock()
+	a.decisions <- decision
+	if a.publish != nil {
+		a.publish(snap)
+	}
+	return true
+}
+
+// logf appends a log entry about step (-1 for the run) and publishes it.
+func (a *activeRun) logf(step int, format string, args ...any) {
+	a.update(func(r *Run) { appendLog(r, step, fmt.Sprintf(format, args...)) })
+}
+
+func appendLog(r *Run, step int, message string) {
+	r.Log = append(r.Log, LogEntry{Time: time.Now(), Step: step, Message: message})
+	if over := len(r.Log) - maxLogEntries; over > 0 {
+		r.Log = append(r.Log[:0:0], r.Log[over:]...)
+		r.LogTruncated = true
+	}
+}
+
+// tail returns the last maxStepOutput bytes of output and whether anything
+// was dropped.
+func tail(output string) (string, bool) {
+	if len(output) <= maxStepOutput {
+		return output, false
+	}
+	return output[len(output)-maxStepOutput:], true
+}
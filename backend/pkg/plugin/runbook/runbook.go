@@ -0,0 +1,257 @@
+// Package runbook implements runbooks: named, parameterized sequences of
+// steps run against a plugin connection. A step runs a shell command in a
+// terminal opened in the connection, executes a resource action, or waits
+// for a resource to reach a health status. Runbooks are persisted in the
+// plugin's data store together with the log of their recent runs.
+package runbook
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+)
+
+// StepType is the kind of a runbook step.
+type StepType string
+
+const (
+	// StepShell runs Command with /bin/sh in a terminal opened in the
+	// connection. The step fails if the command exits with a non-zero code.
+	StepShell StepType = "shell"
+	// StepAction executes the resource action ActionID on a resource.
+	StepAction StepType = "action"
+	// StepWaitHealth polls a resource until its health reaches Health.
+	StepWaitHealth StepType = "waitHealth"
+)
+
+// Default step timeouts, used when Step.TimeoutSeconds is zero. Action steps
+// are bounded by the plugin.
+const (
+	DefaultShellTimeout  = 10 * time.Minute
+	DefaultHealthTimeout = 5 * time.Minute
+)
+
+const (
+	maxNameLength = 128
+	maxSteps      = 100
+)
+
+// paramNamePattern restricts parameter names to shell variable names, since
+// shell steps receive parameters as environment variables.
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// placeholderPattern matches a {{name}} parameter reference.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Param is a runbook parameter. Steps reference it as {{name}} in their
+// command, resource ID, namespace and string action parameters; shell steps
+// also get it as the environment variable name. In a shell command the
+// reference expands to the value quoted as a single shell word.
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	// Required parameters must have a non-empty value when a run starts.
+	Required bool `json:"required,omitempty"`
+}
+
+// Step is a single step of a runbook. Which fields apply depends on Type.
+type Step struct {
+	Name string   `json:"name"`
+	Type StepType `json:"type"`
+	// Command is the command line of a shell step, run by /bin/sh. Runbooks
+	// with shell steps cannot be started on Windows.
+	Command string `json:"command,omitempty"`
+	// ResourceKey, ResourceID and Namespace identify the resource of an
+	// action or waitHealth step.
+	ResourceKey string `json:"resourceKey,omitempty"`
+	ResourceID  string `json:"resourceID,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	// ActionID and ActionParams are the action an action step executes.
+	ActionID     string         `json:"actionID,omitempty"`
+	ActionParams map[string]any `json:"actionParams,omitempty"`
+	// Health is the status a waitHealth step waits for, healthy if empty.
+	Health resource.HealthStatus `json:"health,omitempty"`
+	// TimeoutSeconds bounds a shell or waitHealth step; zero uses the
+	// default for the step type.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// ContinueOnError lets a run go on past a failure of the step instead
+	// of pausing for a decision.
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+}
+
+// timeout returns the step's timeout, zero for none.
+func (s Step) timeout() time.Duration {
+	if s.TimeoutSeconds > 0 {
+		return time.Duration(s.TimeoutSeconds) * time.Second
+	}
+	switch s.Type {
+	case StepShell:
+		return DefaultShellTimeout
+	case StepWaitHealth:
+		return DefaultHealthTimeout
+	}
+	return 0
+}
+
+// Runbook is a saved sequence of steps for a plugin's connections.
+type Runbook struct {
+	ID          string    `json:"id"`
+	PluginID    string    `json:"pluginID"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Params      []Param   `json:"params"`
+	Steps       []Step    `json:"steps"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Validate checks that the runbook is well formed: it is named, its
+// parameters are unique shell variable names, every step is complete for
+// its type and references only declared parameters.
+func (rb *Runbook) Validate() error {
+	name := strings.TrimSpace(rb.Name)
+	if name == "" || len(name) > maxNameLength {
+		return fmt.Errorf("runbook names must be between 1 and %d characters", maxNameLength)
+	}
+	if len(rb.Steps) == 0 || len(rb.Steps) > maxSteps {
+		return fmt.Errorf("runbooks must have between 1 and %d steps", maxSteps)
+	}
+
+	declared := make(map[string]bool, len(rb.Params))
+	for _, p := range rb.Params {
+		if !paramNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("invalid parameter name %q: use letters, digits and underscores", p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("parameter %q is declared twice", p.Name)
+		}
+		declared[p.Name] = true
+	}
+
+	var errs []error
+	for i, step := range rb.Steps {
+		if err := step.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("step %d: %w", i+1, err))
+			continue
+		}
+		for _, ref := range step.references() {
+			if !declared[ref] {
+				errs = append(errs, fmt.Errorf("step %d: parameter %q is not declared", i+1, ref))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s Step) validate() error {
+	if s.TimeoutSeconds < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	switch s.Type {
+	case StepShell:
+		if strings.TrimSpace(s.Command) == "" {
+			return errors.New("shell steps need a command")
+		}
+	case StepAction:
+		if s.ResourceKey == "" || s.ActionID == "" {
+			return errors.New("action steps need a resource key and an action")
+		}
+	case StepWaitHealth:
+		if s.ResourceKey == "" || s.ResourceID == "" {
+			return errors.New("waitHealth steps need a resource key and a resource ID")
+		}
+		switch s.Health {
+		case "", resource.HealthHealthy, resource.HealthDegraded, resource.HealthUnhealthy,
+			resource.HealthPending, resource.HealthUnknown:
+		default:
+			return fmt.Errorf("unknown health status %q", s.Health)
+		}
+	default:
+		return fmt.Errorf("unknown step type %q", s.Type)
+	}
+	return nil
+}
+
+// references returns the parameters the step refers to.
+func (s Step) references() []string {
+	var refs []string
+	collect := func(text string) {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+			refs = append(refs, m[1])
+		}
+	}
+	collect(s.Command)
+	collect(s.ResourceID)
+	collect(s.Namespace)
+	for _, v := range s.ActionParams {
+		if text, ok := v.(string); ok {
+			collect(text)
+		}
+	}
+	return refs
+}
+
+// ResolveParams returns the parameter values for a run: the given values,
+// defaults for the others, and an error naming any required parameter left
+// empty or any value for an undeclared parameter.
+func (rb *Runbook) ResolveParams(values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(rb.Params))
+	var missing []string
+	for _, p := range rb.Params {
+		v, ok := values[p.Name]
+		if !ok {
+			v = p.Default
+		}
+		if p.Required && v == "" {
+			missing = append(missing, p.Name)
+		}
+		resolved[p.Name] = v
+	}
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required parameters: %s", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// expand returns a copy of the step with parameter references replaced by
+// their values. References in the command are replaced by the quoted value,
+// so a value cannot end the word it is in and run commands of its own.
+func (s Step) expand(params map[string]string) Step {
+	substitute := func(text string, quote func(string) string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(m string) string {
+			return quote(params[placeholderPattern.FindStringSubmatch(m)[1]])
+		})
+	}
+	replace := func(text string) string {
+		return substitute(text, func(v string) string { return v })
+	}
+	s.Command = substitute(s.Command, shellQuote)
+	s.ResourceID = replace(s.ResourceID)
+	s.Namespace = replace(s.Namespace)
+	if s.ActionParams != nil {
+		expanded := make(map[string]any, len(s.ActionParams))
+		for k, v := range s.ActionParams {
+			if text, ok := v.(string); ok {
+				v = replace(text)
+			}
+			expanded[k] = v
+		}
+		s.ActionParams = expanded
+	}
+	return s
+}
+
+// shellQuote quotes value as a single POSIX shell word.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,118 @@
+package runbook
+
+import (
+	osexec "os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validRunbook() Runbook {
+	return Runbook{
+		PluginID: "kubernetes",
+		Name:     "Restart web",
+		Params: []Param{
+			{Name: "namespace", Default: "default"},
+			{Name: "deployment", Required: true},
+		},
+		Steps: []Step{
+			{Name: "status", Type: StepShell, Command: "kubectl -n {{namespace}} get deploy {{ deployment }}"},
+			{Name: "restart", Type: StepAction, ResourceKey: "apps::v1::Deployment", ResourceID: "{{deployment}}",
+				Namespace: "{{namespace}}", ActionID: "restart", ActionParams: map[string]any{"reason": "runbook {{deployment}}", "replicas": 2.0}},
+			{Name: "wait", Type: StepWaitHealth, ResourceKey: "apps::v1::Deployment", ResourceID: "{{deployment}}", Namespace: "{{namespace}}"},
+		},
+	}
+}
+
+func TestRunbook_Validate(t *testing.T) {
+	rb := validRunbook()
+	require.NoError(t, rb.Validate())
+
+	tests := []struct {
+		name   string
+		modify func(*Runbook)
+		want   string
+	}{
+		{"no name", func(rb *Runbook) { rb.Name = " " }, "names must be"},
+		{"no steps", func(rb *Runbook) { rb.Steps = nil }, "steps"},
+		{"bad param name", func(rb *Runbook) { rb.Params[0].Name = "name-space" }, "invalid parameter name"},
+		{"duplicate param", func(rb *Runbook) { rb.Params[1].Name = "namespace" }, "declared twice"},
+		{"undeclared reference", func(rb *Runbook) { rb.Steps[0].Command = "echo {{other}}" }, `step 1: parameter "other" is not declared`},
+		{"empty command", func(rb *Runbook) { rb.Steps[0].Command = "" }, "step 1: shell steps need a command"},
+		{"action without action", func(rb *Runbook) { rb.Steps[1].ActionID = "" }, "step 2: action steps need"},
+		{"wait without resource", func(rb *Runbook) { rb.Steps[2].ResourceID = "" }, "step 3: waitHealth steps need"},
+		{"unknown health", func(rb *Runbook) { rb.Steps[2].Health = "green" }, `unknown health status "green"`},
+		{"unknown type", func(rb *Runbook) { rb.Steps[0].Type = "script" }, `unknown step type "script"`},
+		{"negative timeout", func(rb *Runbook) { rb.Steps[0].TimeoutSeconds = -1 }, "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rb := validRunbook()
+			rb.Params = append([]Param(nil), rb.Params...)
+			rb.Steps = append([]Step(nil), rb.Steps...)
+			tt.modify(&rb)
+			err := rb.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestRunbook_ResolveParams(t *testing.T) {
+	rb := validRunbook()
+
+	values, err := rb.ResolveParams(map[string]string{"deployment": "web"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"namespace": "default", "deployment": "web"}, values)
+
+	values, err = rb.ResolveParams(map[string]string{"deployment": "web", "namespace": ""})
+	require.NoError(t, err)
+	assert.Empty(t, values["namespace"], "an explicit empty value overrides the default")
+
+	_, err = rb.ResolveParams(nil)
+	assert.ErrorContains(t, err, "missing required parameters: deployment")
+
+	_, err = rb.ResolveParams(map[string]string{"deployment": "web", "replicas": "3"})
+	assert.ErrorContains(t, err, `unknown parameter "replicas"`)
+}
+
+func TestStep_Expand(t *testing.T) {
+	rb := validRunbook()
+	params := map[string]string{"namespace": "prod", "deployment": "web"}
+
+	shell := rb.Steps[0].expand(params)
+	assert.Equal(t, "kubectl -n 'prod' get deploy 'web'", shell.Command)
+
+	action := rb.Steps[1].expand(params)
+	assert.Equal(t, "web", action.ResourceID)
+	assert.Equal(t, "prod", action.Namespace)
+	assert.Equal(t, map[string]any{"reason": "runbook web", "replicas": 2.0}, action.ActionParams)
+	assert.Equal(t, "runbook {{deployment}}", rb.Steps[1].ActionParams["reason"], "the runbook is not modified")
+}
+
+func TestStep_ExpandQuotesShellValues(t *testing.T) {
+	step := Step{Type: StepShell, Command: "echo {{msg}}", ResourceID: "{{msg}}"}
+	value := `x; rm -rf ~ 'quoted' $(id)`
+
+	expanded := step.expand(map[string]string{"msg": value})
+	assert.Equal(t, `echo 'x; rm -rf ~ '\''quoted'\'' $(id)'`, expanded.Command)
+	assert.Equal(t, value, expanded.ResourceID, "only the command is quoted")
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	out, err := osexec.Command("/bin/sh", "-c", expanded.Command).Output()
+	require.NoError(t, err)
+	assert.Equal(t, value+"\n", string(out), "the value reaches the command as one argument")
+}
+
+func TestStep_Timeout(t *testing.T) {
+	assert.Equal(t, DefaultShellTimeout, Step{Type: StepShell}.timeout())
+	assert.Equal(t, DefaultHealthTimeout, Step{Type: StepWaitHealth, Health: resource.HealthHealthy}.timeout())
+	assert.Zero(t, Step{Type: StepAction}.timeout())
+	assert.Equal(t, 30*time.Second, Step{Type: StepShell, TimeoutSeconds: 30}.timeout())
+}
@@ -0,0 +1,599 @@
+package runbook
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v3/pkg/application"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/exec"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+// RunLabel labels the terminal session of a shell step with the ID of its
+// run.
+const RunLabel = "omniview.dev/runbook-run"
+
+// RunEventPrefix is the prefix of the event, followed by the run ID,
+// carrying the run after every change.
+const RunEventPrefix = "core/runbook/run/"
+
+// shutdownTimeout bounds the wait for cancelled runs to record their logs
+// when the application quits.
+const shutdownTimeout = 5 * time.Second
+
+// healthPollInterval is how often a waitHealth step checks the resource.
+//
+//nolint:gochecknoglobals // lowered in tests
+var healthPollInterval = 2 * time.Second
+
+// ShellRunner runs the commands of shell steps. It is satisfied by
+// exec.Controller.
+type ShellRunner interface {
+	RunCommand(ctx context.Context, plugin, connectionID string, opts sdkexec.SessionOptions) (*exec.CommandRun, error)
+}
+
+// ResourceClient runs action steps and reads the health waitHealth steps
+// wait for. It is satisfied by the resource controller.
+type ResourceClient interface {
+	Get(pluginID, connectionID, key string, input resource.GetInput) (*resource.GetResult, error)
+	GetHealth(pluginID, connectionID, key string, data json.RawMessage) (*resource.ResourceHealth, error)
+	ExecuteAction(pluginID, connectionID, key, actionID string, input resource.ActionInput) (*resource.ActionResult, error)
+}
+
+// Service saves runbooks and runs them against plugin connections. Exposed
+// to the frontend via Wails binding.
+//
+// A run executes its steps in order. When a step fails the run pauses until
+// it is resumed with a Decision, unless the step is marked ContinueOnError.
+// Every change to a run is emitted as a RunEventPrefix event, and finished
+// runs are kept in the store.
+type Service struct {
+	app       *application.App
+	logger    logging.Logger
+	store     *Store
+	shell     ShellRunner
+	resources ResourceClient
+
+	mu   sync.Mutex
+	runs map[string]*activeRun // by run ID
+	wg   sync.WaitGroup
+}
+
+// NewService creates a new Service.
+func NewService(logger logging.Logger, store *Store, shell ShellRunner, resources ResourceClient) *Service {
+	return &Service{
+		logger:    logger.Named("RunbookService"),
+		store:     store,
+		shell:     shell,
+		resources: resources,
+		runs:      make(map[string]*activeRun),
+	}
+}
+
+func (s *Service) ServiceStartup(_ context.Context, _ application.ServiceOptions) error {
+	s.app = application.Get()
+	return nil
+}
+
+// ServiceShutdown cancels the runs in progress and waits briefly for them to
+// record their logs.
+func (s *Service) ServiceShutdown() error {
+	s.mu.Lock()
+	for _, run := range s.runs {
+		run.cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+	}
+	return nil
+}
+
+// ListRunbooks returns a plugin's runbooks in the order they were created.
+func (s *Service) ListRunbooks(pluginID string) ([]Runbook, error) {
+	runbooks, err := s.store.List(pluginID)
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to list runbooks")
+	}
+	return runbooks, nil
+}
+
+// GetRunbook returns a runbook.
+func (s *Service) GetRunbook(pluginID, runbookID string) (*Runbook, error) {
+	rb, err := s.store.Load(pluginID, runbookID)
+	if err != nil {
+		return nil, runbookError(err, runbookID)
+	}
+	return rb, nil
+}
+
+// SaveRunbook creates a runbook, when its ID is empty, or replaces the
+// runbook with its ID. It returns the runbook as saved.
+func (s *Service) SaveRunbook(rb Runbook) (*Runbook, error) {
+	if rb.PluginID == "" {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid runbook", "A plugin is required.")
+	}
+	rb.Name = strings.TrimSpace(rb.Name)
+	if err := rb.Validate(); err != nil {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid runbook", err.Error())
+	}
+
+	now := time.Now()
+	if rb.ID == "" {
+		rb.ID = uuid.NewString()
+		rb.CreatedAt = now
+	} else {
+		existing, err := s.store.Load(rb.PluginID, rb.ID)
+		if err != nil {
+			return nil, runbookError(err, rb.ID)
+		}
+		rb.CreatedAt = existing.CreatedAt
+	}
+	rb.UpdatedAt = now
+	if rb.Params == nil {
+		rb.Params = []Param{}
+	}
+	if err := s.store.Save(&rb); err != nil {
+		return nil, runbookError(err, rb.ID)
+	}
+	return &rb, nil
+}
+
+// DeleteRunbook removes a runbook. The logs of its past runs are kept.
+func (s *Service) DeleteRunbook(pluginID, runbookID string) error {
+	if err := s.store.Delete(pluginID, runbookID); err != nil {
+		return runbookError(err, runbookID)
+	}
+	return nil
+}
+
+// StartRun starts running a runbook against a connection with the given
+// parameter values; parameters left out take their defaults. It returns the
+// run as started. Progress is reported through RunEventPrefix events and
+// GetRun.
+func (s *Service) StartRun(pluginID, runbookID, connectionID string, params map[string]string) (*Run, error) {
+	rb, err := s.store.Load(pluginID, runbookID)
+	if err != nil {
+		return nil, runbookError(err, runbookID)
+	}
+	if connectionID == "" {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid run", "A connection is required.")
+	}
+	values, err := rb.ResolveParams(params)
+	if err != nil {
+		return nil, apperror.New(apperror.TypeValidation, 400, "Invalid runbook parameters", err.Error())
+	}
+	for _, step := range rb.Steps {
+		if step.Type != StepShell {
+			continue
+		}
+		if _, err := shellCommand(step.Command); err != nil {
+			return nil, apperror.New(apperror.TypeValidation, 400, "Unsupported runbook", err.Error())
+		}
+	}
+
+	run := &Run{
+		RunInfo: RunInfo{
+			ID:           uuid.NewString(),
+			RunbookID:    rb.ID,
+			RunbookName:  rb.Name,
+			PluginID:     pluginID,
+			ConnectionID: connectionID,
+			Status:       RunRunning,
+			StartedAt:    time.Now(),
+		},
+		Params: values,
+		Steps:  make([]StepResult, len(rb.Steps)),
+		Log:    []LogEntry{},
+	}
+	for i, step := range rb.Steps {
+		run.Steps[i] = StepResult{Index: i, Name: step.Name, Type: step.Type, Status: StepPending}
+	}
+	appendLog(run, -1, fmt.Sprintf("Started %q on connection %s", rb.Name, connectionID))
+
+	// Runs outlive the call that started them; ServiceShutdown cancels them.
+	ctx, cancel := context.WithCancel(context.Background())
+	active := newActiveRun(run, cancel, s.emit)
+	s.mu.Lock()
+	s.runs[run.ID] = active
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	snap := active.snapshot()
+	go s.execute(ctx, active, rb.Steps, values)
+	return snap, nil
+}
+
+// GetRun returns a run in progress or a stored run.
+func (s *Service) GetRun(pluginID, runID string) (*Run, error) {
+	if active := s.activeRun(runID); active != nil {
+		return active.snapshot(), nil
+	}
+	run, err := s.store.LoadRun(pluginID, runID)
+	if err != nil {
+		return nil, runError(err, runID)
+	}
+	return run, nil
+}
+
+// ListRuns returns a plugin's runs, those in progress and those stored,
+// newest first. A non-empty runbookID limits them to that runbook's runs.
+func (s *Service) ListRuns(pluginID, runbookID string) ([]RunInfo, error) {
+	stored, err := s.store.ListRuns(pluginID)
+	if err != nil {
+		return nil, apperror.Internal(err, "Failed to list runbook runs")
+	}
+	infos := []RunInfo{}
+	seen := make(map[string]bool)
+	s.mu.Lock()
+	for _, active := range s.runs {
+		run := active.snapshot()
+		if run.PluginID == pluginID && (runbookID == "" || run.RunbookID == runbookID) {
+			infos = append(infos, run.RunInfo)
+			seen[run.ID] = true
+		}
+	}
+	s.mu.Unlock()
+	for _, info := range stored {
+		if !seen[info.ID] && (runbookID == "" || info.RunbookID == runbookID) {
+			infos = append(infos, info)
+		}
+	}
+	slices.SortFunc(infos, func(a, b RunInfo) int { return b.StartedAt.Compare(a.StartedAt) })
+	return infos, nil
+}
+
+// ResumeRun continues a run paused at a failed step: DecisionRetry runs the
+// step again, DecisionSkip goes on with the next step and DecisionAbort ends
+// the run as failed.
+func (s *Service) ResumeRun(runID string, decision Decision) error {
+	switch decision {
+	case DecisionRetry, DecisionSkip, DecisionAbort:
+	default:
+		return apperror.New(apperror.TypeValidation, 400, "Invalid decision",
+			fmt.Sprintf("Unknown decision %q: use retry, skip or abort.", decision))
+	}
+	active := s.activeRun(runID)
+	if active == nil {
+		return runError(ErrRunNotFound, runID)
+	}
+	if !active.resume(decision) {
+		return apperror.New(apperror.TypeResourceConflict, 409, "Run is not paused",
+			"Only a run paused at a failed step can be resumed.")
+	}
+	return nil
+}
+
+// CancelRun stops a run in progress. A running shell step's terminal is
+// closed; an action already sent to the plugin completes.
+func (s *Service) CancelRun(runID string) error {
+	active := s.activeRun(runID)
+	if active == nil {
+		return runError(ErrRunNotFound, runID)
+	}
+	active.cancel()
+	return nil
+}
+
+func (s *Service) activeRun(runID string) *activeRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runs[runID]
+}
+
+// emit publishes a run to the frontend.
+func (s *Service) emit(run *Run) {
+	if s.app != nil {
+		s.app.Event.Emit(RunEventPrefix+run.ID, run)
+	}
+}
+
+// execute runs the steps of a run, then records the run and removes it from
+// the runs in progress.
+func (s *Service) execute(ctx context.Context, a *activeRun, steps []Step, params map[string]string) {
+	defer s.wg.Done()
+	defer a.cancel()
+
+	status := s.runSteps(ctx, a, steps, params)
+	a.update(func(r *Run) {
+		r.Status = status
+		r.EndedAt = time.Now()
+		appendLog(r, -1, "Run "+string(status))
+	})
+	run := a.snapshot()
+	if err := s.store.SaveRun(run); err != nil {
+		s.logger.Errorw(context.Background(), "failed to save runbook run", "run", run.ID, "error", err)
+	}
+	s.mu.Lock()
+	delete(s.runs, run.ID)
+	s.mu.Unlock()
+	close(a.done)
+}
+
+// runSteps runs the steps in order, pausing at failures, and returns the
+// final status of the run.
+func (s *Service) runSteps(ctx context.Context, a *activeRun, steps []Step, params map[string]string) RunStatus {
+	for i := 0; i < len(steps); {
+		step := steps[i].expand(params)
+		a.update(func(r *Run) {
+			res := &r.Steps[i]
+			*res = StepResult{
+				Index:     i,
+				Name:      res.Name,
+				Type:      res.Type,
+				Status:    StepRunning,
+				Attempts:  res.Attempts + 1,
+				StartedAt: time.Now(),
+			}
+			appendLog(r, i, "Started "+describeStep(step))
+		})
+
+		err := s.runStep(ctx, a, i, step)
+		if ctx.Err() != nil {
+			a.update(func(r *Run) {
+				r.Steps[i].Status = StepCancelled
+				r.Steps[i].EndedAt = time.Now()
+				appendLog(r, i, "Cancelled")
+			})
+			return RunCancelled
+		}
+		if err == nil {
+			a.update(func(r *Run) {
+				r.Steps[i].Status = StepSucceeded
+				r.Steps[i].EndedAt = time.Now()
+				appendLog(r, i, "Succeeded")
+			})
+			i++
+			continue
+		}
+
+		a.update(func(r *Run) {
+			r.Steps[i].Status = StepFailed
+			r.Steps[i].Error = err.Error()
+			r.Steps[i].EndedAt = time.Now()
+			appendLog(r, i, "Failed: "+err.Error())
+			if !step.ContinueOnError {
+				r.Status = RunPaused
+				appendLog(r, i, "Paused: retry the step, skip it or abort the run")
+			}
+		})
+		if step.ContinueOnError {
+			a.logf(i, "Continuing past the failure")
+			i++
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			a.logf(i, "Cancelled while paused")
+			return RunCancelled
+		case decision := <-a.decisions:
+			switch decision {
+			case DecisionRetry:
+				a.logf(i, "Retrying")
+			case DecisionSkip:
+				a.update(func(r *Run) {
+					r.Steps[i].Status = StepSkipped
+					appendLog(r, i, "Skipped")
+				})
+				i++
+			case DecisionAbort:
+				a.logf(i, "Aborted")
+				return RunFailed
+			}
+		}
+	}
+	return RunSucceeded
+}
+
+func (s *Service) runStep(ctx context.Context, a *activeRun, index int, step Step) error {
+	switch step.Type {
+	case StepShell:
+		return s.runShell(ctx, a, index, step)
+	case StepAction:
+		return s.runAction(a, index, step)
+	case StepWaitHealth:
+		return s.waitHealth(ctx, a, index, step)
+	}
+	return fmt.Errorf("unknown step type %q", step.Type)
+}
+
+// runShell runs a shell step's command in a terminal opened in the run's
+// connection. Parameters are passed as environment variables as well.
+func (s *Service) runShell(ctx context.Context, a *activeRun, index int, step Step) error {
+	run := a.snapshot()
+	env := make(map[string]string, len(run.Params))
+	for name, value := range run.Params {
+		env[terminal.ParamEnvPrefix+name] = value
+	}
+	command, err := shellCommand(step.Command)
+	if err != nil {
+		return err
+	}
+	opts := sdkexec.SessionOptions{
+		ID:      uuid.NewString(),
+		Command: command,
+		Labels:  map[string]string{RunLabel: run.ID},
+		Params:  env,
+	}
+	a.update(func(r *Run) { r.Steps[index].SessionID = opts.ID })
+
+	timeout := step.timeout()
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := s.shell.RunCommand(stepCtx, run.PluginID, run.ConnectionID, opts)
+	if result != nil {
+		a.update(func(r *Run) {
+			res := &r.Steps[index]
+			res.Output, res.Truncated = tail(result.Output)
+			res.Truncated = res.Truncated || result.Truncated
+			if err == nil {
+				code := result.ExitCode
+				res.ExitCode = &code
+			}
+		})
+	}
+	switch {
+	case err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("timed out after %s", timeout)
+	case err != nil:
+		return errors.New(errorDetail(err))
+	case result.ExitCode != 0:
+		return fmt.Errorf("command exited with code %d", result.ExitCode)
+	}
+	return nil
+}
+
+// runAction executes an action step. The action's message and data are the
+// step's output.
+func (s *Service) runAction(a *activeRun, index int, step Step) error {
+	run := a.snapshot()
+	result, err := s.resources.ExecuteAction(run.PluginID, run.ConnectionID, step.ResourceKey, step.ActionID,
+		resource.ActionInput{ID: step.ResourceID, Namespace: step.Namespace, Params: step.ActionParams})
+	if err != nil {
+		return errors.New(errorDetail(err))
+	}
+	output := result.Message
+	if len(result.Data) > 0 {
+		if data, marshalErr := json.MarshalIndent(result.Data, "", "  "); marshalErr == nil {
+			output = strings.TrimSpace(output + "\n" + string(data))
+		}
+	}
+	a.update(func(r *Run) { r.Steps[index].Output, r.Steps[index].Truncated = tail(output) })
+	if !result.Success {
+		return fmt.Errorf("action %s failed: %s", step.ActionID, cmp.Or(result.Message, "no details given"))
+	}
+	return nil
+}
+
+// waitHealth polls a resource's health until it reaches the step's status
+// or the step times out. Errors reading the resource, which may not exist
+// yet, are retried.
+func (s *Service) waitHealth(ctx context.Context, a *activeRun, index int, step Step) error {
+	run := a.snapshot()
+	target := cmp.Or(step.Health, resource.HealthHealthy)
+	timeout := step.timeout()
+	stepCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	last := "no health reported yet"
+	for {
+		health, err := s.health(run.PluginID, run.ConnectionID, step)
+		if err != nil {
+			last = errorDetail(err)
+		} else {
+			last = describeHealth(health)
+			if health.Status == target {
+				a.update(func(r *Run) { r.Steps[index].Output = last })
+				return nil
+			}
+		}
+		a.update(func(r *Run) { r.Steps[index].Output = last })
+
+		select {
+		case <-stepCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("timed out after %s waiting for %s (last: %s)", timeout, target, last)
+		case <-ticker.C:
+		}
+	}
+}
+
+// health reads the current health of a step's resource, unknown if the
+// plugin does not report health for it.
+func (s *Service) health(pluginID, connectionID string, step Step) (*resource.ResourceHealth, error) {
+	got, err := s.resources.Get(pluginID, connectionID, step.ResourceKey,
+		resource.GetInput{ID: step.ResourceID, Namespace: step.Namespace})
+	if err != nil {
+		return nil, err
+	}
+	health, err := s.resources.GetHealth(pluginID, connectionID, step.ResourceKey, got.Result)
+	if err != nil {
+		return nil, err
+	}
+	if health == nil {
+		return &resource.ResourceHealth{Status: resource.HealthUnknown}, nil
+	}
+	return health, nil
+}
+
+func describeHealth(h *resource.ResourceHealth) string {
+	parts := []string{string(h.Status)}
+	if h.Reason != "" {
+		parts = append(parts, h.Reason)
+	}
+	if h.Message != "" {
+		parts = append(parts, h.Message)
+	}
+	return strings.Join(parts, ": ")
+}
+
+func describeStep(step Step) string {
+	name := cmp.Or(step.Name, string(step.Type))
+	switch step.Type {
+	case StepShell:
+		return fmt.Sprintf("%s: %s", name, step.Command)
+	case StepAction:
+		return fmt.Sprintf("%s: %s on %s %s", name, step.ActionID, step.ResourceKey, resourceName(step))
+	case StepWaitHealth:
+		return fmt.Sprintf("%s: wait for %s %s to be %s", name, step.ResourceKey, resourceName(step),
+			cmp.Or(step.Health, resource.HealthHealthy))
+	}
+	return name
+}
+
+func resourceName(step Step) string {
+	if step.Namespace != "" {
+		return step.Namespace + "/" + step.ResourceID
+	}
+	return step.ResourceID
+}
+
+func runbookError(err error, runbookID string) *apperror.AppError {
+	if errors.Is(err, ErrNotFound) {
+		return apperror.NotFound("Runbook not found", fmt.Sprintf("No runbook with ID %q exists.", runbookID))
+	}
+	return apperror.Internal(err, "Runbook storage failed")
+}
+
+func runError(err error, runID string) *apperror.AppError {
+	if errors.Is(err, ErrRunNotFound) {
+		return apperror.NotFound("Run not found", fmt.Sprintf("No runbook run with ID %q exists.", runID))
+	}
+	return apperror.Internal(err, "Runbook storage failed")
+}
+
+// errorDetail returns a human-readable message for err, unwrapping AppErrors
+// whose Error() is a JSON document.
+func errorDetail(err error) string {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return cmp.Or(appErr.Detail, appErr.Title)
+	}
+	return err.Error()
+}
@@ -0,0 +1,331 @@
+package runbook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	resource "github.com/omniviewdev/plugin-sdk/pkg/v1/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/exec"
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+type shellCall struct {
+	plugin, connectionID string
+	opts                 sdkexec.SessionOptions
+}
+
+// fakeShell runs shell steps with run, recording the calls.
+type fakeShell struct {
+	mu    sync.Mutex
+	calls []shellCall
+	run   func(ctx context.Context, call int) (*exec.CommandRun, error)
+}
+
+func (f *fakeShell) RunCommand(ctx context.Context, plugin, connectionID string, opts sdkexec.SessionOptions) (*exec.CommandRun, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, shellCall{plugin, connectionID, opts})
+	n := len(f.calls)
+	f.mu.Unlock()
+	if f.run == nil {
+		return &exec.CommandRun{SessionID: opts.ID, Output: "ok\n"}, nil
+	}
+	return f.run(ctx, n)
+}
+
+func (f *fakeShell) call(i int) shellCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[i]
+}
+
+// fakeResources answers actions with action and reports the health
+// statuses in turn, repeating the last one, or healthy if there are none.
+type fakeResources struct {
+	mu      sync.Mutex
+	actions []resource.ActionInput
+	action  func(call int) (*resource.ActionResult, error)
+	health  []resource.HealthStatus
+	polls   int
+}
+
+func (f *fakeResources) Get(_, _, _ string, input resource.GetInput) (*resource.GetResult, error) {
+	data, _ := json.Marshal(map[string]string{"name": input.ID})
+	return &resource.GetResult{Success: true, Result: data}, nil
+}
+
+func (f *fakeResources) GetHealth(_, _, _ string, _ json.RawMessage) (*resource.ResourceHealth, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.health) == 0 {
+		return &resource.ResourceHealth{Status: resource.HealthHealthy}, nil
+	}
+	status := f.health[min(f.polls, len(f.health)-1)]
+	f.polls++
+	return &resource.ResourceHealth{Status: status, Reason: "Rollout"}, nil
+}
+
+func (f *fakeResources) ExecuteAction(_, _, _, _ string, input resource.ActionInput) (*resource.ActionResult, error) {
+	f.mu.Lock()
+	f.actions = append(f.actions, input)
+	n := len(f.actions)
+	f.mu.Unlock()
+	if f.action == nil {
+		return &resource.ActionResult{Success: true, Message: "restarted"}, nil
+	}
+	return f.action(n)
+}
+
+func newTestService(t *testing.T, shell *fakeShell, resources *fakeResources) *Service {
+	t.Helper()
+	interval := healthPollInterval
+	healthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = interval })
+	s := NewService(logging.NewNop(), NewStore(plugindata.NewMemory()), shell, resources)
+	t.Cleanup(func() { _ = s.ServiceShutdown() })
+	return s
+}
+
+func saveRunbook(t *testing.T, s *Service, rb Runbook) *Runbook {
+	t.Helper()
+	saved, err := s.SaveRunbook(rb)
+	require.NoError(t, err)
+	return saved
+}
+
+// waitStatus waits for a run to reach status and returns it.
+func waitStatus(t *testing.T, s *Service, runID string, status RunStatus) *Run {
+	t.Helper()
+	var run *Run
+	require.Eventually(t, func() bool {
+		var err error
+		run, err = s.GetRun("kubernetes", runID)
+		return err == nil && run.Status == status
+	}, 5*time.Second, 5*time.Millisecond, "run did not become %s", status)
+	return run
+}
+
+func requireAppError(t *testing.T, err error, errType string) {
+	t.Helper()
+	var target *apperror.AppError
+	require.True(t, errors.As(err, &target), "expected an AppError, got %v", err)
+	assert.Equal(t, errType, target.Type)
+}
+
+func TestService_SaveRunbook(t *testing.T) {
+	s := newTestService(t, &fakeShell{}, &fakeResources{})
+
+	created := saveRunbook(t, s, validRunbook())
+	assert.NotEmpty(t, created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	update := *created
+	update.Name = "  Restart web deployment "
+	updated := saveRunbook(t, s, update)
+	assert.Equal(t, "Restart web deployment", updated.Name)
+	assert.True(t, created.CreatedAt.Equal(updated.CreatedAt))
+
+	runbooks, err := s.ListRunbooks("kubernetes")
+	require.NoError(t, err)
+	require.Len(t, runbooks, 1)
+
+	invalid := validRunbook()
+	invalid.Steps[0].Command = "echo {{missing}}"
+	_, err = s.SaveRunbook(invalid)
+	requireAppError(t, err, apperror.TypeValidation)
+
+	unknown := validRunbook()
+	unknown.ID = "nope"
+	_, err = s.SaveRunbook(unknown)
+	requireAppError(t, err, apperror.TypeResourceNotFound)
+
+	require.NoError(t, s.DeleteRunbook("kubernetes", created.ID))
+	_, err = s.GetRunbook("kubernetes", created.ID)
+	requireAppError(t, err, apperror.TypeResourceNotFound)
+}
+
+func TestService_StartRunErrors(t *testing.T) {
+	s := newTestService(t, &fakeShell{}, &fakeResources{})
+	rb := saveRunbook(t, s, validRunbook())
+
+	_, err := s.StartRun("kubernetes", "nope", "prod", nil)
+	requireAppError(t, err, apperror.TypeResourceNotFound)
+	_, err = s.StartRun("kubernetes", rb.ID, "", map[string]string{"deployment": "web"})
+	requireAppError(t, err, apperror.TypeValidation)
+	_, err = s.StartRun("kubernetes", rb.ID, "prod", nil)
+	requireAppError(t, err, apperror.TypeValidation)
+}
+
+func TestService_Run(t *testing.T) {
+	shell := &fakeShell{}
+	resources := &fakeResources{health: []resource.HealthStatus{resource.HealthPending, resource.HealthPending, resource.HealthHealthy}}
+	s := newTestService(t, shell, resources)
+	rb := saveRunbook(t, s, validRunbook())
+
+	started, err := s.StartRun("kubernetes", rb.ID, "prod", map[string]string{"deployment": "web"})
+	require.NoError(t, err)
+	assert.Equal(t, RunRunning, started.Status)
+	require.Len(t, started.Steps, 3)
+
+	run := waitStatus(t, s, started.ID, RunSucceeded)
+	for _, step := range run.Steps {
+		assert.Equal(t, StepSucceeded, step.Status, step.Name)
+		assert.Equal(t, 1, step.Attempts)
+	}
+
+	call := shell.call(0)
+	assert.Equal(t, "kubernetes", call.plugin)
+	assert.Equal(t, "prod", call.connectionID)
+	assert.Equal(t, []string{"/bin/sh", "-c", "kubectl -n 'default' get deploy 'web'"}, call.opts.Command)
+	assert.Equal(t, "web", call.opts.Params["env.deployment"], "parameters are passed as environment variables")
+	assert.Equal(t, started.ID, call.opts.Labels[RunLabel])
+	assert.Equal(t, call.opts.ID, run.Steps[0].SessionID)
+	assert.Equal(t, "ok\n", run.Steps[0].Output)
+	require.NotNil(t, run.Steps[0].ExitCode)
+
+	require.Len(t, resources.actions, 1)
+	assert.Equal(t, resource.ActionInput{ID: "web", Namespace: "default",
+		Params: map[string]any{"reason": "runbook web", "replicas": 2.0}}, resources.actions[0])
+	assert.Equal(t, "restarted", run.Steps[1].Output)
+	assert.Equal(t, "healthy: Rollout", run.Steps[2].Output)
+	assert.GreaterOrEqual(t, resources.polls, 3)
+
+	assert.NotEmpty(t, run.Log)
+	assert.Equal(t, "Run succeeded", run.Log[len(run.Log)-1].Message)
+
+	// the finished run is recorded
+	runs, err := s.ListRuns("kubernetes", rb.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, RunSucceeded, runs[0].Status)
+	assert.False(t, runs[0].EndedAt.IsZero())
+	runs, err = s.ListRuns("kubernetes", "other")
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+}
+
+func TestService_PauseAndRetry(t *testing.T) {
+	shell := &fakeShell{run: func(_ context.Context, call int) (*exec.CommandRun, error) {
+		if call == 1 {
+			return &exec.CommandRun{Output: "error: not found\n", ExitCode: 1}, nil
+		}
+		return &exec.CommandRun{Output: "ok\n"}, nil
+	}}
+	s := newTestService(t, shell, &fakeResources{})
+	rb := saveRunbook(t, s, validRunbook())
+
+	started, err := s.StartRun("kubernetes", rb.ID, "prod", map[string]string{"deployment": "web"})
+	require.NoError(t, err)
+	paused := waitStatus(t, s, started.ID, RunPaused)
+	assert.Equal(t, StepFailed, paused.Steps[0].Status)
+	assert.Equal(t, "command exited with code 1", paused.Steps[0].Error)
+	assert.Equal(t, "error: not found\n", paused.Steps[0].Output)
+	assert.Equal(t, StepPending, paused.Steps[1].Status, "the run waits at the failed step")
+
+	requireAppError(t, s.ResumeRun(started.ID, "later"), apperror.TypeValidation)
+	require.NoError(t, s.ResumeRun(started.ID, DecisionRetry))
+
+	run := waitStatus(t, s, started.ID, RunSucceeded)
+	assert.Equal(t, StepSucceeded, run.Steps[0].Status)
+	assert.Equal(t, 2, run.Steps[0].Attempts)
+	assert.Empty(t, run.Steps[0].Error)
+
+	err = s.ResumeRun(started.ID, DecisionRetry)
+	requireAppError(t, err, apperror.TypeResourceNotFound)
+}
+
+func TestService_SkipAndAbort(t *testing.T) {
+	failing := func(int) (*resource.ActionResult, error) {
+		return &resource.ActionResult{Success: false, Message: "forbidden"}, nil
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		resources := &fakeResources{action: failing}
+		s := newTestService(t, &fakeShell{}, resources)
+		rb := saveRunbook(t, s, validRunbook())
+		started, err := s.StartRun("kubernetes", rb.ID, "prod", map[string]string{"deployment": "web"})
+		require.NoError(t, err)
+
+		paused := waitStatus(t, s, started.ID, RunPaused)
+		assert.Equal(t, "action restart failed: forbidden", paused.Steps[1].Error)
+		require.NoError(t, s.ResumeRun(started.ID, DecisionSkip))
+		run := waitStatus(t, s, started.ID, RunSucceeded)
+		assert.Equal(t, StepSkipped, run.Steps[1].Status)
+		assert.Equal(t, StepSucceeded, run.Steps[2].Status)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		s := newTestService(t, &fakeShell{}, &fakeResources{action: failing})
+		rb := saveRunbook(t, s, validRunbook())
+		started, err := s.StartRun("kubernetes", rb.ID, "prod", map[string]string{"deployment": "web"})
+		require.NoError(t, err)
+
+		waitStatus(t, s, started.ID, RunPaused)
+		require.NoError(t, s.ResumeRun(started.ID, DecisionAbort))
+		run := waitStatus(t, s, started.ID, RunFailed)
+		assert.Equal(t, StepFailed, run.Steps[1].Status)
+		assert.Equal(t, StepPending, run.Steps[2].Status)
+	})
+}
+
+func TestService_ContinueOnError(t *testing.T) {
+	resources := &fakeResources{action: func(int) (*resource.ActionResult, error) {
+		return nil, apperror.New(apperror.TypeInternal, 500, "Action failed", "plugin crashed")
+	}}
+	s := newTestService(t, &fakeShell{}, resources)
+	rb := validRunbook()
+	rb.Steps[1].ContinueOnError = true
+	saved := saveRunbook(t, s, rb)
+
+	started, err := s.StartRun("kubernetes", saved.ID, "prod", map[string]string{"deployment": "web"})
+	require.NoError(t, err)
+	run := waitStatus(t, s, started.ID, RunSucceeded)
+	assert.Equal(t, StepFailed, run.Steps[1].Status)
+	assert.Equal(t, "plugin crashed", run.Steps[1].Error)
+	assert.Equal(t, StepSucceeded, run.Steps[2].Status)
+}
+
+func TestService_Cancel(t *testing.T) {
+	running := make(chan struct{})
+	shell := &fakeShell{run: func(ctx context.Context, _ int) (*exec.CommandRun, error) {
+		close(running)
+		<-ctx.Done()
+		return &exec.CommandRun{Output: "partial", ExitCode: -1}, ctx.Err()
+	}}
+	s := newTestService(t, shell, &fakeResources{})
+	rb := saveRunbook(t, s, validRunbook())
+	started, err := s.StartRun("kubernetes", rb.ID, "prod", map[string]string{"deployment": "web"})
+	require.NoError(t, err)
+
+	<-running
+	require.NoError(t, s.CancelRun(started.ID))
+	run := waitStatus(t, s, started.ID, RunCancelled)
+	assert.Equal(t, StepCancelled, run.Steps[0].Status)
+	assert.Equal(t, "partial", run.Steps[0].Output)
+	assert.Nil(t, run.Steps[0].ExitCode)
+
+	requireAppError(t, s.CancelRun(started.ID), apperror.TypeResourceNotFound)
+}
+
+func TestService_WaitHealthTimeout(t *testing.T) {
+	resources := &fakeResources{health: []resource.HealthStatus{resource.HealthDegraded}}
+	s := newTestService(t, &fakeShell{}, resources)
+	rb := validRunbook()
+	rb.Steps = rb.Steps[2:]
+	rb.Steps[0].TimeoutSeconds = 1
+	saved := saveRunbook(t, s, rb)
+
+	started, err := s.StartRun("kubernetes", saved.ID, "prod", map[string]string{"deployment": "web"})
+	require.NoError(t, err)
+	run := waitStatus(t, s, started.ID, RunPaused)
+	assert.Equal(t, "timed out after 1s waiting for healthy (last: degraded: Rollout)", run.Steps[0].Error)
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package runbook
+
+// shellCommand returns the terminal command that runs a shell step's command
+// line.
+func shellCommand(command string) ([]string, error) {
+	return []string{"/bin/sh", "-c", command}, nil
+}
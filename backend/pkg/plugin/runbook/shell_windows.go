@@ -0,0 +1,14 @@
+//go:build windows
+
+package runbook
+
+import "errors"
+
+var errShellUnsupported = errors.New("runbooks with shell steps cannot run on Windows, which has no POSIX shell for their commands")
+
+// shellCommand returns the terminal command that runs a shell step's command
+// line. Shell steps are written for a POSIX shell, so they are rejected on
+// Windows rather than run through cmd.exe or PowerShell.
+func shellCommand(string) ([]string, error) {
+	return nil, errShellUnsupported
+}
@@ -0,0 +1,188 @@
+package runbook
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+// Keys in the plugin's data store: all runbook definitions are kept together
+// under runbooksKey, finished runs each under runKeyPrefix plus the run ID,
+// and runIndexKey lists the stored runs, oldest first, for pruning.
+const (
+	keyPrefix    = "omniview.runbook."
+	runbooksKey  = keyPrefix + "runbooks"
+	runIndexKey  = keyPrefix + "runs"
+	runKeyPrefix = keyPrefix + "run."
+)
+
+// MaxStoredRuns is the number of finished runs kept per plugin; older runs
+// are removed as new ones are saved.
+const MaxStoredRuns = 50
+
+var (
+	// ErrNotFound is returned when a runbook does not exist.
+	ErrNotFound = errors.New("runbook not found")
+	// ErrRunNotFound is returned when a run does not exist.
+	ErrRunNotFound = errors.New("run not found")
+)
+
+// Store persists runbooks and the logs of finished runs in a plugin data store. A
+// plugin's runbooks are kept under a single key; each run is stored under
+// its own key, with a per-plugin index so listing never loads step output.
+type Store struct {
+	data plugindata.Store
+	mu   sync.Mutex
+}
+
+// NewStore creates a Store backed by data.
+func NewStore(data plugindata.Store) *Store {
+	return &Store{data: data}
+}
+
+// List returns a plugin's runbooks in the order they were created.
+func (s *Store) List(pluginID string) ([]Runbook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readRunbooks(pluginID)
+}
+
+// Load returns a runbook, or ErrNotFound.
+func (s *Store) Load(pluginID, id string) (*Runbook, error) {
+	runbooks, err := s.List(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(runbooks, func(rb Runbook) bool { return rb.ID == id })
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	return &runbooks[i], nil
+}
+
+// Save adds rb, or replaces the runbook with the same ID.
+func (s *Store) Save(rb *Runbook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runbooks, err := s.readRunbooks(rb.PluginID)
+	if err != nil {
+		return err
+	}
+	if i := slices.IndexFunc(runbooks, func(r Runbook) bool { return r.ID == rb.ID }); i >= 0 {
+		runbooks[i] = *rb
+	} else {
+		runbooks = append(runbooks, *rb)
+	}
+	if err := s.data.Set(rb.PluginID, runbooksKey, runbooks); err != nil {
+		return fmt.Errorf("write runbooks: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a runbook, or returns ErrNotFound. The logs of its runs are
+// kept.
+func (s *Store) Delete(pluginID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runbooks, err := s.readRunbooks(pluginID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(runbooks, func(rb Runbook) bool { return rb.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	if err := s.data.Set(pluginID, runbooksKey, slices.Delete(runbooks, i, i+1)); err != nil {
+		return fmt.Errorf("write runbooks: %w", err)
+	}
+	return nil
+}
+
+// SaveRun persists a finished run, removing the oldest runs of the plugin
+// beyond MaxStoredRuns.
+func (s *Store) SaveRun(run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.readRunIndex(run.PluginID)
+	if err != nil {
+		return err
+	}
+	if err := s.data.Set(run.PluginID, runKeyPrefix+run.ID, run); err != nil {
+		return fmt.Errorf("write run: %w", err)
+	}
+	index = slices.DeleteFunc(index, func(info RunInfo) bool { return info.ID == run.ID })
+	index = append(index, run.RunInfo)
+	var evicted []RunInfo
+	if over := len(index) - MaxStoredRuns; over > 0 {
+		evicted = append(evicted, index[:over]...)
+		index = index[over:]
+	}
+	if err := s.data.Set(run.PluginID, runIndexKey, index); err != nil {
+		return fmt.Errorf("write run index: %w", err)
+	}
+	for _, info := range evicted {
+		if err := s.data.Delete(run.PluginID, runKeyPrefix+info.ID); err != nil {
+			return fmt.Errorf("delete run: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadRun returns a stored run, or ErrRunNotFound.
+func (s *Store) LoadRun(pluginID, runID string) (*Run, error) {
+	value, err := s.data.Get(pluginID, runKeyPrefix+runID)
+	if err != nil {
+		return nil, fmt.Errorf("read run: %w", err)
+	}
+	if value == nil {
+		return nil, ErrRunNotFound
+	}
+	var run Run
+	if err := plugindata.Decode(value, &run); err != nil {
+		return nil, fmt.Errorf("decode run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListRuns returns a plugin's stored runs, oldest first.
+func (s *Store) ListRuns(pluginID string) ([]RunInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readRunIndex(pluginID)
+}
+
+// readRunbooks loads a plugin's runbooks. Caller must hold s.mu.
+func (s *Store) readRunbooks(pluginID string) ([]Runbook, error) {
+	value, err := s.data.Get(pluginID, runbooksKey)
+	if err != nil {
+		return nil, fmt.Errorf("read runbooks: %w", err)
+	}
+	runbooks := []Runbook{}
+	if value != nil {
+		if err := plugindata.Decode(value, &runbooks); err != nil {
+			return nil, fmt.Errorf("decode runbooks: %w", err)
+		}
+	}
+	return runbooks, nil
+}
+
+// readRunIndex loads a plugin's run index. Caller must hold s.mu.
+func (s *Store) readRunIndex(pluginID string) ([]RunInfo, error) {
+	value, err := s.data.Get(pluginID, runIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("read run index: %w", err)
+	}
+	index := []RunInfo{}
+	if value != nil {
+		if err := plugindata.Decode(value, &index); err != nil {
+			return nil, fmt.Errorf("decode run index: %w", err)
+		}
+	}
+	return index, nil
+}
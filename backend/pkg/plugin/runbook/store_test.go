@@ -0,0 +1,84 @@
+package runbook
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/plugindata"
+)
+
+func TestStore_Runbooks(t *testing.T) {
+	s := NewStore(plugindata.NewMemory())
+
+	runbooks, err := s.List("kubernetes")
+	require.NoError(t, err)
+	assert.Empty(t, runbooks)
+
+	first := validRunbook()
+	first.ID = "rb-1"
+	second := validRunbook()
+	second.ID = "rb-2"
+	second.Name = "Second"
+	require.NoError(t, s.Save(&first))
+	require.NoError(t, s.Save(&second))
+
+	first.Name = "Renamed"
+	require.NoError(t, s.Save(&first))
+	runbooks, err = s.List("kubernetes")
+	require.NoError(t, err)
+	require.Len(t, runbooks, 2)
+	assert.Equal(t, "Renamed", runbooks[0].Name, "saving an existing runbook keeps its position")
+	assert.Equal(t, first.Steps[1].ActionParams, runbooks[0].Steps[1].ActionParams)
+
+	loaded, err := s.Load("kubernetes", "rb-2")
+	require.NoError(t, err)
+	assert.Equal(t, "Second", loaded.Name)
+	_, err = s.Load("aws", "rb-2")
+	assert.ErrorIs(t, err, ErrNotFound, "runbooks are per plugin")
+
+	require.NoError(t, s.Delete("kubernetes", "rb-1"))
+	assert.ErrorIs(t, s.Delete("kubernetes", "rb-1"), ErrNotFound)
+	runbooks, err = s.List("kubernetes")
+	require.NoError(t, err)
+	require.Len(t, runbooks, 1)
+}
+
+func TestStore_Runs(t *testing.T) {
+	data := plugindata.NewMemory()
+	s := NewStore(data)
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := range MaxStoredRuns + 2 {
+		code := 0
+		require.NoError(t, s.SaveRun(&Run{
+			RunInfo: RunInfo{ID: fmt.Sprintf("run-%d", i), RunbookID: "rb-1", PluginID: "kubernetes",
+				Status: RunSucceeded, StartedAt: start.Add(time.Duration(i) * time.Minute)},
+			Steps: []StepResult{{Name: "status", Type: StepShell, Status: StepSucceeded, Output: "ok", ExitCode: &code}},
+		}))
+	}
+
+	index, err := s.ListRuns("kubernetes")
+	require.NoError(t, err)
+	require.Len(t, index, MaxStoredRuns)
+	assert.Equal(t, "run-2", index[0].ID, "the oldest runs are evicted")
+	_, err = s.LoadRun("kubernetes", "run-0")
+	require.ErrorIs(t, err, ErrRunNotFound)
+
+	run, err := s.LoadRun("kubernetes", "run-5")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", run.Steps[0].Output)
+	require.NotNil(t, run.Steps[0].ExitCode)
+	assert.Equal(t, 0, *run.Steps[0].ExitCode)
+
+	// saving a run again replaces its index entry
+	run.Status = RunFailed
+	require.NoError(t, s.SaveRun(run))
+	index, err = s.ListRuns("kubernetes")
+	require.NoError(t, err)
+	require.Len(t, index, MaxStoredRuns)
+	assert.Equal(t, RunFailed, index[len(index)-1].Status)
+}
@@ -0,0 +1,58 @@
+// Package boltdb opens the bbolt databases of stores whose contents the
+// application can afford to lose, such as caches rebuilt by watches: a
+// corrupt file is set aside and replaced instead of failing startup.
+package boltdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Open opens (or creates) the database at path. If the file is corrupt, it
+// is renamed with a .corrupt.<unix-timestamp> suffix and a fresh database is
+// created in its place. name describes the database in errors.
+func Open(path, name string, opts *bolt.Options) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0o600, opts)
+	if err == nil {
+		return db, nil
+	}
+	if !IsCorruptionError(err) {
+		return nil, fmt.Errorf("open %s database: %w", name, err)
+	}
+	backupPath := fmt.Sprintf("%s.corrupt.%d", path, time.Now().Unix())
+	if renameErr := os.Rename(path, backupPath); renameErr != nil {
+		return nil, fmt.Errorf("open %s database and could not rename corrupt file: %w (rename error: %v)", name, err, renameErr)
+	}
+	db, err = bolt.Open(path, 0o600, opts)
+	if err != nil {
+		return nil, fmt.Errorf("create %s database after corruption recovery: %w", name, err)
+	}
+	return db, nil
+}
+
+// IsCorruptionError reports whether err looks like bbolt file corruption
+// rather than a permission, timeout or I/O error. bbolt has no typed
+// sentinels for these, so match on the message.
+func IsCorruptionError(err error) bool {
+	if err == nil || errors.Is(err, os.ErrPermission) {
+		return false
+	}
+	msg := err.Error()
+	for _, substr := range []string{
+		"invalid database",
+		"checksum error",
+		"unexpected magic",
+		"version mismatch",
+		"invalid freelist",
+	} {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package boltdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestOpen_CreatesDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(path, "test", &bolt.Options{Timeout: time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.FileExists(t, path)
+	matches, err := filepath.Glob(path + ".corrupt.*")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestOpen_ReplacesCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	garbage := []byte("definitely not a bolt database, but long enough to be read as one....................")
+	require.NoError(t, os.WriteFile(path, garbage, 0o600))
+
+	db, err := Open(path, "test", &bolt.Options{Timeout: time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+
+	matches, err := filepath.Glob(path + ".corrupt.*")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	kept, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	assert.Equal(t, garbage, kept, "the corrupt file is kept aside")
+}
+
+func TestIsCorruptionError(t *testing.T) {
+	assert.True(t, IsCorruptionError(errors.New("invalid database")))
+	assert.True(t, IsCorruptionError(fmt.Errorf("open: %w", errors.New("checksum error"))))
+	assert.False(t, IsCorruptionError(nil))
+	assert.False(t, IsCorruptionError(bolt.ErrTimeout))
+	assert.False(t, IsCorruptionError(fmt.Errorf("invalid database: %w", os.ErrPermission)))
+}
@@ -0,0 +1,91 @@
+// Package plugindata holds what the core features that keep their own state
+// in a plugin's data store share: the part of data.Controller they use, the
+// decoding of the generic values it returns, and an in-memory stand-in.
+package plugindata
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Store is the per-plugin JSON key-value store. It is satisfied by
+// data.Controller.
+type Store interface {
+	Get(pluginID, key string) (any, error)
+	Set(pluginID, key string, value any) error
+	Delete(pluginID, key string) error
+}
+
+// Decode converts a value returned by Store.Get into out. Get returns values
+// decoded into generic JSON types, so the value is re-encoded and decoded
+// again; object keys come back sorted.
+func Decode(value, out any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Memory is a Store kept in memory. Like data.Controller it stores values as
+// JSON and returns them as generic values, so it stands in for it in tests.
+type Memory struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+var _ Store = (*Memory)(nil)
+
+// NewMemory returns an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{values: make(map[string][]byte)}
+}
+
+// Get returns the value of a key, or nil if it is not set.
+func (m *Memory) Get(pluginID, key string) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.values[pluginID+"/"+key]
+	if !ok {
+		return nil, nil
+	}
+	var v any
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// Set stores value as JSON under a key.
+func (m *Memory) Set(pluginID, key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[pluginID+"/"+key] = data
+	return nil
+}
+
+// Delete removes a key.
+func (m *Memory) Delete(pluginID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, pluginID+"/"+key)
+	return nil
+}
+
+// Keys returns the keys set for a plugin, sorted.
+func (m *Memory) Keys(pluginID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := []string{}
+	for k := range m.values {
+		if key, ok := strings.CutPrefix(k, pluginID+"/"); ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
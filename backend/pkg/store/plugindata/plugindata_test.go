@@ -0,0 +1,43 @@
+package plugindata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestMemory_RoundTrip(t *testing.T) {
+	m := NewMemory()
+
+	value, err := m.Get("p1", "items")
+	require.NoError(t, err)
+	assert.Nil(t, value, "unset keys read as nil")
+
+	require.NoError(t, m.Set("p1", "items", []item{{Name: "a", Count: 2}}))
+	value, err = m.Get("p1", "items")
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"name": "a", "count": 2.0}}, value, "values come back generic")
+
+	var items []item
+	require.NoError(t, Decode(value, &items))
+	assert.Equal(t, []item{{Name: "a", Count: 2}}, items)
+
+	keys, err := m.Keys("p1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"items"}, keys)
+
+	other, err := m.Get("p2", "items")
+	require.NoError(t, err)
+	assert.Nil(t, other, "keys are per plugin")
+
+	require.NoError(t, m.Delete("p1", "items"))
+	value, err = m.Get("p1", "items")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
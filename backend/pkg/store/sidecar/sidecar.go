@@ -0,0 +1,161 @@
+// Package sidecar holds the bookkeeping shared by stores of on-disk session
+// artifacts, such as terminal recordings and log captures: each artifact is
+// named by a UUID, described by a JSON info file kept beside its data, and
+// tracked in memory while it is still being written.
+package sidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+var (
+	// ErrNotFound is wrapped by a store's error for an unknown artifact.
+	ErrNotFound = errors.New("not found")
+	// ErrActive is wrapped by a store's error for deleting an artifact that
+	// is still being written.
+	ErrActive = errors.New("is still active")
+)
+
+// Live is an artifact being written.
+type Live[I any] interface {
+	Info() I
+	Close() (I, error)
+}
+
+// Index tracks the artifacts being written and reads and writes the info
+// files of every artifact. I is the info type and L the writer of an
+// artifact.
+type Index[I any, L Live[I]] struct {
+	notFound error
+
+	mu     sync.Mutex
+	active map[string]L
+}
+
+// NewIndex returns an Index that reports unknown artifacts with notFound.
+func NewIndex[I any, L Live[I]](notFound error) *Index[I, L] {
+	return &Index[I, L]{notFound: notFound, active: make(map[string]L)}
+}
+
+// NewID returns the ID of a new artifact.
+func NewID() string {
+	return uuid.NewString()
+}
+
+// ValidID reports whether id is an artifact ID, which also keeps it from
+// naming a path outside the store.
+func ValidID(id string) bool {
+	return uuid.Validate(id) == nil
+}
+
+// Track marks an artifact as being written.
+func (x *Index[I, L]) Track(id string, live L) {
+	x.mu.Lock()
+	x.active[id] = live
+	x.mu.Unlock()
+}
+
+// Untrack marks an artifact as finished.
+func (x *Index[I, L]) Untrack(id string) {
+	x.mu.Lock()
+	delete(x.active, id)
+	x.mu.Unlock()
+}
+
+// Live returns the writer of an artifact, if it is still being written.
+func (x *Index[I, L]) Live(id string) (L, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	live, ok := x.active[id]
+	return live, ok
+}
+
+// Info returns the info of an artifact: the live info while it is being
+// written, otherwise the contents of its info file at path. active reports
+// which.
+func (x *Index[I, L]) Info(id, path string) (info I, active bool, err error) {
+	if !ValidID(id) {
+		return info, false, x.notFound
+	}
+	if live, ok := x.Live(id); ok {
+		return live.Info(), true, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return info, false, x.notFound
+	}
+	if err != nil {
+		return info, false, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, false, fmt.Errorf("decode info: %w", err)
+	}
+	return info, false, nil
+}
+
+// WriteInfo replaces the info file at path, so readers never see it half
+// written.
+func (x *Index[I, L]) WriteInfo(path string, info I) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// CloseAll finishes every artifact being written.
+func (x *Index[I, L]) CloseAll() error {
+	x.mu.Lock()
+	active := make([]L, 0, len(x.active))
+	for _, live := range x.active {
+		active = append(active, live)
+	}
+	x.mu.Unlock()
+
+	var errs []error
+	for _, live := range active {
+		if _, err := live.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Kind names a kind of artifact, such as "recording", in the app errors
+// returned to the frontend.
+type Kind string
+
+func (k Kind) title() string {
+	return strings.ToUpper(string(k[:1])) + string(k[1:])
+}
+
+// Unavailable is the error for using a store that could not be opened.
+func (k Kind) Unavailable() *apperror.AppError {
+	return apperror.NotImplemented(k.title()+" unavailable",
+		fmt.Sprintf("The %s store could not be opened when Omniview started.", k))
+}
+
+// Error converts an error from a store of this kind into an app error.
+func (k Kind) Error(err error, id string) *apperror.AppError {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return apperror.NotFound(k.title()+" not found", fmt.Sprintf("No %s with ID %s exists.", k, id))
+	case errors.Is(err, ErrActive):
+		return apperror.New(apperror.TypeResourceConflict, 409, k.title()+" in progress",
+			fmt.Sprintf("Stop the %s before deleting it.", k))
+	default:
+		return apperror.Internal(err, k.title()+" storage failed")
+	}
+}
@@ -0,0 +1,81 @@
+package sidecar
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+)
+
+var errTestNotFound = fmt.Errorf("test %w", ErrNotFound)
+
+type testInfo struct {
+	ID     string `json:"id"`
+	Active bool   `json:"active"`
+}
+
+type testLive struct {
+	info   testInfo
+	closed bool
+}
+
+func (l *testLive) Info() testInfo { return l.info }
+
+func (l *testLive) Close() (testInfo, error) {
+	l.closed = true
+	return l.info, nil
+}
+
+func TestIndex_Info(t *testing.T) {
+	dir := t.TempDir()
+	x := NewIndex[testInfo, *testLive](errTestNotFound)
+	path := func(id string) string { return filepath.Join(dir, id+".json") }
+
+	_, _, err := x.Info("../escape", path("../escape"))
+	assert.ErrorIs(t, err, errTestNotFound, "IDs that are not UUIDs are unknown")
+	missing := NewID()
+	_, _, err = x.Info(missing, path(missing))
+	assert.ErrorIs(t, err, errTestNotFound)
+
+	id := NewID()
+	require.NoError(t, x.WriteInfo(path(id), testInfo{ID: id, Active: true}))
+	info, active, err := x.Info(id, path(id))
+	require.NoError(t, err)
+	assert.False(t, active)
+	assert.Equal(t, testInfo{ID: id, Active: true}, info, "the info file is returned as written")
+
+	live := &testLive{info: testInfo{ID: id, Active: true}}
+	x.Track(id, live)
+	_, active, err = x.Info(id, path(id))
+	require.NoError(t, err)
+	assert.True(t, active)
+
+	require.NoError(t, x.CloseAll())
+	assert.True(t, live.closed)
+
+	x.Untrack(id)
+	_, ok := x.Live(id)
+	assert.False(t, ok)
+}
+
+func TestKind_Error(t *testing.T) {
+	var target *apperror.AppError
+	const kind Kind = "recording"
+
+	require.True(t, errors.As(kind.Error(errTestNotFound, "abc"), &target))
+	assert.Equal(t, apperror.TypeResourceNotFound, target.Type)
+	assert.Equal(t, "Recording not found", target.Title)
+
+	require.True(t, errors.As(kind.Error(fmt.Errorf("test %w", ErrActive), "abc"), &target))
+	assert.Equal(t, apperror.TypeResourceConflict, target.Type)
+
+	require.True(t, errors.As(kind.Error(errors.New("disk full"), "abc"), &target))
+	assert.Equal(t, apperror.TypeInternal, target.Type)
+
+	assert.Equal(t, apperror.TypeNotImplemented, kind.Unavailable().Type)
+}
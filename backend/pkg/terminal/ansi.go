@@ -0,0 +1,60 @@
+package terminal
+
+// StripANSI removes terminal escape sequences from data: CSI sequences
+// (colors, cursor movement), OSC sequences terminated by BEL or ST, and
+// two-byte escapes. Carriage returns that precede a newline are dropped and
+// other carriage returns become newlines, so the result reads as plain text.
+func StripANSI(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b == 0x1b && i+1 < len(data):
+			i = skipEscape(data, i)
+		case b == 0x1b:
+			// A trailing lone ESC carries no text.
+		case b == '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				continue
+			}
+			out = append(out, '\n')
+		case b < 0x20 && b != '\n' && b != '\t':
+			// Other C0 controls (BEL, backspace, ...) are not printable.
+		default:
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// skipEscape returns the index of the last byte of the escape sequence that
+// starts at data[start].
+func skipEscape(data []byte, start int) int {
+	i := start + 1
+	switch data[i] {
+	case '[': // CSI: parameters and intermediates, then a final byte in 0x40-0x7e.
+		for i++; i < len(data); i++ {
+			if data[i] >= 0x40 && data[i] <= 0x7e {
+				return i
+			}
+		}
+		return len(data) - 1
+	case ']', 'P', '_', '^': // OSC, DCS, APC, PM: terminated by BEL or ESC \.
+		for i++; i < len(data); i++ {
+			if data[i] == 0x07 {
+				return i
+			}
+			if data[i] == 0x1b && i+1 < len(data) && data[i+1] == '\\' {
+				return i + 1
+			}
+		}
+		return len(data) - 1
+	case '(', ')', '*', '+': // Character set designation takes one more byte.
+		if i+1 < len(data) {
+			return i + 1
+		}
+		return i
+	default:
+		return i
+	}
+}
@@ -0,0 +1,29 @@
+package terminal
+
+import "testing"
+
+func TestStripANSI(t *testing.T) {
+	cases := []struct {
+		name, in, want string
+	}{
+		{"plain", "hello\nworld", "hello\nworld"},
+		{"sgr colors", "\x1b[1;31merror\x1b[0m: boom", "error: boom"},
+		{"cursor movement", "a\x1b[2Kb\x1b[10;20Hc", "abc"},
+		{"osc title bel", "\x1b]0;user@host: ~\x07$ ls", "$ ls"},
+		{"osc st", "\x1b]133;A\x1b\\$ ", "$ "},
+		{"charset", "\x1b(Bok", "ok"},
+		{"crlf", "one\r\ntwo\r\n", "one\ntwo\n"},
+		{"lone cr", "50%\r100%", "50%\n100%"},
+		{"bell and backspace", "a\x07b\x08c", "abc"},
+		{"truncated csi", "text\x1b[3", "text"},
+		{"trailing esc", "text\x1b", "text"},
+		{"utf8", "\x1b[32m✓\x1b[0m done", "✓ done"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := string(StripANSI([]byte(tc.in))); got != tc.want {
+				t.Errorf("StripANSI(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
@@ -2,29 +2,42 @@ package terminal
 
 import "sync"
 
-// OutputBuffer stores terminal output lines, providing a fixed-size cyclic buffer.
+// OutputBuffer stores the most recent output of a session, up to a fixed
+// number of bytes. Older output is evicted as new output arrives; the number
+// of bytes evicted is kept so offsets into the stream stay meaningful.
 type OutputBuffer struct {
 	buf      []byte     // The buffer.
 	capacity int        // maximum number of bytes to store.
+	dropped  int64      // bytes evicted since the buffer was created.
 	lock     sync.Mutex // Protect concurrent access to lines.
 }
 
 // NewOutputBuffer initializes an OutputBuffer with a specified capacity.
 func NewOutputBuffer(capacity int) *OutputBuffer {
+	if capacity < 0 {
+		capacity = 0
+	}
 	return &OutputBuffer{
 		buf:      make([]byte, 0, capacity),
 		capacity: capacity,
 	}
 }
 
-// Append adds a line to the buffer, managing overflow by removing the oldest line.
+// Append adds data to the buffer, evicting the oldest bytes when it would
+// exceed its capacity.
 func (b *OutputBuffer) Append(data []byte) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 
-	// If the new data will exceed the buffer's capacity, remove the oldest data to make room.
-	if len(b.buf)+len(data) > b.capacity {
-		b.buf = b.buf[len(data):]
+	if len(data) >= b.capacity {
+		// Only the tail of data fits.
+		b.dropped += int64(len(b.buf) + len(data) - b.capacity)
+		b.buf = append(b.buf[:0], data[len(data)-b.capacity:]...)
+		return
+	}
+	if over := len(b.buf) + len(data) - b.capacity; over > 0 {
+		b.dropped += int64(over)
+		b.buf = b.buf[:copy(b.buf, b.buf[over:])]
 	}
 	b.buf = append(b.buf, data...)
 }
@@ -35,3 +48,18 @@ func (b *OutputBuffer) GetAll() []byte {
 	defer b.lock.Unlock()
 	return append([]byte(nil), b.buf...) // Return a copy to avoid external modifications.
 }
+
+// Snapshot returns a copy of the buffered output together with the number
+// of bytes evicted before it, which is the stream offset of its first byte.
+func (b *OutputBuffer) Snapshot() ([]byte, int64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]byte(nil), b.buf...), b.dropped
+}
+
+// Len returns the number of buffered bytes.
+func (b *OutputBuffer) Len() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.buf)
+}
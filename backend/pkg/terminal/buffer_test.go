@@ -0,0 +1,33 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputBuffer(t *testing.T) {
+	b := NewOutputBuffer(8)
+	b.Append([]byte("abc"))
+	b.Append([]byte("defg"))
+	assert.Equal(t, "abcdefg", string(b.GetAll()))
+
+	// only the overflow is evicted
+	b.Append([]byte("hi"))
+	data, dropped := b.Snapshot()
+	assert.Equal(t, "bcdefghi", string(data))
+	assert.Equal(t, int64(1), dropped)
+
+	// data larger than the buffer keeps its tail
+	b.Append([]byte("0123456789"))
+	data, dropped = b.Snapshot()
+	assert.Equal(t, "23456789", string(data))
+	assert.Equal(t, int64(11), dropped)
+	assert.Equal(t, 8, b.Len())
+
+	empty := NewOutputBuffer(0)
+	empty.Append([]byte("x"))
+	data, dropped = empty.Snapshot()
+	assert.Empty(t, data)
+	assert.Equal(t, int64(1), dropped)
+}
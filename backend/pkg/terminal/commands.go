@@ -0,0 +1,373 @@
+package terminal
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+)
+
+// MaxCommandHistory is the number of commands kept per session.
+const MaxCommandHistory = 1000
+
+// maxOSCLength bounds an escape sequence held back while waiting for the
+// rest of it to arrive in the next chunk.
+const maxOSCLength = 8192
+
+// Command is a command run in a shell with shell integration, delimited by
+// OSC 133 (FinalTerm) prompt markers. Offsets count bytes of session output
+// since the session started, including the markers themselves.
+type Command struct {
+	ID      int    `json:"id"`
+	Command string `json:"command"`
+	// PromptStart is the offset of the prompt the command was typed at.
+	PromptStart int64 `json:"promptStart"`
+	// OutputStart and OutputEnd delimit the command's output. OutputEnd is
+	// the current end of output while the command is running.
+	OutputStart int64     `json:"outputStart"`
+	OutputEnd   int64     `json:"outputEnd"`
+	StartedAt   time.Time `json:"startedAt"`
+	EndedAt     time.Time `json:"endedAt,omitzero"`
+	Running     bool      `json:"running"`
+	ExitCode    *int      `json:"exitCode,omitempty"`
+}
+
+// Failed reports whether the command finished with a non-zero exit code.
+func (c Command) Failed() bool {
+	return c.ExitCode != nil && *c.ExitCode != 0
+}
+
+// CommandTracker builds a session's command history from the OSC 133
+// markers in its output:
+//
+//	ESC ] 133 ; A ST   prompt start
+//	ESC ] 133 ; B ST   prompt end, command input start
+//	ESC ] 133 ; C ST   command output start; "C;cmdline_url=<url-encoded>"
+//	                   or "C;cmdline=<text>" also carries the command line
+//	ESC ] 133 ; D [; exit code] ST   command finished
+//
+// ST is BEL or ESC \. Without a command line in the C marker, the command
+// is taken from the text typed between B and C.
+//
+// The tracker appends the output it is fed to the session's buffer, so
+// command offsets can be resolved against the buffer consistently.
+type CommandTracker struct {
+	mu       sync.Mutex
+	buffer   *sdkexec.OutputBuffer
+	offset   int64  // stream offset of the end of the data fed so far
+	pending  []byte // unterminated escape sequence carried to the next chunk
+	nextID   int
+	commands []Command
+
+	promptStart int64
+	inputStart  int64 // -1 outside of command input
+	input       []byte
+	current     int // index into commands of the running command, or -1
+
+	// onFinish, if set, is called with each finished command.
+	onFinish func(Command)
+}
+
+// NewCommandTracker returns a tracker for a new session that appends its
+// output to buffer. onFinish, if not nil, is called outside of the tracker's
+// lock with each finished command.
+func NewCommandTracker(buffer *sdkexec.OutputBuffer, onFinish func(Command)) *CommandTracker {
+	return &CommandTracker{buffer: buffer, inputStart: -1, current: -1, nextID: 1, onFinish: onFinish}
+}
+
+// Feed processes the next chunk of session output.
+func (t *CommandTracker) Feed(data []byte) {
+	t.mu.Lock()
+	var finished []Command
+	if t.buffer != nil {
+		t.buffer.Append(data)
+	}
+
+	// Re-scan a carried partial sequence together with the new data; its
+	// bytes were already counted.
+	buf := data
+	base := t.offset
+	if len(t.pending) > 0 {
+		buf = append(t.pending, data...)
+		base -= int64(len(t.pending))
+		t.pending = nil
+	}
+	t.offset += int64(len(data))
+
+	plainStart := 0
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != 0x1b {
+			continue
+		}
+		if i+1 < len(buf) && buf[i+1] != ']' {
+			continue
+		}
+		var (
+			body []byte
+			end  int
+			ok   bool
+		)
+		if i+1 < len(buf) {
+			body, end, ok = scanOSC(buf[i+2:])
+		}
+		if !ok {
+			if len(buf)-i > maxOSCLength {
+				continue
+			}
+			// Hold back what may be the start of a marker.
+			t.appendInput(buf[plainStart:i])
+			t.pending = append([]byte(nil), buf[i:]...)
+			plainStart = len(buf)
+			break
+		}
+		t.appendInput(buf[plainStart:i])
+		seqStart := base + int64(i)
+		seqEnd := seqStart + 2 + int64(end)
+		if cmd, done := t.handleOSC(body, seqStart, seqEnd); done {
+			finished = append(finished, cmd)
+		}
+		i += 1 + end
+		plainStart = i + 1
+	}
+	if plainStart < len(buf) {
+		t.appendInput(buf[plainStart:])
+	}
+	if t.current >= 0 {
+		t.commands[t.current].OutputEnd = t.offset - int64(len(t.pending))
+	}
+	onFinish := t.onFinish
+	t.mu.Unlock()
+
+	if onFinish != nil {
+		for _, cmd := range finished {
+			onFinish(cmd)
+		}
+	}
+}
+
+// scanOSC finds the terminator of an OSC sequence whose body starts at
+// data[0]. It returns the body and the length consumed including the
+// terminator.
+func scanOSC(data []byte) ([]byte, int, bool) {
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case 0x07:
+			return data[:i], i + 1, true
+		case 0x1b:
+			if i+1 < len(data) {
+				if data[i+1] == '\\' {
+					return data[:i], i + 2, true
+				}
+				// ESC starting something else ends the OSC unterminated.
+				return data[:i], i, true
+			}
+			return nil, 0, false
+		}
+	}
+	return nil, 0, false
+}
+
+func (t *CommandTracker) appendInput(data []byte) {
+	if t.inputStart >= 0 && len(data) > 0 && len(t.input) < maxOSCLength {
+		t.input = append(t.input, data...)
+	}
+}
+
+// handleOSC applies a marker spanning [start, end) of the stream and returns
+// the command it finished, if any.
+func (t *CommandTracker) handleOSC(body []byte, start, end int64) (Command, bool) {
+	params, ok := bytes.CutPrefix(body, []byte("133;"))
+	if !ok || len(params) == 0 {
+		return Command{}, false
+	}
+	kind, args, _ := strings.Cut(string(params), ";")
+
+	switch kind {
+	case "A":
+		cmd, done := t.finish(start, nil)
+		t.promptStart = start
+		t.inputStart = -1
+		return cmd, done
+	case "B":
+		t.inputStart = end
+		t.input = t.input[:0]
+	case "C":
+		cmd, done := t.finish(start, nil)
+		line := commandLine(args)
+		if line == "" && t.inputStart >= 0 {
+			line = typedCommand(t.input)
+		}
+		t.commands = append(t.commands, Command{
+			ID:          t.nextID,
+			Command:     line,
+			PromptStart: t.promptStart,
+			OutputStart: end,
+			OutputEnd:   end,
+			StartedAt:   time.Now(),
+			Running:     true,
+		})
+		t.nextID++
+		t.current = len(t.commands) - 1
+		t.inputStart = -1
+		t.trim()
+		return cmd, done
+	case "D":
+		var code *int
+		if first, _, _ := strings.Cut(args, ";"); first != "" {
+			if n, err := strconv.Atoi(first); err == nil {
+				code = &n
+			}
+		}
+		return t.finish(start, code)
+	}
+	return Command{}, false
+}
+
+// finish ends the running command at offset end.
+func (t *CommandTracker) finish(end int64, code *int) (Command, bool) {
+	if t.current < 0 {
+		return Command{}, false
+	}
+	cmd := &t.commands[t.current]
+	cmd.OutputEnd = end
+	cmd.EndedAt = time.Now()
+	cmd.Running = false
+	cmd.ExitCode = code
+	t.current = -1
+	return *cmd, true
+}
+
+func (t *CommandTracker) trim() {
+	if over := len(t.commands) - MaxCommandHistory; over > 0 {
+		t.commands = append(t.commands[:0:0], t.commands[over:]...)
+		t.current -= over
+	}
+}
+
+// commandLine extracts the command line from the arguments of a C marker.
+func commandLine(args string) string {
+	for _, arg := range strings.Split(args, ";") {
+		if v, ok := strings.CutPrefix(arg, "cmdline_url="); ok {
+			if decoded, err := url.PathUnescape(v); err == nil {
+				return strings.TrimSpace(decoded)
+			}
+		}
+		if v, ok := strings.CutPrefix(arg, "cmdline="); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// typedCommand recovers a command line from the echo of typed input,
+// applying backspaces and dropping escape sequences.
+func typedCommand(input []byte) string {
+	var line []byte
+	for i := 0; i < len(input); i++ {
+		switch b := input[i]; {
+		case b == 0x1b && i+1 < len(input):
+			i = skipEscape(input, i)
+		case b == '\b' || b == 0x7f:
+			// drop a whole UTF-8 sequence
+			for len(line) > 0 {
+				last := line[len(line)-1]
+				line = line[:len(line)-1]
+				if last < 0x80 || last >= 0xc0 {
+					break
+				}
+			}
+		case b == '\r' || b == '\n':
+			line = append(line, ' ')
+		case b >= 0x20:
+			line = append(line, b)
+		}
+	}
+	return strings.TrimSpace(string(line))
+}
+
+// Commands returns a copy of the command history, oldest first.
+func (t *CommandTracker) Commands() []Command {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Command, len(t.commands))
+	copy(out, t.commands)
+	return out
+}
+
+// Command returns a command by ID.
+func (t *CommandTracker) Command(id int) (Command, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, cmd := range t.commands {
+		if cmd.ID == id {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+// CommandOutput is the output of a command as plain text.
+type CommandOutput struct {
+	Command Command `json:"command"`
+	Output  string  `json:"output"`
+	// Truncated reports that the start of the output has been evicted from
+	// the session's buffer.
+	Truncated bool `json:"truncated"`
+}
+
+// Output returns the output of a command, with escape sequences removed.
+func (t *CommandTracker) Output(id int) (CommandOutput, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var cmd *Command
+	for i := range t.commands {
+		if t.commands[i].ID == id {
+			cmd = &t.commands[i]
+			break
+		}
+	}
+	if cmd == nil {
+		return CommandOutput{}, false
+	}
+	out := CommandOutput{Command: *cmd}
+	if t.buffer == nil {
+		out.Truncated = true
+		return out, true
+	}
+
+	// The buffer holds the last Len bytes of the stream.
+	buffered := t.buffer.GetAll()
+	bufStart := t.offset - int64(len(buffered))
+	start, end := cmd.OutputStart, cmd.OutputEnd
+	if start < bufStart {
+		start = bufStart
+		out.Truncated = true
+	}
+	if end > start {
+		out.Output = string(StripANSI(buffered[start-bufStart : end-bufStart]))
+	}
+	return out, true
+}
+
+// Snapshot returns the session's buffered output and the stream offset of
+// its first byte, which is the number of bytes evicted before it.
+func (t *CommandTracker) Snapshot() ([]byte, int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.buffer == nil {
+		return nil, t.offset
+	}
+	buffered := t.buffer.GetAll()
+	return buffered, t.offset - int64(len(buffered))
+}
+
+// Offset returns the stream offset of the end of the output fed so far.
+func (t *CommandTracker) Offset() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.offset
+}
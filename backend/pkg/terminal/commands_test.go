@@ -0,0 +1,153 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	markA = "\x1b]133;A\x07"
+	markB = "\x1b]133;B\x07"
+)
+
+func markC(cmdline string) string { return "\x1b]133;C;cmdline_url=" + cmdline + "\x07" }
+func markD(code string) string    { return "\x1b]133;D;" + code + "\x1b\\" }
+
+// session is a shell session with two commands, one failing.
+var session = markA + "$ " + markB + "ls\r\n" + markC("ls%20-la") + "a\r\nb\r\n" + markD("0") +
+	markA + "$ " + markB + "false\r\n" + markC("false") + markD("1") + markA + "$ " + markB
+
+func TestCommandTracker_History(t *testing.T) {
+	var finished []Command
+	tracker := NewCommandTracker(nil, func(cmd Command) { finished = append(finished, cmd) })
+	tracker.Feed([]byte(session))
+
+	cmds := tracker.Commands()
+	require.Len(t, cmds, 2)
+
+	assert.Equal(t, 1, cmds[0].ID)
+	assert.Equal(t, "ls -la", cmds[0].Command)
+	assert.False(t, cmds[0].Running)
+	require.NotNil(t, cmds[0].ExitCode)
+	assert.Equal(t, 0, *cmds[0].ExitCode)
+	assert.False(t, cmds[0].Failed())
+	assert.Equal(t, "a\r\nb\r\n", session[cmds[0].OutputStart:cmds[0].OutputEnd])
+	assert.Equal(t, int64(0), cmds[0].PromptStart)
+	assert.False(t, cmds[0].EndedAt.Before(cmds[0].StartedAt))
+
+	assert.Equal(t, "false", cmds[1].Command)
+	assert.True(t, cmds[1].Failed())
+	assert.Equal(t, cmds[1].OutputStart, cmds[1].OutputEnd)
+	assert.Equal(t, int64(strings.Index(session, markA+"$ "+markB+"false")), cmds[1].PromptStart)
+
+	assert.Equal(t, cmds, finished)
+	assert.Equal(t, int64(len(session)), tracker.Offset())
+}
+
+func TestCommandTracker_SplitChunks(t *testing.T) {
+	whole := NewCommandTracker(nil, nil)
+	whole.Feed([]byte(session))
+	want := whole.Commands()
+
+	for i := 1; i < len(session); i++ {
+		tracker := NewCommandTracker(nil, nil)
+		tracker.Feed([]byte(session[:i]))
+		tracker.Feed([]byte(session[i:]))
+		got := tracker.Commands()
+		require.Len(t, got, len(want), "split at %d", i)
+		for j := range want {
+			assert.Equal(t, want[j].Command, got[j].Command, "split at %d", i)
+			assert.Equal(t, want[j].OutputStart, got[j].OutputStart, "split at %d", i)
+			assert.Equal(t, want[j].OutputEnd, got[j].OutputEnd, "split at %d", i)
+			assert.Equal(t, want[j].ExitCode, got[j].ExitCode, "split at %d", i)
+		}
+	}
+}
+
+func TestCommandTracker_Running(t *testing.T) {
+	tracker := NewCommandTracker(nil, nil)
+	tracker.Feed([]byte(markA + markB + markC("sleep") + "zz"))
+	tracker.Feed([]byte("z"))
+
+	cmds := tracker.Commands()
+	require.Len(t, cmds, 1)
+	assert.True(t, cmds[0].Running)
+	assert.Nil(t, cmds[0].ExitCode)
+	assert.Equal(t, int64(3), cmds[0].OutputEnd-cmds[0].OutputStart)
+
+	// A prompt without D, e.g. after the shell was interrupted, ends the
+	// command without an exit code.
+	tracker.Feed([]byte(markA))
+	cmds = tracker.Commands()
+	assert.False(t, cmds[0].Running)
+	assert.Nil(t, cmds[0].ExitCode)
+}
+
+func TestCommandTracker_TypedCommand(t *testing.T) {
+	tracker := NewCommandTracker(nil, nil)
+	// no command line in C: taken from the echoed input, with a
+	// backspace and a color escape
+	tracker.Feed([]byte(markA + "$ " + markB + "gitt\b \b\x1b[32m status\x1b[0m\r\n\x1b]133;C\x07"))
+
+	cmds := tracker.Commands()
+	require.Len(t, cmds, 1)
+	assert.Equal(t, "git status", cmds[0].Command)
+}
+
+func TestCommandTracker_IgnoresOtherSequences(t *testing.T) {
+	tracker := NewCommandTracker(nil, nil)
+	tracker.Feed([]byte("\x1b]0;title\x07\x1b[31mred\x1b]133;Z\x07\x1b]133\x07"))
+	assert.Empty(t, tracker.Commands())
+}
+
+func TestCommandTracker_Trim(t *testing.T) {
+	tracker := NewCommandTracker(nil, nil)
+	var b strings.Builder
+	for range MaxCommandHistory + 5 {
+		b.WriteString(markA + markB + markC("x") + markD("0"))
+	}
+	tracker.Feed([]byte(b.String()))
+
+	cmds := tracker.Commands()
+	require.Len(t, cmds, MaxCommandHistory)
+	assert.Equal(t, 6, cmds[0].ID)
+	_, ok := tracker.Command(5)
+	assert.False(t, ok)
+}
+
+func TestCommandTracker_Output(t *testing.T) {
+	buffer := sdkexec.NewDefaultOutputBuffer()
+	tracker := NewCommandTracker(buffer, nil)
+	tracker.Feed([]byte(session))
+	assert.Equal(t, session, string(buffer.GetAll()))
+
+	out, ok := tracker.Output(1)
+	require.True(t, ok)
+	assert.Equal(t, "a\nb\n", out.Output)
+	assert.False(t, out.Truncated)
+	assert.Equal(t, "ls -la", out.Command.Command)
+
+	_, ok = tracker.Output(99)
+	assert.False(t, ok)
+}
+
+func TestCommandTracker_OutputEvicted(t *testing.T) {
+	buffer := sdkexec.NewOutputBuffer(19)
+	tracker := NewCommandTracker(buffer, nil)
+	tracker.Feed([]byte(markA + markB + markC("yes") + strings.Repeat("y\n", 20) + markD("130")))
+
+	out, ok := tracker.Output(1)
+	require.True(t, ok)
+	assert.True(t, out.Truncated)
+	// the D marker takes 13 of the 19 buffered bytes
+	assert.Equal(t, "y\ny\ny\n", out.Output)
+	assert.Equal(t, 130, *out.Command.ExitCode)
+
+	data, dropped := tracker.Snapshot()
+	assert.Len(t, data, 19)
+	assert.Equal(t, tracker.Offset()-19, dropped)
+}
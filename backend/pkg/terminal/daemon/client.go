@@ -0,0 +1,302 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrClosed is returned for requests on a closed or disconnected client.
+var ErrClosed = errors.New("terminal daemon connection closed")
+
+const (
+	requestTimeout = 10 * time.Second
+	spawnTimeout   = 5 * time.Second
+)
+
+// Client is a connection to the daemon.
+type Client struct {
+	conn    net.Conn
+	handler func(Event)
+
+	wmu sync.Mutex
+	enc *json.Encoder
+
+	mu      sync.Mutex
+	seq     uint64
+	pending map[uint64]*call
+	closed  bool
+
+	// Events are handed to the handler on a separate goroutine, in order,
+	// so a handler that blocks does not hold up replies.
+	qmu    sync.Mutex
+	qcond  *sync.Cond
+	queue  []func()
+	qclose bool
+}
+
+type call struct {
+	done chan Message
+	// onReply, if set, runs on the event goroutine before the reply is
+	// delivered, after the events that preceded the reply and ahead of any
+	// that follow it.
+	onReply func(Message)
+}
+
+// Dial connects to a running daemon. handler receives every event, in
+// order on a single goroutine, and a final EventDisconnect when the
+// connection is lost.
+func Dial(socketPath string, handler func(Event)) (*Client, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:    conn,
+		handler: handler,
+		enc:     json.NewEncoder(conn),
+		pending: make(map[uint64]*call),
+	}
+	c.qcond = sync.NewCond(&c.qmu)
+	go c.read()
+	go c.deliver()
+	return c, nil
+}
+
+// Connect dials the daemon at socketPath, first starting it by re-executing
+// executable with InvocationArg if none is running.
+func Connect(socketPath, executable string, handler func(Event)) (*Client, error) {
+	if c, err := Dial(socketPath, handler); err == nil {
+		return c, nil
+	}
+	if err := Spawn(socketPath, executable); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(spawnTimeout)
+	for {
+		c, err := Dial(socketPath, handler)
+		if err == nil {
+			return c, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// Spawn starts the daemon in its own session, detached from the calling
+// process so it survives it.
+func Spawn(socketPath, executable string) error {
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0o700); err != nil {
+		return err
+	}
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+
+	//nolint:gosec // re-executes the application binary
+	cmd := exec.Command(executable, InvocationArg, socketPath)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = devNull, devNull, devNull
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reap the daemon if it exits while we are still running.
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// Close closes the connection. Sessions keep running in the daemon.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Start starts a session and subscribes to its events.
+func (c *Client) Start(opts StartOptions) (SessionInfo, error) {
+	msg, err := c.do(Request{Op: OpStart, Start: &opts}, nil)
+	if err != nil {
+		return SessionInfo{}, err
+	}
+	return *msg.Session, nil
+}
+
+// List returns the daemon's sessions.
+func (c *Client) List() ([]SessionInfo, error) {
+	msg, err := c.do(Request{Op: OpList}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Sessions, nil
+}
+
+// Attach subscribes to a session's events. restore is called with the
+// session and its scrollback before any output that follows it is passed to
+// the event handler.
+func (c *Client) Attach(id string, restore func(info SessionInfo, scrollback []byte)) error {
+	_, err := c.do(Request{Op: OpAttach, ID: id}, func(msg Message) {
+		if msg.Error == "" && msg.Session != nil {
+			restore(*msg.Session, msg.Data)
+		}
+	})
+	return err
+}
+
+// Write writes input to a session.
+func (c *Client) Write(id string, data []byte) error {
+	_, err := c.do(Request{Op: OpWrite, ID: id, Data: data}, nil)
+	return err
+}
+
+// Resize resizes a session's PTY.
+func (c *Client) Resize(id string, rows, cols uint16) error {
+	_, err := c.do(Request{Op: OpResize, ID: id, Rows: rows, Cols: cols}, nil)
+	return err
+}
+
+// CloseSession ends a session's process.
+func (c *Client) CloseSession(id string) error {
+	_, err := c.do(Request{Op: OpClose, ID: id}, nil)
+	return err
+}
+
+func (c *Client) do(req Request, onReply func(Message)) (Message, error) {
+	cl := &call{done: make(chan Message, 1), onReply: onReply}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return Message{}, ErrClosed
+	}
+	c.seq++
+	req.Seq = c.seq
+	c.pending[req.Seq] = cl
+	c.mu.Unlock()
+
+	c.wmu.Lock()
+	err := c.enc.Encode(req)
+	c.wmu.Unlock()
+	if err != nil {
+		c.forget(req.Seq)
+		return Message{}, err
+	}
+
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+	select {
+	case msg, ok := <-cl.done:
+		if !ok {
+			return Message{}, ErrClosed
+		}
+		if msg.Error != "" {
+			return msg, errors.New(msg.Error)
+		}
+		return msg, nil
+	case <-timer.C:
+		c.forget(req.Seq)
+		return Message{}, errors.New("terminal daemon did not respond")
+	}
+}
+
+func (c *Client) forget(seq uint64) {
+	c.mu.Lock()
+	delete(c.pending, seq)
+	c.mu.Unlock()
+}
+
+func (c *Client) read() {
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Seq == 0 {
+			if msg.Event != "" {
+				event := Event{Type: msg.Event, ID: msg.ID, Data: msg.Data, ExitCode: -1}
+				if msg.ExitCode != nil {
+					event.ExitCode = *msg.ExitCode
+				}
+				c.enqueue(func() { c.handle(event) })
+			}
+			continue
+		}
+		c.mu.Lock()
+		cl, ok := c.pending[msg.Seq]
+		delete(c.pending, msg.Seq)
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+		if cl.onReply != nil {
+			c.enqueue(func() {
+				cl.onReply(msg)
+				cl.done <- msg
+			})
+			continue
+		}
+		cl.done <- msg
+	}
+
+	c.conn.Close()
+	c.mu.Lock()
+	c.closed = true
+	pending := c.pending
+	c.pending = make(map[uint64]*call)
+	c.mu.Unlock()
+	c.enqueue(func() {
+		for _, cl := range pending {
+			close(cl.done)
+		}
+		c.handle(Event{Type: EventDisconnect})
+	})
+
+	c.qmu.Lock()
+	c.qclose = true
+	c.qcond.Signal()
+	c.qmu.Unlock()
+}
+
+func (c *Client) handle(event Event) {
+	if c.handler != nil {
+		c.handler(event)
+	}
+}
+
+func (c *Client) enqueue(fn func()) {
+	c.qmu.Lock()
+	c.queue = append(c.queue, fn)
+	c.qcond.Signal()
+	c.qmu.Unlock()
+}
+
+// deliver runs queued event callbacks until the connection is closed and
+// the queue drained.
+func (c *Client) deliver() {
+	for {
+		c.qmu.Lock()
+		for len(c.queue) == 0 && !c.qclose {
+			c.qcond.Wait()
+		}
+		if len(c.queue) == 0 {
+			c.qmu.Unlock()
+			return
+		}
+		fn := c.queue[0]
+		c.queue[0] = nil
+		c.queue = c.queue[1:]
+		c.qmu.Unlock()
+		fn()
+	}
+}
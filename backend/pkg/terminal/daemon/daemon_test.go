@@ -0,0 +1,279 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startServer serves a daemon on a socket in a fresh temp directory.
+func startServer(t *testing.T, idle time.Duration) (string, *Server) {
+	t.Helper()
+	// Keep the path short: Unix socket paths are limited to ~100 bytes.
+	dir, err := os.MkdirTemp("", "termd")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "s.sock")
+
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := NewServer(idle)
+	go srv.Serve(l) //nolint:errcheck // ends with the test
+	t.Cleanup(srv.Close)
+	return socket, srv
+}
+
+// recorder collects events from a client.
+type recorder struct {
+	mu     sync.Mutex
+	output map[string]*strings.Builder
+	exits  map[string]int
+	events []string
+}
+
+func newRecorder() *recorder {
+	return &recorder{output: make(map[string]*strings.Builder), exits: make(map[string]int)}
+}
+
+func (r *recorder) handle(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e.Type)
+	switch e.Type {
+	case EventOutput:
+		if r.output[e.ID] == nil {
+			r.output[e.ID] = &strings.Builder{}
+		}
+		r.output[e.ID].Write(e.Data)
+	case EventExit:
+		r.exits[e.ID] = e.ExitCode
+	}
+}
+
+func (r *recorder) text(id string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b := r.output[id]; b != nil {
+		return b.String()
+	}
+	return ""
+}
+
+func (r *recorder) exited(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.exits[id]
+	return ok
+}
+
+func (r *recorder) exitCode(id string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exits[id]
+}
+
+func shellOptions(id, script string) StartOptions {
+	return StartOptions{
+		ID:      id,
+		Path:    "/bin/sh",
+		Args:    []string{"-c", script},
+		Env:     []string{"PATH=/usr/bin:/bin"},
+		Rows:    24,
+		Cols:    80,
+		Command: []string{"sh"},
+		Labels:  map[string]string{"k": "v"},
+	}
+}
+
+func TestDaemon_SessionLifecycle(t *testing.T) {
+	socket, _ := startServer(t, 0)
+	events := newRecorder()
+	client, err := Dial(socket, events.handle)
+	require.NoError(t, err)
+	defer client.Close()
+
+	info, err := client.Start(shellOptions("s1", "echo ready; cat"))
+	require.NoError(t, err)
+	assert.Equal(t, "s1", info.ID)
+	assert.Positive(t, info.Pid)
+	assert.Equal(t, map[string]string{"k": "v"}, info.Labels)
+
+	require.Eventually(t, func() bool { return strings.Contains(events.text("s1"), "ready") }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.Write("s1", []byte("ping\n")))
+	require.Eventually(t, func() bool { return strings.Count(events.text("s1"), "ping") >= 2 }, 5*time.Second, 10*time.Millisecond,
+		"the PTY echoes the input and cat repeats it")
+	require.NoError(t, client.Resize("s1", 40, 120))
+
+	sessions, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	_, err = client.Start(shellOptions("s1", "true"))
+	assert.Error(t, err, "duplicate ID")
+	assert.Error(t, client.Write("missing", []byte("x")))
+
+	require.NoError(t, client.CloseSession("s1"))
+	require.Eventually(t, func() bool { return events.exited("s1") }, 5*time.Second, 10*time.Millisecond)
+	sessions, err = client.List()
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestDaemon_ReportsExitCode(t *testing.T) {
+	socket, _ := startServer(t, 0)
+	events := newRecorder()
+	client, err := Dial(socket, events.handle)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Start(shellOptions("s1", "echo bye; exit 7"))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return events.exited("s1") }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 7, events.exitCode("s1"))
+	assert.Contains(t, events.text("s1"), "bye")
+}
+
+func TestDaemon_ReattachRestoresScrollback(t *testing.T) {
+	socket, _ := startServer(t, 0)
+	first, err := Dial(socket, nil)
+	require.NoError(t, err)
+	_, err = first.Start(shellOptions("s1", "echo before; cat"))
+	require.NoError(t, err)
+
+	// The application quits: the connection closes, the session keeps running.
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, first.Close())
+
+	events := newRecorder()
+	second, err := Dial(socket, events.handle)
+	require.NoError(t, err)
+	defer second.Close()
+
+	var scrollback string
+	require.Eventually(t, func() bool {
+		err := second.Attach("s1", func(info SessionInfo, data []byte) {
+			assert.Equal(t, "s1", info.ID)
+			scrollback = string(data)
+		})
+		return err == nil && strings.Contains(scrollback, "before")
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, second.Write("s1", []byte("after\n")))
+	require.Eventually(t, func() bool { return strings.Contains(events.text("s1"), "after") }, 5*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, events.text("s1"), "before", "scrollback is not repeated as output")
+
+	assert.Error(t, second.Attach("missing", func(SessionInfo, []byte) {}))
+}
+
+func TestDaemon_StalledClientDoesNotBlockOthers(t *testing.T) {
+	socket, _ := startServer(t, 0)
+	events := newRecorder()
+	client, err := Dial(socket, events.handle)
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Start(shellOptions("flood", "read x; yes"))
+	require.NoError(t, err)
+	_, err = client.Start(shellOptions("quiet", "read x; echo pong; cat"))
+	require.NoError(t, err)
+
+	// A client that attaches to the flooding session and never reads.
+	stalled, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	defer stalled.Close()
+	_, err = stalled.Write([]byte(`{"seq":1,"op":"attach","id":"flood"}` + "\n"))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, client.Write("flood", []byte("go\n")))
+	time.Sleep(500 * time.Millisecond)
+
+	started := time.Now()
+	_, err = client.List()
+	require.NoError(t, err)
+	assert.Less(t, time.Since(started), time.Second, "requests are served while a client stalls")
+
+	require.NoError(t, client.Write("quiet", []byte("go\n")))
+	require.Eventually(t, func() bool { return strings.Contains(events.text("quiet"), "pong") }, 2*time.Second, 10*time.Millisecond,
+		"other sessions keep sending output")
+}
+
+func TestDaemon_ExitEventAndTempFiles(t *testing.T) {
+	socket, _ := startServer(t, 0)
+	events := newRecorder()
+	client, err := Dial(socket, events.handle)
+	require.NoError(t, err)
+	defer client.Close()
+
+	temp := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(temp, nil, 0o600))
+	opts := shellOptions("s1", "exit 0")
+	opts.TempFiles = []string{temp}
+	_, err = client.Start(opts)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return events.exited("s1") }, 5*time.Second, 10*time.Millisecond)
+	assert.NoFileExists(t, temp)
+}
+
+func TestDaemon_DisconnectEvent(t *testing.T) {
+	socket, srv := startServer(t, 0)
+	events := newRecorder()
+	client, err := Dial(socket, events.handle)
+	require.NoError(t, err)
+
+	srv.Close()
+	require.Eventually(t, func() bool {
+		events.mu.Lock()
+		defer events.mu.Unlock()
+		return len(events.events) > 0 && events.events[len(events.events)-1] == EventDisconnect
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = client.List()
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestDaemon_IdleTimeout(t *testing.T) {
+	dir, err := os.MkdirTemp("", "termd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "run", "s.sock")
+
+	done := make(chan error, 1)
+	go func() { done <- Run(socket, 50*time.Millisecond) }()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Error(t, Run(socket, time.Minute), "a second daemon refuses to start")
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not exit when idle")
+	}
+	assert.NoFileExists(t, socket)
+
+	info, err := os.Stat(filepath.Dir(socket))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+}
+
+func TestIsInvocation(t *testing.T) {
+	assert.True(t, IsInvocation([]string{"omniview", InvocationArg, "/tmp/s.sock"}))
+	assert.False(t, IsInvocation([]string{"omniview"}))
+	assert.False(t, IsInvocation([]string{"omniview", "--dev"}))
+}
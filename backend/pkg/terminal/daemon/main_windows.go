@@ -0,0 +1,14 @@
+//go:build windows
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+)
+
+// Main reports that the daemon is not supported on Windows.
+func Main(_ []string) int {
+	fmt.Fprintln(os.Stderr, "terminal daemon: not supported on Windows")
+	return 1
+}
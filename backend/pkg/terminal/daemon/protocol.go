@@ -0,0 +1,116 @@
+// Package daemon implements the terminal session daemon: a helper process
+// that owns local PTYs so shells keep running when the application quits,
+// and a client the terminal manager uses to start, reattach to and drive
+// them.
+//
+// The daemon is the application binary re-executed with InvocationArg. It
+// listens on a Unix socket and speaks newline-delimited JSON: the client
+// sends Requests and the daemon answers each with a Message carrying the
+// same Seq. Output and exit events for the sessions a client has started or
+// attached to arrive as Messages with Seq 0.
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// InvocationArg is the first argument that starts the binary as the daemon.
+const InvocationArg = "__terminal-daemon"
+
+// Request operations.
+const (
+	OpStart  = "start"
+	OpList   = "list"
+	OpAttach = "attach"
+	OpWrite  = "write"
+	OpResize = "resize"
+	OpClose  = "close"
+)
+
+// Event types.
+const (
+	// EventOutput carries PTY output in Data.
+	EventOutput = "output"
+	// EventExit reports that the session's process has exited.
+	EventExit = "exit"
+	// EventDisconnect is delivered by the client, not the daemon, when the
+	// connection to the daemon is lost.
+	EventDisconnect = "disconnect"
+)
+
+// Request is a message from the client to the daemon.
+type Request struct {
+	Seq   uint64        `json:"seq"`
+	Op    string        `json:"op"`
+	ID    string        `json:"id,omitempty"`
+	Start *StartOptions `json:"start,omitempty"`
+	Data  []byte        `json:"data,omitempty"`
+	Rows  uint16        `json:"rows,omitempty"`
+	Cols  uint16        `json:"cols,omitempty"`
+}
+
+// Message is a reply (Seq set) or an event (Event set) from the daemon.
+type Message struct {
+	Seq      uint64        `json:"seq,omitempty"`
+	Event    string        `json:"event,omitempty"`
+	ID       string        `json:"id,omitempty"`
+	Data     []byte        `json:"data,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	ExitCode *int          `json:"exitCode,omitempty"`
+	Session  *SessionInfo  `json:"session,omitempty"`
+	Sessions []SessionInfo `json:"sessions,omitempty"`
+}
+
+// StartOptions describes the process to run in a new session.
+type StartOptions struct {
+	ID   string   `json:"id"`
+	Path string   `json:"path"`
+	Args []string `json:"args"`
+	Env  []string `json:"env"`
+	Dir  string   `json:"dir"`
+	Rows uint16   `json:"rows"`
+	Cols uint16   `json:"cols"`
+	// Command, Labels and Params are the session's exec options, kept so a
+	// reattaching manager can restore the session as it was created.
+	Command []string          `json:"command"`
+	Labels  map[string]string `json:"labels"`
+	Params  map[string]string `json:"params"`
+	// TempFiles are removed when the session ends.
+	TempFiles []string `json:"tempFiles"`
+}
+
+// SessionInfo describes a session owned by the daemon.
+type SessionInfo struct {
+	ID        string            `json:"id"`
+	Pid       int               `json:"pid"`
+	Command   []string          `json:"command"`
+	Labels    map[string]string `json:"labels"`
+	Params    map[string]string `json:"params"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// Event is an output, exit or disconnect notification delivered to the
+// client's handler.
+type Event struct {
+	Type string
+	ID   string
+	Data []byte
+	// ExitCode is the exit code of an exit event, -1 if the process was
+	// killed by a signal.
+	ExitCode int
+}
+
+// IsInvocation reports whether args (typically os.Args) start the daemon.
+func IsInvocation(args []string) bool {
+	return len(args) > 1 && args[1] == InvocationArg
+}
+
+// DefaultSocketPath returns the per-user socket path, in a directory only
+// the user can access. Unix socket paths are limited to about 100 bytes, so
+// it lives under the temp directory rather than the state directory.
+func DefaultSocketPath() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("omniview-%d", os.Getuid()), "terminal.sock")
+}
@@ -0,0 +1,539 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/google/uuid"
+
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+)
+
+const (
+	// DefaultIdleTimeout is how long the daemon waits with no sessions and
+	// no clients before exiting.
+	DefaultIdleTimeout = time.Minute
+	readBufferSize     = 20480
+	writeTimeout       = 10 * time.Second
+	// sendQueueSize bounds the messages waiting to be written to a client.
+	// Output for a client with a full queue waits for room, up to
+	// writeTimeout.
+	sendQueueSize = 256
+	// maxMessageSize bounds a single request line.
+	maxMessageSize = 4 << 20
+)
+
+// Server owns PTY sessions and serves them to clients.
+type Server struct {
+	idleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	clients  map[*serverConn]struct{}
+	idle     *time.Timer
+	done     chan struct{}
+	closed   bool
+}
+
+type session struct {
+	info      SessionInfo
+	ptmx      *os.File
+	cmd       *exec.Cmd
+	tempFiles []string
+	buffer    *sdkexec.OutputBuffer
+
+	// subscribers receive the session's events. Guarded by Server.mu.
+	subscribers map[*serverConn]struct{}
+}
+
+// errSlowClient is returned by send for a client that stopped reading.
+var errSlowClient = errors.New("client is not reading")
+
+// serverConn is one client connection. Messages are queued and written by
+// the connection's own goroutine, so a client that stops reading holds up
+// only the sessions it is attached to, and only until it is disconnected.
+type serverConn struct {
+	conn      net.Conn
+	out       chan Message
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newServerConn(conn net.Conn) *serverConn {
+	c := &serverConn{conn: conn, out: make(chan Message, sendQueueSize), done: make(chan struct{})}
+	go c.writeLoop()
+	return c
+}
+
+// send queues msg for the client, waiting for room for up to writeTimeout.
+// A client that does not drain its queue in time is disconnected.
+func (c *serverConn) send(msg Message) error {
+	if err := c.trySend(msg); !errors.Is(err, errSlowClient) {
+		return err
+	}
+	timer := time.NewTimer(writeTimeout)
+	defer timer.Stop()
+	select {
+	case c.out <- msg:
+		return nil
+	case <-c.done:
+		return net.ErrClosed
+	case <-timer.C:
+		c.close()
+		return errSlowClient
+	}
+}
+
+// trySend queues msg without waiting, for callers holding Server.mu. It
+// returns errSlowClient if the queue is full.
+func (c *serverConn) trySend(msg Message) error {
+	select {
+	case <-c.done:
+		return net.ErrClosed
+	default:
+	}
+	select {
+	case c.out <- msg:
+		return nil
+	default:
+		return errSlowClient
+	}
+}
+
+func (c *serverConn) writeLoop() {
+	enc := json.NewEncoder(c.conn)
+	for {
+		select {
+		case msg := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := enc.Encode(msg); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// close closes the connection, dropping the messages not yet written.
+func (c *serverConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// NewServer returns a server that exits Serve after idleTimeout without
+// sessions or clients. A zero idleTimeout disables the timeout.
+func NewServer(idleTimeout time.Duration) *Server {
+	return &Server{
+		idleTimeout: idleTimeout,
+		sessions:    make(map[string]*session),
+		clients:     make(map[*serverConn]struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Serve accepts clients on l until the server is closed or idle.
+func (s *Server) Serve(l net.Listener) error {
+	go func() {
+		<-s.done
+		l.Close()
+	}()
+	s.mu.Lock()
+	s.checkIdleLocked()
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops serving and ends every session.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	sessions := s.sessions
+	s.sessions = make(map[string]*session)
+	for c := range s.clients {
+		c.close()
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.kill()
+	}
+}
+
+// checkIdleLocked arms the idle timer when nothing is left to serve and
+// disarms it otherwise. Caller must hold s.mu.
+func (s *Server) checkIdleLocked() {
+	if s.idleTimeout <= 0 || s.closed {
+		return
+	}
+	if len(s.sessions) > 0 || len(s.clients) > 0 {
+		if s.idle != nil {
+			s.idle.Stop()
+			s.idle = nil
+		}
+		return
+	}
+	if s.idle == nil {
+		s.idle = time.AfterFunc(s.idleTimeout, s.Close)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	c := newServerConn(conn)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		c.close()
+		return
+	}
+	s.clients[c] = struct{}{}
+	s.checkIdleLocked()
+	s.mu.Unlock()
+
+	defer func() {
+		c.close()
+		s.mu.Lock()
+		delete(s.clients, c)
+		for _, sess := range s.sessions {
+			delete(sess.subscribers, c)
+		}
+		s.checkIdleLocked()
+		s.mu.Unlock()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			_ = c.send(Message{Error: "malformed request: " + err.Error()})
+			continue
+		}
+		var err error
+		if req.Op == OpAttach {
+			err = s.attach(c, req)
+		} else {
+			reply := s.handle(c, req)
+			reply.Seq = req.Seq
+			err = c.send(reply)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(c *serverConn, req Request) Message {
+	switch req.Op {
+	case OpStart:
+		if req.Start == nil {
+			return Message{Error: "missing start options"}
+		}
+		info, err := s.start(c, *req.Start)
+		if err != nil {
+			return Message{Error: err.Error()}
+		}
+		return Message{Session: &info}
+	case OpList:
+		return Message{Sessions: s.list()}
+	case OpWrite:
+		return errorMessage(s.withSession(req.ID, func(sess *session) error {
+			_, err := sess.ptmx.Write(req.Data)
+			return err
+		}))
+	case OpResize:
+		return errorMessage(s.withSession(req.ID, func(sess *session) error {
+			return pty.Setsize(sess.ptmx, &pty.Winsize{Rows: req.Rows, Cols: req.Cols})
+		}))
+	case OpClose:
+		s.mu.Lock()
+		sess, ok := s.sessions[req.ID]
+		s.mu.Unlock()
+		if !ok {
+			return Message{Error: errNotFound(req.ID).Error()}
+		}
+		sess.kill()
+		return Message{}
+	default:
+		return Message{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+}
+
+func errorMessage(err error) Message {
+	if err != nil {
+		return Message{Error: err.Error()}
+	}
+	return Message{}
+}
+
+func errNotFound(id string) error {
+	return fmt.Errorf("session %s not found", id)
+}
+
+func (s *Server) withSession(id string, fn func(*session) error) error {
+	s.mu.Lock()
+	sess, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok {
+		return errNotFound(id)
+	}
+	return fn(sess)
+}
+
+func (s *Server) start(c *serverConn, opts StartOptions) (SessionInfo, error) {
+	if opts.Path == "" {
+		return SessionInfo{}, errors.New("missing command path")
+	}
+	if opts.ID == "" {
+		opts.ID = uuid.NewString()
+	}
+	s.mu.Lock()
+	_, exists := s.sessions[opts.ID]
+	s.mu.Unlock()
+	if exists {
+		return SessionInfo{}, fmt.Errorf("session %s already exists", opts.ID)
+	}
+
+	//nolint:gosec // runs the shell the application asked for
+	cmd := exec.Command(opts.Path, opts.Args...)
+	cmd.Env = opts.Env
+	cmd.Dir = opts.Dir
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Rows: opts.Rows, Cols: opts.Cols})
+	if err != nil {
+		removeAll(opts.TempFiles)
+		return SessionInfo{}, err
+	}
+
+	sess := &session{
+		info: SessionInfo{
+			ID:        opts.ID,
+			Pid:       cmd.Process.Pid,
+			Command:   opts.Command,
+			Labels:    opts.Labels,
+			Params:    opts.Params,
+			CreatedAt: time.Now(),
+		},
+		ptmx:        ptmx,
+		cmd:         cmd,
+		tempFiles:   opts.TempFiles,
+		buffer:      sdkexec.NewDefaultOutputBuffer(),
+		subscribers: map[*serverConn]struct{}{c: {}},
+	}
+	s.mu.Lock()
+	s.sessions[opts.ID] = sess
+	s.checkIdleLocked()
+	s.mu.Unlock()
+
+	go s.pump(sess)
+	return sess.info, nil
+}
+
+func (s *Server) list() []SessionInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]SessionInfo, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		infos = append(infos, sess.info)
+	}
+	return infos
+}
+
+// attach subscribes c to a session and queues its scrollback as the reply.
+// Both happen under s.mu, which pump also holds to buffer output and take
+// the subscribers to send it to, so the client sees every byte exactly once
+// and in order.
+func (s *Server) attach(c *serverConn, req Request) error {
+	s.mu.Lock()
+	sess, ok := s.sessions[req.ID]
+	if !ok {
+		s.mu.Unlock()
+		return c.send(Message{Seq: req.Seq, Error: errNotFound(req.ID).Error()})
+	}
+	info := sess.info
+	err := c.trySend(Message{Seq: req.Seq, Session: &info, Data: sess.buffer.GetAll()})
+	if err == nil {
+		sess.subscribers[c] = struct{}{}
+	}
+	s.mu.Unlock()
+	return err
+}
+
+// pump reads a session's output until the process exits.
+func (s *Server) pump(sess *session) {
+	buf := make([]byte, readBufferSize)
+	for {
+		n, err := sess.ptmx.Read(buf)
+		if n > 0 {
+			data := append([]byte(nil), buf[:n]...)
+			s.mu.Lock()
+			sess.buffer.Append(data)
+			subscribers := sess.subscriberList()
+			s.mu.Unlock()
+			s.broadcast(sess, subscribers, Message{Event: EventOutput, ID: sess.info.ID, Data: data})
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	code := exitCode(sess.cmd.Wait())
+	sess.ptmx.Close()
+	removeAll(sess.tempFiles)
+
+	s.mu.Lock()
+	if s.sessions[sess.info.ID] == sess {
+		delete(s.sessions, sess.info.ID)
+	}
+	subscribers := sess.subscriberList()
+	s.checkIdleLocked()
+	s.mu.Unlock()
+	s.broadcast(sess, subscribers, Message{Event: EventExit, ID: sess.info.ID, ExitCode: &code})
+}
+
+// broadcast sends msg to the given subscribers of sess, unsubscribing the
+// ones that can no longer be sent to. It must be called without s.mu held.
+func (s *Server) broadcast(sess *session, subscribers []*serverConn, msg Message) {
+	for _, c := range subscribers {
+		if err := c.send(msg); err != nil {
+			s.mu.Lock()
+			delete(sess.subscribers, c)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// subscriberList returns the session's subscribers. Caller must hold s.mu.
+func (sess *session) subscriberList() []*serverConn {
+	subscribers := make([]*serverConn, 0, len(sess.subscribers))
+	for c := range sess.subscribers {
+		subscribers = append(subscribers, c)
+	}
+	return subscribers
+}
+
+// exitCode returns the exit code for the result of cmd.Wait, -1 if the
+// process was killed by a signal or did not run.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// kill hangs up the session's process group. pump notices the exit and
+// cleans up.
+func (sess *session) kill() {
+	if sess.cmd.Process != nil {
+		// The shell is the session leader of its PTY; signal its group.
+		if err := syscall.Kill(-sess.cmd.Process.Pid, syscall.SIGHUP); err != nil {
+			_ = sess.cmd.Process.Kill()
+		}
+	}
+	sess.ptmx.Close()
+}
+
+func removeAll(files []string) {
+	for _, f := range files {
+		os.Remove(f)
+	}
+}
+
+// Run serves on socketPath until the daemon is idle. It refuses to start if
+// another daemon is already listening there.
+func Run(socketPath string, idleTimeout time.Duration) error {
+	if err := prepareSocketDir(filepath.Dir(socketPath)); err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", socketPath); err == nil {
+		conn.Close()
+		return errors.New("a terminal daemon is already running")
+	}
+	// A socket left behind by a daemon that crashed.
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(socketPath, 0o600); err != nil {
+		l.Close()
+		return err
+	}
+	srv := NewServer(idleTimeout)
+	defer srv.Close()
+	err = srv.Serve(l)
+	os.Remove(socketPath)
+	return err
+}
+
+// prepareSocketDir creates dir, or checks that an existing one is private
+// to the current user, since anyone who can reach the socket can type into
+// the user's shells.
+func prepareSocketDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by another user", dir)
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return os.Chmod(dir, 0o700)
+	}
+	return nil
+}
+
+// Main runs the daemon for the arguments following InvocationArg and returns
+// the process exit code.
+func Main(args []string) int {
+	socketPath := DefaultSocketPath()
+	if len(args) > 0 {
+		socketPath = args[0]
+	}
+	if err := Run(socketPath, DefaultIdleTimeout); err != nil {
+		fmt.Fprintln(os.Stderr, "terminal daemon:", err)
+		return 1
+	}
+	return 0
+}
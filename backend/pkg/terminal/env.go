@@ -0,0 +1,107 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// shellEnv builds the environment of a local shell: the host environment,
+// the shell identification variables, then the session's contributions.
+// Later entries win, so session values override inherited ones.
+//
+// kubeOverride, if set, is a kubeconfig file that only selects opts.Context;
+// it is placed first in KUBECONFIG so its current-context takes precedence
+// over the files after it.
+func shellEnv(base []string, shell string, opts SessionOptions, kubeOverride string) []string {
+	env := append([]string(nil), base...)
+	env = append(env, "SHELL="+shell, "TERM=xterm-256color")
+
+	for k, v := range opts.Env {
+		env = append(env, k+"="+v)
+	}
+
+	kubeconfig := opts.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = lookupEnv(env, "KUBECONFIG")
+	}
+	if kubeOverride != "" {
+		if kubeconfig == "" {
+			kubeconfig = defaultKubeconfig()
+		}
+		kubeconfig = kubeOverride + string(os.PathListSeparator) + kubeconfig
+	}
+	if kubeconfig != "" {
+		env = append(env, "KUBECONFIG="+kubeconfig)
+	}
+	return env
+}
+
+// lookupEnv returns the last value of key in env, matching os/exec's
+// handling of duplicates.
+func lookupEnv(env []string, key string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(env[i], key+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func defaultKubeconfig() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// writeContextOverride writes a kubeconfig that sets only current-context.
+// kubectl and client-go merge KUBECONFIG files in order and take
+// current-context from the first file that sets it, so listing this file
+// first selects the context without modifying the user's kubeconfig.
+// The caller removes the file when the session ends.
+func writeContextOverride(kubeContext string) (string, error) {
+	name, err := json.Marshal(kubeContext) // a JSON string is a valid YAML scalar
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "omniview-kubecontext-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	_, err = fmt.Fprintf(f, "apiVersion: v1\nkind: Config\ncurrent-context: %s\n", name)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// resolveDir expands a leading "~" in dir and checks that it is a directory.
+// An empty dir stays empty, leaving the shell in the process's directory.
+func resolveDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return dir, nil
+}
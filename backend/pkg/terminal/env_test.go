@@ -0,0 +1,64 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellEnv(t *testing.T) {
+	base := []string{"PATH=/usr/bin", "AWS_PROFILE=default", "KUBECONFIG=/etc/kube"}
+
+	env := shellEnv(base, "/bin/zsh", SessionOptions{Env: map[string]string{"AWS_PROFILE": "prod"}}, "")
+	assert.Equal(t, "/bin/zsh", lookupEnv(env, "SHELL"))
+	assert.Equal(t, "prod", lookupEnv(env, "AWS_PROFILE"), "session env overrides inherited values")
+	assert.Equal(t, "/etc/kube", lookupEnv(env, "KUBECONFIG"))
+	assert.Equal(t, []string{"PATH=/usr/bin", "AWS_PROFILE=default", "KUBECONFIG=/etc/kube"}, base, "base is not modified")
+
+	env = shellEnv(base, "/bin/zsh", SessionOptions{Kubeconfig: "/tmp/prod"}, "/tmp/override")
+	sep := string(os.PathListSeparator)
+	assert.Equal(t, "/tmp/override"+sep+"/tmp/prod", lookupEnv(env, "KUBECONFIG"))
+
+	env = shellEnv([]string{"PATH=/usr/bin"}, "/bin/sh", SessionOptions{}, "/tmp/override")
+	kubeconfig := lookupEnv(env, "KUBECONFIG")
+	assert.True(t, strings.HasPrefix(kubeconfig, "/tmp/override"+sep), kubeconfig)
+	assert.True(t, strings.HasSuffix(kubeconfig, filepath.Join(".kube", "config")), kubeconfig)
+}
+
+func TestWriteContextOverride(t *testing.T) {
+	path, err := writeContextOverride(`arn:aws:eks:us-east-1:1234:cluster/prod "x"`)
+	require.NoError(t, err)
+	t.Cleanup(func() { os.Remove(path) })
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `current-context: "arn:aws:eks:us-east-1:1234:cluster/prod \"x\""`)
+}
+
+func TestResolveDir(t *testing.T) {
+	dir, err := resolveDir("")
+	require.NoError(t, err)
+	assert.Empty(t, dir)
+
+	tmp := t.TempDir()
+	dir, err = resolveDir(tmp)
+	require.NoError(t, err)
+	assert.Equal(t, tmp, dir)
+
+	file := filepath.Join(tmp, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, err = resolveDir(file)
+	assert.Error(t, err)
+	_, err = resolveDir(filepath.Join(tmp, "missing"))
+	assert.Error(t, err)
+
+	if home, err := os.UserHomeDir(); err == nil {
+		dir, err = resolveDir("~")
+		require.NoError(t, err)
+		assert.Equal(t, home, dir)
+	}
+}
@@ -0,0 +1,264 @@
+package terminal
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// Colors of the exported page where the output uses the default colors.
+const (
+	htmlForeground = "#d4d4d4"
+	htmlBackground = "#1e1e1e"
+)
+
+// ansiPalette holds the 16 basic and bright xterm colors.
+//
+//nolint:gochecknoglobals // lookup table
+var ansiPalette = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+// textStyle is the SGR state of the output. Colors are CSS colors, empty
+// for the default.
+type textStyle struct {
+	fg, bg                                              string
+	bold, dim, italic, underline, strike, inverse, hide bool
+}
+
+// css returns the inline style for the state, empty for default text.
+func (s textStyle) css() string {
+	fg, bg := s.fg, s.bg
+	if s.inverse {
+		fg, bg = bg, fg
+		if fg == "" {
+			fg = htmlBackground
+		}
+		if bg == "" {
+			bg = htmlForeground
+		}
+	}
+	var b strings.Builder
+	if fg != "" {
+		fmt.Fprintf(&b, "color:%s;", fg)
+	}
+	if bg != "" {
+		fmt.Fprintf(&b, "background-color:%s;", bg)
+	}
+	if s.bold {
+		b.WriteString("font-weight:bold;")
+	}
+	if s.dim {
+		b.WriteString("opacity:0.7;")
+	}
+	if s.italic {
+		b.WriteString("font-style:italic;")
+	}
+	switch {
+	case s.underline && s.strike:
+		b.WriteString("text-decoration:underline line-through;")
+	case s.underline:
+		b.WriteString("text-decoration:underline;")
+	case s.strike:
+		b.WriteString("text-decoration:line-through;")
+	}
+	if s.hide {
+		b.WriteString("visibility:hidden;")
+	}
+	return b.String()
+}
+
+// applySGR updates the state with the parameters of an SGR sequence
+// (ESC [ ... m).
+func (s *textStyle) applySGR(params string) {
+	if params == "" {
+		*s = textStyle{}
+		return
+	}
+	codes := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	for i := 0; i < len(codes); i++ {
+		n, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			*s = textStyle{}
+		case n == 1:
+			s.bold = true
+		case n == 2:
+			s.dim = true
+		case n == 3:
+			s.italic = true
+		case n == 4:
+			s.underline = true
+		case n == 7:
+			s.inverse = true
+		case n == 8:
+			s.hide = true
+		case n == 9:
+			s.strike = true
+		case n == 22:
+			s.bold, s.dim = false, false
+		case n == 23:
+			s.italic = false
+		case n == 24:
+			s.underline = false
+		case n == 27:
+			s.inverse = false
+		case n == 28:
+			s.hide = false
+		case n == 29:
+			s.strike = false
+		case n >= 30 && n <= 37:
+			s.fg = ansiPalette[n-30]
+		case n == 39:
+			s.fg = ""
+		case n >= 40 && n <= 47:
+			s.bg = ansiPalette[n-40]
+		case n == 49:
+			s.bg = ""
+		case n >= 90 && n <= 97:
+			s.fg = ansiPalette[n-90+8]
+		case n >= 100 && n <= 107:
+			s.bg = ansiPalette[n-100+8]
+		case n == 38 || n == 48:
+			color, used := extendedColor(codes[i+1:])
+			i += used
+			if color == "" {
+				continue
+			}
+			if n == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// extendedColor parses the arguments of a 38 or 48 SGR code: "5;n" for the
+// 256-color palette or "2;r;g;b" for a direct color. It returns the color
+// and the number of arguments used.
+func extendedColor(args []string) (string, int) {
+	if len(args) == 0 {
+		return "", 0
+	}
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return "", len(args)
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 || n > 255 {
+			return "", 2
+		}
+		return palette256(n), 2
+	case "2":
+		if len(args) < 4 {
+			return "", len(args)
+		}
+		var rgb [3]int
+		for i := range rgb {
+			v, err := strconv.Atoi(args[i+1])
+			if err != nil || v < 0 || v > 255 {
+				return "", 4
+			}
+			rgb[i] = v
+		}
+		return fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]), 4
+	}
+	return "", 1
+}
+
+// palette256 returns a color of the xterm 256-color palette.
+func palette256(n int) string {
+	switch {
+	case n < 16:
+		return ansiPalette[n]
+	case n < 232:
+		n -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	default:
+		v := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", v, v, v)
+	}
+}
+
+// RenderHTML renders terminal output as a standalone HTML page, keeping the
+// colors and text attributes set with SGR sequences. Other escape sequences
+// are removed and line endings are handled as in StripANSI.
+func RenderHTML(data []byte, title string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "</head>\n<body style=\"margin:0;background-color:%s\">\n", htmlBackground)
+	fmt.Fprintf(&b, "<pre style=\"margin:0;padding:8px;color:%s;background-color:%s;"+
+		"font-family:Menlo,Monaco,Consolas,monospace;font-size:13px;white-space:pre-wrap\">", htmlForeground, htmlBackground)
+
+	var style textStyle
+	open := false
+	var text []byte
+	flush := func() {
+		if len(text) == 0 {
+			return
+		}
+		b.WriteString(html.EscapeString(string(text)))
+		text = text[:0]
+	}
+	setStyle := func(next textStyle) {
+		if next == style {
+			return
+		}
+		flush()
+		if open {
+			b.WriteString("</span>")
+			open = false
+		}
+		style = next
+		if css := style.css(); css != "" {
+			fmt.Fprintf(&b, "<span style=\"%s\">", css)
+			open = true
+		}
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == 0x1b && i+1 < len(data):
+			end := skipEscape(data, i)
+			// SGR, but not private sequences such as xterm's CSI > 4 m
+			if data[i+1] == '[' && data[end] == 'm' {
+				if params := string(data[i+2 : end]); !strings.ContainsAny(params, "<=>?") {
+					next := style
+					next.applySGR(params)
+					setStyle(next)
+				}
+			}
+			i = end
+		case c == 0x1b:
+		case c == '\r':
+			if i+1 < len(data) && data[i+1] == '\n' {
+				continue
+			}
+			text = append(text, '\n')
+		case c < 0x20 && c != '\n' && c != '\t':
+		default:
+			text = append(text, c)
+		}
+	}
+	flush()
+	if open {
+		b.WriteString("</span>")
+	}
+	b.WriteString("</pre>\n</body>\n</html>\n")
+	return b.String()
+}
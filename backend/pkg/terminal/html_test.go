@@ -0,0 +1,44 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// body returns the contents of the rendered <pre>.
+func body(t *testing.T, page string) string {
+	t.Helper()
+	start := strings.Index(page, "<pre")
+	start += strings.Index(page[start:], ">") + 1
+	end := strings.LastIndex(page, "</pre>")
+	return page[start:end]
+}
+
+func TestRenderHTML(t *testing.T) {
+	page := RenderHTML([]byte("\x1b[1;31mfail\x1b[0m <ok> & \x1b[38;5;21mblue\x1b[39m\r\n\x1b[48;2;1;2;3mbg\x1b[m\x1b]0;title\x07"), "Session <1>")
+
+	assert.Contains(t, page, "<title>Session &lt;1&gt;</title>")
+	assert.Equal(t,
+		`<span style="color:#cd3131;font-weight:bold;">fail</span> &lt;ok&gt; &amp; `+
+			`<span style="color:#0000ff;">blue</span>`+"\n"+
+			`<span style="background-color:#010203;">bg</span>`,
+		body(t, page))
+}
+
+func TestRenderHTML_Attributes(t *testing.T) {
+	page := RenderHTML([]byte("\x1b[7mrev\x1b[27;4;9mline\x1b[>4;2mx\x1b[2Kz"), "")
+	assert.Equal(t,
+		`<span style="color:#1e1e1e;background-color:#d4d4d4;">rev</span>`+
+			`<span style="text-decoration:underline line-through;">linexz</span>`,
+		body(t, page))
+}
+
+func TestPalette256(t *testing.T) {
+	assert.Equal(t, "#cd3131", palette256(1))
+	assert.Equal(t, "#000000", palette256(16))
+	assert.Equal(t, "#ffffff", palette256(231))
+	assert.Equal(t, "#080808", palette256(232))
+	assert.Equal(t, "#eeeeee", palette256(255))
+}
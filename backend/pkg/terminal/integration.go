@@ -0,0 +1,126 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// integrationScripts holds the startup scripts that make bash, zsh and fish
+// report prompts and commands with OSC 133 markers.
+//
+//go:embed all:integration
+var integrationScripts embed.FS
+
+// WithShellIntegration sets the function consulted, for every new
+// interactive bash, zsh or fish session, on whether to load the shell
+// integration scripts that let the session's command history be tracked.
+func WithShellIntegration(enabled func() bool) ManagerOption {
+	return func(m *Manager) { m.shellIntegration = enabled }
+}
+
+// integrationDir returns the directory the integration scripts are
+// installed in, installing them on first use.
+func (m *Manager) integrationDir() (string, error) {
+	m.integrationMu.Lock()
+	defer m.integrationMu.Unlock()
+	if m.scriptsInstalled {
+		return m.scriptsDir, nil
+	}
+	dir := m.scriptsDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cache, "omniview", "shell-integration")
+	}
+	if err := installIntegrationScripts(dir); err != nil {
+		return "", err
+	}
+	m.scriptsDir = dir
+	m.scriptsInstalled = true
+	return dir, nil
+}
+
+// installIntegrationScripts writes the integration scripts to dir. A file
+// is replaced by renaming, so a shell starting in another session never
+// reads a partial script.
+func installIntegrationScripts(dir string) error {
+	return fs.WalkDir(integrationScripts, "integration", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, strings.TrimPrefix(path, "integration"))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o700)
+		}
+		data, err := integrationScripts.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if current, readErr := os.ReadFile(target); readErr == nil && bytes.Equal(current, data) {
+			return nil
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(target), ".install-*")
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(data)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), target)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+		return err
+	})
+}
+
+// integrateShell returns the arguments and environment that start shell
+// with the integration scripts from dir loaded. Only interactive bash, zsh
+// and fish sessions started without arguments of their own are changed.
+func integrateShell(dir string, command []string, shell string, args, env []string) ([]string, []string) {
+	if len(command) > 1 {
+		return args, env
+	}
+	switch shellName(shell) {
+	case "bash":
+		// --init-file replaces the startup files and is only honored by
+		// non-login shells; the script reads the login files itself.
+		return []string{"--init-file", filepath.Join(dir, "bash.sh"), "-i"}, env
+	case "zsh":
+		env = append(env[:len(env):len(env)], "ZDOTDIR="+filepath.Join(dir, "zsh"))
+		if zdotdir, ok := lookupEnvOK(env[:len(env)-1], "ZDOTDIR"); ok {
+			env = append(env, "OMNIVIEW_USER_ZDOTDIR="+zdotdir)
+		}
+		return args, env
+	case "fish":
+		script := filepath.Join(dir, "fish.fish")
+		return append(args[:len(args):len(args)], "--init-command", "source "+fishQuote(script)), env
+	}
+	return args, env
+}
+
+// lookupEnvOK is lookupEnv that also reports whether key is set.
+func lookupEnvOK(env []string, key string) (string, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(env[i], key+"="); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// fishQuote quotes s as a single fish word.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,103 @@
+# Omniview shell integration for bash. Loaded with --init-file in place of
+# the startup files, so it reads them the way a login shell would and then
+# reports prompts and commands with OSC 133 markers.
+
+if [ -r /etc/profile ]; then . /etc/profile; fi
+if [ -r ~/.bash_profile ]; then
+	. ~/.bash_profile
+elif [ -r ~/.bash_login ]; then
+	. ~/.bash_login
+elif [ -r ~/.profile ]; then
+	. ~/.profile
+fi
+
+if [ -z "$__omniview_integrated" ]; then
+	__omniview_integrated=1
+	__omniview_at_prompt=
+	__omniview_running=
+	__omniview_prompted=
+	__omniview_histnum=
+
+	__omniview_urlencode() {
+		local LC_ALL=C s=$1 out= c i
+		for ((i = 0; i < ${#s}; i++)); do
+			c=${s:i:1}
+			case $c in
+			[a-zA-Z0-9.~_/-]) out+=$c ;;
+			*) printf -v c '%%%02X' "'$c"; out+=$c ;;
+			esac
+		done
+		printf '%s' "$out"
+	}
+
+	# Reports the start of the last command in the history, if it was
+	# not reported yet.
+	__omniview_command_start() {
+		local line num
+		line=$(HISTTIMEFORMAT= builtin history 1)
+		num=${line%%[^0-9 ]*}
+		num=${num// /}
+		if [ -z "$num" ] || [ "$num" = "$__omniview_histnum" ]; then
+			return 1
+		fi
+		__omniview_histnum=$num
+		line=${line#*[0-9] }
+		line=${line#"${line%%[! ]*}"}
+		printf '\e]133;C;cmdline_url=%s\a' "$(__omniview_urlencode "$line")"
+	}
+
+	# First in PROMPT_COMMAND, so $? is still the command's status.
+	__omniview_precmd() {
+		local status=$?
+		__omniview_at_prompt=
+		# Commands that skip the DEBUG trap, such as subshells, are only
+		# seen in the history once they are done.
+		if [ -z "$__omniview_running" ] && [ -n "$__omniview_prompted" ] && __omniview_command_start; then
+			__omniview_running=1
+		fi
+		if [ -n "$__omniview_running" ]; then
+			printf '\e]133;D;%s\a' "$status"
+		fi
+		__omniview_running=
+		return $status
+	}
+
+	# Last in PROMPT_COMMAND, after anything that rewrites PS1.
+	__omniview_prompt() {
+		local status=$?
+		if [ -z "$__omniview_prompted" ]; then
+			# Skip the history loaded from HISTFILE.
+			__omniview_prompted=1
+			__omniview_histnum=$(HISTTIMEFORMAT= builtin history 1)
+			__omniview_histnum=${__omniview_histnum%%[^0-9 ]*}
+			__omniview_histnum=${__omniview_histnum// /}
+		fi
+		printf '\e]133;A\a'
+		case $PS1 in
+		*'133;B'*) ;;
+		*) PS1=$PS1'\[\e]133;B\a\]' ;;
+		esac
+		__omniview_at_prompt=1
+		return $status
+	}
+
+	__omniview_preexec() {
+		[ -n "$__omniview_at_prompt" ] || return
+		[ -z "$COMP_LINE" ] || return
+		case $BASH_COMMAND in __omniview_precmd*) return ;; esac
+		__omniview_at_prompt=
+		__omniview_running=1
+		# Without a new history entry the command is taken from the typed
+		# input.
+		__omniview_command_start || printf '\e]133;C\a'
+	}
+
+	if [[ "$(declare -p PROMPT_COMMAND 2>/dev/null)" == "declare -a"* ]]; then
+		PROMPT_COMMAND=(__omniview_precmd "${PROMPT_COMMAND[@]}" __omniview_prompt)
+	else
+		PROMPT_COMMAND="__omniview_precmd${PROMPT_COMMAND:+
+$PROMPT_COMMAND}
+__omniview_prompt"
+	fi
+	trap '__omniview_preexec' DEBUG
+fi
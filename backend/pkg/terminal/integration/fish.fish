@@ -0,0 +1,26 @@
+# Omniview shell integration for fish. Sourced with --init-command after the
+# user's configuration; reports prompts and commands with OSC 133 markers.
+
+if not set -q __omniview_integrated
+    set -g __omniview_integrated 1
+
+    function __omniview_prompt_start --on-event fish_prompt
+        printf '\e]133;A\a'
+    end
+
+    function __omniview_preexec --on-event fish_preexec
+        printf '\e]133;C;cmdline_url=%s\a' (string escape --style=url -- $argv[1])
+    end
+
+    function __omniview_postexec --on-event fish_postexec
+        printf '\e]133;D;%s\a' $status
+    end
+
+    if functions -q fish_prompt
+        functions -c fish_prompt __omniview_user_prompt
+        function fish_prompt
+            __omniview_user_prompt
+            printf '\e]133;B\a'
+        end
+    end
+end
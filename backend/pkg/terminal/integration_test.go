@@ -0,0 +1,124 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallIntegrationScripts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "integration")
+	require.NoError(t, installIntegrationScripts(dir))
+	for _, name := range []string{"bash.sh", "fish.fish", "zsh/.zshenv", "zsh/.zprofile", "zsh/.zshrc", "zsh/.zlogin"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		assert.NotZero(t, info.Size(), name)
+	}
+
+	// reinstalling leaves nothing behind
+	require.NoError(t, installIntegrationScripts(dir))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestIntegrateShell(t *testing.T) {
+	dir := "/cache/integration"
+	env := []string{"HOME=/home/me"}
+
+	args, gotEnv := integrateShell(dir, nil, "/bin/bash", []string{"--login"}, env)
+	assert.Equal(t, []string{"--init-file", "/cache/integration/bash.sh", "-i"}, args)
+	assert.Equal(t, env, gotEnv)
+
+	args, gotEnv = integrateShell(dir, []string{"zsh"}, "zsh", []string{"--login", "-i"}, env)
+	assert.Equal(t, []string{"--login", "-i"}, args)
+	assert.Equal(t, []string{"HOME=/home/me", "ZDOTDIR=/cache/integration/zsh"}, gotEnv)
+	assert.Equal(t, []string{"HOME=/home/me"}, env, "input env not modified")
+
+	_, gotEnv = integrateShell(dir, nil, "/bin/zsh", nil, []string{"ZDOTDIR=/home/me/.config/zsh"})
+	assert.Equal(t, []string{
+		"ZDOTDIR=/home/me/.config/zsh",
+		"ZDOTDIR=/cache/integration/zsh",
+		"OMNIVIEW_USER_ZDOTDIR=/home/me/.config/zsh",
+	}, gotEnv)
+
+	args, _ = integrateShell("/it's", nil, "/usr/bin/fish", []string{"--login", "--interactive"}, env)
+	assert.Equal(t, []string{"--login", "--interactive", "--init-command", `source '/it\'s/fish.fish'`}, args)
+
+	// shells given arguments of their own, and other shells, are left alone
+	args, _ = integrateShell(dir, []string{"bash", "-c", "ls"}, "bash", []string{"--login", "-c", "ls"}, env)
+	assert.Equal(t, []string{"--login", "-c", "ls"}, args)
+	args, _ = integrateShell(dir, nil, "/bin/sh", nil, env)
+	assert.Empty(t, args)
+}
+
+func TestManager_ShellIntegrationBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	// keep the user's startup files out of the test
+	t.Setenv("HOME", t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m, _, out, _ := NewManager(ctx, logging.NewNop(),
+		WithDefaultShell(func() string { return bash }),
+		WithShellIntegration(func() bool { return true }),
+	)
+	m.scriptsDir = filepath.Join(t.TempDir(), "integration")
+	collector := &outputCollector{data: make(map[string]*strings.Builder), closed: make(map[string]bool)}
+	go collector.run(ctx, out)
+
+	finished := make(chan Command, 10)
+	m.SetCommandObserver(func(_ string, cmd Command) { finished <- cmd })
+
+	session, err := m.StartSession(nil, sdkexec.SessionOptions{}, SessionOptions{})
+	require.NoError(t, err)
+	require.NoError(t, m.WriteSession(session.ID, []byte("echo hello\n")))
+	first := waitCommand(t, finished)
+	// a subshell does not trigger bash's DEBUG trap, so it is reported
+	// once it is done
+	require.NoError(t, m.WriteSession(session.ID, []byte("(exit 3)\n")))
+	second := waitCommand(t, finished)
+
+	assert.Equal(t, "echo hello", first.Command)
+	require.NotNil(t, first.ExitCode)
+	assert.Equal(t, 0, *first.ExitCode)
+	assert.Equal(t, "(exit 3)", second.Command)
+	require.NotNil(t, second.ExitCode)
+	assert.Equal(t, 3, *second.ExitCode)
+
+	history, err := m.CommandHistory(session.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	output, err := m.CommandOutput(session.ID, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", output.Output)
+
+	require.NoError(t, m.CloseSession(session.ID))
+	_, err = m.CommandHistory(session.ID)
+	assert.Error(t, err)
+}
+
+func waitCommand(t *testing.T, ch <-chan Command) Command {
+	t.Helper()
+	select {
+	case cmd := <-ch:
+		return cmd
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for a command to finish")
+		return Command{}
+	}
+}
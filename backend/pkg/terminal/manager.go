@@ -4,6 +4,7 @@ package terminal
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -19,6 +20,7 @@ import (
 	logging "github.com/omniviewdev/plugin-sdk/log"
 
 	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal/daemon"
 	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
 	"github.com/omniviewdev/plugin-sdk/pkg/types"
 )
@@ -30,6 +32,10 @@ const (
 	InitialCols           = 72
 )
 
+// outputDrainTimeout bounds the wait for a session's remaining output after
+// its process exits.
+const outputDrainTimeout = 2 * time.Second
+
 // Manager manages terminal sessions, allowing creation, attachment, and more.
 type Manager struct {
 	ctx      context.Context
@@ -39,11 +45,65 @@ type Manager struct {
 	cmds     map[string]*exec.Cmd
 	cancels  map[string]context.CancelFunc
 	buffers  map[string]*sdkexec.OutputBuffer
+	trackers map[string]*CommandTracker
+	// tempFiles holds per-session files (such as a kubeconfig context
+	// override) removed when the session ends.
+	tempFiles map[string]string
+	// streams coalesce and throttle each session's output; see OutputLimits.
+	streams map[string]*outputStream
 
 	inMux     chan sdkexec.StreamInput
 	outMux    chan sdkexec.StreamOutput
 	resizeMux chan sdkexec.StreamResize
 	mux       sync.RWMutex
+
+	// observeOutput, if set, receives every chunk read from a session's PTY.
+	observeOutput func(sessionID string, data []byte)
+	// observeCommand, if set, receives every command that finishes in a
+	// session with shell integration.
+	observeCommand func(sessionID string, cmd Command)
+	// observeExit, if set, receives the exit code of every session's
+	// process.
+	observeExit func(sessionID string, exitCode int)
+	// observeOutputState, if set, receives a session's output state when
+	// its output is paused or resumed.
+	observeOutputState func(sessionID string, state OutputState)
+	// defaultShell returns the configured shell for sessions started
+	// without a command.
+	defaultShell func() string
+	// outputLimits returns the limits for new sessions' output.
+	outputLimits func() OutputLimits
+
+	// shell integration, see WithShellIntegration
+	shellIntegration func() bool
+	integrationMu    sync.Mutex
+	scriptsDir       string
+	scriptsInstalled bool
+
+	// session daemon, see WithSessionDaemon
+	daemonCfg daemonConfig
+	daemonMu  sync.Mutex
+	daemon    *daemon.Client
+	remote    map[string]bool // sessions owned by the daemon; guarded by mux
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithDefaultShell sets the function consulted for the shell to start when a
+// session has no command. It is called for every session, so a changed
+// setting applies to the next terminal. If it returns "" or a shell that
+// cannot be found, the user's login shell is used.
+func WithDefaultShell(fn func() string) ManagerOption {
+	return func(m *Manager) { m.defaultShell = fn }
+}
+
+// WithOutputLimits sets the function consulted for the output limits of a
+// new session. It is called for every session, so a changed setting applies
+// to the next terminal. Without it, output is coalesced with the default
+// frame limits and not rate limited.
+func WithOutputLimits(fn func() OutputLimits) ManagerOption {
+	return func(m *Manager) { m.outputLimits = fn }
 }
 
 // NewManager initializes a new Manager instance. Because we want to be a bit more
@@ -52,6 +112,7 @@ type Manager struct {
 func NewManager(
 	ctx context.Context,
 	log logging.Logger,
+	opts ...ManagerOption,
 ) (*Manager, chan sdkexec.StreamInput, chan sdkexec.StreamOutput, chan sdkexec.StreamResize) {
 	inMux := make(chan sdkexec.StreamInput)
 	outMux := make(chan sdkexec.StreamOutput)
@@ -65,16 +126,68 @@ func NewManager(
 		cmds:      make(map[string]*exec.Cmd),
 		cancels:   make(map[string]context.CancelFunc),
 		buffers:   make(map[string]*sdkexec.OutputBuffer),
+		trackers:  make(map[string]*CommandTracker),
+		tempFiles: make(map[string]string),
+		streams:   make(map[string]*outputStream),
+		remote:    make(map[string]bool),
 		inMux:     inMux,
 		outMux:    outMux,
 		resizeMux: resizeMux,
 	}
+	for _, opt := range opts {
+		opt(mgr)
+	}
 
 	go mgr.forwardSignals()
 
 	return mgr, inMux, outMux, resizeMux
 }
 
+// SetOutputObserver registers fn to receive every chunk of output read from
+// a session's PTY. Unlike the output channel, it does not see the buffered
+// output replayed by AttachSession, and it sees output while the session's
+// output is paused. data is only valid for the duration of the call.
+func (m *Manager) SetOutputObserver(fn func(sessionID string, data []byte)) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.observeOutput = fn
+}
+
+// SetCommandObserver registers fn to receive every command that finishes in
+// a session with shell integration.
+func (m *Manager) SetCommandObserver(fn func(sessionID string, cmd Command)) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.observeCommand = fn
+}
+
+// SetExitObserver registers fn to receive the exit code of every session's
+// process once its output has been emitted. The code is -1 when the process
+// was killed by a signal or its status is unknown.
+func (m *Manager) SetExitObserver(fn func(sessionID string, exitCode int)) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.observeExit = fn
+}
+
+// SetOutputStateObserver registers fn to receive a session's output state
+// whenever its output is paused by the rate limit or resumed.
+func (m *Manager) SetOutputStateObserver(fn func(sessionID string, state OutputState)) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.observeOutputState = fn
+}
+
+// notifyExit passes a session's exit code to the exit observer.
+func (m *Manager) notifyExit(sessionID string, exitCode int) {
+	m.mux.RLock()
+	observe := m.observeExit
+	m.mux.RUnlock()
+	if observe != nil {
+		observe(sessionID, exitCode)
+	}
+}
+
 // GetSession returns a session by its ID.
 func (m *Manager) GetSession(sessionID string) (*sdkexec.Session, error) {
 	m.mux.RLock()
@@ -100,10 +213,15 @@ func (m *Manager) ListSessions(_ *types.PluginContext) []*sdkexec.Session {
 	return sessions
 }
 
-// StartSession creates a new terminal session with a given command.
+// StartSession creates a new terminal session with a given command, or the
+// default shell if the command is empty. local supplies the shell's extra
+// environment, working directory and Kubernetes context, e.g. for a terminal
+// opened in a connection; the ParamDir and ParamEnvPrefix params of opts
+// override it.
 func (m *Manager) StartSession(
 	pCtx *types.PluginContext,
 	opts sdkexec.SessionOptions,
+	local SessionOptions,
 ) (*sdkexec.Session, error) {
 	logger := m.log.With(logging.Any("action", "StartSession"))
 	logger.Debugw(context.Background(), "starting session", "command", opts.Command, "tty", opts.TTY)
@@ -111,67 +229,93 @@ func (m *Manager) StartSession(
 	// Derive from manager context so shutdown cascades to all sessions.
 	ctx, cancel := context.WithCancel(m.ctx)
 
-	// determine the default shell from the commands passed in, since we may want to add flags
-	shell := DefaultLocalShell
-	newopts := []string{}
-
-	if len(opts.Command) > 0 {
-		switch opts.Command[0] {
-		// test for active shells
-		case "zsh":
-			shell = "zsh"
-			newopts = []string{"--login", "-i"}
-			newopts = append(newopts, opts.Command[1:]...)
-		case "/bin/zsh":
-			shell = "/bin/zsh"
-			newopts = []string{"--login", "-i"}
-			newopts = append(newopts, opts.Command[1:]...)
-		case "bash":
-			shell = "bash"
-			newopts = []string{"--login"}
-			newopts = append(newopts, opts.Command[1:]...)
-		case "/bin/bash":
-			shell = "/bin/bash"
-			newopts = []string{"--login"}
-			newopts = append(newopts, opts.Command[1:]...)
-		case "sh":
-			shell = "sh"
-		case "/bin/sh":
-			shell = "/bin/sh"
-		}
+	// start the configured shell when no command is given, adding the
+	// login/interactive flags of known shells
+	var configured string
+	if m.defaultShell != nil {
+		configured = m.defaultShell()
 	}
+	shell, args := shellCommand(opts.Command, resolveDefaultShell(configured))
+	local = local.withParams(opts.Params)
 
 	// start default shell with commands appended to it
 	//nolint:gosec // whole point is to get a local shell from the local IDE, so this is just
 	// going to be exactly what the user wants
-	cmd := exec.CommandContext(ctx, shell, newopts...)
+	cmd := exec.CommandContext(ctx, shell, args...)
 
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, fmt.Sprintf("SHELL=%s", shell), "TERM=xterm-256color")
+	dir, err := resolveDir(local.Dir)
+	if err != nil {
+		cancel()
+		return nil, apperror.Wrap(err, apperror.TypeSessionFailed, 400, "Invalid working directory")
+	}
+	cmd.Dir = dir
+
+	var kubeOverride string
+	if local.Context != "" {
+		if kubeOverride, err = writeContextOverride(local.Context); err != nil {
+			cancel()
+			return nil, apperror.Wrap(err, apperror.TypeSessionFailed, 500, "Failed to select Kubernetes context")
+		}
+	}
+	cleanup := func() {
+		cancel()
+		if kubeOverride != "" {
+			os.Remove(kubeOverride)
+		}
+	}
+	cmd.Env = shellEnv(os.Environ(), shell, local, kubeOverride)
+	if m.shellIntegration != nil && m.shellIntegration() {
+		if dir, dirErr := m.integrationDir(); dirErr != nil {
+			logger.Errorw(ctx, "failed to install shell integration", "error", dirErr)
+		} else {
+			args, cmd.Env = integrateShell(dir, opts.Command, shell, args, cmd.Env)
+			cmd.Args = append(cmd.Args[:1], args...)
+		}
+	}
 
 	if opts.Labels == nil {
 		opts.Labels = make(map[string]string)
 	}
 
+	// Generate a unique ID for the session.
+	if opts.ID == "" {
+		opts.ID = uuid.NewString()
+	}
+
+	if m.persistSessions() {
+		var tempFiles []string
+		if kubeOverride != "" {
+			tempFiles = []string{kubeOverride}
+		}
+		return m.startDaemonSession(ctx, cancel, opts, daemon.StartOptions{
+			ID:        opts.ID,
+			Path:      cmd.Path,
+			Args:      args,
+			Env:       cmd.Env,
+			Dir:       dir,
+			Rows:      InitialRows,
+			Cols:      InitialCols,
+			Command:   opts.Command,
+			Labels:    opts.Labels,
+			Params:    opts.Params,
+			TempFiles: tempFiles,
+		})
+	}
+
 	ptyFile, err := pty.Start(cmd)
 	if err != nil {
 		err = apperror.Wrap(err, apperror.TypeSessionFailed, 500, "Failed to start terminal")
 		logger.Errorw(ctx, "failed to start terminal", "error", err)
-		cancel()
+		cleanup()
 		return nil, err
 	}
 	// set an initial size for the pty, otherwise we get really weird behavior
 	if err = pty.Setsize(ptyFile, &pty.Winsize{Rows: InitialRows, Cols: InitialCols}); err != nil {
 		err = apperror.Wrap(err, apperror.TypeSessionFailed, 500, "Failed to configure terminal size")
-		cancel()
+		cleanup()
 		return nil, err
 	}
 
-	// Generate a unique ID for the session.
-	if opts.ID == "" {
-		opts.ID = uuid.NewString()
-	}
-
 	session := &sdkexec.Session{
 		ID:        opts.ID,
 		Command:   opts.Command,
@@ -186,7 +330,10 @@ func (m *Manager) StartSession(
 	m.ptys[opts.ID] = ptyFile
 	m.cmds[opts.ID] = cmd
 	m.cancels[opts.ID] = cancel
-	m.buffers[opts.ID] = sdkexec.NewDefaultOutputBuffer()
+	m.addBufferLocked(opts.ID)
+	if kubeOverride != "" {
+		m.tempFiles[opts.ID] = kubeOverride
+	}
 	m.mux.Unlock()
 
 	logger.Debugw(ctx, "session started",
@@ -195,14 +342,21 @@ func (m *Manager) StartSession(
 	)
 
 	// Start handling terminal output in a separate goroutine.
-	go m.handleOutStream(ctx, opts.ID, ptyFile)
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		m.handleOutStream(ctx, opts.ID, ptyFile)
+	}()
 	go m.handleSessionClose(ctx, opts.ID)
-	go m.handleWaitForCompletion(ctx, opts.ID, cmd)
+	go m.handleWaitForCompletion(ctx, opts.ID, cmd, drained)
 
 	return session, nil
 }
 
 func (m *Manager) ResizeSession(sessionID string, rows, cols uint16) error {
+	if client := m.remoteClient(sessionID); client != nil {
+		return m.daemonError(client.Resize(sessionID, rows, cols), sessionID)
+	}
 	m.mux.RLock()
 	defer m.mux.RUnlock()
 	ptyFile, exists := m.ptys[sessionID]
@@ -218,11 +372,35 @@ func (m *Manager) ResizeSession(sessionID string, rows, cols uint16) error {
 	return nil
 }
 
-func (m *Manager) handleWaitForCompletion(_ context.Context, sessionID string, cmd *exec.Cmd) {
-	if err := cmd.Wait(); err != nil {
+// handleWaitForCompletion waits for a session's process to exit, lets the
+// output still in the PTY be read, and terminates the session. drained is
+// closed when the output stream has ended.
+func (m *Manager) handleWaitForCompletion(_ context.Context, sessionID string, cmd *exec.Cmd, drained <-chan struct{}) {
+	err := cmd.Wait()
+	if err != nil {
 		m.log.Errorw(context.Background(), "error waiting for command", "session", sessionID, "error", err)
 	}
+	// A background process still holding the terminal open keeps the stream
+	// from ending, so don't wait for it for long.
+	select {
+	case <-drained:
+	case <-time.After(outputDrainTimeout):
+	}
 	m.terminateSession(sessionID)
+	m.notifyExit(sessionID, exitCode(err))
+}
+
+// exitCode returns the exit code for the result of cmd.Wait, -1 if the
+// process was killed by a signal or did not run.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
 }
 
 // forwardSignals listens for host-process signals once and forwards them to
@@ -251,10 +429,11 @@ func (m *Manager) forwardSignals() {
 	}
 }
 
-// handleSessionClose waits for a session's context to be cancelled and emits
-// a CLOSE signal to the frontend.
+// handleSessionClose waits for a session's context to be cancelled, flushes
+// its pending output and emits a CLOSE signal to the frontend.
 func (m *Manager) handleSessionClose(ctx context.Context, sessionID string) {
 	<-ctx.Done()
+	m.closeStream(sessionID)
 	m.outMux <- sdkexec.StreamOutput{
 		SessionID: sessionID,
 		Target:    sdkexec.StreamTargetStdOut,
@@ -268,8 +447,8 @@ func (m *Manager) handleOutStream(
 	sessionID string,
 	stream io.Reader,
 ) {
+	buf := make([]byte, DefaultReadBufferSize)
 	for {
-		buf := make([]byte, DefaultReadBufferSize)
 		read, err := stream.Read(buf)
 		if err != nil {
 			if err != io.EOF {
@@ -290,29 +469,157 @@ func (m *Manager) handleOutStream(
 			return
 		}
 
-		if len(buf) > 0 {
+		if read > 0 {
+			m.emitOutput(sessionID, buf[:read])
+		}
+	}
+}
+
+// emitOutput sends a chunk of session output to the session's output stream,
+// the observer and the session's buffer and command tracker. data is not
+// retained.
+func (m *Manager) emitOutput(sessionID string, data []byte) {
+	m.mux.RLock()
+	stream := m.streams[sessionID]
+	tracker, ok := m.trackers[sessionID]
+	observe := m.observeOutput
+	m.mux.RUnlock()
+	if stream != nil {
+		stream.write(data)
+	}
+	if observe != nil {
+		observe(sessionID, data)
+	}
+	if !ok {
+		// soft error
+		m.log.Errorw(context.Background(), "failed to write to session buffer: couldn't find session")
+		return
+	}
+
+	tracker.Feed(data)
+}
+
+// addBufferLocked creates a session's output buffer, the command tracker
+// that fills it and its output stream. Caller must hold m.mux.
+func (m *Manager) addBufferLocked(sessionID string) {
+	var limits OutputLimits
+	if m.outputLimits != nil {
+		limits = m.outputLimits()
+	}
+	m.streams[sessionID] = newOutputStream(limits,
+		func(frame []byte) {
 			m.outMux <- sdkexec.StreamOutput{
 				SessionID: sessionID,
 				Target:    sdkexec.StreamTargetStdOut,
-				Data:      buf[:read],
+				Data:      frame,
 			}
-
+		},
+		func(state OutputState) {
 			m.mux.RLock()
-			buffer, ok := m.buffers[sessionID]
+			observe := m.observeOutputState
 			m.mux.RUnlock()
-			if !ok {
-				// soft error
-				m.log.Errorw(context.Background(), "failed to write to session buffer: couldn't find session")
-				continue
+			if observe != nil {
+				observe(sessionID, state)
 			}
-
-			buffer.Append(buf[:read])
+		},
+	)
+	buffer := sdkexec.NewDefaultOutputBuffer()
+	m.buffers[sessionID] = buffer
+	m.trackers[sessionID] = NewCommandTracker(buffer, func(cmd Command) {
+		m.mux.RLock()
+		observe := m.observeCommand
+		m.mux.RUnlock()
+		if observe != nil {
+			observe(sessionID, cmd)
 		}
+	})
+}
+
+// closeStream flushes a session's pending output and removes its output
+// stream.
+func (m *Manager) closeStream(sessionID string) {
+	m.mux.Lock()
+	stream := m.streams[sessionID]
+	delete(m.streams, sessionID)
+	m.mux.Unlock()
+	if stream != nil {
+		stream.close()
 	}
 }
 
+// ResumeOutput forwards a session's output again after the rate limit
+// paused it. Output read while paused is not replayed; it remains in the
+// session's buffer. Resuming a session that is not paused does nothing.
+func (m *Manager) ResumeOutput(sessionID string) error {
+	m.mux.RLock()
+	stream, ok := m.streams[sessionID]
+	m.mux.RUnlock()
+	if !ok {
+		return apperror.SessionNotFound(sessionID)
+	}
+	stream.resume()
+	return nil
+}
+
+// OutputState returns whether a session's output is paused, its limits and
+// its output counters.
+func (m *Manager) OutputState(sessionID string) (OutputState, error) {
+	m.mux.RLock()
+	stream, ok := m.streams[sessionID]
+	m.mux.RUnlock()
+	if !ok {
+		return OutputState{}, apperror.SessionNotFound(sessionID)
+	}
+	return stream.state(), nil
+}
+
+// CommandHistory returns the commands run in a session, oldest first. It is
+// empty unless the session's shell reports commands with OSC 133 markers.
+func (m *Manager) CommandHistory(sessionID string) ([]Command, error) {
+	m.mux.RLock()
+	tracker, ok := m.trackers[sessionID]
+	m.mux.RUnlock()
+	if !ok {
+		return nil, apperror.SessionNotFound(sessionID)
+	}
+	return tracker.Commands(), nil
+}
+
+// Scrollback returns a session's buffered output and the number of bytes
+// of output evicted before it.
+func (m *Manager) Scrollback(sessionID string) ([]byte, int64, error) {
+	m.mux.RLock()
+	tracker, ok := m.trackers[sessionID]
+	m.mux.RUnlock()
+	if !ok {
+		return nil, 0, apperror.SessionNotFound(sessionID)
+	}
+	data, dropped := tracker.Snapshot()
+	return data, dropped, nil
+}
+
+// CommandOutput returns the output of a command in a session's history as
+// plain text.
+func (m *Manager) CommandOutput(sessionID string, commandID int) (CommandOutput, error) {
+	m.mux.RLock()
+	tracker, ok := m.trackers[sessionID]
+	m.mux.RUnlock()
+	if !ok {
+		return CommandOutput{}, apperror.SessionNotFound(sessionID)
+	}
+	out, ok := tracker.Output(commandID)
+	if !ok {
+		return CommandOutput{}, apperror.NotFound("Command not found",
+			fmt.Sprintf("Command %d is not in the history of session %s.", commandID, sessionID))
+	}
+	return out, nil
+}
+
 // WriteToSession writes a string to the session's input.
 func (m *Manager) writeToSession(sessionID string, bytes []byte) error {
+	if client := m.remoteClient(sessionID); client != nil {
+		return m.daemonError(client.Write(sessionID, bytes), sessionID)
+	}
 	m.mux.RLock()
 	defer m.mux.RUnlock()
 
@@ -388,6 +695,14 @@ func (m *Manager) DetachSession(sessionID string) (*sdkexec.Session, error) {
 // CloseSession cancels the session's context, effectively terminating
 // its command, and removes it from the manager.
 func (m *Manager) CloseSession(sessionID string) error {
+	if client := m.remoteClient(sessionID); client != nil {
+		if err := client.CloseSession(sessionID); err != nil {
+			m.log.Errorw(context.Background(), "error closing daemon session", "session", sessionID, "error", err)
+		}
+		// The daemon's exit event may already have removed the session.
+		m.terminateSession(sessionID)
+		return nil
+	}
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -422,5 +737,11 @@ func (m *Manager) terminateSessionLocked(sessionID string) {
 	delete(m.cmds, sessionID)
 	delete(m.cancels, sessionID)
 	delete(m.buffers, sessionID)
+	delete(m.trackers, sessionID)
+	delete(m.remote, sessionID)
+	if file, ok := m.tempFiles[sessionID]; ok {
+		os.Remove(file)
+		delete(m.tempFiles, sessionID)
+	}
 	m.log.Debugw(context.Background(), "session terminated", "session", sessionID)
 }
@@ -0,0 +1,236 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"time"
+
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+
+	"github.com/omniviewdev/omniview/backend/pkg/apperror"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal/daemon"
+)
+
+type daemonConfig struct {
+	socketPath string
+	executable string
+	persist    func() bool
+}
+
+// WithSessionDaemon lets sessions outlive the application by running them in
+// the session daemon listening on socketPath. persist is consulted for every
+// new session; when it returns true the session is started in the daemon,
+// which is spawned from executable if it is not running. An empty
+// executable only connects to a daemon that is already running.
+//
+// Sessions a daemon is still running are picked up again with Reattach.
+func WithSessionDaemon(socketPath, executable string, persist func() bool) ManagerOption {
+	return func(m *Manager) {
+		m.daemonCfg = daemonConfig{socketPath: socketPath, executable: executable, persist: persist}
+	}
+}
+
+// persistSessions reports whether new sessions should run in the daemon.
+func (m *Manager) persistSessions() bool {
+	return m.daemonCfg.socketPath != "" && m.daemonCfg.persist != nil && m.daemonCfg.persist()
+}
+
+// daemonClient returns the connection to the daemon, connecting (and, if
+// spawn is set, starting the daemon) when there is none.
+func (m *Manager) daemonClient(spawn bool) (*daemon.Client, error) {
+	m.daemonMu.Lock()
+	defer m.daemonMu.Unlock()
+	if m.daemon != nil {
+		return m.daemon, nil
+	}
+
+	var client *daemon.Client
+	var err error
+	if spawn && m.daemonCfg.executable != "" {
+		client, err = daemon.Connect(m.daemonCfg.socketPath, m.daemonCfg.executable, m.handleDaemonEvent)
+	} else {
+		client, err = daemon.Dial(m.daemonCfg.socketPath, m.handleDaemonEvent)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.daemon = client
+	go func() {
+		// Leave the sessions running in the daemon when the application exits.
+		<-m.ctx.Done()
+		client.Close()
+	}()
+	return client, nil
+}
+
+// remoteClient returns the daemon connection if the session runs in the
+// daemon.
+func (m *Manager) remoteClient(sessionID string) *daemon.Client {
+	m.mux.RLock()
+	remote := m.remote[sessionID]
+	m.mux.RUnlock()
+	if !remote {
+		return nil
+	}
+	m.daemonMu.Lock()
+	defer m.daemonMu.Unlock()
+	return m.daemon
+}
+
+func (m *Manager) daemonError(err error, sessionID string) error {
+	if err == nil {
+		return nil
+	}
+	m.log.Errorw(context.Background(), "terminal daemon request failed", "session", sessionID, "error", err)
+	return apperror.Wrap(err, apperror.TypeSessionFailed, 500, "Terminal daemon request failed")
+}
+
+func (m *Manager) handleDaemonEvent(event daemon.Event) {
+	switch event.Type {
+	case daemon.EventOutput:
+		m.emitOutput(event.ID, event.Data)
+	case daemon.EventExit:
+		m.terminateSession(event.ID)
+		m.notifyExit(event.ID, event.ExitCode)
+	case daemon.EventDisconnect:
+		m.daemonMu.Lock()
+		m.daemon = nil
+		m.daemonMu.Unlock()
+
+		// Without the daemon the sessions are unreachable; end them here.
+		// During shutdown this is the manager closing the connection.
+		if m.ctx.Err() != nil {
+			return
+		}
+		m.mux.Lock()
+		for sessionID := range m.remote {
+			m.terminateSessionLocked(sessionID)
+		}
+		m.mux.Unlock()
+	}
+}
+
+// registerRemote adds a daemon session to the manager. The caller starts
+// handleSessionClose once the session is running in the daemon.
+func (m *Manager) registerRemote(cancel context.CancelFunc, session *sdkexec.Session) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.sessions[session.ID] = session
+	m.cancels[session.ID] = cancel
+	m.addBufferLocked(session.ID)
+	m.remote[session.ID] = true
+}
+
+// unregisterRemote removes a daemon session that failed to start or attach.
+func (m *Manager) unregisterRemote(sessionID string) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.sessions, sessionID)
+	delete(m.cancels, sessionID)
+	delete(m.buffers, sessionID)
+	delete(m.trackers, sessionID)
+	delete(m.streams, sessionID)
+	delete(m.remote, sessionID)
+}
+
+// startDaemonSession starts a session in the daemon. The session is
+// registered first so no early output is lost.
+func (m *Manager) startDaemonSession(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	opts sdkexec.SessionOptions,
+	start daemon.StartOptions,
+) (*sdkexec.Session, error) {
+	fail := func(err error) (*sdkexec.Session, error) {
+		cancel()
+		for _, f := range start.TempFiles {
+			os.Remove(f)
+		}
+		m.log.Errorw(ctx, "failed to start terminal in daemon", "error", err)
+		return nil, apperror.Wrap(err, apperror.TypeSessionFailed, 500, "Failed to start terminal")
+	}
+
+	client, err := m.daemonClient(true)
+	if err != nil {
+		return fail(err)
+	}
+
+	session := &sdkexec.Session{
+		ID:        opts.ID,
+		Command:   opts.Command,
+		Labels:    opts.Labels,
+		Params:    opts.Params,
+		CreatedAt: time.Now(),
+	}
+	m.registerRemote(cancel, session)
+	if _, err = client.Start(start); err != nil {
+		m.unregisterRemote(session.ID)
+		return fail(err)
+	}
+	go m.handleSessionClose(ctx, session.ID)
+	m.log.Debugw(ctx, "session started in daemon", "session", session.ID, "command", session.Command)
+	return session, nil
+}
+
+// Reattach connects to a running session daemon and adopts its sessions,
+// restoring each one's scrollback into the session buffer. It returns the
+// adopted sessions; without a daemon it returns none.
+func (m *Manager) Reattach() []*sdkexec.Session {
+	if m.daemonCfg.socketPath == "" {
+		return nil
+	}
+	client, err := m.daemonClient(false)
+	if err != nil {
+		return nil
+	}
+	infos, err := client.List()
+	if err != nil {
+		m.log.Errorw(context.Background(), "failed to list daemon sessions", "error", err)
+		return nil
+	}
+
+	sessions := make([]*sdkexec.Session, 0, len(infos))
+	for _, info := range infos {
+		m.mux.RLock()
+		_, known := m.sessions[info.ID]
+		m.mux.RUnlock()
+		if known {
+			continue
+		}
+
+		session := &sdkexec.Session{
+			ID:        info.ID,
+			Command:   info.Command,
+			Labels:    info.Labels,
+			Params:    info.Params,
+			CreatedAt: info.CreatedAt,
+		}
+		if session.Labels == nil {
+			session.Labels = make(map[string]string)
+		}
+		ctx, cancel := context.WithCancel(m.ctx)
+		m.registerRemote(cancel, session)
+		err := client.Attach(info.ID, func(_ daemon.SessionInfo, scrollback []byte) {
+			// Replaying the scrollback through the tracker also recovers
+			// the commands still in it.
+			m.mux.RLock()
+			tracker := m.trackers[info.ID]
+			m.mux.RUnlock()
+			if tracker != nil {
+				tracker.Feed(scrollback)
+			}
+		})
+		if err != nil {
+			// The session ended between listing and attaching.
+			m.unregisterRemote(info.ID)
+			cancel()
+			continue
+		}
+		go m.handleSessionClose(ctx, session.ID)
+		sessions = append(sessions, session)
+	}
+	m.log.Debugw(context.Background(), "reattached daemon sessions", "count", len(sessions))
+	return sessions
+}
@@ -0,0 +1,138 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/omniviewdev/omniview/backend/pkg/terminal/daemon"
+)
+
+// newDaemonManager starts a manager backed by the daemon on socket and
+// collects its output.
+func newDaemonManager(t *testing.T, socket string) (*Manager, context.CancelFunc, *outputCollector) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	m, _, out, _ := NewManager(ctx, logging.NewNop(),
+		WithDefaultShell(func() string { return "/bin/sh" }),
+		WithSessionDaemon(socket, "", func() bool { return true }),
+	)
+	collector := &outputCollector{data: make(map[string]*strings.Builder), closed: make(map[string]bool)}
+	go collector.run(ctx, out)
+	t.Cleanup(cancel)
+	return m, cancel, collector
+}
+
+type outputCollector struct {
+	mu     sync.Mutex
+	data   map[string]*strings.Builder
+	closed map[string]bool
+}
+
+func (c *outputCollector) run(ctx context.Context, out chan sdkexec.StreamOutput) {
+	for {
+		select {
+		case <-ctx.Done():
+			// keep draining so session goroutines can finish
+			go func() {
+				for range out { //nolint:revive // drain
+				}
+			}()
+			return
+		case o := <-out:
+			c.mu.Lock()
+			if o.Signal == sdkexec.StreamSignalClose {
+				c.closed[o.SessionID] = true
+			} else {
+				if c.data[o.SessionID] == nil {
+					c.data[o.SessionID] = &strings.Builder{}
+				}
+				c.data[o.SessionID].Write(o.Data)
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *outputCollector) text(id string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b := c.data[id]; b != nil {
+		return b.String()
+	}
+	return ""
+}
+
+func (c *outputCollector) isClosed(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed[id]
+}
+
+func TestManager_DaemonSessionSurvivesRestart(t *testing.T) {
+	dir, err := os.MkdirTemp("", "termd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "s.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	srv := daemon.NewServer(0)
+	go srv.Serve(l) //nolint:errcheck // ends with the test
+	defer srv.Close()
+
+	first, quit, out := newDaemonManager(t, socket)
+	session, err := first.StartSession(nil, sdkexec.SessionOptions{
+		Command: []string{"/bin/sh", "-c", "echo started; cat"},
+		Labels:  map[string]string{"omniview.dev/connection": "prod"},
+	}, SessionOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return strings.Contains(out.text(session.ID), "started") }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, first.WriteSession(session.ID, []byte("one\n")))
+	require.Eventually(t, func() bool { return strings.Count(out.text(session.ID), "one") >= 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// The application quits.
+	quit()
+	time.Sleep(100 * time.Millisecond)
+
+	second, _, out2 := newDaemonManager(t, socket)
+	sessions := second.Reattach()
+	require.Len(t, sessions, 1)
+	assert.Equal(t, session.ID, sessions[0].ID)
+	assert.Equal(t, "prod", sessions[0].Labels["omniview.dev/connection"])
+
+	_, scrollback, err := second.AttachSession(session.ID)
+	require.NoError(t, err)
+	assert.Contains(t, string(scrollback), "started")
+	assert.Contains(t, string(scrollback), "one")
+
+	require.NoError(t, second.WriteSession(session.ID, []byte("two\n")))
+	require.Eventually(t, func() bool { return strings.Contains(out2.text(session.ID), "two") }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, second.ResizeSession(session.ID, 40, 100))
+
+	require.NoError(t, second.CloseSession(session.ID))
+	require.Eventually(t, func() bool { return out2.isClosed(session.ID) }, 5*time.Second, 10*time.Millisecond)
+	_, err = second.GetSession(session.ID)
+	assert.Error(t, err)
+	assert.Empty(t, second.Reattach(), "the daemon has no sessions left")
+}
+
+func TestManager_ReattachWithoutDaemon(t *testing.T) {
+	m, _, _ := newDaemonManager(t, filepath.Join(t.TempDir(), "missing.sock"))
+	assert.Empty(t, m.Reattach())
+
+	_, err := m.StartSession(nil, sdkexec.SessionOptions{}, SessionOptions{})
+	assert.Error(t, err, "no daemon to connect to and none to spawn")
+	assert.Empty(t, m.ListSessions(nil))
+}
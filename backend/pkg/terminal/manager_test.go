@@ -0,0 +1,112 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/omniviewdev/plugin-sdk/log"
+	sdkexec "github.com/omniviewdev/plugin-sdk/pkg/v1/exec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ExitObserver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m, _, out, _ := NewManager(ctx, logging.NewNop())
+	collector := &outputCollector{data: make(map[string]*strings.Builder), closed: make(map[string]bool)}
+	go collector.run(ctx, out)
+
+	var (
+		mu     sync.Mutex
+		output strings.Builder
+	)
+	m.SetOutputObserver(func(_ string, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		output.Write(data)
+	})
+	type exit struct {
+		id   string
+		code int
+	}
+	exits := make(chan exit, 1)
+	m.SetExitObserver(func(id string, code int) { exits <- exit{id, code} })
+
+	session, err := m.StartSession(nil, sdkexec.SessionOptions{
+		Command: []string{"/bin/sh", "-c", "echo finished; exit 4"},
+	}, SessionOptions{})
+	require.NoError(t, err)
+
+	select {
+	case e := <-exits:
+		assert.Equal(t, session.ID, e.id)
+		assert.Equal(t, 4, e.code)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the session to exit")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, output.String(), "finished", "output is emitted before the exit")
+	_, err = m.GetSession(session.ID)
+	assert.Error(t, err)
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, exitCode(nil))
+	assert.Equal(t, -1, exitCode(context.Canceled))
+}
+
+func TestManager_OutputPausedByRateLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m, _, out, _ := NewManager(ctx, logging.NewNop(), WithOutputLimits(func() OutputLimits {
+		return OutputLimits{FrameSize: 1024, RateLimit: 1024}
+	}))
+	collector := &outputCollector{data: make(map[string]*strings.Builder), closed: make(map[string]bool)}
+	go collector.run(ctx, out)
+
+	states := make(chan OutputState, 4)
+	m.SetOutputStateObserver(func(_ string, state OutputState) { states <- state })
+
+	session, err := m.StartSession(nil, sdkexec.SessionOptions{
+		Command: []string{"/bin/sh", "-c", "head -c 100000 /dev/zero | tr '\\0' a; sleep 30"},
+	}, SessionOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.CloseSession(session.ID) })
+
+	select {
+	case state := <-states:
+		assert.True(t, state.Paused)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for output to be paused")
+	}
+	require.Eventually(t, func() bool {
+		state, stateErr := m.OutputState(session.ID)
+		return stateErr == nil && state.Stats.BytesRead >= 100000
+	}, 10*time.Second, 10*time.Millisecond)
+
+	state, err := m.OutputState(session.ID)
+	require.NoError(t, err)
+	assert.True(t, state.Paused)
+	assert.Positive(t, state.Stats.BytesDropped)
+	assert.Less(t, len(collector.text(session.ID)), 100000, "paused output is not forwarded")
+	scrollback, _, err := m.Scrollback(session.ID)
+	require.NoError(t, err)
+	assert.Contains(t, string(scrollback), strings.Repeat("a", 1000), "paused output is still buffered")
+
+	require.NoError(t, m.ResumeOutput(session.ID))
+	assert.False(t, (<-states).Paused)
+	state, err = m.OutputState(session.ID)
+	require.NoError(t, err)
+	assert.False(t, state.Paused)
+
+	assert.Error(t, m.ResumeOutput("missing"))
+	_, err = m.OutputState("missing")
+	assert.Error(t, err)
+}
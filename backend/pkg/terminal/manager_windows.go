@@ -28,9 +28,35 @@ type Manager struct {
 	mux       sync.RWMutex
 }
 
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithDefaultShell is accepted for parity with other platforms.
+func WithDefaultShell(_ func() string) ManagerOption {
+	return func(*Manager) {}
+}
+
+// WithSessionDaemon is accepted for parity with other platforms; the session
+// daemon is not supported on Windows.
+func WithSessionDaemon(_, _ string, _ func() bool) ManagerOption {
+	return func(*Manager) {}
+}
+
+// WithShellIntegration is accepted for parity with other platforms; shell
+// integration is not supported on Windows.
+func WithShellIntegration(_ func() bool) ManagerOption {
+	return func(*Manager) {}
+}
+
+// WithOutputLimits is accepted for parity with other platforms.
+func WithOutputLimits(_ func() OutputLimits) ManagerOption {
+	return func(*Manager) {}
+}
+
 func NewManager(
 	_ context.Context,
 	log logging.Logger,
+	_ ...ManagerOption,
 ) (*Manager, chan sdkexec.StreamInput, chan sdkexec.StreamOutput, chan sdkexec.StreamResize) {
 	inMux := make(chan sdkexec.StreamInput)
 	outMux := make(chan sdkexec.StreamOutput)
@@ -46,6 +72,38 @@ func NewManager(
 	}, inMux, outMux, resizeMux
 }
 
+func (m *Manager) SetOutputObserver(_ func(sessionID string, data []byte)) {}
+
+func (m *Manager) SetCommandObserver(_ func(sessionID string, cmd Command)) {}
+
+func (m *Manager) SetExitObserver(_ func(sessionID string, exitCode int)) {}
+
+func (m *Manager) SetOutputStateObserver(_ func(sessionID string, state OutputState)) {}
+
+func (m *Manager) ResumeOutput(_ string) error {
+	return errUnsupported
+}
+
+func (m *Manager) OutputState(_ string) (OutputState, error) {
+	return OutputState{}, errUnsupported
+}
+
+func (m *Manager) CommandHistory(_ string) ([]Command, error) {
+	return nil, errUnsupported
+}
+
+func (m *Manager) CommandOutput(_ string, _ int) (CommandOutput, error) {
+	return CommandOutput{}, errUnsupported
+}
+
+func (m *Manager) Scrollback(_ string) ([]byte, int64, error) {
+	return nil, 0, errUnsupported
+}
+
+func (m *Manager) Reattach() []*sdkexec.Session {
+	return nil
+}
+
 func (m *Manager) GetSession(_ string) (*sdkexec.Session, error) {
 	return nil, errUnsupported
 }
@@ -54,7 +112,7 @@ func (m *Manager) ListSessions(_ *types.PluginContext) []*sdkexec.Session {
 	return nil
 }
 
-func (m *Manager) StartSession(_ *types.PluginContext, _ sdkexec.SessionOptions) (*sdkexec.Session, error) {
+func (m *Manager) StartSession(_ *types.PluginContext, _ sdkexec.SessionOptions, _ SessionOptions) (*sdkexec.Session, error) {
 	return nil, errUnsupported
 }
 
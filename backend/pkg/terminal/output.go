@@ -0,0 +1,222 @@
+package terminal
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults for OutputLimits fields left zero.
+const (
+	DefaultFrameInterval = 16 * time.Millisecond
+	DefaultFrameSize     = 32 * 1024
+)
+
+// OutputLimits bounds the output a session forwards to the output channel.
+//
+// Output is coalesced into frames: a frame is emitted once FrameSize bytes
+// are pending, or FrameInterval after the first of them was read. With a
+// RateLimit, a session whose frames exceed RateLimit bytes per second,
+// beyond a burst of one second's worth, has its output paused: it is still
+// read, observed and kept in the session's buffer, but no longer forwarded
+// until the session is resumed.
+type OutputLimits struct {
+	FrameInterval time.Duration `json:"frameInterval"`
+	FrameSize     int           `json:"frameSize"`
+	// RateLimit is in bytes per second; zero for no limit.
+	RateLimit int `json:"rateLimit"`
+}
+
+func (l OutputLimits) withDefaults() OutputLimits {
+	if l.FrameInterval <= 0 {
+		l.FrameInterval = DefaultFrameInterval
+	}
+	if l.FrameSize <= 0 {
+		l.FrameSize = DefaultFrameSize
+	}
+	return l
+}
+
+// OutputStats counts the output of a session. Bytes read are either
+// emitted, dropped or still pending in the next frame.
+type OutputStats struct {
+	BytesRead    int64 `json:"bytesRead"`
+	BytesEmitted int64 `json:"bytesEmitted"`
+	// BytesDropped is output not forwarded because output was paused.
+	BytesDropped int64 `json:"bytesDropped"`
+	// BytesCoalesced is output merged into a frame with earlier output
+	// instead of being sent on its own.
+	BytesCoalesced int64 `json:"bytesCoalesced"`
+	Reads          int64 `json:"reads"`
+	Frames         int64 `json:"frames"`
+	// Pauses is the number of times the rate limit paused output.
+	Pauses int64 `json:"pauses"`
+}
+
+// OutputState is the throttling state of a session's output.
+type OutputState struct {
+	Paused bool         `json:"paused"`
+	Limits OutputLimits `json:"limits"`
+	Stats  OutputStats  `json:"stats"`
+}
+
+// outputStream coalesces a session's output into frames and applies its
+// rate limit.
+type outputStream struct {
+	limits OutputLimits
+	// send forwards a frame; it may block, which holds back the writer.
+	send func([]byte)
+	// notify is called when output is paused or resumed.
+	notify func(OutputState)
+
+	// sendMu serializes flushes so frames are sent in order.
+	sendMu sync.Mutex
+
+	mu       sync.Mutex
+	pending  []byte
+	timer    *time.Timer
+	closed   bool
+	paused   bool
+	tokens   float64
+	refilled time.Time
+	stats    OutputStats
+}
+
+func newOutputStream(limits OutputLimits, send func([]byte), notify func(OutputState)) *outputStream {
+	s := &outputStream{limits: limits.withDefaults(), send: send, notify: notify, refilled: time.Now()}
+	s.tokens = s.burst()
+	return s
+}
+
+// burst is the number of bytes the rate limit lets through at once. It is
+// at least a frame, or a full frame could never be sent.
+func (s *outputStream) burst() float64 {
+	return float64(max(s.limits.RateLimit, s.limits.FrameSize))
+}
+
+// write queues a chunk of output, flushing once a frame is full. data is
+// copied.
+func (s *outputStream) write(data []byte) {
+	s.mu.Lock()
+	if s.closed || len(data) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	s.stats.Reads++
+	s.stats.BytesRead += int64(len(data))
+	if s.paused {
+		s.stats.BytesDropped += int64(len(data))
+		s.mu.Unlock()
+		return
+	}
+	if len(s.pending) > 0 {
+		s.stats.BytesCoalesced += int64(len(data))
+	}
+	s.pending = append(s.pending, data...)
+	full := len(s.pending) >= s.limits.FrameSize
+	if !full && s.timer == nil {
+		s.timer = time.AfterFunc(s.limits.FrameInterval, s.flush)
+	}
+	s.mu.Unlock()
+
+	if full {
+		s.flush()
+	}
+}
+
+// flush sends the pending output as a frame, or pauses output if the frame
+// would exceed the rate limit.
+func (s *outputStream) flush() {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	frame := s.pending
+	s.pending = nil
+	if len(frame) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	var paused *OutputState
+	if s.allowLocked(len(frame)) {
+		s.stats.Frames++
+		s.stats.BytesEmitted += int64(len(frame))
+	} else {
+		s.paused = true
+		s.stats.Pauses++
+		s.stats.BytesDropped += int64(len(frame))
+		state := s.stateLocked()
+		paused = &state
+		frame = nil
+	}
+	s.mu.Unlock()
+
+	if frame != nil {
+		s.send(frame)
+	}
+	if paused != nil && s.notify != nil {
+		s.notify(*paused)
+	}
+}
+
+// allowLocked takes n bytes from the rate limit's token bucket, reporting
+// false if there are not enough. Caller must hold s.mu.
+func (s *outputStream) allowLocked(n int) bool {
+	if s.limits.RateLimit <= 0 {
+		return true
+	}
+	now := time.Now()
+	s.tokens = min(s.burst(), s.tokens+now.Sub(s.refilled).Seconds()*float64(s.limits.RateLimit))
+	s.refilled = now
+	if float64(n) > s.tokens {
+		return false
+	}
+	s.tokens -= float64(n)
+	return true
+}
+
+// resume forwards output again after a pause, with a full burst allowance.
+// It reports whether output was paused.
+func (s *outputStream) resume() bool {
+	s.mu.Lock()
+	if !s.paused {
+		s.mu.Unlock()
+		return false
+	}
+	s.paused = false
+	s.tokens = s.burst()
+	s.refilled = time.Now()
+	state := s.stateLocked()
+	s.mu.Unlock()
+
+	if s.notify != nil {
+		s.notify(state)
+	}
+	return true
+}
+
+// close flushes the pending output and drops any written afterwards.
+func (s *outputStream) close() {
+	s.flush()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// state returns the stream's current state.
+func (s *outputStream) state() OutputState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stateLocked()
+}
+
+func (s *outputStream) stateLocked() OutputState {
+	return OutputState{Paused: s.paused, Limits: s.limits, Stats: s.stats}
+}
@@ -0,0 +1,132 @@
+package terminal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frameRecorder collects the frames and state changes of an outputStream.
+type frameRecorder struct {
+	mu     sync.Mutex
+	frames []string
+	states []OutputState
+}
+
+func (r *frameRecorder) stream(limits OutputLimits) *outputStream {
+	return newOutputStream(limits,
+		func(frame []byte) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.frames = append(r.frames, string(frame))
+		},
+		func(state OutputState) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.states = append(r.states, state)
+		},
+	)
+}
+
+func (r *frameRecorder) sent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.frames...)
+}
+
+func TestOutputStream_CoalescesWithinInterval(t *testing.T) {
+	r := &frameRecorder{}
+	s := r.stream(OutputLimits{FrameInterval: 20 * time.Millisecond})
+
+	buf := []byte("one ")
+	s.write(buf)
+	copy(buf, "xxxx") // written data is copied
+	s.write([]byte("two "))
+	s.write([]byte("three"))
+
+	require.Eventually(t, func() bool { return len(r.sent()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"one two three"}, r.sent())
+
+	stats := s.state().Stats
+	assert.Equal(t, int64(3), stats.Reads)
+	assert.Equal(t, int64(1), stats.Frames)
+	assert.Equal(t, int64(13), stats.BytesRead)
+	assert.Equal(t, int64(13), stats.BytesEmitted)
+	assert.Equal(t, int64(9), stats.BytesCoalesced, "output after the first chunk of a frame is coalesced")
+}
+
+func TestOutputStream_FlushesFullFrame(t *testing.T) {
+	r := &frameRecorder{}
+	s := r.stream(OutputLimits{FrameInterval: time.Hour, FrameSize: 8})
+
+	s.write([]byte("1234"))
+	assert.Empty(t, r.sent())
+	s.write([]byte("56789"))
+	assert.Equal(t, []string{"123456789"}, r.sent(), "a full frame is sent without waiting")
+
+	s.write([]byte("tail"))
+	s.close()
+	assert.Equal(t, []string{"123456789", "tail"}, r.sent(), "closing flushes pending output")
+	s.write([]byte("late"))
+	assert.Len(t, r.sent(), 2, "output after close is dropped")
+}
+
+func TestOutputStream_RateLimitPausesAndResumes(t *testing.T) {
+	r := &frameRecorder{}
+	s := r.stream(OutputLimits{FrameInterval: time.Hour, FrameSize: 16, RateLimit: 16})
+
+	s.write([]byte("0123456789abcdef"))
+	assert.Len(t, r.sent(), 1, "the first burst is allowed")
+
+	s.write([]byte("0123456789abcdef"))
+	assert.Len(t, r.sent(), 1, "the next frame exceeds the rate")
+	state := s.state()
+	assert.True(t, state.Paused)
+	require.Len(t, r.states, 1)
+	assert.True(t, r.states[0].Paused)
+
+	s.write([]byte("dropped"))
+	s.close()
+	assert.Len(t, r.sent(), 1, "output is dropped while paused")
+
+	stats := s.state().Stats
+	assert.Equal(t, int64(1), stats.Pauses)
+	assert.Equal(t, int64(23), stats.BytesDropped)
+	assert.Equal(t, int64(16), stats.BytesEmitted)
+	assert.Equal(t, stats.BytesRead, stats.BytesEmitted+stats.BytesDropped)
+}
+
+func TestOutputStream_Resume(t *testing.T) {
+	r := &frameRecorder{}
+	s := r.stream(OutputLimits{FrameInterval: time.Hour, FrameSize: 16, RateLimit: 16})
+
+	assert.False(t, s.resume(), "resuming a stream that is not paused does nothing")
+	s.write([]byte("0123456789abcdef"))
+	s.write([]byte("0123456789abcdef"))
+	require.True(t, s.state().Paused)
+
+	assert.True(t, s.resume())
+	assert.False(t, s.state().Paused)
+	require.Len(t, r.states, 2)
+	assert.False(t, r.states[1].Paused)
+
+	s.write([]byte("0123456789abcdef"))
+	assert.Equal(t, []string{"0123456789abcdef", "0123456789abcdef"}, r.sent(),
+		"resuming refills the burst allowance")
+}
+
+func TestOutputStream_NoRateLimit(t *testing.T) {
+	r := &frameRecorder{}
+	s := r.stream(OutputLimits{FrameInterval: time.Hour, FrameSize: 4})
+
+	for range 100 {
+		s.write([]byte("abcd"))
+	}
+	assert.Len(t, r.sent(), 100)
+	assert.False(t, s.state().Paused)
+	assert.Equal(t, DefaultFrameInterval, (OutputLimits{}).withDefaults().FrameInterval)
+	assert.Equal(t, DefaultFrameSize, (OutputLimits{}).withDefaults().FrameSize)
+}
@@ -0,0 +1,136 @@
+// Package recording records terminal sessions as asciicast v2 files and
+// reads them back for replay and export.
+//
+// See https://docs.asciinema.org/manual/asciicast/v2/ for the format: a JSON
+// header line followed by one [time, type, data] array per event.
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Version is the asciicast format version written by this package.
+const Version = 2
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Command   string            `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// EventType is the code of an asciicast event.
+type EventType string
+
+const (
+	EventOutput EventType = "o"
+	EventInput  EventType = "i"
+	// EventResize data is "<cols>x<rows>".
+	EventResize EventType = "r"
+	EventMarker EventType = "m"
+)
+
+// Event is a single timed event. Time is seconds since the recording started.
+type Event struct {
+	Time float64   `json:"time"`
+	Type EventType `json:"type"`
+	Data string    `json:"data"`
+}
+
+// MarshalJSON encodes the event as an asciicast [time, type, data] array.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Time, e.Type, e.Data})
+}
+
+// UnmarshalJSON decodes an asciicast [time, type, data] array.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("asciicast event has %d elements, want 3", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return fmt.Errorf("asciicast event time: %w", err)
+	}
+	if err := json.Unmarshal(raw[1], &e.Type); err != nil {
+		return fmt.Errorf("asciicast event type: %w", err)
+	}
+	if err := json.Unmarshal(raw[2], &e.Data); err != nil {
+		return fmt.Errorf("asciicast event data: %w", err)
+	}
+	return nil
+}
+
+// Decode reads an asciicast v2 stream.
+func Decode(r io.Reader) (*Header, []Event, error) {
+	dec := json.NewDecoder(r)
+	var header Header
+	if err := dec.Decode(&header); err != nil {
+		return nil, nil, fmt.Errorf("read asciicast header: %w", err)
+	}
+	if header.Version != Version {
+		return nil, nil, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+	events := []Event{}
+	for {
+		var event Event
+		err := dec.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			return &header, events, nil
+		}
+		if err != nil {
+			// A recording cut short by a crash may end with a partial line;
+			// keep everything before it.
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return &header, events, nil
+			}
+			return nil, nil, fmt.Errorf("read asciicast event %d: %w", len(events), err)
+		}
+		events = append(events, event)
+	}
+}
+
+// Replay calls emit for each event at its recorded time, scaled by speed
+// (2 plays twice as fast; <= 0 means 1). Pauses longer than idleLimit are
+// shortened to idleLimit when it is positive. Replay returns ctx.Err() if ctx
+// is cancelled before the last event.
+func Replay(ctx context.Context, events []Event, speed float64, idleLimit time.Duration, emit func(Event)) error {
+	if speed <= 0 {
+		speed = 1
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	var last float64
+	for _, event := range events {
+		wait := time.Duration((event.Time - last) / speed * float64(time.Second))
+		last = event.Time
+		if idleLimit > 0 && wait > idleLimit {
+			wait = idleLimit
+		}
+		if wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+		emit(event)
+	}
+	return nil
+}
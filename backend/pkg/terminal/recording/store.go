@@ -0,0 +1,457 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/omniviewdev/omniview/backend/pkg/store/sidecar"
+	"github.com/omniviewdev/omniview/backend/pkg/terminal"
+)
+
+var (
+	// ErrNotFound is returned for an unknown recording ID.
+	ErrNotFound = fmt.Errorf("recording %w", sidecar.ErrNotFound)
+	// ErrActive is returned when deleting a recording that is still being written.
+	ErrActive = fmt.Errorf("recording %w", sidecar.ErrActive)
+)
+
+const (
+	castExt = ".cast"
+	metaExt = ".json"
+
+	defaultWidth  = 80
+	defaultHeight = 24
+
+	// flushInterval bounds how long recorded events stay buffered before
+	// they reach the .cast file.
+	flushInterval = time.Second
+	bufferSize    = 32 << 10
+)
+
+// DefaultMaxSize is the size of the recordings directory above which the
+// oldest finished recordings are deleted.
+const DefaultMaxSize int64 = 1 << 30
+
+// Info describes a recording. It is stored next to the .cast file so
+// recordings can be listed without reading their events.
+type Info struct {
+	ID           string            `json:"id"`
+	SessionID    string            `json:"sessionID"`
+	PluginID     string            `json:"pluginID"`
+	ConnectionID string            `json:"connectionID,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Command      []string          `json:"command,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	CaptureInput bool              `json:"captureInput"`
+	StartedAt    time.Time         `json:"startedAt"`
+	EndedAt      time.Time         `json:"endedAt,omitzero"`
+	// Duration is the time of the last event, in seconds.
+	Duration float64 `json:"duration"`
+	// Size is the number of bytes written to the .cast file.
+	Size int64 `json:"size"`
+	// Active is set while the session is still being recorded.
+	Active bool `json:"active"`
+	// Error is set if writing stopped early because of an I/O error.
+	Error string `json:"error,omitempty"`
+}
+
+// Options describes a recording to start.
+type Options struct {
+	SessionID    string
+	PluginID     string
+	ConnectionID string
+	Title        string
+	Command      []string
+	Labels       map[string]string
+	// Width and Height are the initial terminal size; defaults are 80x24.
+	Width, Height int
+	// CaptureInput records the data written to the session as "i" events.
+	// Input may contain secrets typed at prompts, so it is off by default.
+	CaptureInput bool
+}
+
+// Store keeps recordings as <id>.cast and <id>.json files in a directory.
+// When the recordings outgrow the store's maximum size, the oldest finished
+// ones are deleted as new recordings start and finish.
+type Store struct {
+	dir   string
+	index *sidecar.Index[Info, *Recorder]
+
+	mu      sync.Mutex
+	maxSize int64
+}
+
+// NewStore returns a Store rooted at dir, creating it if needed.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create recordings directory: %w", err)
+	}
+	return &Store{dir: dir, index: sidecar.NewIndex[Info, *Recorder](ErrNotFound), maxSize: DefaultMaxSize}, nil
+}
+
+// SetMaxSize sets the total size of the recordings above which the oldest
+// finished ones are deleted. A size of 0 or less disables the limit.
+func (s *Store) SetMaxSize(size int64) {
+	s.mu.Lock()
+	s.maxSize = size
+	s.mu.Unlock()
+}
+
+// Start creates a new recording and writes its header.
+func (s *Store) Start(opts Options) (*Recorder, error) {
+	s.prune()
+	width, height := cmp.Or(opts.Width, defaultWidth), cmp.Or(opts.Height, defaultHeight)
+	info := Info{
+		ID:           sidecar.NewID(),
+		SessionID:    opts.SessionID,
+		PluginID:     opts.PluginID,
+		ConnectionID: opts.ConnectionID,
+		Title:        opts.Title,
+		Command:      opts.Command,
+		Labels:       opts.Labels,
+		CaptureInput: opts.CaptureInput,
+		StartedAt:    time.Now().UTC(),
+		Active:       true,
+	}
+
+	f, err := os.OpenFile(s.path(info.ID, castExt), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create recording: %w", err)
+	}
+	header, err := json.Marshal(Header{
+		Version:   Version,
+		Width:     width,
+		Height:    height,
+		Timestamp: info.StartedAt.Unix(),
+		Title:     opts.Title,
+		Command:   strings.Join(opts.Command, " "),
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	})
+	w := bufio.NewWriterSize(f, bufferSize)
+	if err == nil {
+		var n int
+		n, err = w.Write(append(header, '\n'))
+		info.Size = int64(n)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = s.writeInfo(info)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(s.path(info.ID, castExt))
+		return nil, fmt.Errorf("write recording header: %w", err)
+	}
+
+	r := &Recorder{store: s, file: f, w: w, info: info, start: time.Now(), captureInput: opts.CaptureInput}
+	s.index.Track(info.ID, r)
+	return r, nil
+}
+
+// List returns every recording, newest first.
+func (s *Store) List() ([]Info, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := []Info{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), metaExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := s.Get(id)
+		if err != nil {
+			continue // skip unreadable or half-deleted recordings
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b Info) int { return b.StartedAt.Compare(a.StartedAt) })
+	return infos, nil
+}
+
+// Get returns the info of a recording. Active recordings report their live
+// duration and size.
+func (s *Store) Get(id string) (Info, error) {
+	info, active, err := s.index.Info(id, s.path(id, metaExt))
+	if err != nil {
+		return Info{}, err
+	}
+	// A recording left active by a crash is finished as far as readers are
+	// concerned.
+	info.Active = active
+	return info, nil
+}
+
+// ReadCast returns the raw asciicast file of a recording. The buffered events
+// of an active recording are written out first.
+func (s *Store) ReadCast(id string) ([]byte, error) {
+	if !sidecar.ValidID(id) {
+		return nil, ErrNotFound
+	}
+	if r, ok := s.index.Live(id); ok {
+		r.flush()
+	}
+	data, err := os.ReadFile(s.path(id, castExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Load returns the decoded header and events of a recording.
+func (s *Store) Load(id string) (*Header, []Event, error) {
+	data, err := s.ReadCast(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Decode(bytes.NewReader(data))
+}
+
+// Text returns the output of a recording as plain text, with escape
+// sequences removed.
+func (s *Store) Text(id string) (string, error) {
+	_, events, err := s.Load(id)
+	if err != nil {
+		return "", err
+	}
+	var out []byte
+	for _, e := range events {
+		if e.Type == EventOutput {
+			out = append(out, e.Data...)
+		}
+	}
+	return string(terminal.StripANSI(out)), nil
+}
+
+// Delete removes a finished recording.
+func (s *Store) Delete(id string) error {
+	if !sidecar.ValidID(id) {
+		return ErrNotFound
+	}
+	if _, active := s.index.Live(id); active {
+		return ErrActive
+	}
+	err := os.Remove(s.path(id, metaExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(id, castExt)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// CloseAll finishes every active recording.
+func (s *Store) CloseAll() error {
+	return s.index.CloseAll()
+}
+
+func (s *Store) path(id, ext string) string {
+	return filepath.Join(s.dir, id+ext)
+}
+
+func (s *Store) writeInfo(info Info) error {
+	return s.index.WriteInfo(s.path(info.ID, metaExt), info)
+}
+
+func (s *Store) finish(r *Recorder) {
+	s.index.Untrack(r.info.ID)
+	s.prune()
+}
+
+// prune deletes the oldest finished recordings until the recordings fit in
+// the store's maximum size. Active recordings are never deleted.
+func (s *Store) prune() {
+	s.mu.Lock()
+	maxSize := s.maxSize
+	s.mu.Unlock()
+	if maxSize <= 0 {
+		return
+	}
+	infos, err := s.List()
+	if err != nil {
+		return
+	}
+	var total int64
+	for _, info := range infos {
+		total += info.Size
+	}
+	// List is newest first, so delete from the end.
+	for i := len(infos) - 1; i >= 0 && total > maxSize; i-- {
+		if infos[i].Active {
+			continue
+		}
+		if err := s.Delete(infos[i].ID); err == nil {
+			total -= infos[i].Size
+		}
+	}
+}
+
+// ============================================================================
+// Recorder
+// ============================================================================
+
+// Recorder appends events to one recording. It is safe for concurrent use.
+// Write errors stop the recording; they are reported by Close and in Info.
+type Recorder struct {
+	store        *Store
+	start        time.Time
+	captureInput bool
+
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	info Info
+	// flushTimer is set while buffered events wait to be flushed.
+	flushTimer *time.Timer
+	// pending holds the tail of the last output chunk when it ended inside a
+	// multi-byte UTF-8 sequence, so characters split across reads survive
+	// the JSON string encoding.
+	pending []byte
+	err     error
+}
+
+// Info returns the current info of the recording.
+func (r *Recorder) Info() Info {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.info
+}
+
+// Output records data written by the session.
+func (r *Recorder) Output(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data = append(r.pending, data...)
+	cut := incompleteSuffix(data)
+	r.pending = append([]byte(nil), data[len(data)-cut:]...)
+	if len(data) > cut {
+		r.writeLocked(EventOutput, string(data[:len(data)-cut]))
+	}
+}
+
+// Input records data written to the session, if input capture is enabled.
+func (r *Recorder) Input(data []byte) {
+	if !r.captureInput || len(data) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeLocked(EventInput, string(data))
+}
+
+// Resize records a terminal size change.
+func (r *Recorder) Resize(cols, rows uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeLocked(EventResize, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Close finishes the recording and returns its final info. Close is
+// idempotent.
+func (r *Recorder) Close() (Info, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return r.info, r.err
+	}
+	if len(r.pending) > 0 {
+		r.writeLocked(EventOutput, string(r.pending))
+		r.pending = nil
+	}
+	r.flushLocked()
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	r.file = nil
+	r.info.Active = false
+	r.info.EndedAt = time.Now().UTC()
+	if r.err != nil {
+		r.info.Error = r.err.Error()
+	}
+	if err := r.store.writeInfo(r.info); err != nil && r.err == nil {
+		r.err = err
+	}
+	r.store.finish(r)
+	return r.info, r.err
+}
+
+func (r *Recorder) writeLocked(typ EventType, data string) {
+	if r.file == nil || r.err != nil {
+		return
+	}
+	elapsed := time.Since(r.start).Seconds()
+	// Microsecond precision keeps files small and is finer than any player.
+	elapsed = float64(int64(elapsed*1e6)) / 1e6
+	line, err := json.Marshal(Event{Time: elapsed, Type: typ, Data: data})
+	if err == nil {
+		var n int
+		n, err = r.w.Write(append(line, '\n'))
+		r.info.Size += int64(n)
+	}
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	r.info.Duration = elapsed
+	if r.flushTimer == nil {
+		r.flushTimer = time.AfterFunc(flushInterval, r.flush)
+	}
+}
+
+// flush writes the buffered events to the file.
+func (r *Recorder) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushLocked()
+}
+
+func (r *Recorder) flushLocked() {
+	if r.flushTimer != nil {
+		r.flushTimer.Stop()
+		r.flushTimer = nil
+	}
+	if r.file == nil || r.err != nil {
+		return
+	}
+	if err := r.w.Flush(); err != nil {
+		r.fail(err)
+	}
+}
+
+func (r *Recorder) fail(err error) {
+	r.err = err
+	r.info.Error = err.Error()
+}
+
+// incompleteSuffix returns the length of a truncated UTF-8 sequence at the
+// end of data, or 0 if data ends on a character boundary.
+func incompleteSuffix(data []byte) int {
+	for i := 1; i <= utf8.UTFMax-1 && i <= len(data); i++ {
+		b := data[len(data)-i]
+		if utf8.RuneStart(b) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return i
+			}
+			return 0
+		}
+	}
+	return 0
+}
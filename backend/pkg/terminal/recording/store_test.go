@@ -0,0 +1,268 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "recordings"))
+	require.NoError(t, err)
+	return s
+}
+
+func TestRecorder_WritesAsciicast(t *testing.T) {
+	s := newTestStore(t)
+	r, err := s.Start(Options{
+		SessionID: "sess-1",
+		PluginID:  "local",
+		Command:   []string{"zsh", "-l"},
+		Width:     120,
+		Height:    40,
+	})
+	require.NoError(t, err)
+
+	r.Output([]byte("\x1b[32m$\x1b[0m ls\r\n"))
+	r.Input([]byte("ls\r")) // input capture is off
+	r.Resize(100, 30)
+	r.Output([]byte("file.txt\r\n"))
+
+	info, err := r.Close()
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+	assert.False(t, info.EndedAt.IsZero())
+
+	header, events, err := s.Load(info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Version, header.Version)
+	assert.Equal(t, 120, header.Width)
+	assert.Equal(t, 40, header.Height)
+	assert.Equal(t, "zsh -l", header.Command)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, EventOutput, events[0].Type)
+	assert.Equal(t, EventResize, events[1].Type)
+	assert.Equal(t, "100x30", events[1].Data)
+	assert.Equal(t, "file.txt\r\n", events[2].Data)
+	assert.LessOrEqual(t, events[0].Time, events[2].Time)
+
+	// Every line of the file is valid JSON in asciicast's shape.
+	raw, err := s.ReadCast(info.ID)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	require.Len(t, lines, 4)
+	var first []any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &first))
+	assert.Len(t, first, 3)
+	assert.Equal(t, info.Size, int64(len(raw)))
+
+	text, err := s.Text(info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "$ ls\nfile.txt\n", text)
+}
+
+func TestRecorder_CaptureInput(t *testing.T) {
+	s := newTestStore(t)
+	r, err := s.Start(Options{SessionID: "sess-1", CaptureInput: true})
+	require.NoError(t, err)
+	r.Input([]byte("whoami\r"))
+	info, err := r.Close()
+	require.NoError(t, err)
+
+	header, events, err := s.Load(info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, defaultWidth, header.Width)
+	require.Len(t, events, 1)
+	assert.Equal(t, Event{Time: events[0].Time, Type: EventInput, Data: "whoami\r"}, events[0])
+}
+
+func TestRecorder_SplitUTF8(t *testing.T) {
+	s := newTestStore(t)
+	r, err := s.Start(Options{SessionID: "sess-1"})
+	require.NoError(t, err)
+
+	check := []byte("✓ ok") // ✓ is three bytes
+	r.Output(check[:1])
+	r.Output(check[1:2])
+	r.Output(check[2:])
+	r.Output([]byte{0xe2, 0x9c}) // truncated at the end of the session
+	info, err := r.Close()
+	require.NoError(t, err)
+
+	_, events, err := s.Load(info.ID)
+	require.NoError(t, err)
+	var out strings.Builder
+	for _, e := range events {
+		out.WriteString(e.Data)
+	}
+	assert.True(t, strings.HasPrefix(out.String(), "✓ ok"), "got %q", out.String())
+}
+
+func TestStore_ListGetDelete(t *testing.T) {
+	s := newTestStore(t)
+
+	first, err := s.Start(Options{SessionID: "a"})
+	require.NoError(t, err)
+	firstInfo, err := first.Close()
+	require.NoError(t, err)
+
+	second, err := s.Start(Options{SessionID: "b"})
+	require.NoError(t, err)
+	second.Output([]byte("hello"))
+
+	infos, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	assert.Equal(t, "b", infos[0].SessionID, "newest first")
+	assert.True(t, infos[0].Active)
+	assert.False(t, infos[1].Active)
+
+	assert.ErrorIs(t, s.Delete(second.Info().ID), ErrActive)
+	_, err = second.Close()
+	require.NoError(t, err)
+	_, err = second.Close() // idempotent
+	require.NoError(t, err)
+
+	require.NoError(t, s.Delete(firstInfo.ID))
+	_, err = s.Get(firstInfo.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, s.Delete(firstInfo.ID), ErrNotFound)
+
+	infos, err = s.List()
+	require.NoError(t, err)
+	assert.Len(t, infos, 1)
+}
+
+func TestStore_RejectsPathIDs(t *testing.T) {
+	s := newTestStore(t)
+	for _, id := range []string{"", "../etc/passwd", "x/y"} {
+		_, err := s.Get(id)
+		assert.ErrorIs(t, err, ErrNotFound, id)
+		_, err = s.ReadCast(id)
+		assert.ErrorIs(t, err, ErrNotFound, id)
+		assert.ErrorIs(t, s.Delete(id), ErrNotFound, id)
+	}
+}
+
+func TestStore_CrashedRecordingIsReadable(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recordings")
+	s, err := NewStore(dir)
+	require.NoError(t, err)
+	r, err := s.Start(Options{SessionID: "a"})
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	r.Output([]byte("before crash"))
+	r.flush() // as the flush timer would
+	id := r.Info().ID
+
+	// Simulate a crash mid-write: a partial event line and no Close.
+	f, err := os.OpenFile(filepath.Join(dir, id+castExt), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`[1.5, "o", "tru`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := NewStore(dir)
+	require.NoError(t, err)
+	info, err := reopened.Get(id)
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+	_, events, err := reopened.Load(id)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "before crash", events[0].Data)
+}
+
+func TestRecorder_FlushesBufferedEvents(t *testing.T) {
+	s := newTestStore(t)
+	r, err := s.Start(Options{SessionID: "a"})
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	id := r.Info().ID
+
+	r.Output([]byte("buffered"))
+	onDisk := func() int {
+		data, err := os.ReadFile(s.path(id, castExt))
+		require.NoError(t, err)
+		return bytes.Count(data, []byte("\n"))
+	}
+	assert.Equal(t, 1, onDisk(), "events are buffered, only the header is written")
+
+	require.Eventually(t, func() bool { return onDisk() == 2 }, 5*flushInterval, 10*time.Millisecond)
+
+	r.Output([]byte("read while active"))
+	_, events, err := s.Load(id)
+	require.NoError(t, err)
+	require.Len(t, events, 2, "reading an active recording flushes it")
+}
+
+func TestStore_PrunesOldestFinishedRecordings(t *testing.T) {
+	s := newTestStore(t)
+	record := func(output string) Info {
+		r, err := s.Start(Options{SessionID: "a"})
+		require.NoError(t, err)
+		r.Output([]byte(output))
+		info, err := r.Close()
+		require.NoError(t, err)
+		return info
+	}
+	oldest := record(strings.Repeat("x", 1000))
+	time.Sleep(time.Millisecond) // distinct StartedAt
+	middle := record(strings.Repeat("y", 1000))
+
+	active, err := s.Start(Options{SessionID: "b"})
+	require.NoError(t, err)
+	t.Cleanup(func() { active.Close() })
+
+	s.SetMaxSize(middle.Size + active.Info().Size + 1)
+	time.Sleep(time.Millisecond)
+	newest := record("z")
+
+	infos, err := s.List()
+	require.NoError(t, err)
+	var ids []string
+	for _, info := range infos {
+		ids = append(ids, info.ID)
+	}
+	assert.NotContains(t, ids, oldest.ID)
+	assert.NotContains(t, ids, middle.ID)
+	assert.Contains(t, ids, newest.ID)
+	assert.Contains(t, ids, active.Info().ID, "active recordings are kept")
+}
+
+func TestDecode_RejectsOtherVersions(t *testing.T) {
+	_, _, err := Decode(strings.NewReader(`{"version":1,"width":80,"height":24}` + "\n"))
+	assert.Error(t, err)
+}
+
+func TestReplay(t *testing.T) {
+	events := []Event{
+		{Time: 0, Type: EventOutput, Data: "a"},
+		{Time: 0.02, Type: EventOutput, Data: "b"},
+		{Time: 60, Type: EventOutput, Data: "c"}, // long idle gap
+	}
+
+	var got bytes.Buffer
+	start := time.Now()
+	err := Replay(context.Background(), events, 2, 10*time.Millisecond, func(e Event) { got.WriteString(e.Data) })
+	require.NoError(t, err)
+	assert.Equal(t, "abc", got.String())
+	assert.Less(t, time.Since(start), time.Second, "idle limit caps the gap")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got.Reset()
+	err = Replay(ctx, events, 1, 0, func(e Event) { got.WriteString(e.Data) })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, got.String())
+}
@@ -0,0 +1,128 @@
+package terminal
+
+import (
+	"bytes"
+	"regexp"
+)
+
+const (
+	// DefaultSearchLimit is the number of matches returned when
+	// SearchOptions.Limit is not set.
+	DefaultSearchLimit = 500
+	// MaxSearchLimit caps SearchOptions.Limit.
+	MaxSearchLimit = 5000
+	// MaxSearchContext caps SearchOptions.Context.
+	MaxSearchContext = 20
+)
+
+// SearchOptions configures a scrollback search.
+type SearchOptions struct {
+	// Pattern is a regular expression in Go (RE2) syntax, or plain text
+	// when Literal is set.
+	Pattern    string `json:"pattern"`
+	Literal    bool   `json:"literal,omitempty"`
+	IgnoreCase bool   `json:"ignoreCase,omitempty"`
+	// Context is the number of lines returned before and after each match.
+	Context int `json:"context,omitempty"`
+	// Limit is the maximum number of matches returned, DefaultSearchLimit
+	// if zero.
+	Limit int `json:"limit,omitempty"`
+}
+
+// SearchMatch is a match in the scrollback with escape sequences removed.
+type SearchMatch struct {
+	// Line is the zero-based index of the line in the searched scrollback.
+	Line int `json:"line"`
+	// Start and End are byte offsets of the match within Text.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Offset is the byte offset of the match in the searched text.
+	Offset int      `json:"offset"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+// SearchResult holds the matches of a scrollback search.
+type SearchResult struct {
+	Matches []SearchMatch `json:"matches"`
+	// Lines is the number of lines searched.
+	Lines int `json:"lines"`
+	// Truncated reports that the search stopped at the match limit.
+	Truncated bool `json:"truncated"`
+	// Dropped is the number of bytes of output evicted from the scrollback
+	// before the search, which could not be searched.
+	Dropped int64 `json:"dropped"`
+}
+
+// compile builds the regular expression for opts.
+func (opts SearchOptions) compile() (*regexp.Regexp, error) {
+	pattern := opts.Pattern
+	if opts.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// SearchScrollback searches output, with escape sequences removed, line by
+// line. dropped is the number of bytes evicted before output, reported back
+// in the result. Empty matches, of a pattern that can match nothing, are
+// skipped.
+func SearchScrollback(output []byte, dropped int64, opts SearchOptions) (*SearchResult, error) {
+	re, err := opts.compile()
+	if err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	limit = min(limit, MaxSearchLimit)
+	around := min(max(opts.Context, 0), MaxSearchContext)
+
+	text := StripANSI(output)
+	lines := bytes.Split(text, []byte("\n"))
+	// Output ending in a newline has no line after it.
+	if len(lines) > 1 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	result := &SearchResult{Matches: []SearchMatch{}, Lines: len(lines), Dropped: dropped}
+	offset := 0
+	for i, line := range lines {
+		for _, loc := range re.FindAllIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			if len(result.Matches) == limit {
+				result.Truncated = true
+				return result, nil
+			}
+			result.Matches = append(result.Matches, SearchMatch{
+				Line:   i,
+				Start:  loc[0],
+				End:    loc[1],
+				Offset: offset + loc[0],
+				Text:   string(line),
+				Before: lineStrings(lines[max(i-around, 0):i]),
+				After:  lineStrings(lines[i+1 : min(i+1+around, len(lines))]),
+			})
+		}
+		offset += len(line) + 1
+	}
+	return result, nil
+}
+
+func lineStrings(lines [][]byte) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = string(line)
+	}
+	return out
+}
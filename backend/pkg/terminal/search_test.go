@@ -0,0 +1,59 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scrollback = "$ kubectl get pods\r\n" +
+	"NAME    STATUS\r\n" +
+	"web-1   \x1b[32mRunning\x1b[0m\r\n" +
+	"web-2   \x1b[31mError\x1b[0m\r\n" +
+	"db-1    \x1b[31mCrashLoopBackOff\x1b[0m\r\n"
+
+func TestSearchScrollback(t *testing.T) {
+	result, err := SearchScrollback([]byte(scrollback), 42, SearchOptions{Pattern: `web-\d\s+(Error|Crash)`, Context: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Lines)
+	assert.Equal(t, int64(42), result.Dropped)
+	assert.False(t, result.Truncated)
+	require.Len(t, result.Matches, 1)
+
+	m := result.Matches[0]
+	assert.Equal(t, 3, m.Line)
+	assert.Equal(t, "web-2   Error", m.Text)
+	assert.Equal(t, 0, m.Start)
+	assert.Equal(t, 13, m.End)
+	assert.Equal(t, []string{"web-1   Running"}, m.Before)
+	assert.Equal(t, []string{"db-1    CrashLoopBackOff"}, m.After)
+
+	text := string(StripANSI([]byte(scrollback)))
+	assert.Equal(t, "web-2   Error", text[m.Offset:m.Offset+m.End-m.Start])
+}
+
+func TestSearchScrollback_Options(t *testing.T) {
+	result, err := SearchScrollback([]byte(scrollback), 0, SearchOptions{Pattern: "error", IgnoreCase: true})
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	assert.Nil(t, result.Matches[0].Before)
+
+	result, err = SearchScrollback([]byte("a.b axb\n"), 0, SearchOptions{Pattern: "a.b", Literal: true})
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, 0, result.Matches[0].Start)
+
+	result, err = SearchScrollback([]byte(scrollback), 0, SearchOptions{Pattern: `[a-z]+`, Limit: 3})
+	require.NoError(t, err)
+	assert.Len(t, result.Matches, 3)
+	assert.True(t, result.Truncated)
+
+	// empty matches are skipped
+	result, err = SearchScrollback([]byte("ab\n"), 0, SearchOptions{Pattern: `x*`})
+	require.NoError(t, err)
+	assert.Empty(t, result.Matches)
+
+	_, err = SearchScrollback(nil, 0, SearchOptions{Pattern: "("})
+	assert.Error(t, err)
+}
@@ -19,6 +19,8 @@ type Session struct {
 }
 
 // SessionOptions contains options for creating a new terminal session.
+// Manager.StartSession takes the ID and labels from the exec options and
+// uses these for the shell's environment and working directory.
 type SessionOptions struct {
 	ID string `json:"id"`
 	// Labels are arbitrary key-value pairs for the session that can be used to filter sessions, or
@@ -28,8 +30,23 @@ type SessionOptions struct {
 	Kubeconfig string `json:"kubeconfig"`
 	// Context is the name of the context to use.
 	Context string `json:"context"`
+	// Env holds extra environment variables for the shell, overriding
+	// inherited values.
+	Env map[string]string `json:"env"`
+	// Dir is the working directory of the shell. A leading "~" expands to
+	// the user's home directory.
+	Dir string `json:"dir"`
 }
 
+// Session params read by StartSession, for callers that can only pass
+// exec.SessionOptions: "cwd" sets the working directory and each
+// "env.<NAME>" sets an environment variable. They override the values in
+// the local SessionOptions.
+const (
+	ParamDir       = "cwd"
+	ParamEnvPrefix = "env."
+)
+
 // SessionDetails contains details about a terminal session.
 type SessionDetails struct {
 	// Labels are key-value pairs for the session.
@@ -0,0 +1,99 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ShellProfile holds the flags a shell needs to start as an interactive
+// login shell.
+type ShellProfile struct {
+	// Login flags are always passed first.
+	Login []string
+	// Interactive flags are passed when the shell is started without
+	// arguments of its own; a shell given a script or -c runs it as usual.
+	Interactive []string
+}
+
+// ShellProfiles maps shell names (the executable's base name, without a
+// ".exe" suffix) to their flags. Shells not listed are started with only the
+// arguments given.
+//
+//nolint:gochecknoglobals // lookup table
+var ShellProfiles = map[string]ShellProfile{
+	"sh":    {},
+	"bash":  {Login: []string{"--login"}},
+	"zsh":   {Login: []string{"--login"}, Interactive: []string{"-i"}},
+	"dash":  {Login: []string{"-l"}},
+	"ksh":   {Login: []string{"-l"}},
+	"mksh":  {Login: []string{"-l"}},
+	"tcsh":  {Login: []string{"-l"}},
+	"fish":  {Login: []string{"--login"}, Interactive: []string{"--interactive"}},
+	"nu":    {Login: []string{"--login"}, Interactive: []string{"--interactive"}},
+	"xonsh": {Login: []string{"--login"}, Interactive: []string{"-i"}},
+	"pwsh":  {Login: []string{"-Login"}, Interactive: []string{"-NoLogo", "-Interactive"}},
+}
+
+// shellName returns the profile key for a shell path.
+func shellName(shell string) string {
+	return strings.TrimSuffix(filepath.Base(shell), ".exe")
+}
+
+// shellCommand returns the program and arguments for a session command.
+// An empty command starts defaultShell. A command naming a shell in
+// ShellProfiles gets the shell's flags before its own arguments; any other
+// command is run as given.
+func shellCommand(command []string, defaultShell string) (string, []string) {
+	if len(command) == 0 {
+		command = []string{defaultShell}
+	}
+	shell, rest := command[0], command[1:]
+
+	profile, ok := ShellProfiles[shellName(shell)]
+	if !ok {
+		return shell, append([]string(nil), rest...)
+	}
+	args := append([]string(nil), profile.Login...)
+	if len(rest) == 0 {
+		args = append(args, profile.Interactive...)
+	}
+	return shell, append(args, rest...)
+}
+
+// resolveDefaultShell returns the first of the candidates that can be found,
+// followed by the user's login shell, DefaultLocalShell and /bin/sh, so a
+// configured shell that was uninstalled still yields a working terminal.
+func resolveDefaultShell(candidates ...string) string {
+	candidates = append(candidates, os.Getenv("SHELL"), DefaultLocalShell, "/bin/sh")
+	for _, shell := range candidates {
+		if shell == "" {
+			continue
+		}
+		if _, err := exec.LookPath(shell); err == nil {
+			return shell
+		}
+	}
+	return DefaultLocalShell
+}
+
+// withParams applies the ParamDir and ParamEnvPrefix session params to opts.
+func (opts SessionOptions) withParams(params map[string]string) SessionOptions {
+	env := make(map[string]string, len(opts.Env))
+	for k, v := range opts.Env {
+		env[k] = v
+	}
+	for k, v := range params {
+		if name, ok := strings.CutPrefix(k, ParamEnvPrefix); ok && name != "" {
+			env[name] = v
+		}
+	}
+	opts.Env = env
+	if dir := params[ParamDir]; dir != "" {
+		opts.Dir = dir
+	}
+	return opts
+}
@@ -0,0 +1,62 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		shell   string
+		args    []string
+	}{
+		{"default shell", nil, "/usr/bin/fish", []string{"--login", "--interactive"}},
+		{"zsh by name", []string{"zsh"}, "zsh", []string{"--login", "-i"}},
+		{"bash with script", []string{"/bin/bash", "-c", "ls"}, "/bin/bash", []string{"--login", "-c", "ls"}},
+		{"nu", []string{"/opt/homebrew/bin/nu"}, "/opt/homebrew/bin/nu", []string{"--login", "--interactive"}},
+		{"pwsh", []string{"pwsh"}, "pwsh", []string{"-Login", "-NoLogo", "-Interactive"}},
+		{"sh", []string{"/bin/sh"}, "/bin/sh", nil},
+		{"other command", []string{"htop", "-d", "10"}, "htop", []string{"-d", "10"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shell, args := shellCommand(tt.command, "/usr/bin/fish")
+			assert.Equal(t, tt.shell, shell)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestShellCommand_DoesNotAliasProfiles(t *testing.T) {
+	_, args := shellCommand([]string{"zsh", "-c", "true"}, "")
+	args[0] = "changed"
+	assert.Equal(t, []string{"--login"}, ShellProfiles["zsh"].Login)
+}
+
+func TestResolveDefaultShell(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	assert.Equal(t, "/bin/sh", resolveDefaultShell("/does/not/exist"))
+	assert.Equal(t, "/bin/sh", resolveDefaultShell(""))
+	assert.Equal(t, "sh", resolveDefaultShell("sh"), "names are looked up on PATH")
+}
+
+func TestSessionOptions_WithParams(t *testing.T) {
+	local := SessionOptions{Env: map[string]string{"A": "1", "B": "2"}, Dir: "/srv"}
+	got := local.withParams(map[string]string{
+		"env.B":     "override",
+		"env.C":     "3",
+		"env.":      "ignored",
+		ParamDir:    "/tmp",
+		"unrelated": "x",
+	})
+	assert.Equal(t, map[string]string{"A": "1", "B": "override", "C": "3"}, got.Env)
+	assert.Equal(t, "/tmp", got.Dir)
+	assert.Equal(t, "2", local.Env["B"], "the receiver's env is not modified")
+
+	assert.Equal(t, local.Dir, local.withParams(nil).Dir)
+}
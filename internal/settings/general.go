@@ -21,5 +21,19 @@ var General = settings.Category{
 				{Value: "es", Label: "Spanish"},
 			},
 		},
+		"historyMaxRevisions": {
+			ID:          "historyMaxRevisions",
+			Type:        settings.Integer,
+			Label:       "Resource History Revisions",
+			Default:     20, //nolint:gomnd // this is a reasonable default
+			Description: "The number of revisions of each resource to keep in its change history",
+		},
+		"historyMaxAge": {
+			ID:          "historyMaxAge",
+			Type:        settings.Integer,
+			Label:       "Resource History Retention",
+			Default:     24, //nolint:gomnd // this is a reasonable default
+			Description: "The number of hours to keep resource revisions, and deleted resources, in the change history",
+		},
 	},
 }
@@ -38,6 +38,34 @@ var Terminal = settings.Category{
 				return nil
 			},
 		},
+		"persistSessions": {
+			ID:          "persistSessions",
+			Type:        settings.Toggle,
+			Label:       "Keep Sessions Running",
+			Default:     false,
+			Description: "Keep terminal sessions running when Omniview quits, and reattach to them on the next start",
+		},
+		"shellIntegration": {
+			ID:          "shellIntegration",
+			Type:        settings.Toggle,
+			Label:       "Shell Integration",
+			Default:     true,
+			Description: "Mark prompts and commands in bash, zsh and fish terminals to track command history, exit codes and output",
+		},
+		"outputRateLimit": {
+			ID:          "outputRateLimit",
+			Type:        settings.Integer,
+			Label:       "Output Rate Limit",
+			Default:     4096, //nolint:gomnd // this is a reasonable default
+			Description: "Pause terminal output that exceeds this many KiB per second until it is resumed (0 disables the limit)",
+		},
+		"recordingsMaxSize": {
+			ID:          "recordingsMaxSize",
+			Type:        settings.Integer,
+			Label:       "Recordings Storage Limit",
+			Default:     1024, //nolint:gomnd // this is a reasonable default
+			Description: "Delete the oldest session recordings once they take up more than this many MiB (0 disables the limit)",
+		},
 
 		"theme": {
 			ID:          "theme",
@@ -14,20 +14,28 @@ import (
 
 	"github.com/omniviewdev/omniview/backend/diagnostics"
 	"github.com/omniviewdev/omniview/backend/menus"
+	"github.com/omniviewdev/omniview/backend/pkg/notify"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/data"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/devserver"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/exec"
 	pluginlogs "github.com/omniviewdev/omniview/backend/pkg/plugin/logs"
+	logcapture "github.com/omniviewdev/omniview/backend/pkg/plugin/logs/capture"
 	pluginmetric "github.com/omniviewdev/omniview/backend/pkg/plugin/metric"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/networker"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/pluginlog"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/registry"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/resource"
+	resourcehistory "github.com/omniviewdev/omniview/backend/pkg/plugin/resource/history"
+	resourceregistry "github.com/omniviewdev/omniview/backend/pkg/plugin/resource/registry"
+	resourcesnapshot "github.com/omniviewdev/omniview/backend/pkg/plugin/resource/snapshot"
+	"github.com/omniviewdev/omniview/backend/pkg/plugin/runbook"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/settings"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/types"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/ui"
 	"github.com/omniviewdev/omniview/backend/pkg/plugin/utils"
+	termdaemon "github.com/omniviewdev/omniview/backend/pkg/terminal/daemon"
+	terminalrecording "github.com/omniviewdev/omniview/backend/pkg/terminal/recording"
 	"github.com/omniviewdev/omniview/backend/window"
 	"github.com/omniviewdev/omniview/internal/appstate"
 	"github.com/omniviewdev/omniview/internal/bootstrap"
@@ -52,6 +60,12 @@ var icon []byte
 
 //nolint:funlen // main function is expected to be long
 func main() {
+	// The same binary runs the terminal session daemon when re-executed by
+	// the terminal manager.
+	if termdaemon.IsInvocation(os.Args) {
+		os.Exit(termdaemon.Main(os.Args[2:]))
+	}
+
 	// Initialize unified state directory.
 	stateDir, err := appstate.New()
 	if err != nil {
@@ -153,21 +167,64 @@ func main() {
 
 	utilsClient := utils.NewClient()
 
+	// Open the persistent resource registry so the last-known inventory is
+	// available before watches re-sync. Falls back to the in-memory store.
+	var resourceOpts []resource.ControllerOption
+	if registryStore, regErr := resourceregistry.OpenBoltStore(stateDir.RootDir().ResolvePath("registry.db")); regErr != nil {
+		log.Warnw(context.Background(), "failed to open registry store; resource inventory will not persist", "error", regErr)
+	} else {
+		resourceOpts = append(resourceOpts, resource.WithRegistryStore(registryStore))
+	}
+	if historyStore, histErr := resourcehistory.Open(stateDir.RootDir().ResolvePath("history.db")); histErr != nil {
+		log.Warnw(context.Background(), "failed to open resource history store; revision history is disabled", "error", histErr)
+	} else {
+		resourceOpts = append(resourceOpts, resource.WithHistoryStore(historyStore))
+	}
+
 	// Setup the plugin systems
-	resourceController := resource.NewController(log, settingsProvider, stateDir.PluginStore)
+	resourceController := resource.NewController(log, settingsProvider, stateDir.PluginStore, resourceOpts...)
 	graphService := resource.NewGraphService(resourceController.Graph())
 
 	settingsController := settings.NewController(log, settingsProvider, settingsStore)
 
-	execController := exec.NewController(log, settingsProvider, resourceController)
+	var execOpts []exec.ControllerOption
+	if recordingStore, recErr := terminalrecording.NewStore(stateDir.RootDir().ResolvePath("recordings")); recErr != nil {
+		log.Warnw(context.Background(), "failed to open recording store; session recording is disabled", "error", recErr)
+	} else {
+		execOpts = append(execOpts, exec.WithRecordingStore(recordingStore))
+	}
+	if executable, exeErr := os.Executable(); exeErr != nil {
+		log.Warnw(context.Background(), "failed to resolve executable; terminal sessions will not persist", "error", exeErr)
+	} else {
+		execOpts = append(execOpts, exec.WithSessionDaemon(termdaemon.DefaultSocketPath(), executable))
+	}
+	execController := exec.NewController(log, settingsProvider, resourceController, execOpts...)
+
+	dataController := data.NewController(log, stateDir.PluginData)
 
-	networkerController := networker.NewController(log, settingsProvider, resourceController)
+	networkerController := networker.NewController(log, settingsProvider, resourceController,
+		networker.WithProfileStore(networker.NewProfileStore(dataController)))
 
-	logsController := pluginlogs.NewController(log, settingsProvider, resourceController)
+	var logsOpts []pluginlogs.ControllerOption
+	if captureStore, capErr := logcapture.NewStore(stateDir.RootDir().ResolvePath("log-captures"), logcapture.Limits{}); capErr != nil {
+		log.Warnw(context.Background(), "failed to open log capture store; log capture is disabled", "error", capErr)
+	} else {
+		logsOpts = append(logsOpts, pluginlogs.WithCaptureStore(captureStore))
+	}
+	if alertStore, alertErr := pluginlogs.NewAlertStore(stateDir.RootDir().ResolvePath("log-alerts.json")); alertErr != nil {
+		log.Warnw(context.Background(), "failed to open log alert store; log alerts are disabled", "error", alertErr)
+	} else {
+		logsOpts = append(logsOpts, pluginlogs.WithAlertStore(alertStore))
+	}
+	notificationService := notify.New(log)
+	logsOpts = append(logsOpts, pluginlogs.WithAlertNotifier(notificationService))
+	logsController := pluginlogs.NewController(log, settingsProvider, resourceController, logsOpts...)
 
 	metricController := pluginmetric.NewController(log, settingsProvider, resourceController)
 
-	dataController := data.NewController(log, stateDir.PluginData)
+	snapshotService := resource.NewSnapshotService(resourceController, resourcesnapshot.NewStore(dataController))
+	bundleService := resource.NewBundleService(resourceController)
+	runbookService := runbook.NewService(log, runbook.NewStore(dataController), execController, resourceController)
 
 	// Initialize per-plugin log manager for capturing plugin process stderr.
 	// Created here so it can be bound to Wails for UI access.
@@ -208,6 +265,12 @@ func main() {
 	// controller's event listeners, the manager will attempt to reload it.
 	resourceController.SetCrashCallback(pluginManager.HandlePluginCrash)
 
+	// Start and suspend port-forward profiles as their connections come up
+	// and go down.
+	resourceController.SetConnectionStatusCallback(func(status resource.ConnectionStatusPayload) {
+		networkerController.HandleConnectionStatus(status.PluginID, status.ConnectionID, status.Status)
+	})
+
 	devServerManager := devserver.NewDevServerManager(
 		log,
 		stateDir.RootDir(),
@@ -258,8 +321,11 @@ func main() {
 		// 1. Controllers — need ctx before plugin loading
 		application.NewService(&resource.ServiceWrapper{Ctrl: resourceController}),
 		application.NewService(graphService),
+		application.NewService(snapshotService),
+		application.NewService(bundleService),
 		application.NewService(&settings.ServiceWrapper{Ctrl: settingsController}),
 		application.NewService(&exec.ServiceWrapper{Ctrl: execController}),
+		application.NewService(runbookService),
 		application.NewService(&networker.ServiceWrapper{Ctrl: networkerController}),
 		application.NewService(&pluginlogs.ServiceWrapper{Ctrl: logsController}),
 		application.NewService(&pluginmetric.ServiceWrapper{Ctrl: metricController}),
@@ -272,6 +338,7 @@ func main() {
 		// 3. Frontend-facing services (no startup order dependency)
 		application.NewService(appService),
 		application.NewService(diagnosticsClient),
+		application.NewService(notificationService),
 		application.NewService(telemetry.NewTelemetryBinding(telemetrySvc)),
 		application.NewService(&coresettings.ServiceWrapper{
 			Provider: settingsProvider,
@@ -52,4 +52,5 @@ export const ErrorTypes = {
   INTERNAL: 'omniview:internal',
   VALIDATION: 'omniview:validation',
   NOT_IMPLEMENTED: 'omniview:not-implemented',
+  CONFIRMATION_REQUIRED: 'omniview:confirmation-required',
 } as const;
@@ -28,5 +28,21 @@ export const initialState: GeneralSettings = {
         { value: 'ko', label: 'Korean' },
       ],
     },
+    historyMaxRevisions: {
+      label: 'Resource History Revisions',
+      description: 'The number of revisions of each resource to keep in its change history',
+      visible: true,
+      type: 'integer',
+      default: 20,
+      value: 20,
+    },
+    historyMaxAge: {
+      label: 'Resource History Retention',
+      description: 'The number of hours to keep resource revisions, and deleted resources, in the change history',
+      visible: true,
+      type: 'integer',
+      default: 24,
+      value: 24,
+    },
   },
 };
@@ -17,6 +17,38 @@ export const initialState: TerminalSettings = {
       default: '/bin/sh',
       value: '/bin/sh',
     },
+    persistSessions: {
+      label: 'Keep Sessions Running',
+      description: 'Keep terminal sessions running when Omniview quits, and reattach to them on the next start',
+      visible: true,
+      type: 'toggle',
+      default: false,
+      value: false,
+    },
+    shellIntegration: {
+      label: 'Shell Integration',
+      description: 'Mark prompts and commands in bash, zsh and fish terminals to track command history, exit codes and output',
+      visible: true,
+      type: 'toggle',
+      default: true,
+      value: true,
+    },
+    outputRateLimit: {
+      label: 'Output Rate Limit',
+      description: 'Pause terminal output that exceeds this many KiB per second until it is resumed (0 disables the limit)',
+      visible: true,
+      type: 'integer',
+      default: 4096,
+      value: 4096,
+    },
+    recordingsMaxSize: {
+      label: 'Recordings Storage Limit',
+      description: 'Delete the oldest session recordings once they take up more than this many MiB (0 disables the limit)',
+      visible: true,
+      type: 'integer',
+      default: 1024,
+      value: 1024,
+    },
     theme: {
       label: 'Theme',
       description: 'Choose a theme for the terminal',